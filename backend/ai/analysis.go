@@ -2,12 +2,11 @@ package ai
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
-	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/sync/semaphore"
 )
@@ -28,25 +27,30 @@ type AnalyzeCommentRequest struct {
 	Comment    string      // 评论内容
 	Dimensions []Dimension // 评价维度列表
 	VideoTitle string      // 视频标题，作为上下文
+	Source     string      // 评论来源（bilibili.SourceDanmaku 表示弹幕），为空表示评论区评论
 }
 
 // AnalyzeCommentResponse 分析评论响应
 // 包含各维度的得分结果
 type AnalyzeCommentResponse struct {
-	Scores map[string]*float64 `json:"scores"` // 维度名 -> 得分(1-10)，nil表示未提及
-	Brand  string              `json:"brand"`  // 提取的品牌名称
-	Model  string              `json:"model"`  // 提取的具体型号
+	Scores  map[string]*float64        `json:"scores"`            // 维度名 -> 得分(1-10)，nil表示未提及
+	Aspects map[string]AspectSentiment `json:"aspects,omitempty"` // 维度名 -> 情感倾向、置信度和原文片段
+	Brand   string                     `json:"brand"`             // 提取的品牌名称
+	Model   string                     `json:"model"`             // 提取的具体型号
 }
 
 // CommentAnalysisResult 评论分析结果（包含原始评论信息）
 // 用于批量分析时返回完整的分析结果
 type CommentAnalysisResult struct {
-	CommentID string              `json:"comment_id"` // 评论ID
-	Content   string              `json:"content"`    // 评论内容
-	Scores    map[string]*float64 `json:"scores"`     // 各维度得分
-	Brand     string              `json:"brand"`      // AI提取的品牌
-	Model     string              `json:"model"`      // AI提取的型号
-	Error     string              `json:"error"`      // 分析错误信息（如有）
+	CommentID   string                     `json:"comment_id"`            // 评论ID
+	Content     string                     `json:"content"`               // 评论内容
+	Scores      map[string]*float64        `json:"scores"`                // 各维度得分
+	Aspects     map[string]AspectSentiment `json:"aspects,omitempty"`     // 各维度情感倾向及原文片段（已经过 NormalizeAspects 清理）
+	Brand       string                     `json:"brand"`                 // AI提取的品牌
+	Model       string                     `json:"model"`                 // AI提取的型号
+	Source      string                     `json:"source,omitempty"`      // 评论来源（与输入一致）
+	PublishTime time.Time                  `json:"publish_time,omitzero"` // 评论发布时间（与输入一致）
+	Error       string                     `json:"error"`                 // 分析错误信息（如有）
 }
 
 // AnalyzeComment 分析单条评论
@@ -59,6 +63,8 @@ type CommentAnalysisResult struct {
 //   - *AnalyzeCommentResponse: 各维度得分结果
 //   - error: 分析失败时返回错误
 func (c *Client) AnalyzeComment(ctx context.Context, req AnalyzeCommentRequest) (*AnalyzeCommentResponse, error) {
+	ctx = WithStage(ctx, StageAnalysis)
+
 	// 检查评论内容是否为空
 	if strings.TrimSpace(req.Comment) == "" {
 		return nil, fmt.Errorf("评论内容不能为空")
@@ -100,6 +106,8 @@ func (c *Client) AnalyzeComment(ctx context.Context, req AnalyzeCommentRequest)
 - 6-7分：较好/正面评价
 - 8-10分：优秀/强烈好评
 
+3. %s
+
 重要规则：
 1. **必须从评论内容中提取品牌**（视频标题仅供参考上下文）
 2. 如果评论中没有明确提及任何品牌，brand字段必须填"未知"
@@ -114,13 +122,17 @@ func (c *Client) AnalyzeComment(ctx context.Context, req AnalyzeCommentRequest)
 11. 必须严格返回JSON格式，不要添加任何其他文字
 
 返回JSON格式：
-{"brand":"品牌名","model":"型号名","scores":{"维度1":8.5,"维度2":null}}`, strings.Join(dimList, "\n"))
+{"brand":"品牌名","model":"型号名","scores":{"维度1":8.5,"维度2":null},"aspects":{"维度1":{"polarity":"positive","confidence":0.9,"span":"评论原文片段"}}}`, strings.Join(dimList, "\n"), aspectPrompt)
 
+	contentLabel := "评论内容"
+	if req.Source == sourceDanmaku {
+		contentLabel = "弹幕内容"
+	}
 	var userPrompt string
 	if req.VideoTitle != "" {
-		userPrompt = fmt.Sprintf("视频标题：%s\n\n评论内容：%s", req.VideoTitle, req.Comment)
+		userPrompt = fmt.Sprintf("视频标题：%s\n\n%s：%s", req.VideoTitle, contentLabel, req.Comment)
 	} else {
-		userPrompt = fmt.Sprintf("评论内容：%s", req.Comment)
+		userPrompt = fmt.Sprintf("%s：%s", contentLabel, req.Comment)
 	}
 
 	// 构建消息列表
@@ -129,43 +141,41 @@ func (c *Client) AnalyzeComment(ctx context.Context, req AnalyzeCommentRequest)
 		{Role: "user", Content: userPrompt},
 	}
 
-	// 调用AI接口
-	response, err := c.ChatCompletion(ctx, messages)
-	if err != nil {
+	// 调用AI接口（结构化输出，自动校验和修复）
+	var result AnalyzeCommentResponse
+	if _, err := c.ChatJSON(ctx, messages, commentAnalysisSchema(req.Dimensions), &result); err != nil {
 		return nil, fmt.Errorf("AI请求失败: %w", err)
 	}
+	result.Aspects = NormalizeAspects(req.Comment, result.Scores, result.Aspects)
 
-	// 解析AI返回的JSON响应
-	result, err := parseAnalysisResponse(response)
-	if err != nil {
-		return nil, fmt.Errorf("解析AI响应失败: %w", err)
-	}
-
-	return result, nil
+	return &result, nil
 }
 
-// parseAnalysisResponse 解析AI返回的分析响应
-// 从AI返回的文本中提取JSON并解析为结构化数据
-func parseAnalysisResponse(response string) (*AnalyzeCommentResponse, error) {
-	// 尝试直接解析
-	var result AnalyzeCommentResponse
-	if err := json.Unmarshal([]byte(response), &result); err == nil {
-		return &result, nil
+// commentAnalysisSchema 单条评论分析结果的Schema
+func commentAnalysisSchema(dimensions []Dimension) *JSONSchema {
+	return &JSONSchema{
+		Name:        "comment_analysis",
+		Description: "评论的品牌、型号、各维度评分及情感依据",
+		Schema: objectSchema(map[string]interface{}{
+			"brand":   stringSchema(1),
+			"model":   stringSchema(1),
+			"scores":  dimensionScoresSchema(dimensions),
+			"aspects": aspectsSchema(dimensions),
+		}, "brand", "model", "scores"),
 	}
+}
 
-	// 如果直接解析失败，尝试从响应中提取JSON
-	// AI有时会在JSON前后添加额外的文字说明
-	jsonPattern := regexp.MustCompile(`\{[\s\S]*"scores"[\s\S]*\}`)
-	match := jsonPattern.FindString(response)
-	if match == "" {
-		return nil, fmt.Errorf("无法从响应中提取JSON: %s", response)
+// dimensionScoresSchema 维度评分的Schema（维度名 -> 1-10分或null）
+// 未提及的维度可以省略或为null，因此不把维度设为必填
+func dimensionScoresSchema(dimensions []Dimension) map[string]interface{} {
+	if len(dimensions) == 0 {
+		return mapSchema(nullableNumberSchema(1, 10))
 	}
-
-	if err := json.Unmarshal([]byte(match), &result); err != nil {
-		return nil, fmt.Errorf("JSON解析失败: %w, 原始响应: %s", err, response)
+	properties := make(map[string]interface{}, len(dimensions))
+	for _, dim := range dimensions {
+		properties[dim.Name] = nullableNumberSchema(1, 10)
 	}
-
-	return &result, nil
+	return objectSchema(properties)
 }
 
 // AnalyzeCommentsBatch 批量分析评论（并发）
@@ -179,6 +189,8 @@ func parseAnalysisResponse(response string) (*AnalyzeCommentResponse, error) {
 //   - []CommentAnalysisResult: 所有评论的分析结果
 //   - error: 如果所有评论都分析失败则返回错误
 func (c *Client) AnalyzeCommentsBatch(ctx context.Context, comments []CommentInput, dimensions []Dimension) ([]CommentAnalysisResult, error) {
+	ctx = WithStage(ctx, StageAnalysis)
+
 	// 检查输入参数
 	if len(comments) == 0 {
 		return nil, fmt.Errorf("评论列表不能为空")
@@ -201,14 +213,17 @@ func (c *Client) AnalyzeCommentsBatch(ctx context.Context, comments []CommentInp
 			defer wg.Done()
 
 			results[index] = CommentAnalysisResult{
-				CommentID: input.ID,
-				Content:   input.Content,
+				CommentID:   input.ID,
+				Content:     input.Content,
+				Source:      input.Source,
+				PublishTime: input.PublishTime,
 			}
 
 			resp, err := c.AnalyzeComment(ctx, AnalyzeCommentRequest{
 				Comment:    input.Content,
 				Dimensions: dimensions,
 				VideoTitle: input.VideoTitle,
+				Source:     input.Source,
 			})
 
 			if err != nil {
@@ -217,6 +232,7 @@ func (c *Client) AnalyzeCommentsBatch(ctx context.Context, comments []CommentInp
 			}
 
 			results[index].Scores = resp.Scores
+			results[index].Aspects = resp.Aspects
 			results[index].Brand = resp.Brand
 			results[index].Model = resp.Model
 		}(i, comment)
@@ -244,12 +260,17 @@ func (c *Client) AnalyzeCommentsBatch(ctx context.Context, comments []CommentInp
 // CommentInput 评论输入
 // 用于批量分析时传入评论信息
 type CommentInput struct {
-	ID         string // 评论ID
-	Content    string // 评论内容
-	VideoTitle string // 视频标题，作为上下文
-	VideoBVID  string // 视频BVID
+	ID          string    // 评论ID
+	Content     string    // 评论内容
+	VideoTitle  string    // 视频标题，作为上下文
+	VideoBVID   string    // 视频BVID
+	Source      string    // 评论来源（bilibili.SourceDanmaku 表示弹幕），为空表示评论区评论
+	PublishTime time.Time // 评论发布时间，不参与提示词，原样带回分析结果
 }
 
+// sourceDanmaku 弹幕来源标记，与 bilibili.SourceDanmaku 保持一致
+const sourceDanmaku = "danmaku"
+
 // AnalyzeCommentsWithRateLimit 带速率限制的批量分析（优化版）
 // 使用批量合并策略和并发控制，大幅减少 API 调用次数并提高处理速度
 // 参数：
@@ -261,6 +282,9 @@ type CommentInput struct {
 // 返回：
 //   - []CommentAnalysisResult: 分析结果
 //   - error: 错误信息
+//
+// 上下文取消时不再发起新批次，等待进行中的批次结束后返回已完成批次的结果和 ctx.Err()，
+// 调用方可以据此保存部分结果
 func (c *Client) AnalyzeCommentsWithRateLimit(ctx context.Context, comments []CommentInput, dimensions []Dimension, concurrency int) ([]CommentAnalysisResult, error) {
 	// 使用动态批次计算
 	config := DefaultBatchConfig()
@@ -277,16 +301,9 @@ func (c *Client) AnalyzeCommentsWithRateLimit(ctx context.Context, comments []Co
 	var completedCount int
 
 	for i, batch := range batches {
-		// 检查上下文取消
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
-
-		// 获取信号量
-		if err := sem.Acquire(ctx, 1); err != nil {
-			return nil, err
+		// 检查上下文取消，获取信号量失败也意味着上下文已取消
+		if ctx.Err() != nil || sem.Acquire(ctx, 1) != nil {
+			break
 		}
 
 		wg.Add(1)
@@ -298,6 +315,10 @@ func (c *Client) AnalyzeCommentsWithRateLimit(ctx context.Context, comments []Co
 
 			// 尝试批量合并分析
 			results, err := c.AnalyzeCommentsBatchMerged(ctx, b, dimensions)
+			if err != nil && ctx.Err() != nil {
+				// 任务已取消，丢弃本批次，不再降级
+				return
+			}
 			if err != nil {
 				// 降级：使用原有的并发单条分析
 				log.Printf("[AI] 批量分析失败，降级到单条分析: %v", err)
@@ -337,6 +358,11 @@ func (c *Client) AnalyzeCommentsWithRateLimit(ctx context.Context, comments []Co
 		allResults = append(allResults, results...)
 	}
 
+	if err := ctx.Err(); err != nil {
+		log.Printf("[AI] 分析被中断，已完成 %d 条结果: %v", len(allResults), err)
+		return allResults, err
+	}
+
 	log.Printf("[AI] 所有批次分析完成，共 %d 条结果", len(allResults))
 	return allResults, nil
 }
@@ -373,6 +399,8 @@ type BrandStrengthWeakness struct {
 
 // GenerateRecommendation 使用AI生成专业的购买建议
 func (c *Client) GenerateRecommendation(ctx context.Context, input RecommendationInput) (string, error) {
+	ctx = WithStage(ctx, StageRecommendation)
+
 	if len(input.Rankings) == 0 {
 		return "暂无足够数据生成购买建议", nil
 	}
@@ -429,16 +457,38 @@ func (c *Client) GenerateRecommendation(ctx context.Context, input Recommendatio
 // BatchAnalysisResult 批量分析结果（用于 JSON 解析）
 type BatchAnalysisResult struct {
 	Results []struct {
-		ID     string              `json:"id"`
-		Brand  string              `json:"brand"`
-		Model  string              `json:"model"`
-		Scores map[string]*float64 `json:"scores"`
+		ID      string                     `json:"id"`
+		Brand   string                     `json:"brand"`
+		Model   string                     `json:"model"`
+		Scores  map[string]*float64        `json:"scores"`
+		Aspects map[string]AspectSentiment `json:"aspects"`
 	} `json:"results"`
 }
 
+// batchAnalysisSchema 批量评论分析结果的Schema
+func batchAnalysisSchema(dimensions []Dimension) *JSONSchema {
+	item := objectSchema(map[string]interface{}{
+		"id":      stringSchema(1),
+		"brand":   stringSchema(1),
+		"model":   stringSchema(1),
+		"scores":  dimensionScoresSchema(dimensions),
+		"aspects": aspectsSchema(dimensions),
+	}, "id", "brand", "model", "scores")
+
+	return &JSONSchema{
+		Name:        "batch_comment_analysis",
+		Description: "按输入顺序排列的每条评论的品牌、型号、各维度评分及情感依据",
+		Schema: objectSchema(map[string]interface{}{
+			"results": arraySchema(item, 1),
+		}, "results"),
+	}
+}
+
 // AnalyzeCommentsBatchMerged 真正的批量分析（多条评论合并到一个请求）
 // 将多条评论合并到一个 API 请求中，大幅减少请求次数
 func (c *Client) AnalyzeCommentsBatchMerged(ctx context.Context, comments []CommentInput, dimensions []Dimension) ([]CommentAnalysisResult, error) {
+	ctx = WithStage(ctx, StageAnalysis)
+
 	if len(comments) == 0 {
 		return nil, fmt.Errorf("评论列表不能为空")
 	}
@@ -452,16 +502,28 @@ func (c *Client) AnalyzeCommentsBatchMerged(ctx context.Context, comments []Comm
 		dimList = append(dimList, fmt.Sprintf("- %s：%s", dim.Name, dim.Description))
 	}
 
-	// 构建评论列表文本
+	// 构建评论列表文本（弹幕加“弹幕”前缀）
 	var commentList []string
+	hasDanmaku := false
 	for i, c := range comments {
+		prefix := ""
+		if c.Source == sourceDanmaku {
+			prefix = "弹幕 | "
+			hasDanmaku = true
+		}
 		if c.VideoTitle != "" {
-			commentList = append(commentList, fmt.Sprintf("[%d] 视频：%s | 内容：%s", i+1, c.VideoTitle, c.Content))
+			commentList = append(commentList, fmt.Sprintf("[%d] %s视频：%s | 内容：%s", i+1, prefix, c.VideoTitle, c.Content))
 		} else {
-			commentList = append(commentList, fmt.Sprintf("[%d] 内容：%s", i+1, c.Content))
+			commentList = append(commentList, fmt.Sprintf("[%d] %s内容：%s", i+1, prefix, c.Content))
 		}
 	}
 
+	// 只在包含弹幕时追加规则，纯评论批次的提示词保持不变（不影响已有缓存）
+	danmakuRule := ""
+	if hasDanmaku {
+		danmakuRule = "\n- 标记为“弹幕”的是视频弹幕，内容简短且常针对画面，只对明确提及的品牌和维度打分"
+	}
+
 	systemPrompt := fmt.Sprintf(`你是商品评论分析助手。分析以下多条评论，为每条评论：
 1. 提取品牌名称和具体型号
 2. 对以下维度打分（1-10分，未提及则为null）：
@@ -469,6 +531,8 @@ func (c *Client) AnalyzeCommentsBatchMerged(ctx context.Context, comments []Comm
 
 评分标准：1-3差评，4-5一般，6-7较好，8-10优秀
 
+3. %s
+
 重要规则：
 - 每条评论独立分析，用评论编号[1][2]等标识
 - 品牌必须是单一品牌名称，绝对不能包含"/"或其他分隔符
@@ -477,10 +541,10 @@ func (c *Client) AnalyzeCommentsBatchMerged(ctx context.Context, comments []Comm
 - 型号必须是具体型号名（如"V12"、"Max"、"Pro"），不能是描述性文字（如"新款"、"基础款"）
 - 无法确定品牌填"未知"，无法确定型号填"通用"
 - 必须返回JSON格式，不要添加任何其他文字
-- results数组的顺序必须与输入评论顺序一致
+- results数组的顺序必须与输入评论顺序一致%s
 
 返回格式：
-{"results":[{"id":"1","brand":"品牌","model":"型号","scores":{"维度1":8.5,"维度2":null}},{"id":"2",...}]}`, strings.Join(dimList, "\n"))
+{"results":[{"id":"1","brand":"品牌","model":"型号","scores":{"维度1":8.5,"维度2":null},"aspects":{"维度1":{"polarity":"positive","confidence":0.9,"span":"评论原文片段"}}},{"id":"2",...}]}`, strings.Join(dimList, "\n"), aspectPrompt, danmakuRule)
 
 	userPrompt := fmt.Sprintf("评论列表（共%d条）：\n%s", len(comments), strings.Join(commentList, "\n"))
 
@@ -489,35 +553,20 @@ func (c *Client) AnalyzeCommentsBatchMerged(ctx context.Context, comments []Comm
 		{Role: "user", Content: userPrompt},
 	}
 
-	response, err := c.ChatCompletion(ctx, messages)
-	if err != nil {
-		return nil, fmt.Errorf("AI请求失败: %w", err)
-	}
-
-	// 清理响应（移除可能的 markdown 代码块）
-	cleanedResponse := cleanJSONResponse(response)
-
-	// 解析批量结果
+	// 结构化输出，自动校验和修复
 	var batchResult BatchAnalysisResult
-	if err := json.Unmarshal([]byte(cleanedResponse), &batchResult); err != nil {
-		// 尝试提取 JSON
-		jsonPattern := regexp.MustCompile(`\{[\s\S]*"results"[\s\S]*\}`)
-		match := jsonPattern.FindString(response)
-		if match != "" {
-			if err := json.Unmarshal([]byte(match), &batchResult); err != nil {
-				return nil, fmt.Errorf("解析批量响应失败: %w, 原始响应: %s", err, response[:min(len(response), 500)])
-			}
-		} else {
-			return nil, fmt.Errorf("无法从响应中提取JSON: %s", response[:min(len(response), 500)])
-		}
+	if _, err := c.ChatJSON(ctx, messages, batchAnalysisSchema(dimensions), &batchResult); err != nil {
+		return nil, fmt.Errorf("AI请求失败: %w", err)
 	}
 
 	// 转换为 CommentAnalysisResult 格式
 	results := make([]CommentAnalysisResult, len(comments))
 	for i, c := range comments {
 		results[i] = CommentAnalysisResult{
-			CommentID: c.ID,
-			Content:   c.Content,
+			CommentID:   c.ID,
+			Content:     c.Content,
+			Source:      c.Source,
+			PublishTime: c.PublishTime,
 		}
 
 		// 查找对应的分析结果
@@ -527,6 +576,7 @@ func (c *Client) AnalyzeCommentsBatchMerged(ctx context.Context, comments []Comm
 				results[i].Brand = r.Brand
 				results[i].Model = r.Model
 				results[i].Scores = r.Scores
+				results[i].Aspects = NormalizeAspects(c.Content, r.Scores, r.Aspects)
 				break
 			}
 		}
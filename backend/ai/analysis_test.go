@@ -2,63 +2,10 @@ package ai
 
 import (
 	"context"
+	"strings"
 	"testing"
 )
 
-func TestParseAnalysisResponse(t *testing.T) {
-	tests := []struct {
-		name     string
-		response string
-		wantErr  bool
-		check    func(*AnalyzeCommentResponse) bool
-	}{
-		{
-			name:     "valid JSON",
-			response: `{"scores":{"吸力":8.5,"续航":7.0,"噪音":null}}`,
-			wantErr:  false,
-			check: func(r *AnalyzeCommentResponse) bool {
-				if r.Scores["吸力"] == nil || *r.Scores["吸力"] != 8.5 {
-					return false
-				}
-				if r.Scores["续航"] == nil || *r.Scores["续航"] != 7.0 {
-					return false
-				}
-				if r.Scores["噪音"] != nil {
-					return false
-				}
-				return true
-			},
-		},
-		{
-			name:     "JSON with extra text",
-			response: `根据评论分析结果如下：{"scores":{"吸力":9.0,"续航":8.0}}`,
-			wantErr:  false,
-			check: func(r *AnalyzeCommentResponse) bool {
-				return r.Scores["吸力"] != nil && *r.Scores["吸力"] == 9.0
-			},
-		},
-		{
-			name:     "invalid JSON",
-			response: `这不是JSON`,
-			wantErr:  true,
-			check:    nil,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result, err := parseAnalysisResponse(tt.response)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("parseAnalysisResponse() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !tt.wantErr && tt.check != nil && !tt.check(result) {
-				t.Errorf("parseAnalysisResponse() result check failed")
-			}
-		})
-	}
-}
-
 func TestAnalyzeCommentValidation(t *testing.T) {
 	client := NewClient(Config{
 		APIKey: "test-key",
@@ -144,3 +91,34 @@ func TestCommentAnalysisResult(t *testing.T) {
 		t.Error("expected no error")
 	}
 }
+
+// TestAnalyzeCommentsBatchMergedDanmaku 测试弹幕在提示词中带来源标记，结果保留来源
+func TestAnalyzeCommentsBatchMergedDanmaku(t *testing.T) {
+	var requests []map[string]interface{}
+	server := newSequenceServer(t, []string{
+		`{"results":[{"id":"1","brand":"戴森","model":"V12","scores":{"吸力":8}},{"id":"2","brand":"戴森","model":"通用","scores":{"吸力":9}}]}`,
+	}, &requests)
+	defer server.Close()
+
+	client := NewClient(Config{APIBase: server.URL, APIKey: "test-key", Model: "gpt-4"})
+	results, err := client.AnalyzeCommentsBatchMerged(context.Background(), []CommentInput{
+		{ID: "c1", Content: "戴森V12吸力很强，地毯也能吸干净"},
+		{ID: "c2", Content: "戴森吸力无敌", Source: sourceDanmaku},
+	}, []Dimension{{Name: "吸力", Description: "吸尘能力"}})
+	if err != nil {
+		t.Fatalf("AnalyzeCommentsBatchMerged failed: %v", err)
+	}
+	if results[0].Source != "" || results[1].Source != sourceDanmaku {
+		t.Errorf("unexpected sources: %q %q", results[0].Source, results[1].Source)
+	}
+
+	msgs := requests[0]["messages"].([]interface{})
+	system := msgs[0].(map[string]interface{})["content"].(string)
+	user := msgs[1].(map[string]interface{})["content"].(string)
+	if !strings.Contains(user, "[2] 弹幕 | 内容：戴森吸力无敌") || strings.Contains(user, "[1] 弹幕") {
+		t.Errorf("unexpected user prompt: %s", user)
+	}
+	if !strings.Contains(system, "标记为“弹幕”") {
+		t.Error("expected danmaku rule in system prompt")
+	}
+}
@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"math"
+	"strings"
+)
+
+// 维度情感倾向
+const (
+	PolarityPositive = "positive" // 正面
+	PolarityNeutral  = "neutral"  // 中性
+	PolarityNegative = "negative" // 负面
+)
+
+// AspectSentiment 单个维度的情感判断及依据
+type AspectSentiment struct {
+	Polarity   string  `json:"polarity"`       // 情感倾向：positive/neutral/negative
+	Confidence float64 `json:"confidence"`     // 置信度（0-1）
+	Span       string  `json:"span,omitempty"` // 评论原文中支撑该判断的片段
+}
+
+// aspectsSchema 维度情感的Schema（维度名 -> {polarity, confidence, span}）
+// 与 scores 一样不把维度设为必填，未提及的维度可以省略
+func aspectsSchema(dimensions []Dimension) map[string]interface{} {
+	aspect := objectSchema(map[string]interface{}{
+		"polarity":   map[string]interface{}{"type": "string", "enum": []string{PolarityPositive, PolarityNeutral, PolarityNegative}},
+		"confidence": map[string]interface{}{"type": "number", "minimum": 0, "maximum": 1},
+		"span":       stringSchema(0),
+	}, "polarity", "confidence", "span")
+
+	if len(dimensions) == 0 {
+		return mapSchema(aspect)
+	}
+	properties := make(map[string]interface{}, len(dimensions))
+	for _, dim := range dimensions {
+		properties[dim.Name] = aspect
+	}
+	return objectSchema(properties)
+}
+
+// aspectPrompt 提示词中关于维度情感和原文片段的说明（单条和批量分析共用）
+const aspectPrompt = `对每个有评分的维度，在aspects中给出：
+- polarity：该维度的情感倾向，positive（正面）、neutral（中性）或 negative（负面），按评论对该维度的实际态度判断，不要根据分数推断
+- confidence：判断的置信度（0-1），评论表述越明确越高
+- span：评论原文中支撑该判断的连续片段，必须逐字摘自评论内容，不要改写、不要摘视频标题；找不到时填空字符串`
+
+// NormalizeAspects 清理AI返回的维度情感
+//   - 只保留有评分的维度，倾向不合法的维度丢弃
+//   - 置信度限制在 0-1，保留2位小数
+//   - 片段必须逐字出现在评论内容中（忽略首尾空白），否则清空，避免展示AI改写或编造的引文
+//
+// 没有任何有效维度时返回 nil
+func NormalizeAspects(content string, scores map[string]*float64, aspects map[string]AspectSentiment) map[string]AspectSentiment {
+	var result map[string]AspectSentiment
+	for dim, a := range aspects {
+		if score, ok := scores[dim]; !ok || score == nil {
+			continue
+		}
+		switch a.Polarity {
+		case PolarityPositive, PolarityNeutral, PolarityNegative:
+		default:
+			continue
+		}
+		a.Confidence = math.Round(math.Max(0, math.Min(1, a.Confidence))*100) / 100
+		a.Span = strings.TrimSpace(a.Span)
+		if a.Span != "" && !strings.Contains(content, a.Span) {
+			a.Span = ""
+		}
+		if result == nil {
+			result = make(map[string]AspectSentiment)
+		}
+		result[dim] = a
+	}
+	return result
+}
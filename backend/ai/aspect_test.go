@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeAspects(t *testing.T) {
+	s := func(v float64) *float64 { return &v }
+	content := "吸力很猛，就是噪音有点大"
+	scores := map[string]*float64{"吸力": s(9), "噪音": s(4), "续航": nil}
+	aspects := map[string]AspectSentiment{
+		"吸力": {Polarity: PolarityPositive, Confidence: 1.3, Span: " 吸力很猛 "},
+		"噪音": {Polarity: PolarityNegative, Confidence: 0.756, Span: "噪音很大"},
+		"续航": {Polarity: PolarityPositive, Confidence: 0.9, Span: "续航久"},
+		"价格": {Polarity: PolarityPositive, Confidence: 0.9},
+	}
+
+	got := NormalizeAspects(content, scores, aspects)
+	if len(got) != 2 {
+		t.Fatalf("expected only scored dimensions, got %+v", got)
+	}
+	if a := got["吸力"]; a.Span != "吸力很猛" || a.Confidence != 1 {
+		t.Errorf("unexpected 吸力 aspect: %+v", a)
+	}
+	if a := got["噪音"]; a.Span != "" || a.Confidence != 0.76 || a.Polarity != PolarityNegative {
+		t.Errorf("span not in content should be cleared: %+v", a)
+	}
+
+	invalid := NormalizeAspects(content, scores, map[string]AspectSentiment{"吸力": {Polarity: "good"}})
+	if invalid != nil {
+		t.Errorf("invalid polarity should be dropped, got %+v", invalid)
+	}
+}
+
+func TestAnalyzeCommentsBatchMergedAspects(t *testing.T) {
+	var requests []map[string]interface{}
+	server := newSequenceServer(t, []string{
+		`{"results":[{"id":"1","brand":"戴森","model":"V12","scores":{"吸力":9,"噪音":4},` +
+			`"aspects":{"吸力":{"polarity":"positive","confidence":0.92,"span":"吸力很强"},"噪音":{"polarity":"negative","confidence":0.8,"span":"声音太吵了"}}},` +
+			`{"id":"2","brand":"戴森","model":"通用","scores":{"吸力":7}}]}`,
+	}, &requests)
+	defer server.Close()
+
+	client := NewClient(Config{APIBase: server.URL, APIKey: "test-key", Model: "gpt-4"})
+	results, err := client.AnalyzeCommentsBatchMerged(context.Background(), []CommentInput{
+		{ID: "c1", Content: "戴森V12吸力很强，就是有点吵"},
+		{ID: "c2", Content: "还行吧"},
+	}, []Dimension{{Name: "吸力", Description: "吸尘能力"}, {Name: "噪音", Description: "运行噪音"}})
+	if err != nil {
+		t.Fatalf("AnalyzeCommentsBatchMerged failed: %v", err)
+	}
+	if a := results[0].Aspects["吸力"]; a.Polarity != PolarityPositive || a.Span != "吸力很强" || a.Confidence != 0.92 {
+		t.Errorf("unexpected aspect: %+v", a)
+	}
+	if a := results[0].Aspects["噪音"]; a.Polarity != PolarityNegative || a.Span != "" {
+		t.Errorf("rewritten span should be cleared: %+v", a)
+	}
+	if results[1].Aspects != nil || results[1].Scores["吸力"] == nil {
+		t.Errorf("result without aspects should keep scores: %+v", results[1])
+	}
+
+	system := requests[0]["messages"].([]interface{})[0].(map[string]interface{})["content"].(string)
+	if !strings.Contains(system, "span") || !strings.Contains(system, "polarity") {
+		t.Error("expected aspect rules in system prompt")
+	}
+}
@@ -2,7 +2,6 @@ package ai
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
@@ -29,6 +28,8 @@ type BrandIdentifyContext struct {
 // 一次性提交所有未知品牌的型号，返回型号→品牌映射
 // category: 商品类别（如"耳机"、"吸尘器"、"猫砂盆"），用于生成针对性的systemPrompt
 func (c *Client) IdentifyBrandsForModels(ctx context.Context, models []string, identifyCtx BrandIdentifyContext) (map[string]string, error) {
+	ctx = WithStage(ctx, StageBrandIdentify)
+
 	if len(models) == 0 {
 		return make(map[string]string), nil
 	}
@@ -67,24 +68,14 @@ func (c *Client) IdentifyBrandsForModels(ctx context.Context, models []string, i
 		{Role: "user", Content: userPrompt},
 	}
 
-	resp, err := c.ChatCompletion(ctx, messages)
-	if err != nil {
-		return nil, fmt.Errorf("AI调用失败: %w", err)
-	}
-
-	// 解析JSON响应
+	// 结构化输出，自动校验和修复
 	var result BrandIdentifyResponse
-
-	// 尝试提取JSON
-	jsonStr := resp
-	if idx := strings.Index(resp, "{"); idx != -1 {
-		if endIdx := strings.LastIndex(resp, "}"); endIdx != -1 {
-			jsonStr = resp[idx : endIdx+1]
+	if resp, err := c.ChatJSON(ctx, messages, brandIdentifySchema, &result); err != nil {
+		if resp == "" {
+			return nil, fmt.Errorf("AI调用失败: %w", err)
 		}
-	}
-
-	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
-		log.Printf("[AI] 品牌识别JSON解析失败: %v, 原始响应: %s", err, resp)
+		// 修复重试后仍无法解析时不中断任务，视为未识别出任何品牌
+		log.Printf("[AI] 品牌识别JSON解析失败: %v", err)
 		return make(map[string]string), nil
 	}
 
@@ -92,6 +83,15 @@ func (c *Client) IdentifyBrandsForModels(ctx context.Context, models []string, i
 	return result.Results, nil
 }
 
+// brandIdentifySchema 品牌识别结果的Schema（型号 -> 品牌）
+var brandIdentifySchema = &JSONSchema{
+	Name:        "brand_identify",
+	Description: "型号到品牌的映射，无法确定时品牌填未知",
+	Schema: objectSchema(map[string]interface{}{
+		"results": mapSchema(stringSchema(1)),
+	}, "results"),
+}
+
 // buildDynamicBrandPrompt 根据上下文动态构建提示词
 func buildDynamicBrandPrompt(ctx BrandIdentifyContext) string {
 	// 构建已知品牌列表
@@ -0,0 +1,94 @@
+package ai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+)
+
+// Cache AI响应缓存
+// 相同的模型、消息和输出结构（其中包含评价维度）会得到相同的缓存键，
+// 重复分析同一品类或恢复任务时直接复用之前的响应，避免重复计费
+type Cache interface {
+	// Get 读取缓存，未命中或已过期时返回 false
+	Get(key string) (string, bool)
+	// Set 写入缓存
+	Set(key, model, response string)
+	// Delete 删除缓存（响应未通过校验时调用，避免坏结果被反复命中）
+	Delete(key string)
+}
+
+// CacheStats 缓存命中统计（进程内累计，重启后清零）
+type CacheStats struct {
+	Hits   int64 `json:"hits"`   // 命中次数
+	Misses int64 `json:"misses"` // 未命中次数
+	Writes int64 `json:"writes"` // 写入次数
+}
+
+var cacheHits, cacheMisses, cacheWrites atomic.Int64
+
+// GetCacheStats 获取缓存命中统计
+func GetCacheStats() CacheStats {
+	return CacheStats{
+		Hits:   cacheHits.Load(),
+		Misses: cacheMisses.Load(),
+		Writes: cacheWrites.Load(),
+	}
+}
+
+// cacheKey 计算缓存键：提供方 + 接口地址 + 模型 + 消息列表 + 输出结构 的 SHA-256
+// 评价维度会体现在提示词和 Schema 中，维度变化时缓存键随之变化；
+// 不同接口地址即使模型名相同也可能是不同的模型，不共用缓存
+func (c *Client) cacheKey(req ChatRequest) string {
+	payload := struct {
+		Provider string                 `json:"provider"`
+		APIBase  string                 `json:"api_base"`
+		Model    string                 `json:"model"`
+		Messages []Message              `json:"messages"`
+		Schema   map[string]interface{} `json:"schema,omitempty"`
+	}{
+		Provider: c.provider.Name(),
+		APIBase:  strings.TrimRight(c.apiBase, "/"),
+		Model:    req.Model,
+		Messages: req.Messages,
+	}
+	if req.Schema != nil {
+		payload.Schema = req.Schema.Schema
+	}
+
+	data, _ := json.Marshal(payload)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheGet 读取缓存并更新统计
+func (c *Client) cacheGet(key string) (string, bool) {
+	if c.cache == nil {
+		return "", false
+	}
+	resp, ok := c.cache.Get(key)
+	if ok {
+		cacheHits.Add(1)
+	} else {
+		cacheMisses.Add(1)
+	}
+	return resp, ok
+}
+
+// cacheSet 写入缓存并更新统计
+func (c *Client) cacheSet(key, response string) {
+	if c.cache == nil || response == "" {
+		return
+	}
+	c.cache.Set(key, c.model, response)
+	cacheWrites.Add(1)
+}
+
+// cacheDelete 删除缓存
+func (c *Client) cacheDelete(key string) {
+	if c.cache != nil {
+		c.cache.Delete(key)
+	}
+}
@@ -0,0 +1,164 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// memoryCache 测试用内存缓存
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{entries: make(map[string]string)}
+}
+
+func (m *memoryCache) Get(key string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.entries[key]
+	return v, ok
+}
+
+func (m *memoryCache) Set(key, model, response string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = response
+}
+
+func (m *memoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+}
+
+// TestChatCompletionCache 测试相同请求命中缓存、不同请求不命中
+func TestChatCompletionCache(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"OK"}}]}`))
+	}))
+	defer server.Close()
+
+	cache := newMemoryCache()
+	client := NewClient(Config{APIBase: server.URL, APIKey: "test-key", Model: "gpt-4", Cache: cache})
+
+	before := GetCacheStats()
+	for i := 0; i < 2; i++ {
+		got, err := client.ChatCompletion(context.Background(), testMessages)
+		if err != nil || got != "OK" {
+			t.Fatalf("ChatCompletion = %q, %v", got, err)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected 1 request with cache, got %d", n)
+	}
+
+	// 消息不同时不命中
+	if _, err := client.ChatCompletion(context.Background(), []Message{{Role: "user", Content: "另一个问题"}}); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("Expected 2 requests, got %d", n)
+	}
+
+	after := GetCacheStats()
+	if after.Hits-before.Hits != 1 || after.Misses-before.Misses != 2 || after.Writes-before.Writes != 2 {
+		t.Errorf("Unexpected stats change: before %+v, after %+v", before, after)
+	}
+
+	// 不同模型的缓存键不同
+	other := NewClient(Config{APIBase: server.URL, APIKey: "test-key", Model: "gpt-4o", Cache: cache})
+	if _, err := other.ChatCompletion(context.Background(), testMessages); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("Expected a new request for a different model, got %d requests", n)
+	}
+}
+
+// TestCacheKeyIncludesSchema 测试输出结构（含维度）不同时缓存键不同
+func TestCacheKeyIncludesSchema(t *testing.T) {
+	client := NewClient(Config{APIKey: "test-key", Model: "gpt-4"})
+	a := client.cacheKey(ChatRequest{Model: "gpt-4", Messages: testMessages, Schema: commentAnalysisSchema([]Dimension{{Name: "吸力"}})})
+	b := client.cacheKey(ChatRequest{Model: "gpt-4", Messages: testMessages, Schema: commentAnalysisSchema([]Dimension{{Name: "续航"}})})
+	if a == b {
+		t.Error("Expected different cache keys for different dimensions")
+	}
+}
+
+// TestCacheKeyIncludesEndpoint 测试提供方或接口地址不同时缓存键不同
+func TestCacheKeyIncludesEndpoint(t *testing.T) {
+	req := ChatRequest{Model: "gpt-4", Messages: testMessages}
+	base := NewClient(Config{APIBase: "https://api.example.com/v1", APIKey: "test-key", Model: "gpt-4"}).cacheKey(req)
+
+	same := NewClient(Config{APIBase: "https://api.example.com/v1/", APIKey: "other-key", Model: "gpt-4"}).cacheKey(req)
+	if base != same {
+		t.Error("Expected the same cache key for the same endpoint")
+	}
+
+	otherBase := NewClient(Config{APIBase: "https://proxy.example.com/v1", APIKey: "test-key", Model: "gpt-4"}).cacheKey(req)
+	if base == otherBase {
+		t.Error("Expected different cache keys for different API base URLs")
+	}
+
+	otherProvider := NewClient(Config{Provider: ProviderOllama, APIBase: "https://api.example.com/v1", Model: "gpt-4"}).cacheKey(req)
+	if base == otherProvider {
+		t.Error("Expected different cache keys for different providers")
+	}
+}
+
+// TestChatJSONInvalidResponseNotCached 测试未通过校验的响应会从缓存中删除
+func TestChatJSONInvalidResponseNotCached(t *testing.T) {
+	server := newSequenceServer(t, []string{
+		`不是JSON`,
+		`{"is_relevant": true, "reason": "相关"}`,
+	}, nil)
+	defer server.Close()
+
+	cache := newMemoryCache()
+	client := NewClient(Config{APIBase: server.URL, APIKey: "test-key", Model: "gpt-4", Cache: cache})
+	checker := NewVideoRelevanceChecker(client)
+
+	if _, _, err := checker.CheckRelevance(context.Background(), "戴森V12测评", "吸尘器"); err != nil {
+		t.Fatalf("CheckRelevance failed: %v", err)
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if len(cache.entries) != 1 {
+		t.Fatalf("Expected only the valid response cached, got %d entries", len(cache.entries))
+	}
+	for _, v := range cache.entries {
+		if v == `不是JSON` {
+			t.Error("Invalid response should not stay in cache")
+		}
+	}
+}
+
+// TestNilCache 测试未配置缓存时正常请求
+func TestNilCache(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"OK"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{APIBase: server.URL, APIKey: "test-key", Model: "gpt-4"})
+	for i := 0; i < 2; i++ {
+		if _, err := client.ChatCompletion(context.Background(), testMessages); err != nil {
+			t.Fatalf("ChatCompletion failed: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("Expected 2 requests without cache, got %d", n)
+	}
+}
@@ -1,34 +1,39 @@
 package ai
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
+	"log"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/sync/semaphore"
 )
 
 // Client AI客户端
-// 用于与OpenAI兼容的API进行交互
+// 通过 Provider 与 OpenAI 兼容接口、Anthropic、Gemini、Ollama 等模型服务交互
 type Client struct {
-	apiBase          string              // API基础URL（如：https://api.openai.com/v1）
-	apiKey           string              // API密钥
-	model            string              // 使用的模型名称（如：gemini-3-flash-preview）
-	httpClient       *http.Client        // HTTP客户端
-	sem              *semaphore.Weighted // 并发控制信号量
-	progressCallback ProgressCallback    // 进度回调函数
+	apiBase           string              // API基础URL（如：https://api.openai.com/v1）
+	apiKey            string              // API密钥
+	model             string              // 使用的模型名称（如：gemini-3-flash-preview）
+	provider          Provider            // 模型服务提供方（负责请求格式、认证和响应解析）
+	schemaUnsupported atomic.Bool         // 接口是否拒绝过结构化输出参数
+	httpClient        *http.Client        // HTTP客户端
+	sem               *semaphore.Weighted // 并发控制信号量
+	progressCallback  ProgressCallback    // 进度回调函数
+	cache             Cache               // 响应缓存（nil 表示不使用缓存）
+	usage             *UsageTracker       // Token 用量统计（nil 表示不统计）
 }
 
 // Config AI客户端配置
 type Config struct {
-	APIBase       string // API Base URL（默认：https://api.openai.com/v1）
+	Provider      string // 提供方：openai/anthropic/gemini/ollama（默认：openai）
+	APIBase       string // API Base URL（默认：对应提供方的官方地址，如 https://api.openai.com/v1）
 	APIKey        string // API Key
 	Model         string // 模型名称
 	MaxConcurrent int64  // 最大并发数（默认：5）
+	Cache         Cache  // 响应缓存（可选，nil 表示不使用缓存）
 }
 
 // NewClient 创建新的AI客户端
@@ -40,28 +45,52 @@ type Config struct {
 func NewClient(cfg Config) *Client {
 	// 设置默认API Base URL
 	if cfg.APIBase == "" {
-		cfg.APIBase = "https://api.openai.com/v1"
+		cfg.APIBase = DefaultAPIBase(cfg.Provider)
 	}
 	// 设置默认最大并发数
 	if cfg.MaxConcurrent == 0 {
 		cfg.MaxConcurrent = 10
 	}
 
+	provider, err := NewProvider(cfg.Provider, cfg.APIBase, cfg.APIKey)
+	if err != nil {
+		// 配置错误时不中断创建，所有请求都会返回该错误
+		log.Printf("[AI] %v", err)
+		provider = &unsupportedProvider{err: err}
+	}
+
 	return &Client{
-		apiBase: cfg.APIBase,
-		apiKey:  cfg.APIKey,
-		model:   cfg.Model,
+		apiBase:  cfg.APIBase,
+		apiKey:   cfg.APIKey,
+		model:    cfg.Model,
+		provider: provider,
 		httpClient: &http.Client{
 			Timeout: 60 * time.Second, // 60秒超时（AI请求可能较慢）
 		},
-		sem: semaphore.NewWeighted(cfg.MaxConcurrent), // 创建并发控制信号量
+		sem:   semaphore.NewWeighted(cfg.MaxConcurrent), // 创建并发控制信号量
+		cache: cfg.Cache,
 	}
 }
 
 // ChatCompletionRequest Chat Completion请求结构
 type ChatCompletionRequest struct {
-	Model    string    `json:"model"`    // 模型名称
-	Messages []Message `json:"messages"` // 消息列表
+	Model          string          `json:"model"`                     // 模型名称
+	Messages       []Message       `json:"messages"`                  // 消息列表
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"` // 结构化输出格式（可选）
+}
+
+// ResponseFormat 结构化输出格式（OpenAI response_format）
+type ResponseFormat struct {
+	Type       string              `json:"type"`                  // json_schema
+	JSONSchema *ResponseJSONSchema `json:"json_schema,omitempty"` // Schema 定义
+}
+
+// ResponseJSONSchema response_format 中的 Schema 定义
+type ResponseJSONSchema struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Schema      map[string]interface{} `json:"schema"`
+	Strict      bool                   `json:"strict"`
 }
 
 // Message 消息结构
@@ -77,6 +106,10 @@ type ChatCompletionResponse struct {
 	Created int64    `json:"created"` // 创建时间戳
 	Model   string   `json:"model"`   // 使用的模型
 	Choices []Choice `json:"choices"` // 选择项列表
+	Usage   struct {
+		PromptTokens     int64 `json:"prompt_tokens"`     // 输入 Token 数
+		CompletionTokens int64 `json:"completion_tokens"` // 输出 Token 数
+	} `json:"usage"` // Token 用量
 }
 
 // Choice 选择项结构
@@ -95,16 +128,48 @@ type Choice struct {
 //   - string: AI返回的文本内容
 //   - error: 请求失败时返回错误
 func (c *Client) ChatCompletion(ctx context.Context, messages []Message) (string, error) {
+	return c.chat(ctx, ChatRequest{
+		Model:    c.model,
+		Messages: messages,
+	})
+}
+
+// chat 发送请求（含缓存、用量统计、并发控制和重试），ChatCompletion 和 ChatJSON 共用
+func (c *Client) chat(ctx context.Context, req ChatRequest) (string, error) {
+	stage := stageFromContext(ctx)
+
+	// 命中缓存时直接返回，不占用并发名额，也不消耗 Token
+	key := c.cacheKey(req)
+	if resp, ok := c.cacheGet(key); ok {
+		c.recordUsage(stage, Usage{}, true)
+		return resp, nil
+	}
+
+	// 预算已用尽时不再发起新请求
+	if c.usage != nil && c.usage.Exceeded() {
+		return "", ErrTokenBudgetExceeded
+	}
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	c.recordUsage(stage, resp.Usage, false)
+	c.cacheSet(key, resp.Content)
+	return resp.Content, nil
+}
+
+// send 发送请求（含并发控制和重试）
+func (c *Client) send(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
 	// 并发控制：获取信号量（限制同时进行的请求数）
 	if err := c.sem.Acquire(ctx, 1); err != nil {
-		return "", fmt.Errorf("acquire semaphore failed: %w", err)
+		return nil, fmt.Errorf("acquire semaphore failed: %w", err)
 	}
 	defer c.sem.Release(1) // 请求完成后释放信号量
 
-	// 构建请求
-	req := ChatCompletionRequest{
-		Model:    c.model,
-		Messages: messages,
+	// 接口不支持结构化输出参数时，后续请求不再发送 Schema（仍会在本地校验）
+	if c.schemaUnsupported.Load() {
+		req.Schema = nil
 	}
 
 	// 重试逻辑：最多重试1次（总共尝试2次）
@@ -116,75 +181,51 @@ func (c *Client) ChatCompletion(ctx context.Context, messages []Message) (string
 		}
 		lastErr = err
 
-		// 第一次失败后等待1秒再重试
+		// 带 Schema 的请求因结构化输出参数被拒绝时，视为接口不支持结构化输出，去掉 Schema 立即重试
+		if req.Schema != nil && isSchemaRejection(err) {
+			log.Printf("[AI] %s 接口不支持结构化输出参数，改为仅本地校验: %v", c.provider.Name(), err)
+			c.schemaUnsupported.Store(true)
+			req.Schema = nil
+			attempt--
+			continue
+		}
+
+		// 第一次失败后等待1秒再重试（上下文取消时立即返回）
 		if attempt == 0 {
-			time.Sleep(1 * time.Second)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(1 * time.Second):
+			}
 		}
 	}
 
-	return "", fmt.Errorf("request failed after 2 attempts: %w", lastErr)
+	return nil, fmt.Errorf("request failed after 2 attempts: %w", lastErr)
 }
 
-// doRequest 执行HTTP请求
+// doRequest 通过提供方发送一次请求
 // 参数：
 //   - ctx: 上下文
-//   - req: Chat Completion请求
+//   - req: 对话请求
 //
 // 返回：
-//   - string: AI返回的文本内容
+//   - *ChatResponse: AI返回的文本内容和 Token 用量
 //   - error: 请求失败时返回错误
-func (c *Client) doRequest(ctx context.Context, req ChatCompletionRequest) (string, error) {
-	// 序列化请求体为JSON
-	body, err := json.Marshal(req)
-	if err != nil {
-		return "", fmt.Errorf("marshal request failed: %w", err)
-	}
-
-	// 创建HTTP请求
-	httpReq, err := http.NewRequestWithContext(
-		ctx,
-		"POST",
-		c.apiBase+"/chat/completions", // OpenAI Chat Completion端点
-		bytes.NewReader(body),
-	)
-	if err != nil {
-		return "", fmt.Errorf("create request failed: %w", err)
-	}
-
-	// 设置请求头
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey) // Bearer Token认证
-
-	// 发送HTTP请求
-	httpResp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return "", fmt.Errorf("http request failed: %w", err)
-	}
-	defer httpResp.Body.Close()
-
-	// 读取响应体
-	respBody, err := io.ReadAll(httpResp.Body)
-	if err != nil {
-		return "", fmt.Errorf("read response failed: %w", err)
-	}
-
-	// 检查HTTP状态码
-	if httpResp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API returned status %d: %s", httpResp.StatusCode, string(respBody))
-	}
+func (c *Client) doRequest(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	return c.provider.Chat(ctx, c.httpClient, req)
+}
 
-	// 解析JSON响应
-	var resp ChatCompletionResponse
-	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return "", fmt.Errorf("unmarshal response failed: %w", err)
-	}
+// SetUsageTracker 设置 Token 用量统计
+// 同一任务的所有调用共用一个 UsageTracker；设置了预算时，超出后的请求返回 ErrTokenBudgetExceeded
+func (c *Client) SetUsageTracker(tracker *UsageTracker) {
+	c.usage = tracker
+}
 
-	// 提取AI返回的文本内容
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response")
+// recordUsage 记录一次调用的 Token 用量
+func (c *Client) recordUsage(stage string, usage Usage, cached bool) {
+	if c.usage != nil {
+		c.usage.Add(stage, usage, cached)
 	}
-
-	return resp.Choices[0].Message.Content, nil
 }
 
 // SetProgressCallback 设置进度回调
@@ -2,7 +2,6 @@ package ai
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -20,6 +19,8 @@ import (
 //   - []Dimension: 生成的评价维度列表（6个维度）
 //   - error: 生成失败时返回错误
 func (c *Client) GenerateDimensions(ctx context.Context, videoTitle, videoDesc string, comments []string) ([]Dimension, error) {
+	ctx = WithStage(ctx, StageDimensions)
+
 	// 构建评论样本文本（限制数量，避免内容过长）
 	maxComments := 20 // 最多使用20条评论作为样本
 	if len(comments) > maxComments {
@@ -50,7 +51,7 @@ func (c *Client) GenerateDimensions(ctx context.Context, videoTitle, videoDesc s
 1. 维度必须与视频主题直接相关
 2. 每个维度名称4-6个字
 3. 每个维度描述10-20字
-4. 只返回JSON数组，格式：[{"name": "xxx", "description": "xxx"}]`
+4. 只返回JSON，格式：{"dimensions": [{"name": "xxx", "description": "xxx"}]}`
 
 	// 构建用户提示词
 	userPrompt := fmt.Sprintf(`【视频信息】
@@ -66,57 +67,23 @@ func (c *Client) GenerateDimensions(ctx context.Context, videoTitle, videoDesc s
 		{Role: "user", Content: userPrompt},
 	}
 
-	// 调用AI接口
-	response, err := c.ChatCompletion(ctx, messages)
-	if err != nil {
-		return nil, fmt.Errorf("AI请求失败: %w", err)
-	}
-
-	// 清理AI返回的响应（移除Markdown代码块标记）
-	cleanedResponse := cleanJSONResponse(response)
-
-	// 尝试解析JSON数组
-	var dimensions []Dimension
-	if err := json.Unmarshal([]byte(cleanedResponse), &dimensions); err != nil {
-		// 如果直接解析失败，尝试从响应中提取JSON数组
-		extracted := extractJSONArray(cleanedResponse)
-		if extracted == "" {
-			return nil, fmt.Errorf("无法从响应中提取JSON数组: %s", response[:min(len(response), 200)])
-		}
-
-		if err := json.Unmarshal([]byte(extracted), &dimensions); err != nil {
-			return nil, fmt.Errorf("JSON解析失败: %w, 原始响应: %s", err, response[:min(len(response), 200)])
-		}
-	}
-
-	// 验证结果
-	if len(dimensions) == 0 {
-		return nil, fmt.Errorf("AI未返回任何评价维度")
+	// 调用AI接口（结构化输出，维度为空或缺少描述时自动要求AI修复）
+	var result struct {
+		Dimensions []Dimension `json:"dimensions"`
 	}
-
-	// 确保维度名称和描述不为空
-	for i := range dimensions {
-		if dimensions[i].Name == "" {
-			return nil, fmt.Errorf("第%d个维度名称为空", i+1)
-		}
-		if dimensions[i].Description == "" {
-			return nil, fmt.Errorf("第%d个维度描述为空", i+1)
-		}
+	if _, err := c.ChatJSON(ctx, messages, generateDimensionsSchema, &result); err != nil {
+		return nil, fmt.Errorf("AI请求失败: %w", err)
 	}
 
-	return dimensions, nil
+	return result.Dimensions, nil
 }
 
-// extractJSONArray 从文本中提取JSON数组
-// 用于处理AI返回的包含额外文本的情况
-func extractJSONArray(text string) string {
-	// 查找第一个 [ 和最后一个 ]
-	start := strings.Index(text, "[")
-	end := strings.LastIndex(text, "]")
-
-	if start == -1 || end == -1 || start >= end {
-		return ""
-	}
-
-	return text[start : end+1]
+// generateDimensionsSchema 视频评价维度生成结果的Schema
+// 结构化输出要求根节点为对象，因此维度数组包装在 dimensions 字段中
+var generateDimensionsSchema = &JSONSchema{
+	Name:        "video_dimensions",
+	Description: "适合分析该视频评论的评价维度",
+	Schema: objectSchema(map[string]interface{}{
+		"dimensions": arraySchema(dimensionSchema, 1),
+	}, "dimensions"),
 }
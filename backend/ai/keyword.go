@@ -2,7 +2,6 @@ package ai
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
@@ -78,6 +77,8 @@ func cleanJSONResponse(response string) string {
 // ParseKeyword 解析关键词
 // 这是核心方法，调用AI来解析用户输入的商品类目
 func (c *Client) ParseKeyword(ctx context.Context, req ParseKeywordRequest) (*ParseKeywordResponse, error) {
+	ctx = WithStage(ctx, StageKeyword)
+
 	// 构建系统提示词，告诉AI它的角色和任务
 	systemPrompt := `你是一个商品分析助手。用户会用自然语言描述他们的购买需求，你需要：
 
@@ -143,42 +144,38 @@ func (c *Client) ParseKeyword(ctx context.Context, req ParseKeywordRequest) (*Pa
 		{Role: "user", Content: userPrompt},
 	}
 
-	// 发送请求到AI服务
-	response, err := c.ChatCompletion(ctx, messages)
-	if err != nil {
-		return nil, fmt.Errorf("AI请求失败: %w", err)
-	}
-
-	// 清理AI返回的响应（移除Markdown代码块标记）
-	cleanedResponse := cleanJSONResponse(response)
-
-	// 解析AI返回的JSON响应
+	// 发送请求到AI服务（结构化输出，必填字段缺失时自动要求AI修复）
 	var result ParseKeywordResponse
-	if err := json.Unmarshal([]byte(cleanedResponse), &result); err != nil {
-		// 如果解析失败，记录原始响应以便调试
-		return nil, fmt.Errorf("解析AI响应失败: %w (原始响应: %s)", err, response[:min(len(response), 200)])
-	}
-
-	// 验证结果的完整性
-	if result.Understanding == "" {
-		return nil, fmt.Errorf("AI未返回需求理解描述")
-	}
-	if result.ProductType == "" {
-		return nil, fmt.Errorf("AI未返回商品类型")
-	}
-	if len(result.Brands) == 0 {
-		return nil, fmt.Errorf("AI未返回品牌列表")
-	}
-	if len(result.Dimensions) == 0 {
-		return nil, fmt.Errorf("AI未返回评价维度")
-	}
-	if len(result.Keywords) == 0 {
-		return nil, fmt.Errorf("AI未返回搜索关键词")
+	if _, err := c.ChatJSON(ctx, messages, parseKeywordSchema, &result); err != nil {
+		return nil, fmt.Errorf("AI请求失败: %w", err)
 	}
 
 	return &result, nil
 }
 
+// parseKeywordSchema 需求解析结果的Schema
+// 需求理解、商品类型、品牌、维度和关键词均为必填且不能为空
+var parseKeywordSchema = &JSONSchema{
+	Name:        "parse_requirement",
+	Description: "用户购买需求的解析结果",
+	Schema: objectSchema(map[string]interface{}{
+		"understanding": stringSchema(1),
+		"product_type":  stringSchema(1),
+		"budget":        stringSchema(0),
+		"scenario":      stringSchema(0),
+		"special_needs": arraySchema(stringSchema(0), 0),
+		"brands":        arraySchema(stringSchema(1), 1),
+		"dimensions":    arraySchema(dimensionSchema, 1),
+		"keywords":      arraySchema(stringSchema(1), 1),
+	}, "understanding", "product_type", "brands", "dimensions", "keywords"),
+}
+
+// dimensionSchema 评价维度的Schema
+var dimensionSchema = objectSchema(map[string]interface{}{
+	"name":        stringSchema(1),
+	"description": stringSchema(1),
+}, "name", "description")
+
 // min 返回两个整数中的较小值
 func min(a, b int) int {
 	if a < b {
@@ -0,0 +1,466 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// 支持的模型服务提供方
+const (
+	ProviderOpenAI    = "openai"    // OpenAI 兼容接口（POST /chat/completions，Bearer 认证）
+	ProviderAnthropic = "anthropic" // Anthropic Messages API（POST /messages，x-api-key 认证）
+	ProviderGemini    = "gemini"    // Gemini 原生接口（POST /models/{model}:generateContent）
+	ProviderOllama    = "ollama"    // Ollama 本地服务（POST /api/chat）
+)
+
+// ChatRequest 与具体服务无关的对话请求
+type ChatRequest struct {
+	Model    string      // 模型名称
+	Messages []Message   // 消息列表（可包含 system 消息）
+	Schema   *JSONSchema // 期望的结构化输出（可选，为空时返回自由文本）
+}
+
+// ChatResponse 与具体服务无关的对话响应
+type ChatResponse struct {
+	Content string // 模型返回的文本内容
+	Usage   Usage  // Token 用量（接口未返回时为0）
+}
+
+// Provider 模型服务提供方
+// 每个实现负责自己的请求地址、认证请求头以及响应结构解析
+type Provider interface {
+	// Name 返回提供方标识（如 openai、anthropic）
+	Name() string
+	// Chat 发送一次对话请求（不含重试，重试由 Client 统一处理）
+	Chat(ctx context.Context, httpClient *http.Client, req ChatRequest) (*ChatResponse, error)
+}
+
+// NewProvider 根据名称创建提供方
+// 参数：
+//   - name: 提供方标识（openai/anthropic/gemini/ollama，空字符串视为 openai）
+//   - apiBase: API基础URL（为空时使用该提供方的默认地址）
+//   - apiKey: API密钥
+//
+// 返回：
+//   - Provider: 提供方实例
+//   - error: 不支持的提供方时返回错误
+func NewProvider(name, apiBase, apiKey string) (Provider, error) {
+	if apiBase == "" {
+		apiBase = DefaultAPIBase(name)
+	}
+	apiBase = strings.TrimRight(apiBase, "/")
+
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", ProviderOpenAI:
+		return &openAIProvider{apiBase: apiBase, apiKey: apiKey}, nil
+	case ProviderAnthropic:
+		return &anthropicProvider{apiBase: apiBase, apiKey: apiKey}, nil
+	case ProviderGemini:
+		return &geminiProvider{apiBase: apiBase, apiKey: apiKey}, nil
+	case ProviderOllama:
+		return &ollamaProvider{apiBase: apiBase, apiKey: apiKey}, nil
+	default:
+		return nil, fmt.Errorf("unsupported AI provider: %s", name)
+	}
+}
+
+// RequiresAPIKey 判断提供方是否必须配置API Key（Ollama 本地服务不需要）
+func RequiresAPIKey(name string) bool {
+	return strings.ToLower(strings.TrimSpace(name)) != ProviderOllama
+}
+
+// DefaultAPIBase 返回提供方的默认API基础URL
+func DefaultAPIBase(name string) string {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case ProviderAnthropic:
+		return "https://api.anthropic.com/v1"
+	case ProviderGemini:
+		return "https://generativelanguage.googleapis.com/v1beta"
+	case ProviderOllama:
+		return "http://localhost:11434"
+	default:
+		return "https://api.openai.com/v1"
+	}
+}
+
+// APIError 模型服务返回的非200响应
+type APIError struct {
+	StatusCode int    // HTTP状态码
+	Body       string // 响应体
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// schemaParamNames 各提供方结构化输出参数名（小写），出现在错误响应中说明接口不支持该参数
+var schemaParamNames = []string{"response_format", "json_schema", "responsejsonschema", "tools", "tool_choice"}
+
+// isSchemaRejection 判断是否为接口拒绝结构化输出参数的错误
+// 只有请求参数类错误（400/422）且响应体提到结构化输出参数时才算，
+// 提示词过长、格式错误等其他参数错误不影响后续请求使用结构化输出
+func isSchemaRejection(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.StatusCode != http.StatusBadRequest && apiErr.StatusCode != http.StatusUnprocessableEntity {
+		return false
+	}
+	body := strings.ToLower(apiErr.Body)
+	for _, name := range schemaParamNames {
+		if strings.Contains(body, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// postJSON 发送JSON请求并解析JSON响应（各提供方共用）
+func postJSON(ctx context.Context, httpClient *http.Client, url string, headers map[string]string, payload interface{}, out interface{}) error {
+	// 序列化请求体为JSON
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal request failed: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("http request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return fmt.Errorf("read response failed: %w", err)
+	}
+
+	// 检查HTTP状态码
+	if httpResp.StatusCode != http.StatusOK {
+		return &APIError{StatusCode: httpResp.StatusCode, Body: string(respBody)}
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response failed: %w", err)
+	}
+	return nil
+}
+
+// splitSystemMessages 拆分 system 消息和对话消息
+// Anthropic 和 Gemini 要求 system 提示词单独传递，且对话需要 user/assistant 交替出现，
+// 这里把相邻的同角色消息合并为一条
+func splitSystemMessages(messages []Message) (string, []Message) {
+	var system []string
+	var turns []Message
+	for _, m := range messages {
+		if m.Role == "system" {
+			system = append(system, m.Content)
+			continue
+		}
+		role := m.Role
+		if role != "assistant" {
+			role = "user"
+		}
+		if n := len(turns); n > 0 && turns[n-1].Role == role {
+			turns[n-1].Content += "\n\n" + m.Content
+			continue
+		}
+		turns = append(turns, Message{Role: role, Content: m.Content})
+	}
+	return strings.Join(system, "\n\n"), turns
+}
+
+// openAIProvider OpenAI 兼容接口
+type openAIProvider struct {
+	apiBase string
+	apiKey  string
+}
+
+func (p *openAIProvider) Name() string { return ProviderOpenAI }
+
+func (p *openAIProvider) Chat(ctx context.Context, httpClient *http.Client, req ChatRequest) (*ChatResponse, error) {
+	var resp ChatCompletionResponse
+	err := postJSON(ctx, httpClient, p.apiBase+"/chat/completions", map[string]string{
+		"Authorization": "Bearer " + p.apiKey, // Bearer Token认证
+	}, ChatCompletionRequest{
+		Model:          req.Model,
+		Messages:       req.Messages,
+		ResponseFormat: openAIResponseFormat(req.Schema),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// 提取AI返回的文本内容
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
+	}
+	return &ChatResponse{
+		Content: resp.Choices[0].Message.Content,
+		Usage:   Usage{PromptTokens: resp.Usage.PromptTokens, CompletionTokens: resp.Usage.CompletionTokens},
+	}, nil
+}
+
+// openAIResponseFormat 把 Schema 转换为 response_format
+// 使用非严格模式：严格模式要求所有字段必填且禁止 additionalProperties，与部分 Schema 不兼容
+func openAIResponseFormat(schema *JSONSchema) *ResponseFormat {
+	if schema == nil {
+		return nil
+	}
+	return &ResponseFormat{
+		Type: "json_schema",
+		JSONSchema: &ResponseJSONSchema{
+			Name:        schema.Name,
+			Description: schema.Description,
+			Schema:      schema.Schema,
+		},
+	}
+}
+
+// anthropicProvider Anthropic Messages API
+type anthropicProvider struct {
+	apiBase string
+	apiKey  string
+}
+
+// anthropicAPIVersion Anthropic API 版本请求头
+const anthropicAPIVersion = "2023-06-01"
+
+// anthropicMaxTokens Messages API 必填的最大输出长度
+const anthropicMaxTokens = 8192
+
+type anthropicRequest struct {
+	Model      string                 `json:"model"`
+	MaxTokens  int                    `json:"max_tokens"`
+	System     string                 `json:"system,omitempty"`
+	Messages   []Message              `json:"messages"`
+	Tools      []anthropicTool        `json:"tools,omitempty"`
+	ToolChoice map[string]interface{} `json:"tool_choice,omitempty"`
+}
+
+// anthropicTool 工具定义（结构化输出通过强制调用唯一的工具实现）
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Input json.RawMessage `json:"input"` // tool_use 块的参数
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int64 `json:"input_tokens"`
+		OutputTokens int64 `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+func (p *anthropicProvider) Name() string { return ProviderAnthropic }
+
+func (p *anthropicProvider) Chat(ctx context.Context, httpClient *http.Client, req ChatRequest) (*ChatResponse, error) {
+	system, turns := splitSystemMessages(req.Messages)
+
+	payload := anthropicRequest{
+		Model:     req.Model,
+		MaxTokens: anthropicMaxTokens,
+		System:    system,
+		Messages:  turns,
+	}
+	if req.Schema != nil {
+		payload.Tools = []anthropicTool{{
+			Name:        req.Schema.Name,
+			Description: req.Schema.Description,
+			InputSchema: req.Schema.Schema,
+		}}
+		payload.ToolChoice = map[string]interface{}{"type": "tool", "name": req.Schema.Name}
+	}
+
+	var resp anthropicResponse
+	err := postJSON(ctx, httpClient, p.apiBase+"/messages", map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicAPIVersion,
+	}, payload, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := Usage{PromptTokens: resp.Usage.InputTokens, CompletionTokens: resp.Usage.OutputTokens}
+	var text strings.Builder
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "tool_use":
+			// 强制工具调用时，工具参数即结构化结果
+			return &ChatResponse{Content: string(block.Input), Usage: usage}, nil
+		case "text":
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return nil, fmt.Errorf("no text content in response")
+	}
+	return &ChatResponse{Content: text.String(), Usage: usage}, nil
+}
+
+// geminiProvider Gemini 原生接口
+type geminiProvider struct {
+	apiBase string
+	apiKey  string
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiRequest struct {
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent         `json:"contents"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	ResponseMimeType   string                 `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema map[string]interface{} `json:"responseJsonSchema,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int64 `json:"promptTokenCount"`
+		CandidatesTokenCount int64 `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+func (p *geminiProvider) Name() string { return ProviderGemini }
+
+func (p *geminiProvider) Chat(ctx context.Context, httpClient *http.Client, req ChatRequest) (*ChatResponse, error) {
+	system, turns := splitSystemMessages(req.Messages)
+
+	payload := geminiRequest{}
+	if system != "" {
+		payload.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: system}}}
+	}
+	if req.Schema != nil {
+		payload.GenerationConfig = &geminiGenerationConfig{
+			ResponseMimeType:   "application/json",
+			ResponseJSONSchema: req.Schema.Schema,
+		}
+	}
+	for _, m := range turns {
+		role := "user"
+		if m.Role == "assistant" {
+			role = "model" // Gemini 使用 model 表示助手角色
+		}
+		payload.Contents = append(payload.Contents, geminiContent{
+			Role:  role,
+			Parts: []geminiPart{{Text: m.Content}},
+		})
+	}
+
+	var resp geminiResponse
+	err := postJSON(ctx, httpClient, p.apiBase+"/models/"+req.Model+":generateContent", map[string]string{
+		"x-goog-api-key": p.apiKey,
+	}, payload, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Candidates) == 0 {
+		return nil, fmt.Errorf("no candidates in response")
+	}
+	var text strings.Builder
+	for _, part := range resp.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+	return &ChatResponse{
+		Content: text.String(),
+		Usage: Usage{
+			PromptTokens:     resp.UsageMetadata.PromptTokenCount,
+			CompletionTokens: resp.UsageMetadata.CandidatesTokenCount,
+		},
+	}, nil
+}
+
+// ollamaProvider Ollama 本地服务
+type ollamaProvider struct {
+	apiBase string
+	apiKey  string // 通常为空；通过反向代理鉴权时作为 Bearer Token 发送
+}
+
+type ollamaRequest struct {
+	Model    string                 `json:"model"`
+	Messages []Message              `json:"messages"`
+	Stream   bool                   `json:"stream"`
+	Format   map[string]interface{} `json:"format,omitempty"` // 结构化输出 Schema
+}
+
+type ollamaResponse struct {
+	Message         Message `json:"message"`
+	Done            bool    `json:"done"`
+	PromptEvalCount int64   `json:"prompt_eval_count"` // 输入 Token 数
+	EvalCount       int64   `json:"eval_count"`        // 输出 Token 数
+}
+
+func (p *ollamaProvider) Name() string { return ProviderOllama }
+
+func (p *ollamaProvider) Chat(ctx context.Context, httpClient *http.Client, req ChatRequest) (*ChatResponse, error) {
+	headers := map[string]string{}
+	if p.apiKey != "" {
+		headers["Authorization"] = "Bearer " + p.apiKey
+	}
+
+	var resp ollamaResponse
+	payload := ollamaRequest{
+		Model:    req.Model,
+		Messages: req.Messages,
+		Stream:   false, // 关闭流式输出，一次性返回完整结果
+	}
+	if req.Schema != nil {
+		payload.Format = req.Schema.Schema
+	}
+
+	err := postJSON(ctx, httpClient, p.apiBase+"/api/chat", headers, payload, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &ChatResponse{
+		Content: resp.Message.Content,
+		Usage:   Usage{PromptTokens: resp.PromptEvalCount, CompletionTokens: resp.EvalCount},
+	}, nil
+}
+
+// unsupportedProvider 配置了不支持的提供方时使用，所有请求直接返回配置错误
+type unsupportedProvider struct {
+	err error
+}
+
+func (p *unsupportedProvider) Name() string { return "unsupported" }
+
+func (p *unsupportedProvider) Chat(ctx context.Context, httpClient *http.Client, req ChatRequest) (*ChatResponse, error) {
+	return nil, p.err
+}
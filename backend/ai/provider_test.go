@@ -0,0 +1,164 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestServer 创建模拟服务，记录请求并返回固定响应
+func newTestServer(t *testing.T, wantPath string, check func(r *http.Request, body map[string]interface{}), response string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != wantPath {
+			t.Errorf("Expected path %s, got %s", wantPath, r.URL.Path)
+		}
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Fatalf("Invalid request body: %v", err)
+		}
+		check(r, body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(response))
+	}))
+}
+
+var testMessages = []Message{
+	{Role: "system", Content: "你是助手"},
+	{Role: "user", Content: "你好"},
+}
+
+// TestOpenAIProvider 测试OpenAI兼容接口的请求格式和响应解析
+func TestOpenAIProvider(t *testing.T) {
+	server := newTestServer(t, "/v1/chat/completions", func(r *http.Request, body map[string]interface{}) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Expected Bearer auth, got %q", got)
+		}
+		if msgs := body["messages"].([]interface{}); len(msgs) != 2 {
+			t.Errorf("Expected system message kept in messages, got %d messages", len(msgs))
+		}
+	}, `{"choices":[{"index":0,"message":{"role":"assistant","content":"OK"}}]}`)
+	defer server.Close()
+
+	client := NewClient(Config{Provider: ProviderOpenAI, APIBase: server.URL + "/v1", APIKey: "test-key", Model: "gpt-4"})
+	got, err := client.ChatCompletion(context.Background(), testMessages)
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if got != "OK" {
+		t.Errorf("Expected OK, got %q", got)
+	}
+}
+
+// TestAnthropicProvider 测试Anthropic Messages接口的认证头和system参数
+func TestAnthropicProvider(t *testing.T) {
+	server := newTestServer(t, "/v1/messages", func(r *http.Request, body map[string]interface{}) {
+		if got := r.Header.Get("x-api-key"); got != "test-key" {
+			t.Errorf("Expected x-api-key header, got %q", got)
+		}
+		if r.Header.Get("anthropic-version") == "" {
+			t.Error("Expected anthropic-version header")
+		}
+		if body["system"] != "你是助手" {
+			t.Errorf("Expected system prompt in top-level field, got %v", body["system"])
+		}
+		if msgs := body["messages"].([]interface{}); len(msgs) != 1 {
+			t.Errorf("Expected system message removed from messages, got %d messages", len(msgs))
+		}
+		if body["max_tokens"] == nil {
+			t.Error("Expected max_tokens to be set")
+		}
+	}, `{"content":[{"type":"text","text":"O"},{"type":"text","text":"K"}],"stop_reason":"end_turn"}`)
+	defer server.Close()
+
+	client := NewClient(Config{Provider: ProviderAnthropic, APIBase: server.URL + "/v1", APIKey: "test-key", Model: "claude"})
+	got, err := client.ChatCompletion(context.Background(), testMessages)
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if got != "OK" {
+		t.Errorf("Expected OK, got %q", got)
+	}
+}
+
+// TestGeminiProvider 测试Gemini原生接口的请求地址和内容结构
+func TestGeminiProvider(t *testing.T) {
+	server := newTestServer(t, "/v1beta/models/gemini-pro:generateContent", func(r *http.Request, body map[string]interface{}) {
+		if got := r.Header.Get("x-goog-api-key"); got != "test-key" {
+			t.Errorf("Expected x-goog-api-key header, got %q", got)
+		}
+		if body["systemInstruction"] == nil {
+			t.Error("Expected systemInstruction to be set")
+		}
+		contents := body["contents"].([]interface{})
+		if len(contents) != 1 || contents[0].(map[string]interface{})["role"] != "user" {
+			t.Errorf("Unexpected contents: %v", contents)
+		}
+	}, `{"candidates":[{"content":{"role":"model","parts":[{"text":"OK"}]},"finishReason":"STOP"}]}`)
+	defer server.Close()
+
+	client := NewClient(Config{Provider: ProviderGemini, APIBase: server.URL + "/v1beta", APIKey: "test-key", Model: "gemini-pro"})
+	got, err := client.ChatCompletion(context.Background(), testMessages)
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if got != "OK" {
+		t.Errorf("Expected OK, got %q", got)
+	}
+}
+
+// TestOllamaProvider 测试Ollama本地接口（关闭流式输出，无需API Key）
+func TestOllamaProvider(t *testing.T) {
+	server := newTestServer(t, "/api/chat", func(r *http.Request, body map[string]interface{}) {
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Expected no auth header, got %q", got)
+		}
+		if body["stream"] != false {
+			t.Errorf("Expected stream=false, got %v", body["stream"])
+		}
+	}, `{"message":{"role":"assistant","content":"OK"},"done":true}`)
+	defer server.Close()
+
+	client := NewClient(Config{Provider: ProviderOllama, APIBase: server.URL, Model: "qwen2.5"})
+	got, err := client.ChatCompletion(context.Background(), testMessages)
+	if err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+	if got != "OK" {
+		t.Errorf("Expected OK, got %q", got)
+	}
+}
+
+// TestUnsupportedProvider 测试不支持的提供方
+func TestUnsupportedProvider(t *testing.T) {
+	if _, err := NewProvider("unknown", "", ""); err == nil {
+		t.Error("Expected error for unsupported provider")
+	}
+
+	client := NewClient(Config{Provider: "unknown", APIKey: "test-key", Model: "x"})
+	_, err := client.ChatCompletion(context.Background(), testMessages)
+	if err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("Expected unsupported provider error, got %v", err)
+	}
+}
+
+// TestSplitSystemMessages 测试system消息拆分和相邻同角色消息合并
+func TestSplitSystemMessages(t *testing.T) {
+	system, turns := splitSystemMessages([]Message{
+		{Role: "system", Content: "A"},
+		{Role: "user", Content: "B"},
+		{Role: "user", Content: "C"},
+		{Role: "assistant", Content: "D"},
+	})
+	if system != "A" {
+		t.Errorf("Expected system A, got %q", system)
+	}
+	if len(turns) != 2 || turns[0].Content != "B\n\nC" || turns[1].Role != "assistant" {
+		t.Errorf("Unexpected turns: %+v", turns)
+	}
+}
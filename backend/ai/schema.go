@@ -0,0 +1,252 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// JSONSchema 结构化输出的 JSON Schema 定义
+// 支持的提供方会通过 response_format / tools 等方式把 Schema 发送给模型，
+// 返回结果统一由 validateSchema 校验
+type JSONSchema struct {
+	Name        string                 // Schema 名称（仅允许字母、数字、下划线，用作 OpenAI schema 名和 Anthropic 工具名）
+	Description string                 // Schema 说明
+	Schema      map[string]interface{} // JSON Schema 本体（根节点必须是 object）
+}
+
+// 以下为构造 Schema 的辅助函数，只覆盖本项目用到的关键字：
+// type、properties、required、items、additionalProperties、enum、minItems、minLength、minimum、maximum
+
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	s := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func mapSchema(valueSchema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": valueSchema,
+	}
+}
+
+func arraySchema(items map[string]interface{}, minItems int) map[string]interface{} {
+	s := map[string]interface{}{
+		"type":  "array",
+		"items": items,
+	}
+	if minItems > 0 {
+		s["minItems"] = minItems
+	}
+	return s
+}
+
+func stringSchema(minLength int) map[string]interface{} {
+	s := map[string]interface{}{"type": "string"}
+	if minLength > 0 {
+		s["minLength"] = minLength
+	}
+	return s
+}
+
+func boolSchema() map[string]interface{} {
+	return map[string]interface{}{"type": "boolean"}
+}
+
+// nullableNumberSchema 可为 null 的数值（用于维度评分，未提及为 null）
+func nullableNumberSchema(minimum, maximum float64) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    []string{"number", "null"},
+		"minimum": minimum,
+		"maximum": maximum,
+	}
+}
+
+// validateSchema 校验 JSON 值是否符合 Schema
+// value 为 json.Unmarshal 到 interface{} 得到的值，path 用于错误信息定位
+func validateSchema(value interface{}, schema map[string]interface{}, path string) error {
+	if path == "" {
+		path = "$"
+	}
+
+	if t, ok := schema["type"]; ok {
+		if !matchesType(value, schemaTypes(t)) {
+			return fmt.Errorf("%s: 类型应为 %s，实际为 %s", path, strings.Join(schemaTypes(t), "|"), jsonTypeOf(value))
+		}
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		if !inEnum(value, enum) {
+			return fmt.Errorf("%s: 取值不在允许范围内", path)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return validateObject(v, schema, path)
+	case []interface{}:
+		if minItems, ok := toFloat(schema["minItems"]); ok && float64(len(v)) < minItems {
+			return fmt.Errorf("%s: 至少需要 %d 项", path, int(minItems))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateSchema(item, items, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		if minLength, ok := toFloat(schema["minLength"]); ok && float64(len([]rune(strings.TrimSpace(v)))) < minLength {
+			return fmt.Errorf("%s: 不能为空", path)
+		}
+	case float64:
+		if minimum, ok := toFloat(schema["minimum"]); ok && v < minimum {
+			return fmt.Errorf("%s: %v 小于最小值 %v", path, v, minimum)
+		}
+		if maximum, ok := toFloat(schema["maximum"]); ok && v > maximum {
+			return fmt.Errorf("%s: %v 大于最大值 %v", path, v, maximum)
+		}
+	}
+	return nil
+}
+
+func validateObject(obj map[string]interface{}, schema map[string]interface{}, path string) error {
+	for _, name := range schemaRequired(schema["required"]) {
+		if _, ok := obj[name]; !ok {
+			return fmt.Errorf("%s: 缺少必填字段 %q", path, name)
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"].(map[string]interface{})
+
+	// 按字段名排序，保证错误信息稳定
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		childPath := path + "." + k
+		if propSchema, ok := properties[k].(map[string]interface{}); ok {
+			if err := validateSchema(obj[k], propSchema, childPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if hasAdditional {
+			if err := validateSchema(obj[k], additional, childPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func schemaTypes(t interface{}) []string {
+	switch v := t.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		types := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func schemaRequired(r interface{}) []string {
+	switch v := r.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		names := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+func matchesType(value interface{}, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	actual := jsonTypeOf(value)
+	for _, t := range types {
+		if t == actual {
+			return true
+		}
+		// 整数也是合法的 number；integer 要求没有小数部分
+		if actual == "number" && t == "integer" {
+			if f := value.(float64); f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func inEnum(value interface{}, enum interface{}) bool {
+	var options []interface{}
+	switch v := enum.(type) {
+	case []interface{}:
+		options = v
+	case []string:
+		for _, s := range v {
+			options = append(options, s)
+		}
+	}
+	for _, o := range options {
+		if o == value {
+			return true
+		}
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// sponsorBatchSize 每次AI请求判断的视频数
+const sponsorBatchSize = 10
+
+// sponsorTextCues 标题或简介中表示商单（品牌方付费推广）的表述
+var sponsorTextCues = []string{
+	"商单", "恰饭", "商业合作", "品牌合作", "合作推广", "广告", "赞助", "本期视频由", "本视频由",
+	"品牌方", "专属优惠", "优惠券", "领券", "购买链接", "下单链接", "专属链接",
+}
+
+// sponsorNegations 声明非商单的表述，出现时不按关键词判定，交给AI判断
+var sponsorNegations = []string{
+	"非商单", "不是商单", "无商单", "非广", "无广", "非广告", "不是广告", "没有广告", "不恰饭", "没恰饭", "自费", "自购", "自己买",
+	"不接广告", "拒绝广告", "谢绝广告", "零广告", "广告位招租",
+}
+
+// SponsorCues 查找标题和简介中的商单表述，返回依据（如 "标题含「恰饭」"）
+// 标题或简介声明了自费、非商单时不按关键词判定，返回 nil
+func SponsorCues(title, description string) []string {
+	for _, neg := range sponsorNegations {
+		if strings.Contains(title, neg) || strings.Contains(description, neg) {
+			return nil
+		}
+	}
+	var signals []string
+	for _, field := range []struct{ label, text string }{{"标题", title}, {"简介", description}} {
+		for _, cue := range sponsorTextCues {
+			if strings.Contains(field.text, cue) {
+				signals = append(signals, fmt.Sprintf("%s含「%s」", field.label, cue))
+			}
+		}
+	}
+	return signals
+}
+
+// SponsorCheckVideo 商单判断的输入
+type SponsorCheckVideo struct {
+	Title       string // 视频标题
+	Author      string // UP主
+	Description string // 视频简介
+}
+
+// SponsorVerdict AI对单个视频的商单判断
+type SponsorVerdict struct {
+	Sponsored bool   `json:"sponsored"` // 是否为商单
+	Reason    string `json:"reason"`    // 判断理由
+}
+
+// sponsorBatchResponse 批量商单判断的返回结构
+type sponsorBatchResponse struct {
+	Results []struct {
+		Index     int    `json:"index"`
+		Sponsored bool   `json:"sponsored"`
+		Reason    string `json:"reason"`
+	} `json:"results"`
+}
+
+// sponsorSchema 批量商单判断结果的Schema
+var sponsorSchema = &JSONSchema{
+	Name:        "sponsored_videos",
+	Description: "每个视频是否为品牌方付费推广（商单）及理由",
+	Schema: objectSchema(map[string]interface{}{
+		"results": arraySchema(objectSchema(map[string]interface{}{
+			"index":     map[string]interface{}{"type": "integer", "minimum": 0},
+			"sponsored": boolSchema(),
+			"reason":    stringSchema(0),
+		}, "index", "sponsored", "reason"), 0),
+	}, "results"),
+}
+
+const sponsorSystemPrompt = `你是B站测评视频的商单识别助手。
+
+商单指品牌方付费或提供产品、由UP主推广的视频，常见特征：
+1. 标题或简介提到合作、赞助、推广、"本期视频由XX支持"
+2. 简介附带购买链接、优惠券、专属折扣码
+3. 只介绍单一品牌的产品且通篇正面，标题带有品牌新品宣传口吻
+
+以下情况不是商单：UP主声明自费购买、多品牌横评且有明显缺点对比、用户使用体验分享。
+证据不足时判定为非商单。
+
+输出格式（JSON）：
+{"results": [{"index": 视频序号, "sponsored": true/false, "reason": "判断理由"}]}`
+
+// ClassifySponsored 使用AI判断一组视频是否为商单，返回结果与 videos 一一对应
+// AI遗漏的视频视为非商单
+func (c *Client) ClassifySponsored(ctx context.Context, videos []SponsorCheckVideo) ([]SponsorVerdict, error) {
+	ctx = WithStage(ctx, StageSponsor)
+	verdicts := make([]SponsorVerdict, len(videos))
+	if len(videos) == 0 {
+		return verdicts, nil
+	}
+
+	var b strings.Builder
+	for i, v := range videos {
+		fmt.Fprintf(&b, "[%d] 标题：%s\nUP主：%s\n简介：%s\n\n", i, v.Title, v.Author, truncateRunes(v.Description, 300))
+	}
+	messages := []Message{
+		{Role: "system", Content: sponsorSystemPrompt},
+		{Role: "user", Content: b.String()},
+	}
+
+	var result sponsorBatchResponse
+	if _, err := c.ChatJSON(ctx, messages, sponsorSchema, &result); err != nil {
+		return nil, fmt.Errorf("AI请求失败: %w", err)
+	}
+	for _, r := range result.Results {
+		if r.Index >= 0 && r.Index < len(verdicts) {
+			verdicts[r.Index] = SponsorVerdict{Sponsored: r.Sponsored, Reason: r.Reason}
+		}
+	}
+	return verdicts, nil
+}
+
+// BatchClassifySponsored 分批并发调用 ClassifySponsored
+// 某一批失败时该批视频视为非商单并返回第一个错误，其余批次的结果仍然有效
+func (c *Client) BatchClassifySponsored(ctx context.Context, videos []SponsorCheckVideo, concurrency int) ([]SponsorVerdict, error) {
+	if concurrency <= 0 {
+		concurrency = 3
+	}
+	verdicts := make([]SponsorVerdict, len(videos))
+	sem := semaphore.NewWeighted(int64(concurrency))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+
+	for start := 0; start < len(videos); start += sponsorBatchSize {
+		if sem.Acquire(ctx, 1) != nil {
+			break
+		}
+		end := min(start+sponsorBatchSize, len(videos))
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			defer sem.Release(1)
+			batch, err := c.ClassifySponsored(ctx, videos[start:end])
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			copy(verdicts[start:end], batch)
+		}(start, end)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return verdicts, firstErr
+}
+
+// truncateRunes 按字符数截断文本，避免过长的简介占用过多 Token
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
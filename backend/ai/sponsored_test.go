@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSponsorCues(t *testing.T) {
+	tests := []struct {
+		title, desc string
+		want        []string
+	}{
+		{"戴森V12深度体验", "本期视频由戴森赞助", []string{"简介含「赞助」", "简介含「本期视频由」"}},
+		{"恰饭！追觅新品上手", "", []string{"标题含「恰饭」"}},
+		{"吸尘器横评：自费购买，没有广告", "", nil},
+		{"五款吸尘器横评", "测试环境说明", nil},
+		// 声明不接广告的表述不能因为含「广告」被当作商单
+		{"吸尘器横评", "本频道不接广告，测评结果仅供参考", nil},
+		{"扫地机器人避障实测（拒绝广告）", "", nil},
+		{"洗地机对比", "广告位招租", nil},
+		{"洗地机对比", "本期含广告", []string{"简介含「广告」"}},
+	}
+	for _, tt := range tests {
+		got := SponsorCues(tt.title, tt.desc)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("SponsorCues(%q, %q) = %v, want %v", tt.title, tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestClassifySponsored(t *testing.T) {
+	var requests []map[string]interface{}
+	server := newSequenceServer(t, []string{
+		`{"results": [{"index": 1, "sponsored": true, "reason": "简介附带专属折扣码"}, {"index": 9, "sponsored": true, "reason": "越界"}]}`,
+	}, &requests)
+	defer server.Close()
+
+	client := NewClient(Config{APIBase: server.URL, APIKey: "test-key", Model: "gpt-4"})
+	verdicts, err := client.ClassifySponsored(context.Background(), []SponsorCheckVideo{
+		{Title: "五款吸尘器横评"},
+		{Title: "新品首发体验", Description: "折扣码 DYSON50"},
+	})
+	if err != nil {
+		t.Fatalf("ClassifySponsored failed: %v", err)
+	}
+	if len(verdicts) != 2 || verdicts[0].Sponsored || !verdicts[1].Sponsored || verdicts[1].Reason != "简介附带专属折扣码" {
+		t.Errorf("unexpected verdicts: %+v", verdicts)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("expected one request for the batch, got %d", len(requests))
+	}
+	messages := requests[0]["messages"].([]interface{})
+	user := messages[len(messages)-1].(map[string]interface{})["content"].(string)
+	if !strings.Contains(user, "[1] 标题：新品首发体验") || !strings.Contains(user, "折扣码 DYSON50") {
+		t.Errorf("prompt should list indexed videos:\n%s", user)
+	}
+}
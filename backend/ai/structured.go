@@ -0,0 +1,142 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxRepairAttempts 结构化输出校验失败后的最大修复重试次数
+const maxRepairAttempts = 2
+
+// ParseStats 结构化输出解析统计（按 Schema 名称统计）
+type ParseStats struct {
+	Schema         string `json:"schema"`          // Schema 名称
+	Requests       int64  `json:"requests"`        // 结构化请求总数
+	ParseFailures  int64  `json:"parse_failures"`  // 解析或校验失败次数（每次失败的响应都计数）
+	RepairAttempts int64  `json:"repair_attempts"` // 发起修复重试的次数
+	Repaired       int64  `json:"repaired"`        // 经修复重试后成功的请求数
+	GaveUp         int64  `json:"gave_up"`         // 修复重试用尽仍失败的请求数
+}
+
+var (
+	parseStatsMu sync.Mutex
+	parseStats   = make(map[string]*ParseStats)
+)
+
+// recordParseStat 更新解析统计
+func recordParseStat(schema string, update func(s *ParseStats)) {
+	parseStatsMu.Lock()
+	defer parseStatsMu.Unlock()
+	s, ok := parseStats[schema]
+	if !ok {
+		s = &ParseStats{Schema: schema}
+		parseStats[schema] = s
+	}
+	update(s)
+}
+
+// GetParseStats 获取结构化输出解析统计（按 Schema 名称排序）
+func GetParseStats() []ParseStats {
+	parseStatsMu.Lock()
+	defer parseStatsMu.Unlock()
+
+	stats := make([]ParseStats, 0, len(parseStats))
+	for _, s := range parseStats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Schema < stats[j].Schema })
+	return stats
+}
+
+// ChatJSON 发送要求结构化输出的请求，并把结果解析到 out
+// 工作流程：
+//  1. 提供方支持时通过 response_format / tools 等方式发送 Schema
+//  2. 从响应中提取 JSON 并按 Schema 校验
+//  3. 解析或校验失败时，把错误原因回传给模型要求修复，最多重试 maxRepairAttempts 次
+//
+// 参数：
+//   - ctx: 上下文
+//   - messages: 消息列表
+//   - schema: 期望的输出结构
+//   - out: 解析目标（指针）
+//
+// 返回：
+//   - string: 最后一次的原始响应（用于日志）
+//   - error: 请求失败或修复重试用尽时返回错误
+func (c *Client) ChatJSON(ctx context.Context, messages []Message, schema *JSONSchema, out interface{}) (string, error) {
+	recordParseStat(schema.Name, func(s *ParseStats) { s.Requests++ })
+
+	conversation := append([]Message(nil), messages...)
+	var lastErr error
+	var response string
+
+	for attempt := 0; attempt <= maxRepairAttempts; attempt++ {
+		if attempt > 0 {
+			recordParseStat(schema.Name, func(s *ParseStats) { s.RepairAttempts++ })
+		}
+
+		req := ChatRequest{
+			Model:    c.model,
+			Messages: conversation,
+			Schema:   schema,
+		}
+		var err error
+		response, err = c.chat(ctx, req)
+		if err != nil {
+			return "", err
+		}
+
+		lastErr = decodeStructured(response, schema, out)
+		if lastErr == nil {
+			if attempt > 0 {
+				recordParseStat(schema.Name, func(s *ParseStats) { s.Repaired++ })
+			}
+			return response, nil
+		}
+
+		recordParseStat(schema.Name, func(s *ParseStats) { s.ParseFailures++ })
+		c.cacheDelete(c.cacheKey(req))
+		log.Printf("[AI] 结构化输出 %s 校验失败（第%d次）: %v", schema.Name, attempt+1, lastErr)
+
+		// 把错误原因和期望结构回传给模型，要求修复
+		conversation = append(conversation,
+			Message{Role: "assistant", Content: response},
+			Message{Role: "user", Content: buildRepairPrompt(schema, lastErr)},
+		)
+	}
+
+	recordParseStat(schema.Name, func(s *ParseStats) { s.GaveUp++ })
+	return response, fmt.Errorf("结构化输出校验失败: %w, 原始响应: %s", lastErr, response[:min(len(response), 200)])
+}
+
+// decodeStructured 提取、校验并解析结构化响应
+func decodeStructured(response string, schema *JSONSchema, out interface{}) error {
+	cleaned := cleanJSONResponse(response)
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(cleaned), &value); err != nil {
+		return fmt.Errorf("不是合法的JSON: %w", err)
+	}
+	if err := validateSchema(value, schema.Schema, ""); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(cleaned), out); err != nil {
+		return fmt.Errorf("JSON解析失败: %w", err)
+	}
+	return nil
+}
+
+// buildRepairPrompt 构造修复提示词
+func buildRepairPrompt(schema *JSONSchema, err error) string {
+	schemaJSON, _ := json.Marshal(schema.Schema)
+	var b strings.Builder
+	fmt.Fprintf(&b, "你上一次的输出没有通过校验：%v\n", err)
+	b.WriteString("请修正后重新输出。只返回符合以下 JSON Schema 的 JSON，不要使用Markdown代码块，不要添加任何其他文字：\n")
+	b.Write(schemaJSON)
+	return b.String()
+}
@@ -0,0 +1,220 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestValidateSchema 测试Schema校验
+func TestValidateSchema(t *testing.T) {
+	schema := commentAnalysisSchema([]Dimension{{Name: "吸力"}, {Name: "续航"}}).Schema
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"valid", `{"brand":"戴森","model":"V12","scores":{"吸力":8.5,"续航":null}}`, ""},
+		{"missing dimension allowed", `{"brand":"戴森","model":"V12","scores":{"吸力":8}}`, ""},
+		{"missing brand", `{"model":"V12","scores":{}}`, "brand"},
+		{"empty brand", `{"brand":" ","model":"V12","scores":{}}`, "brand"},
+		{"score out of range", `{"brand":"戴森","model":"V12","scores":{"吸力":11}}`, "最大值"},
+		{"score wrong type", `{"brand":"戴森","model":"V12","scores":{"吸力":"高"}}`, "类型"},
+		{"root not object", `[1,2]`, "类型"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.input), &value); err != nil {
+				t.Fatalf("invalid test input: %v", err)
+			}
+			err := validateSchema(value, schema, "")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestValidateSchemaArray 测试数组最少项数和元素校验
+func TestValidateSchemaArray(t *testing.T) {
+	schema := parseKeywordSchema.Schema
+
+	var value interface{}
+	_ = json.Unmarshal([]byte(`{"understanding":"我理解","product_type":"吸尘器","brands":[],"dimensions":[{"name":"吸力","description":"吸力大小"}],"keywords":["吸尘器评测"]}`), &value)
+	if err := validateSchema(value, schema, ""); err == nil || !strings.Contains(err.Error(), "brands") {
+		t.Errorf("Expected brands minItems error, got %v", err)
+	}
+
+	_ = json.Unmarshal([]byte(`{"understanding":"我理解","product_type":"吸尘器","brands":["戴森"],"dimensions":[{"name":"吸力"}],"keywords":["吸尘器评测"]}`), &value)
+	if err := validateSchema(value, schema, ""); err == nil || !strings.Contains(err.Error(), "description") {
+		t.Errorf("Expected dimension description error, got %v", err)
+	}
+}
+
+// newSequenceServer 创建按顺序返回内容的OpenAI兼容模拟服务
+func newSequenceServer(t *testing.T, contents []string, requests *[]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	var idx int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		_ = json.Unmarshal(raw, &body)
+		if requests != nil {
+			*requests = append(*requests, body)
+		}
+
+		i := int(atomic.AddInt32(&idx, 1)) - 1
+		if i >= len(contents) {
+			i = len(contents) - 1
+		}
+		resp, _ := json.Marshal(ChatCompletionResponse{
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: contents[i]}}},
+		})
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resp)
+	}))
+}
+
+// TestChatJSONRepair 测试校验失败后自动修复重试
+func TestChatJSONRepair(t *testing.T) {
+	var requests []map[string]interface{}
+	server := newSequenceServer(t, []string{
+		`我认为是相关的`,
+		`{"is_relevant": true, "reason": "直接涉及吸尘器"}`,
+	}, &requests)
+	defer server.Close()
+
+	client := NewClient(Config{APIBase: server.URL, APIKey: "test-key", Model: "gpt-4"})
+	checker := NewVideoRelevanceChecker(client)
+
+	before := statsFor("video_relevance")
+	relevant, reason, err := checker.CheckRelevance(context.Background(), "戴森V12吸尘器测评", "吸尘器")
+	if err != nil {
+		t.Fatalf("CheckRelevance failed: %v", err)
+	}
+	if !relevant || reason != "直接涉及吸尘器" {
+		t.Errorf("Unexpected result: %v %q", relevant, reason)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests (original + repair), got %d", len(requests))
+	}
+	if requests[0]["response_format"] == nil {
+		t.Error("Expected response_format to be sent")
+	}
+	// 修复请求应带上上一次的错误输出
+	msgs := requests[1]["messages"].([]interface{})
+	if last := msgs[len(msgs)-1].(map[string]interface{}); !strings.Contains(last["content"].(string), "没有通过校验") {
+		t.Errorf("Expected repair prompt, got %v", last["content"])
+	}
+
+	after := statsFor("video_relevance")
+	if after.ParseFailures-before.ParseFailures != 1 || after.Repaired-before.Repaired != 1 {
+		t.Errorf("Unexpected stats change: before %+v, after %+v", before, after)
+	}
+}
+
+// TestCheckRelevanceNoSubstringGuess 测试无法解析时返回错误，而不是按"相关"子串猜测
+func TestCheckRelevanceNoSubstringGuess(t *testing.T) {
+	server := newSequenceServer(t, []string{`不太相关`}, nil)
+	defer server.Close()
+
+	client := NewClient(Config{APIBase: server.URL, APIKey: "test-key", Model: "gpt-4"})
+	checker := NewVideoRelevanceChecker(client)
+
+	before := statsFor("video_relevance")
+	_, _, err := checker.CheckRelevance(context.Background(), "扫地机器人推荐", "吸尘器")
+	if err == nil {
+		t.Fatal("Expected error for unparseable response")
+	}
+	after := statsFor("video_relevance")
+	if after.GaveUp-before.GaveUp != 1 {
+		t.Errorf("Expected gave_up to increase by 1, before %+v, after %+v", before, after)
+	}
+}
+
+// TestSchemaUnsupportedFallback 测试接口拒绝 response_format 时去掉Schema重试
+func TestSchemaUnsupportedFallback(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		raw, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(raw), "response_format") {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"response_format not supported"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"is_relevant\":false,\"reason\":\"无关\"}"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{APIBase: server.URL, APIKey: "test-key", Model: "gpt-4"})
+	checker := NewVideoRelevanceChecker(client)
+
+	relevant, _, err := checker.CheckRelevance(context.Background(), "猫咪喂食器推荐", "猫砂盆")
+	if err != nil {
+		t.Fatalf("CheckRelevance failed: %v", err)
+	}
+	if relevant {
+		t.Error("Expected not relevant")
+	}
+	if !client.schemaUnsupported.Load() {
+		t.Error("Expected schema to be disabled after 400")
+	}
+
+	// 后续请求不再发送 response_format
+	atomic.StoreInt32(&calls, 0)
+	if _, _, err := checker.CheckRelevance(context.Background(), "猫咪喂食器推荐", "猫砂盆"); err != nil {
+		t.Fatalf("Second CheckRelevance failed: %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected 1 request after fallback, got %d", n)
+	}
+}
+
+func statsFor(schema string) ParseStats {
+	for _, s := range GetParseStats() {
+		if s.Schema == schema {
+			return s
+		}
+	}
+	return ParseStats{Schema: schema}
+}
+
+// TestSchemaKeptAfterUnrelatedClientError 测试与结构化输出无关的400不会关闭结构化输出
+func TestSchemaKeptAfterUnrelatedClientError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"maximum context length exceeded"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"is_relevant\":true,\"reason\":\"相关\"}"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{APIBase: server.URL, APIKey: "test-key", Model: "gpt-4"})
+	checker := NewVideoRelevanceChecker(client)
+
+	if _, _, err := checker.CheckRelevance(context.Background(), "猫咪喂食器推荐", "猫咪喂食器"); err != nil {
+		t.Fatalf("CheckRelevance failed: %v", err)
+	}
+	if client.schemaUnsupported.Load() {
+		t.Error("Unrelated 400 should not disable structured output")
+	}
+}
@@ -0,0 +1,247 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrTokenBudgetExceeded 任务的 Token 预算已用尽
+var ErrTokenBudgetExceeded = errors.New("token budget exceeded")
+
+// Token 用量统计阶段
+const (
+	StageRelevance      = "relevance"      // 视频相关性检查
+	StageSponsor        = "sponsor"        // 商单视频识别
+	StageAnalysis       = "analysis"       // 评论分析
+	StageBrandIdentify  = "brand_identify" // 型号品牌识别
+	StageRecommendation = "recommendation" // 购买建议生成
+	StageKeyword        = "keyword"        // 需求解析
+	StageDimensions     = "dimensions"     // 维度生成
+	StageOther          = "other"          // 未标记阶段的调用
+)
+
+// Usage 单次调用的 Token 用量
+type Usage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`     // 输入 Token 数
+	CompletionTokens int64 `json:"completion_tokens"` // 输出 Token 数
+}
+
+// Total 输入和输出 Token 合计
+func (u Usage) Total() int64 {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+type stageKey struct{}
+
+// WithStage 在上下文中标记当前调用所属的统计阶段
+func WithStage(ctx context.Context, stage string) context.Context {
+	return context.WithValue(ctx, stageKey{}, stage)
+}
+
+// stageFromContext 读取上下文中的统计阶段（未标记时为 other）
+func stageFromContext(ctx context.Context) string {
+	if stage, ok := ctx.Value(stageKey{}).(string); ok && stage != "" {
+		return stage
+	}
+	return StageOther
+}
+
+// StageUsage 单个阶段的用量
+type StageUsage struct {
+	Calls            int64 `json:"calls"`             // 实际请求次数
+	CachedCalls      int64 `json:"cached_calls"`      // 命中缓存的次数（不消耗 Token）
+	PromptTokens     int64 `json:"prompt_tokens"`     // 输入 Token 数
+	CompletionTokens int64 `json:"completion_tokens"` // 输出 Token 数
+}
+
+// UsageSummary 任务的 Token 用量汇总
+type UsageSummary struct {
+	Model            string                `json:"model"`                     // 使用的模型
+	Stages           map[string]StageUsage `json:"stages"`                    // 阶段 -> 用量
+	PromptTokens     int64                 `json:"prompt_tokens"`             // 输入 Token 合计
+	CompletionTokens int64                 `json:"completion_tokens"`         // 输出 Token 合计
+	TotalTokens      int64                 `json:"total_tokens"`              // Token 合计
+	Cost             float64               `json:"cost"`                      // 费用（按价格表计算，未配置单价时为0）
+	Priced           bool                  `json:"priced"`                    // 价格表中是否有该模型的单价
+	Budget           int64                 `json:"budget,omitempty"`          // Token 预算（0表示不限制）
+	BudgetExceeded   bool                  `json:"budget_exceeded,omitempty"` // 是否因超出预算而中止
+}
+
+// UsageTracker 任务级 Token 用量统计
+// 同一任务的所有AI调用共用一个 UsageTracker，按上下文中标记的阶段分别累计
+// 设置预算后，累计用量达到预算时调用 onExceeded（通常用于取消任务上下文），之后的请求直接返回 ErrTokenBudgetExceeded
+type UsageTracker struct {
+	mu         sync.Mutex
+	stages     map[string]*StageUsage
+	total      int64
+	budget     int64
+	exceeded   bool
+	onExceeded func()
+}
+
+// NewUsageTracker 创建用量统计
+// 参数：
+//   - budget: Token 预算，0 表示不限制
+//   - onExceeded: 首次超出预算时的回调（可为 nil）
+func NewUsageTracker(budget int64, onExceeded func()) *UsageTracker {
+	return &UsageTracker{
+		stages:     make(map[string]*StageUsage),
+		budget:     budget,
+		onExceeded: onExceeded,
+	}
+}
+
+// Restore 合并之前保存的用量（恢复任务时调用，预算按累计用量计算）
+func (t *UsageTracker) Restore(prev UsageSummary) {
+	t.mu.Lock()
+	for stage, u := range prev.Stages {
+		s := t.stage(stage)
+		s.Calls += u.Calls
+		s.CachedCalls += u.CachedCalls
+		s.PromptTokens += u.PromptTokens
+		s.CompletionTokens += u.CompletionTokens
+		t.total += u.PromptTokens + u.CompletionTokens
+	}
+	notify := t.checkBudget()
+	t.mu.Unlock()
+
+	if notify {
+		t.onExceeded()
+	}
+}
+
+// Add 记录一次调用
+func (t *UsageTracker) Add(stage string, usage Usage, cached bool) {
+	t.mu.Lock()
+	s := t.stage(stage)
+	if cached {
+		s.CachedCalls++
+	} else {
+		s.Calls++
+	}
+	s.PromptTokens += usage.PromptTokens
+	s.CompletionTokens += usage.CompletionTokens
+	t.total += usage.Total()
+	notify := t.checkBudget()
+	t.mu.Unlock()
+
+	if notify {
+		t.onExceeded()
+	}
+}
+
+// checkBudget 检查是否首次超出预算（调用方需持有锁），返回是否需要调用 onExceeded
+func (t *UsageTracker) checkBudget() bool {
+	if t.budget <= 0 || t.total < t.budget || t.exceeded {
+		return false
+	}
+	t.exceeded = true
+	return t.onExceeded != nil
+}
+
+// Exceeded 是否已超出预算
+func (t *UsageTracker) Exceeded() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.exceeded
+}
+
+// Summary 生成用量汇总，并按价格表计算费用
+func (t *UsageTracker) Summary(model string, prices PriceTable) UsageSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	summary := UsageSummary{
+		Model:          model,
+		Stages:         make(map[string]StageUsage, len(t.stages)),
+		Budget:         t.budget,
+		BudgetExceeded: t.exceeded,
+	}
+	for stage, s := range t.stages {
+		summary.Stages[stage] = *s
+		summary.PromptTokens += s.PromptTokens
+		summary.CompletionTokens += s.CompletionTokens
+	}
+	summary.TotalTokens = summary.PromptTokens + summary.CompletionTokens
+	summary.Cost, summary.Priced = prices.Cost(model, Usage{
+		PromptTokens:     summary.PromptTokens,
+		CompletionTokens: summary.CompletionTokens,
+	})
+	return summary
+}
+
+func (t *UsageTracker) stage(name string) *StageUsage {
+	s, ok := t.stages[name]
+	if !ok {
+		s = &StageUsage{}
+		t.stages[name] = s
+	}
+	return s
+}
+
+// ModelPrice 模型单价（每百万 Token 的价格，货币单位由配置者决定）
+type ModelPrice struct {
+	Prompt     float64 `json:"prompt"`     // 输入单价
+	Completion float64 `json:"completion"` // 输出单价
+}
+
+// PriceTable 模型价格表：模型名 -> 单价
+// 模型名不区分大小写；没有完全匹配时使用最长的前缀匹配（如 "gpt-4o" 可匹配 "gpt-4o-2024-08-06"）
+type PriceTable map[string]ModelPrice
+
+// ParsePriceTable 解析价格表配置（JSON 对象），空字符串返回空表
+//
+// 示例：
+//
+//	{"gpt-4o": {"prompt": 2.5, "completion": 10}, "gemini-3-flash": {"prompt": 0.3, "completion": 2.5}}
+func ParsePriceTable(s string) (PriceTable, error) {
+	table := PriceTable{}
+	if strings.TrimSpace(s) == "" {
+		return table, nil
+	}
+	if err := json.Unmarshal([]byte(s), &table); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// Lookup 查找模型单价
+func (t PriceTable) Lookup(model string) (ModelPrice, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return ModelPrice{}, false
+	}
+
+	names := make([]string, 0, len(t))
+	for name := range t {
+		names = append(names, name)
+	}
+	// 按长度倒序，保证前缀匹配时优先使用最具体的配置
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+
+	for _, name := range names {
+		if strings.ToLower(name) == model {
+			return t[name], true
+		}
+	}
+	for _, name := range names {
+		if strings.HasPrefix(model, strings.ToLower(name)) {
+			return t[name], true
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// Cost 计算费用，第二个返回值表示是否找到该模型的单价
+func (t PriceTable) Cost(model string, usage Usage) (float64, bool) {
+	price, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	cost := (float64(usage.PromptTokens)*price.Prompt + float64(usage.CompletionTokens)*price.Completion) / 1e6
+	return cost, true
+}
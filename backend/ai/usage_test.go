@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestProviderUsage 测试各提供方的 Token 用量解析
+func TestProviderUsage(t *testing.T) {
+	tests := []struct {
+		provider string
+		path     string
+		response string
+	}{
+		{ProviderOpenAI, "/chat/completions", `{"choices":[{"message":{"role":"assistant","content":"OK"}}],"usage":{"prompt_tokens":12,"completion_tokens":3}}`},
+		{ProviderAnthropic, "/messages", `{"content":[{"type":"text","text":"OK"}],"usage":{"input_tokens":12,"output_tokens":3}}`},
+		{ProviderGemini, "/models/m:generateContent", `{"candidates":[{"content":{"parts":[{"text":"OK"}]}}],"usageMetadata":{"promptTokenCount":12,"candidatesTokenCount":3}}`},
+		{ProviderOllama, "/api/chat", `{"message":{"role":"assistant","content":"OK"},"done":true,"prompt_eval_count":12,"eval_count":3}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.path {
+					t.Errorf("Expected path %s, got %s", tt.path, r.URL.Path)
+				}
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client := NewClient(Config{Provider: tt.provider, APIBase: server.URL, APIKey: "test-key", Model: "m"})
+			tracker := NewUsageTracker(0, nil)
+			client.SetUsageTracker(tracker)
+
+			if _, err := client.ChatCompletion(WithStage(context.Background(), StageAnalysis), testMessages); err != nil {
+				t.Fatalf("ChatCompletion failed: %v", err)
+			}
+
+			summary := tracker.Summary("m", nil)
+			got := summary.Stages[StageAnalysis]
+			if got.Calls != 1 || got.PromptTokens != 12 || got.CompletionTokens != 3 || summary.TotalTokens != 15 {
+				t.Errorf("Unexpected usage: %+v (total %d)", got, summary.TotalTokens)
+			}
+		})
+	}
+}
+
+// TestUsageTrackerStagesAndCache 测试按阶段统计以及缓存命中不计 Token
+func TestUsageTrackerStagesAndCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"is_relevant\":true,\"reason\":\"相关\"}"}}],"usage":{"prompt_tokens":100,"completion_tokens":10}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{APIBase: server.URL, APIKey: "test-key", Model: "gpt-4o", Cache: newMemoryCache()})
+	tracker := NewUsageTracker(0, nil)
+	client.SetUsageTracker(tracker)
+	checker := NewVideoRelevanceChecker(client)
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := checker.CheckRelevance(context.Background(), "戴森V12测评", "吸尘器"); err != nil {
+			t.Fatalf("CheckRelevance failed: %v", err)
+		}
+	}
+	if _, err := client.ChatCompletion(context.Background(), testMessages); err != nil {
+		t.Fatalf("ChatCompletion failed: %v", err)
+	}
+
+	summary := tracker.Summary("gpt-4o", PriceTable{"gpt-4o": {Prompt: 2.5, Completion: 10}})
+	rel := summary.Stages[StageRelevance]
+	if rel.Calls != 1 || rel.CachedCalls != 1 || rel.PromptTokens != 100 {
+		t.Errorf("Unexpected relevance usage: %+v", rel)
+	}
+	if other := summary.Stages[StageOther]; other.Calls != 1 {
+		t.Errorf("Expected untagged call in other stage, got %+v", other)
+	}
+	if summary.TotalTokens != 220 {
+		t.Errorf("Expected 220 total tokens, got %d", summary.TotalTokens)
+	}
+	wantCost := (200*2.5 + 20*10) / 1e6
+	if !summary.Priced || math.Abs(summary.Cost-wantCost) > 1e-12 {
+		t.Errorf("Expected cost %v, got %v (priced=%v)", wantCost, summary.Cost, summary.Priced)
+	}
+}
+
+// TestUsageTrackerBudget 测试超出预算后回调并拒绝新请求
+func TestUsageTrackerBudget(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"OK"}}],"usage":{"prompt_tokens":60,"completion_tokens":0}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{APIBase: server.URL, APIKey: "test-key", Model: "gpt-4"})
+	exceeded := 0
+	tracker := NewUsageTracker(100, func() { exceeded++ })
+	client.SetUsageTracker(tracker)
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.ChatCompletion(context.Background(), testMessages); err != nil {
+			t.Fatalf("ChatCompletion %d failed: %v", i, err)
+		}
+	}
+	if exceeded != 1 || !tracker.Exceeded() {
+		t.Fatalf("Expected budget exceeded once, got %d", exceeded)
+	}
+
+	_, err := client.ChatCompletion(context.Background(), testMessages)
+	if !errors.Is(err, ErrTokenBudgetExceeded) {
+		t.Errorf("Expected ErrTokenBudgetExceeded, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected no request after budget exceeded, got %d requests", calls)
+	}
+}
+
+// TestUsageTrackerRestore 测试恢复任务时合并已有用量并计入预算
+func TestUsageTrackerRestore(t *testing.T) {
+	exceeded := false
+	tracker := NewUsageTracker(1000, func() { exceeded = true })
+	tracker.Restore(UsageSummary{Stages: map[string]StageUsage{
+		StageAnalysis: {Calls: 5, PromptTokens: 900, CompletionTokens: 50},
+	}})
+	if exceeded {
+		t.Fatal("Should not exceed budget yet")
+	}
+	tracker.Add(StageRecommendation, Usage{PromptTokens: 40, CompletionTokens: 20}, false)
+	if !exceeded {
+		t.Error("Expected budget exceeded after restore + add")
+	}
+	if s := tracker.Summary("m", nil); s.TotalTokens != 1010 || s.Stages[StageAnalysis].Calls != 5 {
+		t.Errorf("Unexpected summary: %+v", s)
+	}
+}
+
+// TestPriceTableLookup 测试价格表匹配（不区分大小写，最长前缀优先）
+func TestPriceTableLookup(t *testing.T) {
+	table, err := ParsePriceTable(`{"gpt-4o": {"prompt": 2.5, "completion": 10}, "gpt-4o-mini": {"prompt": 0.15, "completion": 0.6}}`)
+	if err != nil {
+		t.Fatalf("ParsePriceTable failed: %v", err)
+	}
+
+	tests := []struct {
+		model  string
+		prompt float64
+		found  bool
+	}{
+		{"gpt-4o", 2.5, true},
+		{"GPT-4o-2024-08-06", 2.5, true},
+		{"gpt-4o-mini-2024-07-18", 0.15, true},
+		{"claude-3", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		price, ok := table.Lookup(tt.model)
+		if ok != tt.found || price.Prompt != tt.prompt {
+			t.Errorf("Lookup(%q) = %+v, %v; want prompt %v, %v", tt.model, price, ok, tt.prompt, tt.found)
+		}
+	}
+
+	if _, err := ParsePriceTable(`not json`); err == nil {
+		t.Error("Expected error for invalid price table")
+	}
+	if table, err := ParsePriceTable(""); err != nil || len(table) != 0 {
+		t.Errorf("Expected empty table, got %v, %v", table, err)
+	}
+}
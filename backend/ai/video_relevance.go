@@ -2,9 +2,7 @@ package ai
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"strings"
 	"sync"
 
 	"golang.org/x/sync/semaphore"
@@ -63,6 +61,8 @@ func (c *VideoRelevanceChecker) CheckRelevance(
 	videoTitle string,
 	userRequirement string,
 ) (isRelevant bool, reason string, err error) {
+	ctx = WithStage(ctx, StageRelevance)
+
 	// 参数校验：标题或需求为空时直接返回不相关
 	if videoTitle == "" || userRequirement == "" {
 		return false, "视频标题或用户需求为空", nil
@@ -96,37 +96,26 @@ func (c *VideoRelevanceChecker) CheckRelevance(
 		{Role: "user", Content: userPrompt},
 	}
 
-	// 调用AI模型
-	response, err := c.client.ChatCompletion(ctx, messages)
-	if err != nil {
-		return false, "", fmt.Errorf("AI请求失败: %w", err)
-	}
-
-	// 解析JSON响应
+	// 调用AI模型（结构化输出，自动校验和修复）
+	// 修复重试后仍无法解析时返回错误，由调用方决定是否保留该视频
 	var result CheckRelevanceResponse
-	if err := json.Unmarshal([]byte(response), &result); err != nil {
-		// 如果解析失败，尝试从文本中提取（容错处理）
-		// 注意：必须优先处理"不相关"，避免被"相关"子串误判。
-		text := strings.ToLower(strings.TrimSpace(response))
-		switch {
-		case strings.Contains(text, "\"is_relevant\":false"),
-			strings.Contains(text, "is_relevant:false"),
-			strings.Contains(text, "不相关"):
-			return false, response, nil
-		case strings.Contains(text, "\"is_relevant\":true"),
-			strings.Contains(text, "is_relevant:true"),
-			strings.Contains(text, "相关"),
-			strings.Contains(text, "true"):
-			return true, response, nil
-		default:
-			// 无法判断时默认相关，避免误杀潜在有效视频。
-			return true, response, nil
-		}
+	if _, err := c.client.ChatJSON(ctx, messages, relevanceSchema, &result); err != nil {
+		return false, "", fmt.Errorf("AI请求失败: %w", err)
 	}
 
 	return result.IsRelevant, result.Reason, nil
 }
 
+// relevanceSchema 相关性判断结果的Schema
+var relevanceSchema = &JSONSchema{
+	Name:        "video_relevance",
+	Description: "视频标题与用户需求是否相关及理由",
+	Schema: objectSchema(map[string]interface{}{
+		"is_relevant": boolSchema(),
+		"reason":      stringSchema(0),
+	}, "is_relevant", "reason"),
+}
+
 // BatchCheckRelevance 批量检查视频相关性
 //
 // 工作原理：
@@ -170,15 +159,9 @@ func (c *VideoRelevanceChecker) BatchCheckRelevance(
 	}, len(videoTitles))
 
 	for i, title := range videoTitles {
-		// 检查上下文取消
-		select {
-		case <-ctx.Done():
-			return nil, nil, ctx.Err()
-		default:
-		}
-
-		if err := sem.Acquire(ctx, 1); err != nil {
-			return nil, nil, err
+		// 检查上下文取消，获取信号量失败也意味着上下文已取消
+		if ctx.Err() != nil || sem.Acquire(ctx, 1) != nil {
+			break
 		}
 
 		wg.Add(1)
@@ -206,6 +189,11 @@ func (c *VideoRelevanceChecker) BatchCheckRelevance(
 
 	wg.Wait()
 
+	// 任务被取消时结果不完整，直接返回
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
 	// 收集结果
 	for i, result := range results {
 		if result.err != nil {
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/task"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccountResponse 账号池中的账号（Cookie 只返回脱敏后的内容）
+type AccountResponse struct {
+	ID               uint       `json:"id"`
+	Name             string     `json:"name"`
+	CookiePreview    string     `json:"cookie_preview"`
+	Enabled          bool       `json:"enabled"`
+	Status           string     `json:"status"`
+	IsLogin          bool       `json:"is_login"`
+	Uname            string     `json:"uname"`
+	Mid              int64      `json:"mid"`
+	LastCheckedAt    *time.Time `json:"last_checked_at"`
+	LastFailure      string     `json:"last_failure"`
+	LastFailureAt    *time.Time `json:"last_failure_at"`
+	FailureCount     int        `json:"failure_count"`
+	QuarantinedUntil *time.Time `json:"quarantined_until"`
+}
+
+// AccountRequest 新增/修改账号请求
+// 修改时 cookie 为空表示不修改Cookie
+type AccountRequest struct {
+	Name    string `json:"name"`
+	Cookie  string `json:"cookie"`
+	Enabled *bool  `json:"enabled"`
+}
+
+// HandleListAccounts 获取账号池
+// GET /api/config/accounts
+//
+// 响应示例：
+//
+//	{"accounts": [{"id": 1, "name": "主账号", "cookie_preview": "SESSDATA=ab12…", "status": "healthy", "is_login": true, "uname": "xxx", ...}]}
+func HandleListAccounts(c *gin.Context) {
+	accounts, err := listAccountResponses()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取账号列表失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts})
+}
+
+// HandleCreateAccount 添加账号，添加后立即检查登录状态
+// POST /api/config/accounts
+//
+// 请求示例：
+//
+//	{"name": "主账号", "cookie": "SESSDATA=xxx; bili_jct=xxx"}
+func HandleCreateAccount(c *gin.Context) {
+	var req AccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	req.Cookie = strings.TrimSpace(req.Cookie)
+	if req.Cookie == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cookie不能为空"})
+		return
+	}
+
+	account := models.BilibiliAccount{
+		Name:    strings.TrimSpace(req.Name),
+		Cookie:  req.Cookie,
+		Enabled: req.Enabled == nil || *req.Enabled,
+		Status:  models.AccountStatusUnknown,
+	}
+	if err := database.DB.Create(&account).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存账号失败: " + err.Error()})
+		return
+	}
+
+	respondAccountCheck(c, &account)
+}
+
+// HandleUpdateAccount 修改账号（备注名、Cookie、启用状态）
+// PUT /api/config/accounts/:id
+// 修改Cookie后会重置状态并重新检查登录状态
+func HandleUpdateAccount(c *gin.Context) {
+	account, ok := findAccount(c)
+	if !ok {
+		return
+	}
+
+	var req AccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	updates := map[string]interface{}{"name": strings.TrimSpace(req.Name)}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+	cookieChanged := false
+	if cookie := strings.TrimSpace(req.Cookie); cookie != "" && cookie != account.Cookie {
+		cookieChanged = true
+		updates["cookie"] = cookie
+		updates["status"] = models.AccountStatusUnknown
+		updates["is_login"] = false
+		updates["quarantined_until"] = nil
+	}
+	if err := database.DB.Model(account).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存账号失败: " + err.Error()})
+		return
+	}
+
+	if cookieChanged {
+		respondAccountCheck(c, account)
+		return
+	}
+	c.JSON(http.StatusOK, toAccountResponse(account))
+}
+
+// HandleDeleteAccount 删除账号
+// DELETE /api/config/accounts/:id
+func HandleDeleteAccount(c *gin.Context) {
+	account, ok := findAccount(c)
+	if !ok {
+		return
+	}
+	if err := database.DB.Delete(account).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除账号失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "账号已删除"})
+}
+
+// HandleCheckAccount 立即检查账号登录状态
+// POST /api/config/accounts/:id/check
+// 检查结果写入账号的 status/is_login/last_failure 字段
+func HandleCheckAccount(c *gin.Context) {
+	account, ok := findAccount(c)
+	if !ok {
+		return
+	}
+	respondAccountCheck(c, account)
+}
+
+// respondAccountCheck 检查账号登录状态后返回最新的账号信息
+func respondAccountCheck(c *gin.Context, account *models.BilibiliAccount) {
+	if err := task.CheckBilibiliAccount(c.Request.Context(), account); err != nil {
+		log.Printf("保存账号 %d 检查结果失败: %v", account.ID, err)
+	}
+	if err := database.DB.First(account, account.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取账号失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toAccountResponse(account))
+}
+
+// findAccount 按路径参数 id 查找账号，找不到时直接写入错误响应
+func findAccount(c *gin.Context) (*models.BilibiliAccount, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid account ID"})
+		return nil, false
+	}
+	var account models.BilibiliAccount
+	if err := database.DB.First(&account, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "账号不存在"})
+		return nil, false
+	}
+	return &account, true
+}
+
+// listAccountResponses 获取脱敏后的账号列表
+func listAccountResponses() ([]AccountResponse, error) {
+	accounts, err := database.ListBilibiliAccounts()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]AccountResponse, 0, len(accounts))
+	for i := range accounts {
+		result = append(result, toAccountResponse(&accounts[i]))
+	}
+	return result, nil
+}
+
+func toAccountResponse(a *models.BilibiliAccount) AccountResponse {
+	status := a.Status
+	// 隔离期已过但尚未重新检查的账号已恢复使用
+	if status == models.AccountStatusQuarantined && a.QuarantinedUntil != nil && !a.QuarantinedUntil.After(time.Now()) {
+		status = models.AccountStatusUnknown
+	}
+	return AccountResponse{
+		ID:               a.ID,
+		Name:             a.Name,
+		CookiePreview:    maskCookie(a.Cookie),
+		Enabled:          a.Enabled,
+		Status:           status,
+		IsLogin:          a.IsLogin,
+		Uname:            a.Uname,
+		Mid:              a.Mid,
+		LastCheckedAt:    a.LastCheckedAt,
+		LastFailure:      a.LastFailure,
+		LastFailureAt:    a.LastFailureAt,
+		FailureCount:     a.FailureCount,
+		QuarantinedUntil: a.QuarantinedUntil,
+	}
+}
+
+// maskCookie Cookie 脱敏：只保留开头几个字符
+func maskCookie(cookie string) string {
+	runes := []rune(cookie)
+	if len(runes) <= 12 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:12]) + "…"
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/database"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleGetAIStats 获取AI调用统计
+// GET /api/ai/stats
+// parse 为结构化输出的解析统计（按 Schema 分组），用于观察各提示词的解析失败率和修复成功率
+// cache 为响应缓存统计：hits/misses/writes 为本次启动以来的累计值，entries/expired/total_hits 来自缓存表
+//
+// 响应示例：
+//
+//	{
+//	  "parse": [{"schema": "video_relevance", "requests": 120, "parse_failures": 3, "repair_attempts": 3, "repaired": 3, "gave_up": 0}],
+//	  "cache": {"hits": 80, "misses": 40, "writes": 40, "hit_rate": 0.67, "entries": 1200, "expired": 15, "total_hits": 3400}
+//	}
+func HandleGetAIStats(c *gin.Context) {
+	stats := ai.GetCacheStats()
+	hitRate := 0.0
+	if total := stats.Hits + stats.Misses; total > 0 {
+		hitRate = float64(stats.Hits) / float64(total)
+	}
+
+	cache := gin.H{
+		"hits":     stats.Hits,
+		"misses":   stats.Misses,
+		"writes":   stats.Writes,
+		"hit_rate": hitRate,
+	}
+	if summary, err := database.GetAICacheSummary(); err == nil {
+		cache["entries"] = summary.Entries
+		cache["expired"] = summary.Expired
+		cache["total_hits"] = summary.TotalHits
+	} else {
+		log.Printf("获取AI缓存统计失败: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"parse": ai.GetParseStats(),
+		"cache": cache,
+	})
+}
+
+// HandlePurgeAICache 清除AI响应缓存
+// DELETE /api/ai/cache?expired=true&model=xxx
+// 参数 expired=true 时只清除已过期的缓存；model 非空时只清除该模型的缓存
+// 不带参数时清除全部缓存
+//
+// 响应示例：
+//
+//	{"deleted": 1200, "message": "缓存已清除"}
+func HandlePurgeAICache(c *gin.Context) {
+	expiredOnly := c.Query("expired") == "true"
+	model := c.Query("model")
+
+	deleted, err := database.PurgeAICache(expiredOnly, model)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "清除缓存失败: " + err.Error()})
+		return
+	}
+
+	log.Printf("AI响应缓存已清除: %d 条 (expired=%v, model=%q)", deleted, expiredOnly, model)
+	c.JSON(http.StatusOK, gin.H{
+		"deleted": deleted,
+		"message": "缓存已清除",
+	})
+}
@@ -0,0 +1,273 @@
+package api
+
+import (
+	"bilibili-analyzer/backend/comment"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/task"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BrandAliasRequest 新增/修改品牌词条请求
+type BrandAliasRequest struct {
+	Category string   `json:"category"` // 适用类目，为空表示所有类目
+	Name     string   `json:"name"`     // 标准名称
+	English  string   `json:"english"`
+	Pinyin   string   `json:"pinyin"`
+	Aliases  []string `json:"aliases"`
+}
+
+// BrandAliasSeedRequest 导入类目词条请求，entries 为空时导入该类目的内置词条
+type BrandAliasSeedRequest struct {
+	Category string              `json:"category"`
+	Entries  []BrandAliasRequest `json:"entries"`
+}
+
+// BrandAliasResponse 品牌词条
+type BrandAliasResponse struct {
+	ID        uint      `json:"id"`
+	Category  string    `json:"category"`
+	Name      string    `json:"name"`
+	English   string    `json:"english"`
+	Pinyin    string    `json:"pinyin"`
+	Aliases   []string  `json:"aliases"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HandleListBrandAliases 获取品牌词典
+// GET /api/brand-aliases?category=扫地机器人
+// 不传 category 返回全部词条；category 为空字符串时只返回通用词条
+//
+// 响应示例：
+//
+//	{"aliases": [{"id": 1, "category": "", "name": "追觅", "english": "Dreame", "pinyin": "zhuimi", "aliases": [], "source": "seed", ...}],
+//	 "builtin_categories": ["吸尘器", "手机", "扫地机器人", "猫砂盆"]}
+func HandleListBrandAliases(c *gin.Context) {
+	var category *string
+	if v, ok := c.GetQuery("category"); ok {
+		category = &v
+	}
+	aliases, err := database.ListBrandAliases(category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取品牌词典失败: " + err.Error()})
+		return
+	}
+	result := make([]BrandAliasResponse, 0, len(aliases))
+	for i := range aliases {
+		result = append(result, toBrandAliasResponse(&aliases[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"aliases": result, "builtin_categories": database.BuiltinBrandAliasCategories()})
+}
+
+// HandleCreateBrandAlias 添加品牌词条
+// POST /api/brand-aliases
+// 同一类目下的写法不能同时属于两个品牌
+//
+// 请求示例：
+//
+//	{"category": "扫地机器人", "name": "石头", "english": "Roborock", "pinyin": "shitou", "aliases": ["石头科技"]}
+func HandleCreateBrandAlias(c *gin.Context) {
+	var req BrandAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	alias := models.BrandAlias{Source: models.BrandAliasSourceManual}
+	if !applyBrandAliasRequest(c, &alias, &req) {
+		return
+	}
+	if err := database.DB.Create(&alias).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存品牌词条失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toBrandAliasResponse(&alias))
+}
+
+// HandleUpdateBrandAlias 修改品牌词条（修改后来源标记为 manual，之后导入内置词条不会覆盖）
+// PUT /api/brand-aliases/:id
+// 只影响之后的分析，已生成的报告不变
+func HandleUpdateBrandAlias(c *gin.Context) {
+	alias, ok := findBrandAlias(c)
+	if !ok {
+		return
+	}
+
+	var req BrandAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if !applyBrandAliasRequest(c, alias, &req) {
+		return
+	}
+	alias.Source = models.BrandAliasSourceManual
+	if err := database.DB.Save(alias).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存品牌词条失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toBrandAliasResponse(alias))
+}
+
+// HandleDeleteBrandAlias 删除品牌词条
+// DELETE /api/brand-aliases/:id
+func HandleDeleteBrandAlias(c *gin.Context) {
+	alias, ok := findBrandAlias(c)
+	if !ok {
+		return
+	}
+	if err := database.DB.Delete(alias).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除品牌词条失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "品牌词条已删除"})
+}
+
+// HandleSeedBrandAliases 按类目导入品牌词条
+// POST /api/brand-aliases/seed
+// 同类目已有同名词条时只合并别名、补全空的英文名和拼音
+//
+// 请求示例（entries 为空时导入内置词条）：
+//
+//	{"category": "扫地机器人"}
+//	{"category": "空气净化器", "entries": [{"name": "352", "aliases": ["三五二"]}, {"name": "IQAir", "pinyin": "iqair"}]}
+//
+// 响应示例：
+//
+//	{"category": "扫地机器人", "created": 5, "updated": 1}
+func HandleSeedBrandAliases(c *gin.Context) {
+	var req BrandAliasSeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	category := strings.TrimSpace(req.Category)
+
+	var seeds []models.BrandAlias
+	if len(req.Entries) == 0 {
+		seeds = database.BuiltinBrandAliases(category)
+		if seeds == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "该类目没有内置品牌词条，请在 entries 中提供词条"})
+			return
+		}
+	}
+	for _, e := range req.Entries {
+		seeds = append(seeds, models.BrandAlias{
+			Name:    strings.TrimSpace(e.Name),
+			English: strings.TrimSpace(e.English),
+			Pinyin:  strings.TrimSpace(e.Pinyin),
+			Aliases: strings.Join(e.Aliases, ","),
+			Source:  models.BrandAliasSourceManual,
+		})
+	}
+
+	created, updated, err := database.SeedBrandAliases(category, seeds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导入品牌词条失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"category": category, "created": created, "updated": updated})
+}
+
+// HandleResolveBrand 查看品牌名称在分析时会归并到哪个品牌（用于调试词典）
+// GET /api/brand-aliases/resolve?name=Dreame X40&requirement=扫地机器人推荐
+//
+// 响应示例：
+//
+//	{"name": "Dreame X40", "resolved": "追觅", "matched": true}
+func HandleResolveBrand(c *gin.Context) {
+	name := strings.TrimSpace(c.Query("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "品牌名称不能为空"})
+		return
+	}
+	dict := task.LoadBrandDictionary(c.Query("requirement"))
+	resolved := dict.Resolve(name, nil)
+	_, matched := dict.Lookup(resolved)
+	c.JSON(http.StatusOK, gin.H{"name": name, "resolved": resolved, "matched": matched})
+}
+
+// applyBrandAliasRequest 校验请求并写入词条字段，校验失败时直接写入错误响应
+func applyBrandAliasRequest(c *gin.Context, alias *models.BrandAlias, req *BrandAliasRequest) bool {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "品牌名称不能为空"})
+		return false
+	}
+	category := strings.TrimSpace(req.Category)
+	english := strings.TrimSpace(req.English)
+	pinyin := strings.TrimSpace(req.Pinyin)
+	aliases := database.SplitAliasList(strings.Join(req.Aliases, ","))
+
+	// 同一类目下每个写法只能属于一个品牌
+	others, err := database.ListBrandAliases(&category)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询品牌词典失败: " + err.Error()})
+		return false
+	}
+	owners := make(map[string]string)
+	for _, other := range others {
+		if other.ID == alias.ID {
+			continue
+		}
+		for _, spelling := range append([]string{other.Name, other.English, other.Pinyin}, database.SplitAliasList(other.Aliases)...) {
+			if key := comment.BrandKey(spelling); key != "" {
+				owners[key] = other.Name
+			}
+		}
+	}
+	for _, spelling := range append([]string{name, english, pinyin}, aliases...) {
+		if owner, ok := owners[comment.BrandKey(spelling)]; ok {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("写法 %s 已属于品牌 %s", spelling, owner)})
+			return false
+		}
+	}
+
+	alias.Category = category
+	alias.Name = name
+	alias.English = english
+	alias.Pinyin = pinyin
+	alias.Aliases = strings.Join(aliases, ",")
+	return true
+}
+
+// findBrandAlias 按路径参数 id 查找品牌词条，找不到时直接写入错误响应
+func findBrandAlias(c *gin.Context) (*models.BrandAlias, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "词条ID无效"})
+		return nil, false
+	}
+	var alias models.BrandAlias
+	if err := database.DB.First(&alias, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "品牌词条不存在"})
+		return nil, false
+	}
+	return &alias, true
+}
+
+func toBrandAliasResponse(a *models.BrandAlias) BrandAliasResponse {
+	aliases := database.SplitAliasList(a.Aliases)
+	if aliases == nil {
+		aliases = []string{}
+	}
+	return BrandAliasResponse{
+		ID:        a.ID,
+		Category:  a.Category,
+		Name:      a.Name,
+		English:   a.English,
+		Pinyin:    a.Pinyin,
+		Aliases:   aliases,
+		Source:    a.Source,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+	}
+}
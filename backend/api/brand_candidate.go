@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/report"
+	"bilibili-analyzer/backend/task"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 候选品牌列表中每个品牌展示的示例评论数
+const candidateSampleComments = 5
+
+// BrandCandidateAcceptRequest 接受候选品牌请求（均可选）
+type BrandCandidateAcceptRequest struct {
+	Name     string   `json:"name"`     // 报告中使用的品牌名称，与已有品牌相同时评论并入该品牌
+	Category string   `json:"category"` // 写入品牌词典的类目，为空表示通用词条
+	Aliases  []string `json:"aliases"`  // 额外写入品牌词典的别名
+}
+
+// BrandCandidateResponse 候选品牌
+type BrandCandidateResponse struct {
+	ID               uint                    `json:"id"`
+	HistoryID        uint                    `json:"history_id"`
+	ReportID         uint                    `json:"report_id"`
+	Brand            string                  `json:"brand"`
+	Spellings        []string                `json:"spellings"`          // AI 返回的原始写法
+	Score            float64                 `json:"score"`              // 发现品牌综合得分
+	CommentCount     int                     `json:"comment_count"`      // 评论数
+	VideoCount       int                     `json:"video_count"`        // 覆盖视频数
+	CategoryHitRatio float64                 `json:"category_hit_ratio"` // 评论中提及类目的比例
+	ModelHitRatio    float64                 `json:"model_hit_ratio"`    // 评论中带有效型号的比例
+	Status           string                  `json:"status"`
+	AcceptedAs       string                  `json:"accepted_as,omitempty"`
+	SampleComments   []report.TypicalComment `json:"sample_comments"`
+	CreatedAt        time.Time               `json:"created_at"`
+	UpdatedAt        time.Time               `json:"updated_at"`
+}
+
+// HandleListBrandCandidates 获取候选品牌
+// GET /api/brand-candidates?history_id=12&status=pending
+// status 默认 pending，传 all 返回全部；不传 history_id 返回所有任务的候选品牌
+//
+// 响应示例：
+//
+//	{"candidates": [{"id": 3, "history_id": 12, "report_id": 18, "brand": "希喂", "spellings": ["CEWEY"], "score": 0.52,
+//	                 "comment_count": 6, "video_count": 2, "category_hit_ratio": 0.33, "model_hit_ratio": 0.5,
+//	                 "status": "pending", "sample_comments": [{"content": "...", "score": 7.5, "like": 12, "url": "..."}], ...}]}
+func HandleListBrandCandidates(c *gin.Context) {
+	var historyID uint
+	if raw := c.Query("history_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "历史记录ID无效"})
+			return
+		}
+		historyID = uint(id)
+	}
+	status := c.DefaultQuery("status", models.BrandCandidatePending)
+	if status == "all" {
+		status = ""
+	}
+
+	candidates, err := database.ListBrandCandidates(historyID, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取候选品牌失败: " + err.Error()})
+		return
+	}
+	result := make([]BrandCandidateResponse, 0, len(candidates))
+	for i := range candidates {
+		result = append(result, toBrandCandidateResponse(&candidates[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"candidates": result})
+}
+
+// HandleAcceptBrandCandidate 接受候选品牌
+// POST /api/brand-candidates/:id/accept
+// 把候选品牌的评论加入报告并重新生成报告（报告ID不变，不重新抓取和分析），同时把品牌及其原始写法写入品牌词典
+//
+// 请求示例（请求体可为空）：
+//
+//	{"name": "追觅", "category": "扫地机器人", "aliases": ["追觅科技"]}
+//
+// 响应示例：
+//
+//	{"candidate": {...}, "report_id": 18, "rankings": [...]}
+func HandleAcceptBrandCandidate(c *gin.Context) {
+	candidate, ok := findBrandCandidate(c)
+	if !ok {
+		return
+	}
+	var req BrandCandidateAcceptRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	data, err := task.AcceptBrandCandidate(candidate, task.AcceptCandidateOptions{
+		Name:     req.Name,
+		Category: req.Category,
+		Aliases:  req.Aliases,
+	})
+	if errors.Is(err, task.ErrCandidateReviewed) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "接受候选品牌失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"candidate": toBrandCandidateResponse(candidate),
+		"report_id": candidate.ReportID,
+		"rankings":  data.Rankings,
+	})
+}
+
+// HandleRejectBrandCandidate 拒绝候选品牌（报告不变）
+// POST /api/brand-candidates/:id/reject
+func HandleRejectBrandCandidate(c *gin.Context) {
+	candidate, ok := findBrandCandidate(c)
+	if !ok {
+		return
+	}
+	if err := task.RejectBrandCandidate(candidate); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, task.ErrCandidateReviewed) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toBrandCandidateResponse(candidate))
+}
+
+// findBrandCandidate 按路径参数 id 查找候选品牌，找不到时直接写入错误响应
+func findBrandCandidate(c *gin.Context) (*models.BrandCandidate, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "候选品牌ID无效"})
+		return nil, false
+	}
+	var candidate models.BrandCandidate
+	if err := database.DB.First(&candidate, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "候选品牌不存在"})
+		return nil, false
+	}
+	return &candidate, true
+}
+
+func toBrandCandidateResponse(b *models.BrandCandidate) BrandCandidateResponse {
+	resp := BrandCandidateResponse{
+		ID:               b.ID,
+		HistoryID:        b.HistoryID,
+		ReportID:         b.ReportID,
+		Brand:            b.Brand,
+		Spellings:        database.SplitAliasList(b.Spellings),
+		Score:            b.Score,
+		CommentCount:     b.CommentCount,
+		VideoCount:       b.VideoCount,
+		CategoryHitRatio: b.CategoryHitRatio,
+		ModelHitRatio:    b.ModelHitRatio,
+		Status:           b.Status,
+		AcceptedAs:       b.AcceptedAs,
+		SampleComments:   []report.TypicalComment{},
+		CreatedAt:        b.CreatedAt,
+		UpdatedAt:        b.UpdatedAt,
+	}
+	if resp.Spellings == nil {
+		resp.Spellings = []string{}
+	}
+	if comments, err := task.CandidateComments(b); err == nil {
+		resp.SampleComments = report.SampleComments(comments, candidateSampleComments)
+	}
+	return resp
+}
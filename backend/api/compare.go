@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/pdf"
+	"bilibili-analyzer/backend/report"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleCompareReports 对比两份报告
+// GET /api/compare?ids=a,b
+//
+// a 为基准（旧）报告，b 为对比（新）报告；品牌和维度名称会做精确及模糊对齐
+//
+// 响应示例：
+//
+//	{"base": {"id": 1, ...}, "target": {"id": 2, ...}, "brands": [{"brand": "小米", "status": "both", "base_score": 7.2, "target_score": 7.8, "delta": 0.6, "rank_change": 1, ...}], "new_brands": ["追觅"], "vanished_brands": [], ...}
+func HandleCompareReports(c *gin.Context) {
+	cmp, ok := loadComparison(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, cmp)
+}
+
+// HandleExportComparePDF 导出报告对比PDF
+// GET /api/compare/pdf?ids=a,b
+func HandleExportComparePDF(c *gin.Context) {
+	cmp, ok := loadComparison(c)
+	if !ok {
+		return
+	}
+
+	log.Printf("[PDF] 开始生成对比PDF，报告ID: %d -> %d", cmp.Base.ID, cmp.Target.ID)
+	pdfBytes, err := pdf.GenerateComparisonPDF(cmp)
+	if err != nil {
+		log.Printf("[PDF] 生成对比PDF失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成PDF失败: %v", err)})
+		return
+	}
+
+	filename := fmt.Sprintf("compare_%d_%d.pdf", cmp.Base.ID, cmp.Target.ID)
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Length", fmt.Sprintf("%d", len(pdfBytes)))
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// loadComparison 解析 ids 参数、读取两份报告并生成对比结果，出错时直接写入错误响应
+func loadComparison(c *gin.Context) (*report.Comparison, bool) {
+	parts := strings.Split(c.Query("ids"), ",")
+	if len(parts) != 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids 参数需要两个报告ID，如 ids=1,2"})
+		return nil, false
+	}
+
+	var ids [2]uint
+	for i, part := range parts {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "报告ID无效: " + part})
+			return nil, false
+		}
+		ids[i] = uint(id)
+	}
+	if ids[0] == ids[1] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择两份不同的报告"})
+		return nil, false
+	}
+
+	var reports [2]models.Report
+	var data [2]report.ReportData
+	for i, id := range ids {
+		if err := database.DB.First(&reports[i], id).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("报告 %d 不存在", id)})
+			return nil, false
+		}
+		if err := json.Unmarshal([]byte(reports[i].ReportData), &data[i]); err != nil {
+			log.Printf("[Compare] 解析报告 %d 数据失败: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("解析报告 %d 数据失败", id)})
+			return nil, false
+		}
+	}
+
+	cmp := report.CompareReports(&data[0], &data[1])
+	cmp.Base.ID, cmp.Base.CreatedAt = reports[0].ID, reports[0].CreatedAt
+	cmp.Target.ID, cmp.Target.CreatedAt = reports[1].ID, reports[1].CreatedAt
+	return cmp, true
+}
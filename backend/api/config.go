@@ -1,8 +1,12 @@
 package api
 
 import (
+	"bilibili-analyzer/backend/ai"
 	"bilibili-analyzer/backend/database"
 	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/report"
+	"bilibili-analyzer/backend/task"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -17,24 +21,50 @@ func HandleGetConfig(c *gin.Context) {
 		return setting.Value
 	}
 
+	accounts, err := listAccountResponses()
+	if err != nil {
+		log.Printf("获取账号池失败: %v", err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"ai_base_url":            getSettingValue(models.SettingKeyAIAPIBase),
-		"ai_api_key":             getSettingValue(models.SettingKeyAIAPIKey),
-		"ai_model":               getSettingValue(models.SettingKeyAIModel),
-		"bilibili_cookie":        getSettingValue(models.SettingKeyBilibiliCookie),
-		"scrape_max_concurrency": getSettingValue(models.SettingKeyScrapeMaxConcurrency),
-		"ai_max_concurrency":     getSettingValue(models.SettingKeyAIMaxConcurrency),
+		"ai_provider":             getSettingValue(models.SettingKeyAIProvider),
+		"ai_base_url":             getSettingValue(models.SettingKeyAIAPIBase),
+		"ai_api_key":              getSettingValue(models.SettingKeyAIAPIKey),
+		"ai_model":                getSettingValue(models.SettingKeyAIModel),
+		"bilibili_cookie":         getSettingValue(models.SettingKeyBilibiliCookie),
+		"scrape_max_concurrency":  getSettingValue(models.SettingKeyScrapeMaxConcurrency),
+		"ai_max_concurrency":      getSettingValue(models.SettingKeyAIMaxConcurrency),
+		"queue_workers":           getSettingValue(models.SettingKeyQueueWorkers),
+		"queue_order":             getSettingValue(models.SettingKeyQueueOrder),
+		"ai_cache_ttl_hours":      getSettingValue(models.SettingKeyAICacheTTLHours),
+		"ai_price_table":          getSettingValue(models.SettingKeyAIPriceTable),
+		"ai_token_budget":         getSettingValue(models.SettingKeyAITokenBudget),
+		"bilibili_rate_limit":     getSettingValue(models.SettingKeyBilibiliRateLimit),
+		"comment_weighting":       getSettingValue(models.SettingKeyCommentWeighting),
+		"sponsored_video_mode":    getSettingValue(models.SettingKeySponsoredVideoMode),
+		"suspicious_comment_mode": getSettingValue(models.SettingKeySuspiciousMode),
+		"bilibili_accounts":       accounts,
 	})
 }
 
 func HandleSaveConfig(c *gin.Context) {
 	var req struct {
+		AIProvider           string `json:"ai_provider"`
 		AIBaseURL            string `json:"ai_base_url"`
 		AIAPIKey             string `json:"ai_api_key"`
 		AIModel              string `json:"ai_model"`
 		BilibiliCookie       string `json:"bilibili_cookie"`
 		ScrapeMaxConcurrency string `json:"scrape_max_concurrency"`
 		AIMaxConcurrency     string `json:"ai_max_concurrency"`
+		QueueWorkers         string `json:"queue_workers"`
+		QueueOrder           string `json:"queue_order"`
+		AICacheTTLHours      string `json:"ai_cache_ttl_hours"`
+		AIPriceTable         string `json:"ai_price_table"`
+		AITokenBudget        string `json:"ai_token_budget"`
+		BilibiliRateLimit    string `json:"bilibili_rate_limit"`
+		CommentWeighting     string `json:"comment_weighting"`
+		SponsoredVideoMode   string `json:"sponsored_video_mode"`
+		SuspiciousMode       string `json:"suspicious_comment_mode"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -42,38 +72,90 @@ func HandleSaveConfig(c *gin.Context) {
 		return
 	}
 
-	saveOrUpdate := func(key, value string) error {
-		var setting models.Settings
-		result := database.DB.Where("key = ?", key).First(&setting)
-		if result.Error != nil {
-			setting = models.Settings{Key: key, Value: value}
-			return database.DB.Create(&setting).Error
+	if req.AIProvider != "" {
+		if _, err := ai.NewProvider(req.AIProvider, req.AIBaseURL, req.AIAPIKey); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
-		setting.Value = value
-		return database.DB.Save(&setting).Error
+	}
+	if _, err := ai.ParsePriceTable(req.AIPriceTable); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "模型价格表格式错误: " + err.Error()})
+		return
+	}
+	if _, err := report.ParseCommentWeighting(req.CommentWeighting); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "评论权重配置错误: " + err.Error()})
+		return
+	}
+	if _, err := task.ParseSponsoredMode(req.SponsoredVideoMode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "商单处理方式错误: " + err.Error()})
+		return
+	}
+	if _, err := task.ParseSuspiciousMode(req.SuspiciousMode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "可疑评论处理方式错误: " + err.Error()})
+		return
+	}
+	if err := database.SaveSetting(models.SettingKeyAIProvider, req.AIProvider); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
+		return
+	}
+	if err := database.SaveSetting(models.SettingKeyAIAPIBase, req.AIBaseURL); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
+		return
+	}
+	if err := database.SaveSetting(models.SettingKeyAIAPIKey, req.AIAPIKey); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
+		return
+	}
+	if err := database.SaveSetting(models.SettingKeyAIModel, req.AIModel); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
+		return
+	}
+	if err := database.SaveSetting(models.SettingKeyBilibiliCookie, req.BilibiliCookie); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
+		return
+	}
+	if err := database.SaveSetting(models.SettingKeyScrapeMaxConcurrency, req.ScrapeMaxConcurrency); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
+		return
+	}
+	if err := database.SaveSetting(models.SettingKeyAIMaxConcurrency, req.AIMaxConcurrency); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
+		return
 	}
 
-	if err := saveOrUpdate(models.SettingKeyAIAPIBase, req.AIBaseURL); err != nil {
+	if err := database.SaveSetting(models.SettingKeyQueueWorkers, req.QueueWorkers); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
+		return
+	}
+	if err := database.SaveSetting(models.SettingKeyQueueOrder, req.QueueOrder); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
+		return
+	}
+	if err := database.SaveSetting(models.SettingKeyAICacheTTLHours, req.AICacheTTLHours); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
+		return
+	}
+	if err := database.SaveSetting(models.SettingKeyAIPriceTable, req.AIPriceTable); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
 		return
 	}
-	if err := saveOrUpdate(models.SettingKeyAIAPIKey, req.AIAPIKey); err != nil {
+	if err := database.SaveSetting(models.SettingKeyAITokenBudget, req.AITokenBudget); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
 		return
 	}
-	if err := saveOrUpdate(models.SettingKeyAIModel, req.AIModel); err != nil {
+	if err := database.SaveSetting(models.SettingKeyBilibiliRateLimit, req.BilibiliRateLimit); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
 		return
 	}
-	if err := saveOrUpdate(models.SettingKeyBilibiliCookie, req.BilibiliCookie); err != nil {
+	if err := database.SaveSetting(models.SettingKeyCommentWeighting, req.CommentWeighting); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
 		return
 	}
-	if err := saveOrUpdate(models.SettingKeyScrapeMaxConcurrency, req.ScrapeMaxConcurrency); err != nil {
+	if err := database.SaveSetting(models.SettingKeySponsoredVideoMode, req.SponsoredVideoMode); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
 		return
 	}
-	if err := saveOrUpdate(models.SettingKeyAIMaxConcurrency, req.AIMaxConcurrency); err != nil {
+	if err := database.SaveSetting(models.SettingKeySuspiciousMode, req.SuspiciousMode); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save config"})
 		return
 	}
@@ -4,7 +4,6 @@ import (
 	"bilibili-analyzer/backend/ai"
 	"bilibili-analyzer/backend/sse"
 	"bilibili-analyzer/backend/task"
-	"context"
 	"log"
 	"net/http"
 
@@ -13,19 +12,26 @@ import (
 )
 
 type ConfirmRequest struct {
-	Requirement string   `json:"requirement"`
-	Brands      []string `json:"brands"`
-	Dimensions  []struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
-	} `json:"dimensions"`
-	Keywords              []string `json:"keywords"`
-	VideoDateRangeMonths  int      `json:"video_date_range_months,omitempty"`   // 视频时间范围（月），0表示不限制，默认24
-	MinVideoDuration      int      `json:"min_video_duration,omitempty"`        // 最小视频时长（秒），0表示不过滤
-	MaxComments           int      `json:"max_comments,omitempty"`              // 最大分析评论数，默认500
-	MinVideoComments      int      `json:"min_video_comments,omitempty"`        // 最小视频评论数过滤（默认0，表示不限制）
-	MinCommentsPerVideo   int      `json:"min_comments_per_video,omitempty"`    // 每视频最少抓取数（默认10）
-	MaxCommentsPerVideoV2 int      `json:"max_comments_per_video_v2,omitempty"` // 每视频最多抓取数（默认200）
+	Requirement           string             `json:"requirement"`
+	Brands                []string           `json:"brands"`
+	Dimensions            []ConfirmDimension `json:"dimensions"`
+	Keywords              []string           `json:"keywords"`
+	VideoDateRangeMonths  int                `json:"video_date_range_months,omitempty"`   // 视频时间范围（月），0表示不限制，默认24
+	MinVideoDuration      int                `json:"min_video_duration,omitempty"`        // 最小视频时长（秒），0表示不过滤
+	MaxComments           int                `json:"max_comments,omitempty"`              // 最大分析评论数，默认500
+	MinVideoComments      int                `json:"min_video_comments,omitempty"`        // 最小视频评论数过滤（默认0，表示不限制）
+	MinCommentsPerVideo   int                `json:"min_comments_per_video,omitempty"`    // 每视频最少抓取数（默认10）
+	MaxCommentsPerVideoV2 int                `json:"max_comments_per_video_v2,omitempty"` // 每视频最多抓取数（默认200）
+	Priority              int                `json:"priority,omitempty"`                  // 队列优先级（数值越大越先执行，仅在 priority 排序模式下生效）
+	NoCache               bool               `json:"no_cache,omitempty"`                  // 跳过AI响应缓存（强制重新调用AI）
+	TokenBudget           int64              `json:"token_budget,omitempty"`              // Token 预算（超出后中止任务，0 表示使用全局配置）
+	IncludeDanmaku        bool               `json:"include_danmaku,omitempty"`           // 同时抓取视频弹幕参与分析
+}
+
+// ConfirmDimension 确认的评价维度
+type ConfirmDimension struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
 }
 
 func HandleConfirm(c *gin.Context) {
@@ -34,27 +40,48 @@ func HandleConfirm(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
 		return
 	}
-
-	if req.Requirement == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "需求描述不能为空"})
-		return
-	}
-	if len(req.Brands) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "品牌列表不能为空"})
-		return
-	}
-	if len(req.Dimensions) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "评价维度不能为空"})
-		return
-	}
-	if len(req.Keywords) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "搜索关键词不能为空"})
+	if msg := req.Validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
 		return
 	}
 
 	taskID := uuid.New().String()
 	sse.CreateTaskChannel(taskID)
 
+	// 任务加入队列，由队列调度器按并发限制执行
+	taskReq, config := req.ToTask(taskID)
+	_, err := task.Enqueue(taskReq, config, req.Priority)
+	if err != nil {
+		log.Printf("[Task %s] Enqueue failed: %v", taskID, err)
+		sse.CloseTaskChannel(taskID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建任务失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"task_id":        taskID,
+		"queue_position": task.QueuePosition(taskID),
+		"message":        "任务已创建，请通过SSE接口获取实时进度",
+	})
+}
+
+// Validate 校验必填字段，返回错误提示（为空表示通过）
+func (req *ConfirmRequest) Validate() string {
+	switch {
+	case req.Requirement == "":
+		return "需求描述不能为空"
+	case len(req.Brands) == 0:
+		return "品牌列表不能为空"
+	case len(req.Dimensions) == 0:
+		return "评价维度不能为空"
+	case len(req.Keywords) == 0:
+		return "搜索关键词不能为空"
+	}
+	return ""
+}
+
+// ToTask 转换为任务请求和任务配置
+func (req *ConfirmRequest) ToTask(taskID string) (task.TaskRequest, *task.TaskConfig) {
 	dimensions := make([]ai.Dimension, len(req.Dimensions))
 	for i, d := range req.Dimensions {
 		dimensions[i] = ai.Dimension{
@@ -63,35 +90,47 @@ func HandleConfirm(c *gin.Context) {
 		}
 	}
 
-	go func() {
-		defer sse.CloseTaskChannel(taskID)
-
-		config := &task.TaskConfig{
-			VideoDateRangeMonths:  req.VideoDateRangeMonths,
-			MinVideoDuration:      req.MinVideoDuration,
-			MaxComments:           req.MaxComments,
-			MinVideoComments:      req.MinVideoComments,
-			MinCommentsPerVideo:   req.MinCommentsPerVideo,
-			MaxCommentsPerVideoV2: req.MaxCommentsPerVideoV2,
-		}
-
-		executor := task.NewExecutor(config)
-		err := executor.Execute(context.Background(), task.TaskRequest{
-			TaskID:      taskID,
-			Requirement: req.Requirement,
-			Brands:      req.Brands,
-			Dimensions:  dimensions,
-			Keywords:    req.Keywords,
-		})
+	config := &task.TaskConfig{
+		VideoDateRangeMonths:  req.VideoDateRangeMonths,
+		MinVideoDuration:      req.MinVideoDuration,
+		MaxComments:           req.MaxComments,
+		MinVideoComments:      req.MinVideoComments,
+		MinCommentsPerVideo:   req.MinCommentsPerVideo,
+		MaxCommentsPerVideoV2: req.MaxCommentsPerVideoV2,
+	}
 
-		if err != nil {
-			log.Printf("[Task %s] Execution failed: %v", taskID, err)
-			sse.PushError(taskID, err.Error())
-		}
-	}()
+	return task.TaskRequest{
+		TaskID:         taskID,
+		Requirement:    req.Requirement,
+		Brands:         req.Brands,
+		Dimensions:     dimensions,
+		Keywords:       req.Keywords,
+		NoCache:        req.NoCache,
+		TokenBudget:    req.TokenBudget,
+		IncludeDanmaku: req.IncludeDanmaku,
+	}, config
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"task_id": taskID,
-		"message": "任务已创建，请通过SSE接口获取实时进度",
-	})
+// confirmRequestFromTask 从任务请求和配置还原确认请求（ToTask 的逆操作）
+func confirmRequestFromTask(req task.TaskRequest, config task.TaskConfig, priority int) ConfirmRequest {
+	dimensions := make([]ConfirmDimension, len(req.Dimensions))
+	for i, d := range req.Dimensions {
+		dimensions[i] = ConfirmDimension{Name: d.Name, Description: d.Description}
+	}
+	return ConfirmRequest{
+		Requirement:           req.Requirement,
+		Brands:                req.Brands,
+		Dimensions:            dimensions,
+		Keywords:              req.Keywords,
+		VideoDateRangeMonths:  config.VideoDateRangeMonths,
+		MinVideoDuration:      config.MinVideoDuration,
+		MaxComments:           config.MaxComments,
+		MinVideoComments:      config.MinVideoComments,
+		MinCommentsPerVideo:   config.MinCommentsPerVideo,
+		MaxCommentsPerVideoV2: config.MaxCommentsPerVideoV2,
+		Priority:              priority,
+		NoCache:               req.NoCache,
+		TokenBudget:           req.TokenBudget,
+		IncludeDanmaku:        req.IncludeDanmaku,
+	}
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bilibili-analyzer/backend/ai"
 	"bilibili-analyzer/backend/database"
 	"bilibili-analyzer/backend/models"
 	"encoding/json"
@@ -13,28 +14,31 @@ import (
 
 // HistoryListResponse 历史记录列表响应结构
 type HistoryListResponse struct {
-	ID           uint   `json:"id"`           // 历史记录ID
-	TaskId       string `json:"taskId"`       // 任务ID
-	Category     string `json:"category"`     // 商品类目
-	VideoCount   int    `json:"videoCount"`   // 视频数量
-	CommentCount int    `json:"commentCount"` // 评论数量
-	Status       string `json:"status"`       // 任务状态
-	ReportID     uint   `json:"reportId"`     // 关联的报告ID
-	CreatedAt    string `json:"createdAt"`    // 创建时间
+	ID           uint    `json:"id"`           // 历史记录ID
+	TaskId       string  `json:"taskId"`       // 任务ID
+	Category     string  `json:"category"`     // 商品类目
+	VideoCount   int     `json:"videoCount"`   // 视频数量
+	CommentCount int     `json:"commentCount"` // 评论数量
+	Status       string  `json:"status"`       // 任务状态
+	ReportID     uint    `json:"reportId"`     // 关联的报告ID
+	TotalTokens  int64   `json:"totalTokens"`  // Token 合计
+	Cost         float64 `json:"cost"`         // 费用
+	CreatedAt    string  `json:"createdAt"`    // 创建时间
 }
 
 // HistoryDetailResponse 历史记录详情响应结构
 type HistoryDetailResponse struct {
-	ID           uint     `json:"id"`           // 历史记录ID
-	Category     string   `json:"category"`     // 商品类目
-	Keywords     []string `json:"keywords"`     // 搜索关键词
-	Brands       []string `json:"brands"`       // 品牌列表
-	Dimensions   []string `json:"dimensions"`   // 评价维度
-	VideoCount   int      `json:"videoCount"`   // 视频数量
-	CommentCount int      `json:"commentCount"` // 评论数量
-	Status       string   `json:"status"`       // 任务状态
-	ReportData   string   `json:"reportData"`   // 报告JSON数据
-	CreatedAt    string   `json:"createdAt"`    // 创建时间
+	ID           uint             `json:"id"`                   // 历史记录ID
+	Category     string           `json:"category"`             // 商品类目
+	Keywords     []string         `json:"keywords"`             // 搜索关键词
+	Brands       []string         `json:"brands"`               // 品牌列表
+	Dimensions   []string         `json:"dimensions"`           // 评价维度
+	VideoCount   int              `json:"videoCount"`           // 视频数量
+	CommentCount int              `json:"commentCount"`         // 评论数量
+	Status       string           `json:"status"`               // 任务状态
+	ReportData   string           `json:"reportData"`           // 报告JSON数据
+	TokenUsage   *ai.UsageSummary `json:"tokenUsage,omitempty"` // Token 用量明细（按阶段）和费用
+	CreatedAt    string           `json:"createdAt"`            // 创建时间
 }
 
 // HandleGetHistory 获取历史记录列表
@@ -62,6 +66,8 @@ func HandleGetHistory(c *gin.Context) {
 			CommentCount: h.CommentCount,
 			Status:       h.Status,
 			ReportID:     h.ReportID,
+			TotalTokens:  h.TotalTokens,
+			Cost:         h.Cost,
 			CreatedAt:    h.CreatedAt.Format("2006-01-02 15:04:05"),
 		}
 	}
@@ -115,6 +121,12 @@ func HandleGetHistoryDetail(c *gin.Context) {
 		ReportData:   reportData,
 		CreatedAt:    history.CreatedAt.Format("2006-01-02 15:04:05"),
 	}
+	if history.TokenUsage != "" {
+		var usage ai.UsageSummary
+		if err := json.Unmarshal([]byte(history.TokenUsage), &usage); err == nil {
+			response.TokenUsage = &usage
+		}
+	}
 
 	c.JSON(http.StatusOK, response)
 }
@@ -145,6 +157,14 @@ func HandleDeleteHistory(c *gin.Context) {
 	// 删除关联的报告数据
 	if history.ReportID > 0 {
 		database.DB.Delete(&models.Report{}, history.ReportID)
+		if err := database.DeleteReportComments(history.ReportID); err != nil {
+			log.Printf("删除报告 %d 的证据评论失败: %v", history.ReportID, err)
+		}
+	}
+
+	// 删除关联的候选品牌
+	if err := database.DeleteBrandCandidates(history.ID); err != nil {
+		log.Printf("删除历史 %d 的候选品牌失败: %v", history.ID, err)
 	}
 
 	// 删除关联的原始评论数据
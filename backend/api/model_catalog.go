@@ -0,0 +1,341 @@
+package api
+
+import (
+	"bilibili-analyzer/backend/comment"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/task"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModelCatalogRequest 新增/修改型号库词条请求
+type ModelCatalogRequest struct {
+	Brand       string   `json:"brand"`
+	Model       string   `json:"model"` // 标准型号名称
+	Aliases     []string `json:"aliases"`
+	ReleaseYear int      `json:"release_year"` // 0 表示未知
+}
+
+// ModelReviewApproveRequest 审核通过请求
+// catalog_id 不为 0 时把待审核的写法追加为该词条的别名；否则新建词条，model 为空时使用待审核的写法
+type ModelReviewApproveRequest struct {
+	CatalogID   uint     `json:"catalog_id"`
+	Model       string   `json:"model"`
+	Aliases     []string `json:"aliases"`
+	ReleaseYear int      `json:"release_year"`
+}
+
+// ModelCatalogResponse 型号库词条
+type ModelCatalogResponse struct {
+	ID          uint      `json:"id"`
+	Brand       string    `json:"brand"`
+	Model       string    `json:"model"`
+	Aliases     []string  `json:"aliases"`
+	ReleaseYear int       `json:"release_year"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ModelReviewResponse 待审核型号
+type ModelReviewResponse struct {
+	ID           uint      `json:"id"`
+	Brand        string    `json:"brand"`
+	Model        string    `json:"model"`
+	Category     string    `json:"category"`
+	Mentions     int       `json:"mentions"`
+	ReportCount  int       `json:"report_count"`
+	LastReportID uint      `json:"last_report_id"`
+	Status       string    `json:"status"`
+	CatalogID    uint      `json:"catalog_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// HandleListModelCatalog 获取型号库
+// GET /api/model-catalog?brand=追觅
+//
+// 响应示例：
+//
+//	{"models": [{"id": 1, "brand": "追觅", "model": "X40 Pro", "aliases": ["X40Pro"], "release_year": 2024, ...}]}
+func HandleListModelCatalog(c *gin.Context) {
+	entries, err := database.ListModelCatalog(c.Query("brand"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取型号库失败: " + err.Error()})
+		return
+	}
+	result := make([]ModelCatalogResponse, 0, len(entries))
+	for i := range entries {
+		result = append(result, toModelCatalogResponse(&entries[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"models": result})
+}
+
+// HandleCreateModelCatalog 添加型号库词条
+// POST /api/model-catalog
+// 品牌按品牌词典归并为标准名称后保存；同一品牌下的写法不能同时属于两个型号
+//
+// 请求示例：
+//
+//	{"brand": "Dreame", "model": "X40 Pro", "aliases": ["X40Pro", "X40 Pro Ultra"], "release_year": 2024}
+func HandleCreateModelCatalog(c *gin.Context) {
+	var req ModelCatalogRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	var entry models.ModelCatalogEntry
+	if !applyModelCatalogRequest(c, &entry, &req) {
+		return
+	}
+	if err := database.DB.Create(&entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存型号失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toModelCatalogResponse(&entry))
+}
+
+// HandleUpdateModelCatalog 修改型号库词条（只影响之后的分析）
+// PUT /api/model-catalog/:id
+func HandleUpdateModelCatalog(c *gin.Context) {
+	entry, ok := findModelCatalogEntry(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req ModelCatalogRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if !applyModelCatalogRequest(c, entry, &req) {
+		return
+	}
+	if err := database.DB.Save(entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存型号失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toModelCatalogResponse(entry))
+}
+
+// HandleDeleteModelCatalog 删除型号库词条
+// DELETE /api/model-catalog/:id
+func HandleDeleteModelCatalog(c *gin.Context) {
+	entry, ok := findModelCatalogEntry(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if err := database.DB.Delete(entry).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除型号失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "型号已删除"})
+}
+
+// HandleListModelReviews 获取待审核型号（报告中未命中型号库的型号，按累计评论数倒序）
+// GET /api/model-reviews?status=pending&brand=追觅
+// status 默认 pending，传 all 返回全部
+//
+// 响应示例：
+//
+//	{"reviews": [{"id": 3, "brand": "追觅", "model": "x50 ultra", "mentions": 12, "report_count": 2, "last_report_id": 18, "status": "pending", ...}]}
+func HandleListModelReviews(c *gin.Context) {
+	status := c.DefaultQuery("status", models.ModelReviewPending)
+	if status == "all" {
+		status = ""
+	}
+	reviews, err := database.ListModelReviews(status, c.Query("brand"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取待审核型号失败: " + err.Error()})
+		return
+	}
+	result := make([]ModelReviewResponse, 0, len(reviews))
+	for _, r := range reviews {
+		result = append(result, ModelReviewResponse{
+			ID:           r.ID,
+			Brand:        r.Brand,
+			Model:        r.Model,
+			Category:     r.Category,
+			Mentions:     r.Mentions,
+			ReportCount:  r.ReportCount,
+			LastReportID: r.LastReportID,
+			Status:       r.Status,
+			CatalogID:    r.CatalogID,
+			CreatedAt:    r.CreatedAt,
+			UpdatedAt:    r.UpdatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"reviews": result})
+}
+
+// HandleApproveModelReview 审核通过：收录到型号库
+// POST /api/model-reviews/:id/approve
+//
+// 请求示例：
+//
+//	{"model": "X50 Ultra", "release_year": 2025}   // 新建词条
+//	{"catalog_id": 1}                              // 作为已有型号的别名
+func HandleApproveModelReview(c *gin.Context) {
+	review, ok := findModelReview(c)
+	if !ok {
+		return
+	}
+	// 请求体可以为空（使用待审核的写法新建词条）
+	var req ModelReviewApproveRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	var entry *models.ModelCatalogEntry
+	if req.CatalogID != 0 {
+		if entry, ok = findModelCatalogEntry(c, strconv.FormatUint(uint64(req.CatalogID), 10)); !ok {
+			return
+		}
+		if comment.BrandKey(entry.Brand) != comment.BrandKey(review.Brand) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "型号库词条与待审核型号的品牌不一致"})
+			return
+		}
+		entry.Aliases = database.MergeAliasList(entry.Aliases, review.Model)
+	} else {
+		model := strings.TrimSpace(req.Model)
+		if model == "" {
+			model = review.Model
+		}
+		aliases := req.Aliases
+		if comment.ModelKey(model) != review.ModelKey {
+			aliases = append(aliases, review.Model)
+		}
+		entry = &models.ModelCatalogEntry{}
+		if !applyModelCatalogRequest(c, entry, &ModelCatalogRequest{
+			Brand:       review.Brand,
+			Model:       model,
+			Aliases:     aliases,
+			ReleaseYear: req.ReleaseYear,
+		}) {
+			return
+		}
+	}
+
+	if err := database.ApproveModelReview(review, entry); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "收录型号失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toModelCatalogResponse(entry))
+}
+
+// HandleIgnoreModelReview 忽略待审核型号（之后再出现也不会重新进入队列）
+// POST /api/model-reviews/:id/ignore
+func HandleIgnoreModelReview(c *gin.Context) {
+	review, ok := findModelReview(c)
+	if !ok {
+		return
+	}
+	review.Status = models.ModelReviewIgnored
+	if err := database.DB.Save(review).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已忽略"})
+}
+
+// applyModelCatalogRequest 校验请求并写入词条字段，校验失败时直接写入错误响应
+func applyModelCatalogRequest(c *gin.Context, entry *models.ModelCatalogEntry, req *ModelCatalogRequest) bool {
+	brand := task.LoadBrandDictionary("").Resolve(req.Brand, nil)
+	model := strings.TrimSpace(req.Model)
+	if brand == "" || brand == "未知" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "品牌不能为空"})
+		return false
+	}
+	if comment.IsGenericModel(model) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "型号名称无效（不能为空或单独的系列后缀）"})
+		return false
+	}
+	if req.ReleaseYear < 0 || req.ReleaseYear > time.Now().Year()+1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "发布年份无效"})
+		return false
+	}
+	aliases := database.SplitAliasList(strings.Join(req.Aliases, ","))
+
+	// 同一品牌下每个写法只能属于一个型号
+	others, err := database.ListModelCatalog(brand)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询型号库失败: " + err.Error()})
+		return false
+	}
+	owners := make(map[string]string)
+	for _, other := range others {
+		if other.ID == entry.ID {
+			continue
+		}
+		for _, spelling := range append([]string{other.Model}, database.SplitAliasList(other.Aliases)...) {
+			owners[comment.ModelKey(spelling)] = other.Model
+		}
+	}
+	for _, spelling := range append([]string{model}, aliases...) {
+		if owner, ok := owners[comment.ModelKey(spelling)]; ok {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("写法 %s 已属于型号 %s %s", spelling, brand, owner)})
+			return false
+		}
+	}
+
+	entry.Brand = brand
+	entry.Model = model
+	entry.Aliases = strings.Join(aliases, ",")
+	entry.ReleaseYear = req.ReleaseYear
+	return true
+}
+
+// findModelCatalogEntry 按 id 查找型号库词条，找不到时直接写入错误响应
+func findModelCatalogEntry(c *gin.Context, rawID string) (*models.ModelCatalogEntry, bool) {
+	id, err := strconv.ParseUint(rawID, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "型号ID无效"})
+		return nil, false
+	}
+	var entry models.ModelCatalogEntry
+	if err := database.DB.First(&entry, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "型号不存在"})
+		return nil, false
+	}
+	return &entry, true
+}
+
+// findModelReview 按路径参数 id 查找待审核型号，找不到时直接写入错误响应
+func findModelReview(c *gin.Context) (*models.ModelReview, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "待审核型号ID无效"})
+		return nil, false
+	}
+	var review models.ModelReview
+	if err := database.DB.First(&review, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "待审核型号不存在"})
+		return nil, false
+	}
+	return &review, true
+}
+
+func toModelCatalogResponse(e *models.ModelCatalogEntry) ModelCatalogResponse {
+	aliases := database.SplitAliasList(e.Aliases)
+	if aliases == nil {
+		aliases = []string{}
+	}
+	return ModelCatalogResponse{
+		ID:          e.ID,
+		Brand:       e.Brand,
+		Model:       e.Model,
+		Aliases:     aliases,
+		ReleaseYear: e.ReleaseYear,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"bilibili-analyzer/backend/ai"
 	"bilibili-analyzer/backend/database"
 	"bilibili-analyzer/backend/models"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -44,38 +47,56 @@ func HandleParse(c *gin.Context) {
 		return
 	}
 
-	// 2. 从数据库获取AI配置
-	// 需要读取用户在设置页面配置的API Key、API Base和模型名称
-	var apiKey, apiBase, model string
-	if err := database.DB.Model(&models.Settings{}).Where("key = ?", "ai_api_key").Pluck("value", &apiKey).Error; err != nil {
-		// 忽略记录不存在的错误，只处理真正的数据库错误
-		if err.Error() != "record not found" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "数据库查询失败: " + err.Error()})
-			return
-		}
+	// 2. 调用AI解析关键词
+	result, err := ParseRequirement(c.Request.Context(), req.Requirement)
+	if errors.Is(err, ErrAIKeyMissing) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "AI API密钥未配置，请先在设置页面配置"})
+		return
 	}
-	if err := database.DB.Model(&models.Settings{}).Where("key = ?", "ai_api_base").Pluck("value", &apiBase).Error; err != nil {
-		if err.Error() != "record not found" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "数据库查询失败: " + err.Error()})
-			return
-		}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
-	if err := database.DB.Model(&models.Settings{}).Where("key = ?", "ai_model").Pluck("value", &model).Error; err != nil {
-		if err.Error() != "record not found" {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "数据库查询失败: " + err.Error()})
-			return
+
+	// 3. 返回解析结果
+	c.JSON(http.StatusOK, result)
+}
+
+// ErrAIKeyMissing AI API密钥未配置
+var ErrAIKeyMissing = errors.New("AI API密钥未配置")
+
+// ParseRequirement 按数据库中的AI配置解析需求描述，返回品牌、维度和关键词
+// HTTP 接口和命令行共用
+func ParseRequirement(ctx context.Context, requirement string) (*ai.ParseKeywordResponse, error) {
+	// 1. 从数据库获取AI配置
+	// 需要读取用户在设置页面配置的API Key、API Base和模型名称
+	var apiKey, apiBase, model, provider string
+	settings := []struct {
+		key   string
+		value *string
+	}{
+		{"ai_api_key", &apiKey},
+		{"ai_api_base", &apiBase},
+		{"ai_model", &model},
+		{models.SettingKeyAIProvider, &provider},
+	}
+	for _, setting := range settings {
+		if err := database.DB.Model(&models.Settings{}).Where("key = ?", setting.key).Pluck("value", setting.value).Error; err != nil {
+			// 忽略记录不存在的错误，只处理真正的数据库错误
+			if err.Error() != "record not found" {
+				return nil, fmt.Errorf("数据库查询失败: %w", err)
+			}
 		}
 	}
 
-	// 3. 验证AI配置是否完整
-	if apiKey == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "AI API密钥未配置，请先在设置页面配置"})
-		return
+	// 2. 验证AI配置是否完整
+	if apiKey == "" && ai.RequiresAPIKey(provider) {
+		return nil, ErrAIKeyMissing
 	}
 
 	// 如果没有配置API Base，使用默认值
 	if apiBase == "" {
-		apiBase = "https://api.openai.com/v1"
+		apiBase = ai.DefaultAPIBase(provider)
 	}
 
 	// 如果没有配置模型，使用默认值
@@ -83,22 +104,20 @@ func HandleParse(c *gin.Context) {
 		model = "gemini-3-flash-preview"
 	}
 
-	// 4. 创建AI客户端
+	// 3. 创建AI客户端
 	aiClient := ai.NewClient(ai.Config{
-		APIBase: apiBase,
-		APIKey:  apiKey,
-		Model:   model,
+		Provider: provider,
+		APIBase:  apiBase,
+		APIKey:   apiKey,
+		Model:    model,
 	})
 
-	// 5. 调用AI解析关键词
-	result, err := aiClient.ParseKeyword(c.Request.Context(), ai.ParseKeywordRequest{
-		Requirement: req.Requirement,
+	// 4. 调用AI解析关键词
+	result, err := aiClient.ParseKeyword(ctx, ai.ParseKeywordRequest{
+		Requirement: requirement,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "AI解析失败: " + err.Error()})
-		return
+		return nil, fmt.Errorf("AI解析失败: %w", err)
 	}
-
-	// 6. 返回解析结果
-	c.JSON(http.StatusOK, result)
+	return result, nil
 }
@@ -1,14 +1,22 @@
 package api
 
 import (
+	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/bilibili"
 	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/export"
 	"bilibili-analyzer/backend/models"
 	"bilibili-analyzer/backend/pdf"
 	"bilibili-analyzer/backend/report"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -81,3 +89,184 @@ func HandleExportPDF(c *gin.Context) {
 	c.Header("Content-Length", fmt.Sprintf("%d", len(pdfBytes)))
 	c.Data(http.StatusOK, "application/pdf", pdfBytes)
 }
+
+// HandleExportReport 按格式导出报告
+// GET /api/report/:id/export?format=xlsx
+//
+// format 可选 pdf/xlsx/csv/md/html/json，默认 pdf：
+//   - xlsx: 品牌排名、型号排名、维度矩阵、评论、视频来源各一个工作表
+//   - csv: 品牌排名表（含各维度得分）
+//   - md: Markdown 摘要
+//   - html: 单文件 HTML 报告（图表内联，可离线打开）
+//   - json: 报告原始数据
+func HandleExportReport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "报告ID无效"})
+		return
+	}
+	writer, err := export.Get(c.DefaultQuery("format", "pdf"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	in, err := export.Load(uint(id))
+	if errors.Is(err, export.ErrReportNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "报告不存在"})
+		return
+	}
+	if err != nil {
+		log.Printf("[Export] 读取报告 %d 失败: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := writer.Write(&buf, in); err != nil {
+		log.Printf("[Export] 生成 %s 失败，报告ID: %d: %v", writer.Format(), id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成%s失败: %v", writer.Format(), err)})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", export.Filename(in.ReportID, writer)))
+	c.Data(http.StatusOK, writer.ContentType(), buf.Bytes())
+}
+
+// 证据评论分页参数
+const (
+	defaultReportCommentLimit = 50
+	maxReportCommentLimit     = 500
+)
+
+// ReportCommentResponse 证据评论
+type ReportCommentResponse struct {
+	ID           uint               `json:"id"`
+	Brand        string             `json:"brand"`
+	Model        string             `json:"model"`
+	Source       string             `json:"source"`
+	RPID         int64              `json:"rpid"`
+	VideoBVID    string             `json:"bvid"`
+	Mid          int64              `json:"mid"`
+	Author       string             `json:"author"`
+	Like         int                `json:"like"`
+	Content      string             `json:"content"`
+	OverallScore float64            `json:"overall_score"`
+	Scores       map[string]float64 `json:"scores"`
+	PublishTime  *time.Time         `json:"publish_time"`
+	URL          string             `json:"url"`
+
+	Aspects map[string]ai.AspectSentiment `json:"aspects,omitempty"` // 维度 -> 情感倾向、置信度和原文片段
+}
+
+// HandleGetReportComments 查询报告中参与评分的评论（证据下钻）
+// GET /api/report/:id/comments?brand=&model=&dimension=&min=&max=&limit=&offset=
+//
+// 参数说明：
+//   - brand: 品牌（与报告 scores 中的品牌一致）
+//   - model: 型号（忽略大小写、空格和"-"）
+//   - dimension: 维度名称；指定时 min/max 按该维度得分筛选，否则按综合得分筛选
+//   - min, max: 分数区间（含端点）
+//   - limit, offset: 分页，limit 默认50、最大500
+//
+// 响应示例：
+//
+//	{"total": 12, "comments": [{"rpid": 123, "bvid": "BV1xx", "author": "xxx", "like": 56, "content": "...", "scores": {"吸力": 9},
+//	                               "aspects": {"吸力": {"polarity": "positive", "confidence": 0.9, "span": "吸力很猛"}}, "url": "https://www.bilibili.com/video/BV1xx#reply123", ...}]}
+func HandleGetReportComments(c *gin.Context) {
+	reportID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "报告ID无效"})
+		return
+	}
+	var reportModel models.Report
+	if err := database.DB.Select("id").First(&reportModel, reportID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "报告不存在"})
+		return
+	}
+
+	filter := database.ReportCommentFilter{
+		ReportID:  reportModel.ID,
+		Brand:     strings.TrimSpace(c.Query("brand")),
+		ModelKey:  report.NormalizeModelName(c.Query("model")),
+		Dimension: strings.TrimSpace(c.Query("dimension")),
+		Limit:     defaultReportCommentLimit,
+	}
+	if filter.Min, err = parseScoreQuery(c, "min"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.Max, err = parseScoreQuery(c, "max"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if raw := c.Query("limit"); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v > 0 {
+			filter.Limit = min(v, maxReportCommentLimit)
+		}
+	}
+	if raw := c.Query("offset"); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v > 0 {
+			filter.Offset = v
+		}
+	}
+
+	rows, total, err := database.QueryReportComments(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询评论失败: " + err.Error()})
+		return
+	}
+
+	comments := make([]ReportCommentResponse, 0, len(rows))
+	for _, row := range rows {
+		comments = append(comments, toReportCommentResponse(row))
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "comments": comments})
+}
+
+// parseScoreQuery 解析可选的分数参数，未传时返回 nil
+func parseScoreQuery(c *gin.Context, name string) (*float64, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("参数 %s 必须是数字", name)
+	}
+	return &v, nil
+}
+
+func toReportCommentResponse(row models.ReportComment) ReportCommentResponse {
+	resp := ReportCommentResponse{
+		ID:           row.ID,
+		Brand:        row.Brand,
+		Model:        row.Model,
+		Source:       row.Source,
+		RPID:         row.RPID,
+		VideoBVID:    row.VideoBVID,
+		Mid:          row.Mid,
+		Author:       row.Author,
+		Like:         row.Likes,
+		Content:      row.Content,
+		OverallScore: row.OverallScore,
+		Scores:       make(map[string]float64, len(row.Scores)),
+	}
+	for _, s := range row.Scores {
+		resp.Scores[s.Dimension] = s.Score
+		if s.Polarity != "" {
+			if resp.Aspects == nil {
+				resp.Aspects = make(map[string]ai.AspectSentiment)
+			}
+			resp.Aspects[s.Dimension] = ai.AspectSentiment{Polarity: s.Polarity, Confidence: s.Confidence, Span: s.Span}
+		}
+	}
+	if !row.PublishTime.IsZero() {
+		t := row.PublishTime
+		resp.PublishTime = &t
+	}
+	if row.VideoBVID != "" {
+		resp.URL = bilibili.CommentURL(row.VideoBVID, row.RPID)
+	}
+	return resp
+}
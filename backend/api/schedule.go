@@ -0,0 +1,356 @@
+package api
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/scheduler"
+	"bilibili-analyzer/backend/task"
+	"bilibili-analyzer/backend/webhook"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 告警阈值默认值
+const (
+	defaultScoreThreshold    = 0.5 // 综合得分变化0.5分
+	defaultNegativeThreshold = 5.0 // 差评占比变化5个百分点
+)
+
+// ScheduleRequest 新增/修改定时计划请求
+// 阈值为空时使用默认值，为0时不检查该指标
+type ScheduleRequest struct {
+	Name              string         `json:"name"`
+	Cron              string         `json:"cron"`
+	Enabled           *bool          `json:"enabled"`
+	WebhookURL        string         `json:"webhook_url"`
+	ScoreThreshold    *float64       `json:"score_threshold"`
+	NegativeThreshold *float64       `json:"negative_threshold"`
+	Request           ConfirmRequest `json:"request"` // 与 /api/confirm 的请求体相同
+}
+
+// ScheduleResponse 定时计划
+type ScheduleResponse struct {
+	ID                uint           `json:"id"`
+	Name              string         `json:"name"`
+	Cron              string         `json:"cron"`
+	Enabled           bool           `json:"enabled"`
+	Category          string         `json:"category"`
+	WebhookURL        string         `json:"webhook_url"`
+	WebhookID         uint           `json:"webhook_id"`               // 告警地址对应的专用接收端ID（可在 /api/webhooks 中轮换密钥、查看投递日志）
+	WebhookSecret     string         `json:"webhook_secret,omitempty"` // 告警签名密钥（只在创建、修改计划时返回）
+	ScoreThreshold    float64        `json:"score_threshold"`
+	NegativeThreshold float64        `json:"negative_threshold"`
+	Request           ConfirmRequest `json:"request"`
+	NextRunAt         *time.Time     `json:"next_run_at"`
+	LastRunAt         *time.Time     `json:"last_run_at"`
+	LastError         string         `json:"last_error"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+}
+
+// ScheduleRunResponse 计划的一次运行
+type ScheduleRunResponse struct {
+	ID         uint                              `json:"id"`
+	HistoryID  uint                              `json:"history_id"`
+	TaskID     string                            `json:"task_id"`
+	Status     string                            `json:"status"`
+	ReportID   uint                              `json:"report_id"`
+	Metrics    map[string]scheduler.BrandMetrics `json:"metrics"`
+	Alerts     []scheduler.Alert                 `json:"alerts"`
+	Error      string                            `json:"error"`
+	CreatedAt  time.Time                         `json:"created_at"`
+	FinishedAt *time.Time                        `json:"finished_at"`
+}
+
+// HandleListSchedules 获取定时计划列表
+// GET /api/schedules
+func HandleListSchedules(c *gin.Context) {
+	schedules, err := database.ListSchedules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取计划列表失败: " + err.Error()})
+		return
+	}
+	result := make([]ScheduleResponse, 0, len(schedules))
+	for i := range schedules {
+		result = append(result, toScheduleResponse(&schedules[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"schedules": result})
+}
+
+// HandleCreateSchedule 创建定时计划
+// POST /api/schedules
+//
+// 请求示例：
+//
+//	{"name": "吸尘器周报", "cron": "0 9 * * 1", "webhook_url": "https://example.com/hook", "score_threshold": 0.5, "negative_threshold": 5,
+//	 "request": {"requirement": "吸尘器", "brands": ["戴森", "小米"], "dimensions": [{"name": "吸力", "description": "..."}], "keywords": ["吸尘器评测"]}}
+func HandleCreateSchedule(c *gin.Context) {
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	var schedule models.Schedule
+	if err := applyScheduleRequest(&schedule, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	endpoint, err := saveScheduleWebhook(&schedule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存告警接收端失败: " + err.Error()})
+		return
+	}
+	if err := database.DB.Create(&schedule).Error; err != nil {
+		if endpoint != nil {
+			database.DB.Delete(endpoint)
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存计划失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toScheduleResponseWithSecret(&schedule, endpoint))
+}
+
+// HandleUpdateSchedule 修改定时计划（请求体与创建相同，整体替换）
+// PUT /api/schedules/:id
+// 修改后按新的 cron 表达式重新计算下次运行时间
+func HandleUpdateSchedule(c *gin.Context) {
+	schedule, ok := findSchedule(c)
+	if !ok {
+		return
+	}
+
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if err := applyScheduleRequest(schedule, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	endpoint, err := saveScheduleWebhook(schedule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存告警接收端失败: " + err.Error()})
+		return
+	}
+	// Save 会写入全部字段（包括 enabled=false 和清空的 next_run_at）
+	if err := database.DB.Save(schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存计划失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toScheduleResponseWithSecret(schedule, endpoint))
+}
+
+// HandleDeleteSchedule 删除定时计划及其运行记录（已生成的报告保留在历史记录中）
+// DELETE /api/schedules/:id
+func HandleDeleteSchedule(c *gin.Context) {
+	schedule, ok := findSchedule(c)
+	if !ok {
+		return
+	}
+	if err := database.DeleteSchedule(schedule); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除计划失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "计划已删除"})
+}
+
+// HandleRunSchedule 立即运行一次计划
+// POST /api/schedules/:id/run
+// 上一次运行尚未结束时返回 409
+func HandleRunSchedule(c *gin.Context) {
+	schedule, ok := findSchedule(c)
+	if !ok {
+		return
+	}
+	run, err := scheduler.RunNow(schedule)
+	if errors.Is(err, scheduler.ErrRunInProgress) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"task_id":        run.TaskID,
+		"queue_position": task.QueuePosition(run.TaskID),
+		"run":            toScheduleRunResponse(run),
+	})
+}
+
+// HandleGetScheduleRuns 获取计划的运行记录（报告序列）
+// GET /api/schedules/:id/runs?limit=
+//
+// 响应示例：
+//
+//	{"runs": [{"id": 3, "status": "completed", "report_id": 12, "metrics": {"小米": {"overall_score": 7.8, "negative_pct": 12.5}},
+//	           "alerts": [{"brand": "小米", "metric": "overall_score", "previous": 8.4, "current": 7.8, "delta": -0.6, "threshold": 0.5}], ...}]}
+func HandleGetScheduleRuns(c *gin.Context) {
+	schedule, ok := findSchedule(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	runs, err := database.ListScheduleRuns(schedule.ID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取运行记录失败: " + err.Error()})
+		return
+	}
+	result := make([]ScheduleRunResponse, 0, len(runs))
+	for i := range runs {
+		result = append(result, toScheduleRunResponse(&runs[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"runs": result})
+}
+
+// applyScheduleRequest 校验请求并写入计划字段
+func applyScheduleRequest(schedule *models.Schedule, req *ScheduleRequest) error {
+	req.Cron = strings.TrimSpace(req.Cron)
+	if _, err := scheduler.ParseCron(req.Cron); err != nil {
+		return err
+	}
+	if msg := req.Request.Validate(); msg != "" {
+		return errors.New(msg)
+	}
+	webhookURL := strings.TrimSpace(req.WebhookURL)
+	if webhookURL != "" {
+		u, err := url.Parse(webhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("Webhook 地址无效")
+		}
+	}
+
+	taskReq, config := req.Request.ToTask("")
+	requestJSON, err := json.Marshal(taskReq)
+	if err != nil {
+		return err
+	}
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	schedule.Name = strings.TrimSpace(req.Name)
+	if schedule.Name == "" {
+		schedule.Name = req.Request.Requirement
+	}
+	schedule.Cron = req.Cron
+	schedule.Enabled = req.Enabled == nil || *req.Enabled
+	schedule.Category = req.Request.Requirement
+	schedule.Request = string(requestJSON)
+	schedule.TaskConfig = string(configJSON)
+	schedule.Priority = req.Request.Priority
+	schedule.WebhookURL = webhookURL
+	schedule.ScoreThreshold = thresholdOrDefault(req.ScoreThreshold, defaultScoreThreshold)
+	schedule.NegativeThreshold = thresholdOrDefault(req.NegativeThreshold, defaultNegativeThreshold)
+	schedule.NextRunAt = nil
+	if schedule.Enabled {
+		schedule.NextRunAt = scheduler.NextRunAt(schedule.Cron, time.Now())
+	}
+	return nil
+}
+
+// saveScheduleWebhook 同步计划告警地址对应的专用 Webhook 接收端
+// 地址变化时沿用原接收端和签名密钥，地址清空时删除接收端
+func saveScheduleWebhook(schedule *models.Schedule) (*models.WebhookEndpoint, error) {
+	name := fmt.Sprintf("定时计划「%s」告警", schedule.Name)
+	endpoint, err := webhook.SaveDedicatedEndpoint(schedule.WebhookID, name, schedule.WebhookURL, webhook.EventScheduleAlert)
+	if err != nil {
+		return nil, err
+	}
+	schedule.WebhookID = 0
+	if endpoint != nil {
+		schedule.WebhookID = endpoint.ID
+	}
+	return endpoint, nil
+}
+
+func thresholdOrDefault(v *float64, def float64) float64 {
+	if v == nil {
+		return def
+	}
+	if *v < 0 {
+		return 0
+	}
+	return *v
+}
+
+// findSchedule 按路径参数 id 查找计划，找不到时直接写入错误响应
+func findSchedule(c *gin.Context) (*models.Schedule, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "计划ID无效"})
+		return nil, false
+	}
+	var schedule models.Schedule
+	if err := database.DB.First(&schedule, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "计划不存在"})
+		return nil, false
+	}
+	return &schedule, true
+}
+
+func toScheduleResponse(s *models.Schedule) ScheduleResponse {
+	var req task.TaskRequest
+	_ = json.Unmarshal([]byte(s.Request), &req)
+	var config task.TaskConfig
+	_ = json.Unmarshal([]byte(s.TaskConfig), &config)
+
+	return ScheduleResponse{
+		ID:                s.ID,
+		Name:              s.Name,
+		Cron:              s.Cron,
+		Enabled:           s.Enabled,
+		Category:          s.Category,
+		WebhookURL:        s.WebhookURL,
+		WebhookID:         s.WebhookID,
+		ScoreThreshold:    s.ScoreThreshold,
+		NegativeThreshold: s.NegativeThreshold,
+		Request:           confirmRequestFromTask(req, config, s.Priority),
+		NextRunAt:         s.NextRunAt,
+		LastRunAt:         s.LastRunAt,
+		LastError:         s.LastError,
+		CreatedAt:         s.CreatedAt,
+		UpdatedAt:         s.UpdatedAt,
+	}
+}
+
+// toScheduleResponseWithSecret 创建、修改计划的响应，附带告警签名密钥
+func toScheduleResponseWithSecret(s *models.Schedule, endpoint *models.WebhookEndpoint) ScheduleResponse {
+	resp := toScheduleResponse(s)
+	if endpoint != nil {
+		resp.WebhookSecret = endpoint.Secret
+	}
+	return resp
+}
+
+func toScheduleRunResponse(r *models.ScheduleRun) ScheduleRunResponse {
+	resp := ScheduleRunResponse{
+		ID:         r.ID,
+		HistoryID:  r.HistoryID,
+		TaskID:     r.TaskID,
+		Status:     r.Status,
+		ReportID:   r.ReportID,
+		Alerts:     []scheduler.Alert{},
+		Error:      r.Error,
+		CreatedAt:  r.CreatedAt,
+		FinishedAt: r.FinishedAt,
+	}
+	if r.Metrics != "" {
+		_ = json.Unmarshal([]byte(r.Metrics), &resp.Metrics)
+	}
+	if r.Alerts != "" {
+		_ = json.Unmarshal([]byte(r.Alerts), &resp.Alerts)
+	}
+	return resp
+}
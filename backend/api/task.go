@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/task"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleCancelTask 取消正在执行的分析任务
+// POST /api/task/:id/cancel
+// id 为任务ID（task_id，UUID格式），执行中和排队中的任务都可以取消
+// 取消后任务状态变为 cancelled，并通过SSE推送取消状态
+//
+// 响应示例：
+//
+//	{"task_id": "xxx-xxx-xxx", "message": "任务已取消"}
+func HandleCancelTask(c *gin.Context) {
+	taskID := c.Param("id")
+	if taskID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "任务ID不能为空"})
+		return
+	}
+
+	// 任务正在运行：取消上下文，由执行器负责标记状态和推送SSE
+	if task.Cancel(taskID) {
+		log.Printf("[Task %s] Cancel requested", taskID)
+		c.JSON(http.StatusOK, gin.H{
+			"task_id": taskID,
+			"message": "任务已取消",
+		})
+		return
+	}
+
+	// 任务在队列中排队：直接从队列移除
+	if task.CancelQueued(taskID) {
+		log.Printf("[Task %s] Removed from queue", taskID)
+		c.JSON(http.StatusOK, gin.H{
+			"task_id": taskID,
+			"message": "任务已取消",
+		})
+		return
+	}
+
+	// 任务不在运行也不在排队：可能已结束，或是没有执行者的 processing 记录
+	var history models.AnalysisHistory
+	if err := database.DB.Where("task_id = ?", taskID).First(&history).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	if history.Status != models.StatusProcessing {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "任务已结束，无法取消",
+			"status": history.Status,
+		})
+		return
+	}
+
+	task.MarkCancelled(history.ID, taskID)
+	c.JSON(http.StatusOK, gin.H{
+		"task_id": taskID,
+		"message": "任务已取消",
+	})
+}
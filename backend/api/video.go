@@ -3,19 +3,15 @@ package api
 import (
 	"bilibili-analyzer/backend/ai"
 	"bilibili-analyzer/backend/bilibili"
-	"bilibili-analyzer/backend/comment"
 	"bilibili-analyzer/backend/database"
 	"bilibili-analyzer/backend/models"
-	"bilibili-analyzer/backend/report"
 	"bilibili-analyzer/backend/sse"
 	"bilibili-analyzer/backend/task"
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -50,6 +46,9 @@ type VideoAnalyzeRequest struct {
 		Name        string `json:"name"`        // 维度名称
 		Description string `json:"description"` // 维度描述
 	} `json:"dimensions,omitempty"`
+	NoCache        bool  `json:"no_cache,omitempty"`        // 跳过AI响应缓存（强制重新调用AI）
+	TokenBudget    int64 `json:"token_budget,omitempty"`    // Token 预算（超出后中止任务，0 表示使用全局配置）
+	IncludeDanmaku bool  `json:"include_danmaku,omitempty"` // 同时抓取视频弹幕参与分析
 }
 
 // VideoAnalyzeResponse 视频分析响应
@@ -80,8 +79,7 @@ func HandleVideoParse(c *gin.Context) {
 		return
 	}
 
-	cookie := getBilibiliCookie()
-	client := bilibili.NewClient(cookie)
+	client := task.NewBilibiliClient(getBilibiliCookie(), "")
 
 	videoInfo, err := client.GetVideoInfo(bvid)
 	if err != nil {
@@ -128,8 +126,7 @@ func HandleVideoDimensions(c *gin.Context) {
 		return
 	}
 
-	cookie := getBilibiliCookie()
-	client := bilibili.NewClient(cookie)
+	client := task.NewBilibiliClient(getBilibiliCookie(), "")
 
 	videoInfo, err := client.GetVideoInfo(req.BVID)
 	if err != nil {
@@ -137,7 +134,7 @@ func HandleVideoDimensions(c *gin.Context) {
 		return
 	}
 
-	comments, err := client.SampleComments(req.BVID, 50)
+	comments, err := client.SampleComments(c.Request.Context(), req.BVID, 50)
 	log.Printf("评论采样结果: 数量=%d, 错误=%v", len(comments), err)
 	if err != nil {
 		comments = nil
@@ -174,9 +171,10 @@ func generateVideoDimensions(ctx context.Context, videoInfo *bilibili.VideoDetai
 	}
 
 	aiClient := ai.NewClient(ai.Config{
-		APIBase: settings.AIBaseURL,
-		APIKey:  settings.AIAPIKey,
-		Model:   settings.AIModel,
+		Provider: settings.AIProvider,
+		APIBase:  settings.AIBaseURL,
+		APIKey:   settings.AIAPIKey,
+		Model:    settings.AIModel,
 	})
 
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
@@ -194,7 +192,7 @@ func generateVideoDimensions(ctx context.Context, videoInfo *bilibili.VideoDetai
 
 // HandleVideoAnalyze 处理视频评论分析
 // POST /api/video/analyze
-// 启动单个视频的评论分析任务，通过SSE推送实时进度
+// 解析视频并创建单视频分析任务，任务加入队列后通过SSE推送实时进度
 //
 // 请求示例：
 //
@@ -216,20 +214,25 @@ func HandleVideoAnalyze(c *gin.Context) {
 		return
 	}
 
-	// 设置默认最大评论数
-	maxComments := req.MaxComments
-	if maxComments <= 0 {
-		maxComments = 1000 // 默认分析1000条评论
-	}
-
 	// 生成任务ID
 	taskID := uuid.New().String()
 
+	taskReq, config, err := req.ToTask(c.Request.Context(), taskID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 创建SSE任务通道
 	sse.CreateTaskChannel(taskID)
 
-	// 异步启动视频分析任务（传递维度参数）
-	go executeVideoAnalyzeTask(taskID, req.VideoURL, maxComments, req.Dimensions)
+	// 任务加入队列，与搜索分析共用执行器和断点恢复
+	if _, err := task.Enqueue(taskReq, config, 0); err != nil {
+		log.Printf("[Task %s] Enqueue failed: %v", taskID, err)
+		sse.CloseTaskChannel(taskID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建任务失败: " + err.Error()})
+		return
+	}
 
 	// 立即返回任务ID
 	c.JSON(http.StatusOK, VideoAnalyzeResponse{
@@ -238,254 +241,68 @@ func HandleVideoAnalyze(c *gin.Context) {
 	})
 }
 
-// executeVideoAnalyzeTask 执行视频分析任务
-// 在后台goroutine中运行，完成以下步骤：
-// 1. 解析视频URL获取BV号
-// 2. 获取视频详细信息
-// 3. 抓取视频评论
-// 4. AI分析评论
-// 5. 生成分析报告
-func executeVideoAnalyzeTask(taskID, videoURL string, maxComments int, requestDimensions []struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-}) {
-	// 确保任务结束时关闭SSE通道
-	defer sse.CloseTaskChannel(taskID)
-	taskCtx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
-	defer cancel()
-
-	// 推送初始状态
-	sse.PushProgress(taskID, sse.StatusParsing, 0, 100, "正在解析视频链接...")
-
-	// 步骤1：解析视频URL
-	bvid, err := bilibili.ParseVideoURL(videoURL)
+// ToTask 解析视频链接并获取视频信息，转换为单视频的任务请求和任务配置
+// 任务跳过搜索和相关性过滤阶段，其余阶段与搜索分析相同；ctx 取消时中止视频信息请求
+func (req *VideoAnalyzeRequest) ToTask(ctx context.Context, taskID string) (task.TaskRequest, *task.TaskConfig, error) {
+	bvid, err := bilibili.ParseVideoURL(req.VideoURL)
 	if err != nil {
-		sse.PushError(taskID, fmt.Sprintf("解析视频链接失败: %v", err))
-		return
+		return task.TaskRequest{}, nil, fmt.Errorf("解析视频链接失败: %w", err)
 	}
 
-	// 推送进度：正在获取视频信息
-	sse.PushProgress(taskID, sse.StatusSearching, 5, 100, "正在获取视频信息...")
-
-	// 步骤2：获取配置和创建客户端
 	settings, err := loadTaskSettings()
 	if err != nil {
-		sse.PushError(taskID, err.Error())
-		return
+		return task.TaskRequest{}, nil, err
 	}
 
-	// 创建B站客户端
-	biliClient := bilibili.NewClient(settings.BilibiliCookie)
-
-	// 获取视频详细信息
-	videoInfo, err := biliClient.GetVideoInfo(bvid)
+	videoInfo, err := task.NewBilibiliClient(settings.BilibiliCookie, taskID).GetVideoInfoWithContext(ctx, bvid)
 	if err != nil {
-		sse.PushError(taskID, fmt.Sprintf("获取视频信息失败: %v", err))
-		return
+		return task.TaskRequest{}, nil, fmt.Errorf("获取视频信息失败: %w", err)
 	}
-
 	log.Printf("[Task %s] Video info: %s, comments: %d", taskID, videoInfo.Title, videoInfo.CommentCount)
 
-	// 步骤3：创建历史记录
-	history, err := createVideoAnalyzeHistory(taskID, videoInfo, maxComments)
-	if err != nil {
-		sse.PushError(taskID, fmt.Sprintf("创建任务记录失败: %v", err))
-		return
-	}
-
-	// 推送进度：正在抓取评论
-	sse.PushProgress(taskID, sse.StatusScraping, 10, 100,
-		fmt.Sprintf("正在抓取评论（预计 %d 条）...", min(maxComments, videoInfo.CommentCount)))
-
-	// 步骤4：抓取评论
-	scraper := bilibili.NewScraper(biliClient, &bilibili.ScraperConfig{
-		MaxVideos:           1,
-		MaxCommentsPerVideo: maxComments,
-		MaxConcurrency:      1, // 单个视频，不需要并发
-		FetchReplies:        true,
-		RequestDelay:        200 * time.Millisecond,
-	})
-	scraper.SetProgressCallback(func(stage string, current, total int, message string) {
-		// 抓取阶段在整体任务中占 10%-40%
-		progress := 10 + (current * 30 / max(total, 1))
-		sse.PushProgress(taskID, sse.StatusScraping, progress, 100, message)
-	})
-
-	// 构建视频信息用于抓取
-	videos := []bilibili.VideoInfo{{
-		BVID:        videoInfo.BVID,
-		Title:       videoInfo.Title,
-		Author:      videoInfo.Author,
-		VideoReview: videoInfo.CommentCount,
-		Pic:         videoInfo.Cover,
-	}}
-
-	// 设置评论分配
-	commentAllocation := map[string]int{
-		videoInfo.BVID: min(maxComments, videoInfo.CommentCount),
-	}
-
-	scrapeResult, err := scraper.ScrapeByVideos(taskCtx, videos, commentAllocation)
-	if err != nil {
-		updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, fmt.Sprintf("抓取评论失败: %v", err))
-		return
-	}
-
-	actualCommentCount := scrapeResult.Stats.TotalComments
-	log.Printf("[Task %s] Scraped %d comments", taskID, actualCommentCount)
-
-	// 更新历史记录统计
-	updateHistoryStats(history.ID, 1, actualCommentCount)
-
-	if actualCommentCount == 0 {
-		updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, "该视频没有评论可分析")
-		return
+	// 设置默认最大评论数
+	maxComments := req.MaxComments
+	if maxComments <= 0 {
+		maxComments = 1000 // 默认分析1000条评论
 	}
 
-	// 推送进度：正在AI分析
-	sse.PushProgress(taskID, sse.StatusAnalyzing, 40, 100,
-		fmt.Sprintf("正在AI分析 %d 条评论...", actualCommentCount))
-
-	// 步骤5：创建AI客户端
-	aiClient := ai.NewClient(ai.Config{
-		APIBase: settings.AIBaseURL,
-		APIKey:  settings.AIAPIKey,
-		Model:   settings.AIModel,
-	})
-
-	// 设置AI进度回调
-	aiClient.SetProgressCallback(func(stage string, current, total int, message string) {
-		progress := 40 + (current * 40 / max(total, 1)) // 40-80%
-		sse.PushProgress(taskID, sse.StatusAnalyzing, progress, 100, message)
-	})
-
 	// 使用传递的维度，若为空则使用默认维度
-	var dimensions []ai.Dimension
-	if len(requestDimensions) > 0 {
-		dimensions = make([]ai.Dimension, len(requestDimensions))
-		for i, d := range requestDimensions {
+	dimensions := getDefaultDimensions()
+	if len(req.Dimensions) > 0 {
+		dimensions = make([]ai.Dimension, len(req.Dimensions))
+		for i, d := range req.Dimensions {
 			dimensions[i] = ai.Dimension{Name: d.Name, Description: d.Description}
 		}
-	} else {
-		dimensions = getDefaultDimensions()
-	}
-
-	// 准备评论数据用于AI分析
-	comments := getAllCommentsWithVideo(scrapeResult)
-
-	// 统一评论质量过滤（长度、纯符号、热度排序）
-	rawComments := make([]bilibili.Comment, 0, len(comments))
-	commentMetaByKey := make(map[string]commentWithVideo, len(comments))
-	for _, c := range comments {
-		rawComments = append(rawComments, c.Comment)
-		if _, exists := commentMetaByKey[c.CommentKey]; !exists {
-			commentMetaByKey[c.CommentKey] = c
-		}
-	}
-
-	filteredComments := comment.FilterAndRank(rawComments, comment.FilterConfig{
-		MaxComments: maxComments,
-		MinLength:   10,
-		FilterEmoji: true,
-	})
-
-	// 过滤后构建 AI 输入
-	var inputs []ai.CommentInput
-	for i, c := range filteredComments {
-		key := buildCommentKey(c)
-		meta, ok := commentMetaByKey[key]
-		if !ok || len(strings.TrimSpace(meta.Content)) < 5 {
-			continue
-		}
-		inputs = append(inputs, ai.CommentInput{
-			ID:         fmt.Sprintf("comment_%d", i),
-			Content:    meta.Content,
-			VideoTitle: meta.VideoTitle,
-			VideoBVID:  meta.VideoBVID,
-		})
-	}
-
-	if len(inputs) == 0 {
-		updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, "没有有效的评论可分析")
-		return
-	}
-
-	// 执行AI分析
-	analysisResults, err := aiClient.AnalyzeCommentsWithRateLimit(taskCtx, inputs, dimensions, 10)
-	if err != nil {
-		updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, fmt.Sprintf("AI分析失败: %v", err))
-		return
-	}
-
-	// 处理分析结果，按品牌分组
-	resultsByBrand := processAnalysisResults(analysisResults)
-
-	log.Printf("[Task %s] Analysis completed for %d brands", taskID, len(resultsByBrand))
-
-	// 推送进度：正在生成报告
-	sse.PushProgress(taskID, sse.StatusGenerating, 85, 100, "正在生成分析报告...")
-
-	// 步骤6：生成报告
-	reportInput := report.GenerateReportInput{
-		Category:        videoInfo.Title, // 使用视频标题作为类目
-		Brands:          getBrandList(resultsByBrand),
-		Dimensions:      dimensions,
-		AnalysisResults: resultsByBrand,
-		Stats: report.ReportStats{
-			TotalVideos:     1,
-			TotalComments:   actualCommentCount,
-			CommentsByBrand: getCommentsByBrand(resultsByBrand),
-		},
-		Videos: videos,
-	}
-
-	reportData, err := report.GenerateReportWithInput(reportInput)
-	if err != nil {
-		updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, fmt.Sprintf("生成报告失败: %v", err))
-		return
-	}
-
-	// 推送进度：正在生成购买建议
-	sse.PushProgress(taskID, sse.StatusGenerating, 90, 100, "正在生成AI购买建议...")
-
-	// 生成AI购买建议
-	aiRecommendation, err := generateAIRecommendation(taskCtx, aiClient, reportData)
-	if err == nil && aiRecommendation != "" {
-		reportData.Recommendation = aiRecommendation
 	}
 
-	// 推送进度：正在保存报告
-	sse.PushProgress(taskID, sse.StatusGenerating, 95, 100, "正在保存报告...")
-
-	// 步骤7：保存报告到数据库
-	reportID, err := saveReport(history.ID, reportData)
-	if err != nil {
-		updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, fmt.Sprintf("保存报告失败: %v", err))
-		return
-	}
-
-	// 更新历史记录状态为完成
-	updateHistoryWithReport(history.ID, reportID)
-
-	log.Printf("[Task %s] Completed! Report ID: %d", taskID, reportID)
-
-	// 推送完成状态（包含报告ID）
-	sse.PushStatus(taskID, sse.TaskStatus{
-		TaskID:  taskID,
-		Status:  sse.StatusCompleted,
-		Message: fmt.Sprintf("分析完成！共分析1个视频，%d条评论", actualCommentCount),
-		Progress: &sse.Progress{
-			Current: 100,
-			Total:   100,
-			Stage:   fmt.Sprintf("%d", reportID), // 使用Stage字段传递报告ID
-		},
-	})
+	config := &task.TaskConfig{
+		MaxComments:           maxComments,
+		MaxCommentsPerVideo:   maxComments,
+		MaxCommentsPerVideoV2: maxComments,
+	}
+
+	return task.TaskRequest{
+		TaskID:         taskID,
+		Requirement:    videoInfo.Title, // 使用视频标题作为类目
+		Brands:         []string{},
+		Dimensions:     dimensions,
+		Keywords:       []string{},
+		NoCache:        req.NoCache,
+		TokenBudget:    req.TokenBudget,
+		IncludeDanmaku: req.IncludeDanmaku,
+		Videos: []bilibili.VideoInfo{{
+			BVID:        videoInfo.BVID,
+			AID:         videoInfo.AID,
+			Title:       videoInfo.Title,
+			Author:      videoInfo.Author,
+			Play:        videoInfo.PlayCount,
+			VideoReview: videoInfo.CommentCount,
+			Pic:         videoInfo.Cover,
+			Description: videoInfo.Description,
+			CID:         videoInfo.CID,
+			Commercial:  videoInfo.Commercial,
+		}},
+	}, config, nil
 }
 
 // getBilibiliCookie 获取B站Cookie配置
@@ -508,17 +325,18 @@ func loadTaskSettings() (*taskSettings, error) {
 	}
 
 	settings := &taskSettings{
+		AIProvider:     getSettingValue(models.SettingKeyAIProvider),
 		AIBaseURL:      getSettingValue(models.SettingKeyAIAPIBase),
 		AIAPIKey:       getSettingValue(models.SettingKeyAIAPIKey),
 		AIModel:        getSettingValue(models.SettingKeyAIModel),
 		BilibiliCookie: getSettingValue(models.SettingKeyBilibiliCookie),
 	}
 
-	if settings.AIAPIKey == "" {
+	if settings.AIAPIKey == "" && ai.RequiresAPIKey(settings.AIProvider) {
 		return nil, fmt.Errorf("请先配置AI API Key")
 	}
-	if settings.BilibiliCookie == "" {
-		return nil, fmt.Errorf("请先配置B站Cookie")
+	if !task.HasBilibiliCookie(settings.BilibiliCookie) {
+		return nil, fmt.Errorf("请先配置B站Cookie（或账号池中没有可用账号）")
 	}
 
 	return settings, nil
@@ -526,81 +344,13 @@ func loadTaskSettings() (*taskSettings, error) {
 
 // taskSettings 任务配置
 type taskSettings struct {
+	AIProvider     string
 	AIBaseURL      string
 	AIAPIKey       string
 	AIModel        string
 	BilibiliCookie string
 }
 
-// createVideoAnalyzeHistory 创建视频分析历史记录
-func createVideoAnalyzeHistory(taskID string, videoInfo *bilibili.VideoDetail, maxComments int) (*models.AnalysisHistory, error) {
-	// 构建任务配置
-	configJSON, _ := json.Marshal(map[string]interface{}{
-		"max_comments": maxComments,
-	})
-
-	history := &models.AnalysisHistory{
-		TaskID:        taskID,
-		Category:      videoInfo.Title,
-		Keywords:      "[]",
-		Brands:        "[]",
-		Dimensions:    "[]",
-		Status:        models.StatusProcessing,
-		Stage:         "initializing",
-		Progress:      0,
-		ProgressMsg:   "任务初始化中...",
-		TaskConfig:    string(configJSON),
-		LastHeartbeat: time.Now(),
-	}
-
-	if err := database.DB.Create(history).Error; err != nil {
-		return nil, err
-	}
-
-	return history, nil
-}
-
-// updateHistoryStatus 更新历史记录状态
-func updateHistoryStatus(historyID uint, status string) {
-	database.DB.Model(&models.AnalysisHistory{}).Where("id = ?", historyID).Update("status", status)
-}
-
-// updateHistoryStats 更新历史记录统计信息
-func updateHistoryStats(historyID uint, videoCount, commentCount int) {
-	database.DB.Model(&models.AnalysisHistory{}).Where("id = ?", historyID).Updates(map[string]interface{}{
-		"video_count":   videoCount,
-		"comment_count": commentCount,
-	})
-}
-
-// updateHistoryWithReport 更新历史记录的报告ID和状态
-func updateHistoryWithReport(historyID uint, reportID uint) {
-	database.DB.Model(&models.AnalysisHistory{}).Where("id = ?", historyID).Updates(map[string]interface{}{
-		"report_id": reportID,
-		"status":    models.StatusCompleted,
-	})
-}
-
-// saveReport 保存报告到数据库
-func saveReport(historyID uint, reportData *report.ReportData) (uint, error) {
-	data, err := json.Marshal(reportData)
-	if err != nil {
-		return 0, err
-	}
-
-	reportRecord := &models.Report{
-		HistoryID:  historyID,
-		Category:   reportData.Category,
-		ReportData: string(data),
-	}
-
-	if err := database.DB.Create(reportRecord).Error; err != nil {
-		return 0, err
-	}
-
-	return reportRecord.ID, nil
-}
-
 // getDefaultDimensions 获取默认的6个评价维度
 func getDefaultDimensions() []ai.Dimension {
 	return []ai.Dimension{
@@ -613,149 +363,6 @@ func getDefaultDimensions() []ai.Dimension {
 	}
 }
 
-// getAllCommentsWithVideo 获取所有评论（带视频信息）
-type commentWithVideo struct {
-	Content    string
-	VideoTitle string
-	VideoBVID  string
-	Comment    bilibili.Comment
-	CommentKey string
-}
-
-func getAllCommentsWithVideo(result *bilibili.ScrapeResult) []commentWithVideo {
-	var comments []commentWithVideo
-
-	videoTitleMap := make(map[string]string)
-	for _, video := range result.Videos {
-		videoTitleMap[video.BVID] = video.Title
-	}
-
-	for bvid, videoComments := range result.Comments {
-		videoTitle := videoTitleMap[bvid]
-		for _, c := range videoComments {
-			cKey := buildCommentKey(c)
-			comments = append(comments, commentWithVideo{
-				Content:    c.Content.Message,
-				VideoTitle: videoTitle,
-				VideoBVID:  bvid,
-				Comment:    c,
-				CommentKey: cKey,
-			})
-			// 添加回复
-			for _, r := range c.Replies {
-				rKey := buildCommentKey(r)
-				comments = append(comments, commentWithVideo{
-					Content:    r.Content.Message,
-					VideoTitle: videoTitle,
-					VideoBVID:  bvid,
-					Comment:    r,
-					CommentKey: rKey,
-				})
-			}
-		}
-	}
-
-	return comments
-}
-
-// processAnalysisResults 处理AI分析结果，按品牌分组
-func processAnalysisResults(results []ai.CommentAnalysisResult) map[string][]report.CommentWithScore {
-	brandResults := make(map[string][]report.CommentWithScore)
-
-	for _, r := range results {
-		if r.Error != "" || r.Scores == nil {
-			continue
-		}
-
-		brand := strings.TrimSpace(r.Brand)
-		if brand == "" || brand == "未知" {
-			brand = "未知品牌"
-		}
-
-		// 清洗品牌和型号
-		brand = comment.CleanBrandName(brand, nil)
-		model := comment.CleanModelName(r.Model)
-		if model == "" || model == "未知" || model == "通用" {
-			model = task.ExtractModelFromContent(r.Content)
-		}
-
-		commentItem := report.CommentWithScore{
-			Content:     r.Content,
-			Scores:      r.Scores,
-			Brand:       brand,
-			Model:       model,
-			PublishTime: time.Time{},
-		}
-
-		brandResults[brand] = append(brandResults[brand], commentItem)
-	}
-
-	return brandResults
-}
-
-func buildCommentKey(c bilibili.Comment) string {
-	if c.RPID > 0 {
-		return fmt.Sprintf("rpid_%d", c.RPID)
-	}
-	return fmt.Sprintf("fallback_%d_%s", c.Ctime, strings.TrimSpace(c.Content.Message))
-}
-
-// getBrandList 获取品牌列表
-func getBrandList(results map[string][]report.CommentWithScore) []string {
-	brands := make([]string, 0, len(results))
-	for brand := range results {
-		brands = append(brands, brand)
-	}
-	return brands
-}
-
-// getCommentsByBrand 获取每个品牌的评论数
-func getCommentsByBrand(results map[string][]report.CommentWithScore) map[string]int {
-	counts := make(map[string]int)
-	for brand, comments := range results {
-		counts[brand] = len(comments)
-	}
-	return counts
-}
-
-// generateAIRecommendation 生成AI购买建议
-func generateAIRecommendation(ctx context.Context, aiClient *ai.Client, reportData *report.ReportData) (string, error) {
-	rankings := make([]ai.BrandRankingInfo, len(reportData.Rankings))
-	for i, r := range reportData.Rankings {
-		rankings[i] = ai.BrandRankingInfo{
-			Brand:        r.Brand,
-			OverallScore: r.OverallScore,
-			Rank:         r.Rank,
-		}
-	}
-
-	modelRankings := make([]ai.ModelRankingInfo, len(reportData.ModelRankings))
-	for i, m := range reportData.ModelRankings {
-		modelRankings[i] = ai.ModelRankingInfo{
-			Model:        m.Model,
-			Brand:        m.Brand,
-			OverallScore: m.OverallScore,
-			Rank:         m.Rank,
-			CommentCount: m.CommentCount,
-		}
-	}
-
-	brandAnalysis := make(map[string]ai.BrandStrengthWeakness)
-	for brand, analysis := range reportData.BrandAnalysis {
-		brandAnalysis[brand] = ai.BrandStrengthWeakness{
-			Strengths:  analysis.Strengths,
-			Weaknesses: analysis.Weaknesses,
-		}
-	}
-
-	return aiClient.GenerateRecommendation(ctx, ai.RecommendationInput{
-		Category:      reportData.Category,
-		Rankings:      rankings,
-		BrandAnalysis: brandAnalysis,
-		ModelRankings: modelRankings,
-	})
-}
-
 // min 返回两个整数中的较小值
 func min(a, b int) int {
 	if a < b {
@@ -764,14 +371,6 @@ func min(a, b int) int {
 	return b
 }
 
-// max 返回两个整数中的较大值
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
 // parseIntSafely 安全解析整数
 func parseIntSafely(s string, defaultValue int) int {
 	if s == "" {
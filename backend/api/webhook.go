@@ -0,0 +1,352 @@
+package api
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/webhook"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 投递记录分页
+const (
+	defaultWebhookDeliveryLimit = 50
+	maxWebhookDeliveryLimit     = 500
+)
+
+// WebhookRequest 新增/修改 Webhook 接收端请求
+// 修改时 secret 为空表示不修改密钥；rotate_secret 为 true 时重新生成密钥
+type WebhookRequest struct {
+	Name         string   `json:"name"`
+	URL          string   `json:"url"`
+	Secret       string   `json:"secret"`
+	RotateSecret bool     `json:"rotate_secret"`
+	Events       []string `json:"events"` // 为空表示订阅全部事件
+	Enabled      *bool    `json:"enabled"`
+}
+
+// WebhookResponse Webhook 接收端
+// 密钥只在创建和重新生成时完整返回，其他情况只返回脱敏后的内容
+type WebhookResponse struct {
+	ID            uint      `json:"id"`
+	Name          string    `json:"name"`
+	URL           string    `json:"url"`
+	Secret        string    `json:"secret,omitempty"`
+	SecretPreview string    `json:"secret_preview"`
+	Events        []string  `json:"events"`
+	Enabled       bool      `json:"enabled"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// WebhookDeliveryResponse 投递记录
+type WebhookDeliveryResponse struct {
+	ID            uint            `json:"id"`
+	WebhookID     uint            `json:"webhook_id"`
+	Event         string          `json:"event"`
+	PayloadID     string          `json:"payload_id"`
+	TaskID        string          `json:"task_id"`
+	URL           string          `json:"url"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	ResponseCode  int             `json:"response_code"`
+	ResponseBody  string          `json:"response_body"`
+	Error         string          `json:"error"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// HandleListWebhooks 获取 Webhook 接收端列表
+// GET /api/webhooks
+//
+// 响应示例：
+//
+//	{"webhooks": [{"id": 1, "name": "飞书机器人", "url": "https://example.com/hook", "secret_preview": "whsec_1a2b…", "events": ["task.completed"], "enabled": true, ...}],
+//	 "events": ["task.started", "task.stage_changed", "task.completed", "task.failed", "task.cancelled", "schedule.alert"]}
+func HandleListWebhooks(c *gin.Context) {
+	endpoints, err := database.ListWebhookEndpoints()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取Webhook列表失败: " + err.Error()})
+		return
+	}
+	result := make([]WebhookResponse, 0, len(endpoints))
+	for i := range endpoints {
+		result = append(result, toWebhookResponse(&endpoints[i], false))
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": result, "events": webhook.Events})
+}
+
+// HandleCreateWebhook 添加 Webhook 接收端
+// POST /api/webhooks
+// 未指定 secret 时自动生成，响应中返回完整密钥（之后只返回脱敏内容）
+//
+// 请求示例：
+//
+//	{"name": "飞书机器人", "url": "https://example.com/hook", "events": ["task.completed", "task.failed"]}
+func HandleCreateWebhook(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+
+	endpoint := models.WebhookEndpoint{Enabled: req.Enabled == nil || *req.Enabled}
+	if err := applyWebhookRequest(&endpoint, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if endpoint.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "生成密钥失败: " + err.Error()})
+			return
+		}
+		endpoint.Secret = secret
+	}
+	if err := database.DB.Create(&endpoint).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存Webhook失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toWebhookResponse(&endpoint, true))
+}
+
+// HandleUpdateWebhook 修改 Webhook 接收端
+// PUT /api/webhooks/:id
+// 修改地址后，尚未投递的记录会发送到新地址
+func HandleUpdateWebhook(c *gin.Context) {
+	endpoint, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误: " + err.Error()})
+		return
+	}
+	if err := applyWebhookRequest(endpoint, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Enabled != nil {
+		endpoint.Enabled = *req.Enabled
+	}
+	secretChanged := strings.TrimSpace(req.Secret) != ""
+	if req.RotateSecret {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "生成密钥失败: " + err.Error()})
+			return
+		}
+		endpoint.Secret = secret
+		secretChanged = true
+	}
+	// Save 会写入全部字段（包括 enabled=false 和清空的订阅列表）
+	if err := database.DB.Save(endpoint).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "保存Webhook失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toWebhookResponse(endpoint, secretChanged))
+}
+
+// HandleDeleteWebhook 删除 Webhook 接收端（投递记录保留，未投递的记录不再发送）
+// DELETE /api/webhooks/:id
+func HandleDeleteWebhook(c *gin.Context) {
+	endpoint, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+	if err := database.DB.Delete(endpoint).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除Webhook失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook已删除"})
+}
+
+// HandleTestWebhook 向接收端发送一条 ping 事件（不检查启用状态和订阅）
+// POST /api/webhooks/:id/test
+// 投递是异步的，结果通过投递记录查询
+func HandleTestWebhook(c *gin.Context) {
+	endpoint, ok := findWebhook(c)
+	if !ok {
+		return
+	}
+	delivery, err := webhook.EmitTo(endpoint, webhook.EventPing, &webhook.Payload{
+		Data: gin.H{"webhook_id": endpoint.ID, "message": "这是一条测试消息"},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建测试投递失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, toWebhookDeliveryResponse(delivery))
+}
+
+// HandleListWebhookDeliveries 查询投递记录（按时间倒序）
+// GET /api/webhook-deliveries
+//
+// 查询参数（均可选）：
+//   - webhook_id: 接收端ID
+//   - event: 事件类型
+//   - status: pending/success/failed
+//   - task_id: 任务ID
+//   - limit, offset: 分页，limit 默认50、最大500
+//
+// 响应示例：
+//
+//	{"total": 3, "deliveries": [{"id": 9, "webhook_id": 1, "event": "task.completed", "status": "pending", "attempts": 2,
+//	                             "response_code": 502, "error": "接收端返回状态码 502", "next_attempt_at": "...", "payload": {...}, ...}]}
+func HandleListWebhookDeliveries(c *gin.Context) {
+	filter := database.WebhookDeliveryFilter{
+		Event:  strings.TrimSpace(c.Query("event")),
+		Status: strings.TrimSpace(c.Query("status")),
+		TaskID: strings.TrimSpace(c.Query("task_id")),
+		Limit:  defaultWebhookDeliveryLimit,
+	}
+	if raw := c.Query("webhook_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Webhook ID无效"})
+			return
+		}
+		filter.WebhookID = uint(id)
+	}
+	if raw := c.Query("limit"); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v > 0 {
+			filter.Limit = min(v, maxWebhookDeliveryLimit)
+		}
+	}
+	if raw := c.Query("offset"); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v > 0 {
+			filter.Offset = v
+		}
+	}
+
+	rows, total, err := database.QueryWebhookDeliveries(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询投递记录失败: " + err.Error()})
+		return
+	}
+	deliveries := make([]WebhookDeliveryResponse, 0, len(rows))
+	for i := range rows {
+		deliveries = append(deliveries, toWebhookDeliveryResponse(&rows[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "deliveries": deliveries})
+}
+
+// HandleRetryWebhookDelivery 立即重新投递（重置尝试次数）
+// POST /api/webhook-deliveries/:id/retry
+func HandleRetryWebhookDelivery(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "投递记录ID无效"})
+		return
+	}
+	var delivery models.WebhookDelivery
+	if err := database.DB.First(&delivery, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "投递记录不存在"})
+		return
+	}
+	if err := webhook.Retry(&delivery); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重新投递失败: " + err.Error()})
+		return
+	}
+	database.DB.First(&delivery, delivery.ID)
+	c.JSON(http.StatusOK, toWebhookDeliveryResponse(&delivery))
+}
+
+// applyWebhookRequest 校验请求并写入接收端字段（启用状态由调用方处理）
+func applyWebhookRequest(endpoint *models.WebhookEndpoint, req *WebhookRequest) error {
+	rawURL := strings.TrimSpace(req.URL)
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("Webhook 地址无效")
+	}
+
+	events := make([]string, 0, len(req.Events))
+	for _, e := range req.Events {
+		e = strings.TrimSpace(e)
+		if e == "" || slices.Contains(events, e) {
+			continue
+		}
+		if !slices.Contains(webhook.Events, e) {
+			return errors.New("不支持的事件类型: " + e)
+		}
+		events = append(events, e)
+	}
+
+	endpoint.Name = strings.TrimSpace(req.Name)
+	endpoint.URL = rawURL
+	endpoint.Events = strings.Join(events, ",")
+	if secret := strings.TrimSpace(req.Secret); secret != "" {
+		endpoint.Secret = secret
+	}
+	return nil
+}
+
+// findWebhook 按路径参数 id 查找接收端，找不到时直接写入错误响应
+func findWebhook(c *gin.Context) (*models.WebhookEndpoint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Webhook ID无效"})
+		return nil, false
+	}
+	var endpoint models.WebhookEndpoint
+	if err := database.DB.First(&endpoint, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook不存在"})
+		return nil, false
+	}
+	return &endpoint, true
+}
+
+func toWebhookResponse(w *models.WebhookEndpoint, showSecret bool) WebhookResponse {
+	resp := WebhookResponse{
+		ID:            w.ID,
+		Name:          w.Name,
+		URL:           w.URL,
+		SecretPreview: maskCookie(w.Secret),
+		Events:        []string{},
+		Enabled:       w.Enabled,
+		CreatedAt:     w.CreatedAt,
+		UpdatedAt:     w.UpdatedAt,
+	}
+	if showSecret {
+		resp.Secret = w.Secret
+	}
+	if w.Events != "" {
+		resp.Events = strings.Split(w.Events, ",")
+	}
+	return resp
+}
+
+func toWebhookDeliveryResponse(d *models.WebhookDelivery) WebhookDeliveryResponse {
+	resp := WebhookDeliveryResponse{
+		ID:            d.ID,
+		WebhookID:     d.WebhookID,
+		Event:         d.Event,
+		PayloadID:     d.PayloadID,
+		TaskID:        d.TaskID,
+		URL:           d.URL,
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		ResponseCode:  d.ResponseCode,
+		ResponseBody:  d.ResponseBody,
+		Error:         d.Error,
+		NextAttemptAt: d.NextAttemptAt,
+		DeliveredAt:   d.DeliveredAt,
+		CreatedAt:     d.CreatedAt,
+	}
+	if json.Valid([]byte(d.Payload)) {
+		resp.Payload = json.RawMessage(d.Payload)
+	}
+	return resp
+}
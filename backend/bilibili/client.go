@@ -1,16 +1,22 @@
 package bilibili
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"time"
 )
 
 // Client B站API客户端
-// 封装HTTP请求，自动处理Cookie和WBI签名
+// 封装HTTP请求，自动处理Cookie、WBI签名、限流和风控退避
 type Client struct {
-	httpClient *http.Client // HTTP客户端
-	cookie     string       // 用户Cookie（用于需要登录的接口）
+	httpClient *http.Client     // HTTP客户端
+	cookie     string           // 用户Cookie（用于需要登录的接口）
+	pool       *CookiePool      // 多账号Cookie池（设置后优先于 cookie 使用）
+	limiter    *RateLimiter     // 限流器（默认为所有客户端共享的全局限流器）
+	onThrottle ThrottleCallback // 限流事件回调（可选）
 }
 
 // NewClient 创建新的B站API客户端
@@ -28,7 +34,8 @@ func NewClient(cookie string) *Client {
 		httpClient: &http.Client{
 			Timeout: 60 * time.Second, // 设置20秒超时
 		},
-		cookie: cookie,
+		cookie:  cookie,
+		limiter: defaultLimiter,
 	}
 }
 
@@ -49,6 +56,78 @@ func NewClient(cookie string) *Client {
 //	// 不需要签名的请求
 //	resp, err := client.Get("https://api.bilibili.com/x/web-interface/nav", false)
 func (c *Client) Get(urlStr string, needSign bool) (*http.Response, error) {
+	return c.GetWithContext(context.Background(), urlStr, needSign)
+}
+
+// GetWithContext 发送GET请求（支持通过ctx取消）
+// 参数与 Get 相同，ctx 取消时正在进行的请求会立即中断
+//
+// 所有请求都经过限流器：
+//   - 按令牌桶控制请求速率，调用方无需再自行 sleep
+//   - 检测到 HTTP 412、code -352/-412 或风控HTML页面时，全局暂停（指数退避+随机抖动）后重试
+//   - 设置了Cookie池时，账号级风控（code -352/-412）会隔离当前账号并立即换号重试，
+//     只有没有其他健康账号时才全局暂停
+//   - 多次重试仍被拦截时返回 ErrRiskControl
+func (c *Client) GetWithContext(ctx context.Context, urlStr string, needSign bool) (*http.Response, error) {
+	limiter := c.limiter
+	if limiter == nil {
+		limiter = defaultLimiter
+	}
+
+	for attempt := 0; ; attempt++ {
+		// 其他请求触发了全局暂停：通知调用方当前处于限流状态
+		if wait := limiter.PauseRemaining(); wait > 0 {
+			c.notifyThrottle(ThrottleEvent{Reason: "全局暂停中", Wait: wait})
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		account, pooled := c.pickAccount()
+		resp, err := c.do(ctx, urlStr, needSign, account.Cookie)
+		if err != nil {
+			return nil, err
+		}
+
+		reason, err := detectRiskControl(resp)
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" {
+			limiter.Success()
+			return resp, nil
+		}
+
+		if attempt >= maxRiskRetries {
+			return nil, fmt.Errorf("%w: %s（已重试%d次），请稍后重试或更换Cookie", ErrRiskControl, reason, attempt)
+		}
+
+		if pooled && accountLevelRisk(reason) && c.pool.Quarantine(account, reason) {
+			log.Printf("[Bilibili] Risk control on account %d %s (%s), switching account (retry %d/%d)",
+				account.ID, account.Name, reason, attempt+1, maxRiskRetries)
+			continue
+		}
+
+		wait := limiter.Throttle()
+		log.Printf("[Bilibili] Risk control detected (%s), pausing all requests for %v (retry %d/%d)",
+			reason, wait.Round(time.Second), attempt+1, maxRiskRetries)
+		c.notifyThrottle(ThrottleEvent{Reason: reason, Wait: wait, Attempt: attempt + 1})
+	}
+}
+
+// pickAccount 选择本次请求使用的账号
+// 未设置Cookie池或池为空时使用客户端自身的Cookie
+func (c *Client) pickAccount() (CookieAccount, bool) {
+	if c.pool != nil {
+		if account, ok := c.pool.Next(); ok {
+			return account, true
+		}
+	}
+	return CookieAccount{Cookie: c.cookie}, false
+}
+
+// do 发送一次GET请求（每次重试都重新签名）
+func (c *Client) do(ctx context.Context, urlStr string, needSign bool, cookie string) (*http.Response, error) {
 	// 解析URL
 	u, err := url.Parse(urlStr)
 	if err != nil {
@@ -63,7 +142,7 @@ func (c *Client) Get(urlStr string, needSign bool) (*http.Response, error) {
 	}
 
 	// 创建HTTP请求
-	req, err := http.NewRequest("GET", u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -84,14 +163,43 @@ func (c *Client) Get(urlStr string, needSign bool) (*http.Response, error) {
 	req.Header.Set("Sec-Fetch-Site", "same-site")
 
 	// 设置Cookie（如果提供）
-	if c.cookie != "" {
-		req.Header.Set("Cookie", c.cookie)
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
 	}
 
 	// 发送请求
 	return c.httpClient.Do(req)
 }
 
+// SetRateLimiter 设置限流器（默认使用全局共享的限流器）
+func (c *Client) SetRateLimiter(limiter *RateLimiter) {
+	c.limiter = limiter
+}
+
+// SetCookiePool 设置多账号Cookie池，请求在池中的健康账号间轮换
+func (c *Client) SetCookiePool(pool *CookiePool) {
+	c.pool = pool
+}
+
+// SetThrottleCallback 设置限流事件回调
+// 触发风控暂停或等待全局暂停时调用，用于向前端推送"限流中"状态
+//
+// 示例：
+//
+//	client.SetThrottleCallback(func(ev bilibili.ThrottleEvent) {
+//	    log.Printf("限流中（%s），%v 后继续", ev.Reason, ev.Wait)
+//	})
+func (c *Client) SetThrottleCallback(callback ThrottleCallback) {
+	c.onThrottle = callback
+}
+
+// notifyThrottle 通知限流事件
+func (c *Client) notifyThrottle(event ThrottleEvent) {
+	if c.onThrottle != nil {
+		c.onThrottle(event)
+	}
+}
+
 // SetCookie 设置Cookie
 // 参数：
 //   - cookie: 新的Cookie字符串
@@ -1,10 +1,10 @@
 package bilibili
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"strings"
 	"time"
 )
 
@@ -48,6 +48,32 @@ type Comment struct {
 	Member     Member    `json:"member"`  // 评论者信息
 	Replies    []Comment `json:"replies"` // 楼中楼评论（预加载的前3条）
 	ReplyCount int       `json:"-"`       // 实际回复数（用于判断是否需要获取更多）
+
+	// 以下字段仅弹幕使用（弹幕通过 Danmaku.ToComment 转换为评论结构）
+	Source    string `json:"source,omitempty"`   // 来源：comment/danmaku（为空表示评论区评论）
+	DanmakuID int64  `json:"dmid,omitempty"`     // 弹幕ID
+	Progress  int    `json:"progress,omitempty"` // 弹幕出现时间（视频内的毫秒数）
+}
+
+// IsDanmaku 是否为弹幕
+func (c Comment) IsDanmaku() bool {
+	return c.Source == SourceDanmaku
+}
+
+// CommentURL 评论在B站的链接，rpid 为0时返回视频链接
+func CommentURL(bvid string, rpid int64) string {
+	if rpid > 0 {
+		return fmt.Sprintf("https://www.bilibili.com/video/%s#reply%d", bvid, rpid)
+	}
+	return fmt.Sprintf("https://www.bilibili.com/video/%s", bvid)
+}
+
+// PublishTime 发布时间，Ctime 缺失时返回零值
+func (c Comment) PublishTime() time.Time {
+	if c.Ctime <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(c.Ctime, 0)
 }
 
 // Content 评论内容结构
@@ -58,12 +84,17 @@ type Content struct {
 
 // Member 评论者信息结构
 type Member struct {
-	Mid    string `json:"mid"`                      // 用户UID（字符串格式）
-	Uname  string `json:"uname"`                    // 用户昵称
-	Sex    string `json:"sex"`                      // 性别
-	Sign   string `json:"sign"`                     // 个性签名
-	Avatar string `json:"avatar"`                   // 头像URL
-	Level  int    `json:"level_info.current_level"` // 用户等级
+	Mid       string    `json:"mid"`        // 用户UID（字符串格式）
+	Uname     string    `json:"uname"`      // 用户昵称
+	Sex       string    `json:"sex"`        // 性别
+	Sign      string    `json:"sign"`       // 个性签名
+	Avatar    string    `json:"avatar"`     // 头像URL
+	LevelInfo LevelInfo `json:"level_info"` // 用户等级信息
+}
+
+// LevelInfo 用户等级信息
+type LevelInfo struct {
+	CurrentLevel int `json:"current_level"` // 当前等级（1-6，0表示未知）
 }
 
 // GetComments 获取视频评论列表
@@ -79,13 +110,13 @@ type Member struct {
 //
 // 示例：
 //
-//	comments, total, err := client.GetComments(GetCommentsRequest{
+//	comments, total, err := client.GetComments(ctx, GetCommentsRequest{
 //	    BVID: "BV1mH4y1u7UA",
 //	    Page: 1,
 //	    PageSize: 20,
 //	    Sort: 1, // 按点赞排序
 //	})
-func (c *Client) GetComments(req GetCommentsRequest) ([]Comment, int, error) {
+func (c *Client) GetComments(ctx context.Context, req GetCommentsRequest) ([]Comment, int, error) {
 	// 设置默认值
 	if req.Page <= 0 {
 		req.Page = 1
@@ -111,7 +142,7 @@ func (c *Client) GetComments(req GetCommentsRequest) ([]Comment, int, error) {
 	)
 
 	// 发送请求（评论API不需要WBI签名）
-	resp, err := c.Get(u, false)
+	resp, err := c.GetWithContext(ctx, u, false)
 	if err != nil {
 		return nil, 0, fmt.Errorf("获取评论失败: %w", err)
 	}
@@ -123,11 +154,6 @@ func (c *Client) GetComments(req GetCommentsRequest) ([]Comment, int, error) {
 		return nil, 0, fmt.Errorf("读取响应失败: %w", err)
 	}
 
-	// 在解析JSON前检查响应是否为HTML错误页面（如412风控页面）
-	if strings.HasPrefix(strings.TrimSpace(string(body)), "<!DOCTYPE") {
-		return nil, 0, fmt.Errorf("B站返回错误页面（可能是Cookie过期或触发风控），请更新Cookie后重试")
-	}
-
 	// 解析JSON响应
 	var commentResp CommentsResponse
 	if err := json.Unmarshal(body, &commentResp); err != nil {
@@ -163,8 +189,8 @@ func (c *Client) GetComments(req GetCommentsRequest) ([]Comment, int, error) {
 //
 // 示例：
 //
-//	replies, err := client.GetReplies("BV1mH4y1u7UA", 123456789, 1, 20)
-func (c *Client) GetReplies(bvid string, rootRPID int64, page, pageSize int) ([]Comment, error) {
+//	replies, err := client.GetReplies(ctx, "BV1mH4y1u7UA", 123456789, 1, 20)
+func (c *Client) GetReplies(ctx context.Context, bvid string, rootRPID int64, page, pageSize int) ([]Comment, error) {
 	// 设置默认值
 	if page <= 0 {
 		page = 1
@@ -187,7 +213,7 @@ func (c *Client) GetReplies(bvid string, rootRPID int64, page, pageSize int) ([]
 	)
 
 	// 发送请求
-	resp, err := c.Get(u, false)
+	resp, err := c.GetWithContext(ctx, u, false)
 	if err != nil {
 		return nil, fmt.Errorf("获取楼中楼评论失败: %w", err)
 	}
@@ -233,13 +259,13 @@ func (c *Client) GetReplies(bvid string, rootRPID int64, page, pageSize int) ([]
 // 返回：
 //   - []Comment: 所有回复列表
 //   - error: 错误信息
-func (c *Client) GetAllReplies(bvid string, rootRPID int64, maxReplies int) ([]Comment, error) {
+func (c *Client) GetAllReplies(ctx context.Context, bvid string, rootRPID int64, maxReplies int) ([]Comment, error) {
 	var allReplies []Comment
 	page := 1
 	pageSize := 20
 
 	for {
-		replies, err := c.GetReplies(bvid, rootRPID, page, pageSize)
+		replies, err := c.GetReplies(ctx, bvid, rootRPID, page, pageSize)
 		if err != nil {
 			return nil, err
 		}
@@ -262,9 +288,6 @@ func (c *Client) GetAllReplies(bvid string, rootRPID int64, maxReplies int) ([]C
 		if page > 10 {
 			break
 		}
-
-		// 添加延迟，避免请求过快
-		time.Sleep(100 * time.Millisecond)
 	}
 
 	return allReplies, nil
@@ -282,9 +305,9 @@ func (c *Client) GetAllReplies(bvid string, rootRPID int64, maxReplies int) ([]C
 //   - []Comment: 评论列表（包含楼中楼）
 //   - int: 评论总数
 //   - error: 错误信息
-func (c *Client) GetCommentsWithReplies(req GetCommentsRequest, fetchReplies bool, maxRepliesPerComment int) ([]Comment, int, error) {
+func (c *Client) GetCommentsWithReplies(ctx context.Context, req GetCommentsRequest, fetchReplies bool, maxRepliesPerComment int) ([]Comment, int, error) {
 	// 获取评论列表
-	comments, total, err := c.GetComments(req)
+	comments, total, err := c.GetComments(ctx, req)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -298,16 +321,17 @@ func (c *Client) GetCommentsWithReplies(req GetCommentsRequest, fetchReplies boo
 	for i := range comments {
 		// 只有当评论有回复时才获取
 		if comments[i].ReplyCount > 0 {
-			replies, err := c.GetAllReplies(req.BVID, comments[i].RPID, maxRepliesPerComment)
+			replies, err := c.GetAllReplies(ctx, req.BVID, comments[i].RPID, maxRepliesPerComment)
 			if err != nil {
+				// 任务已取消时立即返回
+				if ctx.Err() != nil {
+					return nil, 0, ctx.Err()
+				}
 				// 获取楼中楼失败不影响主流程，记录错误继续
 				continue
 			}
 			comments[i].Replies = replies
 		}
-
-		// 添加延迟，避免请求过快
-		time.Sleep(50 * time.Millisecond)
 	}
 
 	return comments, total, nil
@@ -317,6 +341,7 @@ func (c *Client) GetCommentsWithReplies(req GetCommentsRequest, fetchReplies boo
 // 自动分页获取评论，直到达到指定数量或没有更多结果
 //
 // 参数：
+//   - ctx: 上下文（用于取消）
 //   - bvid: 视频BV号
 //   - maxComments: 最大评论数量（默认500）
 //   - fetchReplies: 是否获取楼中楼
@@ -324,7 +349,7 @@ func (c *Client) GetCommentsWithReplies(req GetCommentsRequest, fetchReplies boo
 // 返回：
 //   - []Comment: 评论列表
 //   - error: 错误信息
-func (c *Client) GetAllComments(bvid string, maxComments int, fetchReplies bool) ([]Comment, error) {
+func (c *Client) GetAllComments(ctx context.Context, bvid string, maxComments int, fetchReplies bool) ([]Comment, error) {
 	// 默认限制500条评论
 	if maxComments <= 0 {
 		maxComments = 500
@@ -336,7 +361,7 @@ func (c *Client) GetAllComments(bvid string, maxComments int, fetchReplies bool)
 
 	for len(allComments) < maxComments {
 		// 获取当前页评论
-		comments, _, err := c.GetCommentsWithReplies(GetCommentsRequest{
+		comments, _, err := c.GetCommentsWithReplies(ctx, GetCommentsRequest{
 			BVID:     bvid,
 			Page:     page,
 			PageSize: pageSize,
@@ -359,9 +384,6 @@ func (c *Client) GetAllComments(bvid string, maxComments int, fetchReplies bool)
 		if page > 50 {
 			break
 		}
-
-		// 添加延迟，避免请求过快
-		time.Sleep(200 * time.Millisecond)
 	}
 
 	// 截取到指定数量
@@ -371,3 +393,15 @@ func (c *Client) GetAllComments(bvid string, maxComments int, fetchReplies bool)
 
 	return allComments, nil
 }
+
+// sleepWithContext 等待指定时长，ctx 取消时提前返回
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
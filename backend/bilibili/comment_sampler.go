@@ -1,9 +1,12 @@
 package bilibili
 
+import "context"
+
 // SampleComments 采样视频评论，只获取主评论内容
 // 用于快速获取评论样本进行AI分析
 //
 // 参数：
+//   - ctx: 上下文（用于取消）
 //   - bvid: 视频BV号
 //   - count: 需要采样的评论数量
 //
@@ -13,12 +16,12 @@ package bilibili
 //
 // 示例：
 //
-//	comments, err := client.SampleComments("BV1mH4y1u7UA", 50)
+//	comments, err := client.SampleComments(ctx, "BV1mH4y1u7UA", 50)
 //	// 返回 ["评论1内容", "评论2内容", ...]
-func (c *Client) SampleComments(bvid string, count int) ([]string, error) {
+func (c *Client) SampleComments(ctx context.Context, bvid string, count int) ([]string, error) {
 	// 调用GetAllComments获取评论
 	// fetchReplies=false 表示不获取楼中楼，提高速度
-	comments, err := c.GetAllComments(bvid, count, false)
+	comments, err := c.GetAllComments(ctx, bvid, count, false)
 	if err != nil {
 		return nil, err
 	}
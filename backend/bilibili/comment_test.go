@@ -0,0 +1,18 @@
+package bilibili
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestCommentMemberLevel 测试从评论接口的嵌套字段解析用户等级
+func TestCommentMemberLevel(t *testing.T) {
+	raw := `{"rpid":1,"root":0,"rcount":3,"like":12,"member":{"mid":"42","uname":"用户A","level_info":{"current_level":5}}}`
+	var c Comment
+	if err := json.Unmarshal([]byte(raw), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Member.LevelInfo.CurrentLevel != 5 || c.RCount != 3 || c.Like != 12 {
+		t.Errorf("unexpected comment: %+v", c)
+	}
+}
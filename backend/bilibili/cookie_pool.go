@@ -0,0 +1,179 @@
+package bilibili
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultQuarantine 账号触发风控后的默认隔离时长
+const DefaultQuarantine = 30 * time.Minute
+
+// CookieAccount 账号池中的一个B站账号
+type CookieAccount struct {
+	ID     uint   // 账号ID（对应 bilibili_accounts 表，0 表示旧版单Cookie配置）
+	Name   string // 备注名（用于日志）
+	Cookie string // 完整Cookie字符串
+}
+
+// QuarantineCallback 账号被隔离时的回调（用于记录失败原因）
+type QuarantineCallback func(account CookieAccount, reason string, until time.Time)
+
+// CookiePool 多账号Cookie池
+// 请求按轮询方式使用健康账号；账号触发风控时被隔离一段时间，期间不再使用
+type CookiePool struct {
+	mu           sync.Mutex
+	accounts     []CookieAccount
+	until        []time.Time // 各账号的隔离截止时间（与 accounts 一一对应）
+	next         int
+	quarantine   time.Duration
+	onQuarantine QuarantineCallback
+}
+
+// NewCookiePool 创建Cookie池（忽略Cookie为空的账号）
+func NewCookiePool(accounts []CookieAccount) *CookiePool {
+	p := &CookiePool{quarantine: DefaultQuarantine}
+	for _, a := range accounts {
+		if strings.TrimSpace(a.Cookie) == "" {
+			continue
+		}
+		p.accounts = append(p.accounts, a)
+		p.until = append(p.until, time.Time{})
+	}
+	return p
+}
+
+// SetQuarantineCallback 设置账号隔离回调
+func (p *CookiePool) SetQuarantineCallback(callback QuarantineCallback) {
+	p.onQuarantine = callback
+}
+
+// SetQuarantineDuration 设置隔离时长
+func (p *CookiePool) SetQuarantineDuration(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.quarantine = d
+}
+
+// Len 账号总数
+func (p *CookiePool) Len() int {
+	return len(p.accounts)
+}
+
+// Healthy 当前未被隔离的账号数
+func (p *CookiePool) Healthy() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.healthyLocked(time.Now())
+}
+
+func (p *CookiePool) healthyLocked(now time.Time) int {
+	n := 0
+	for _, u := range p.until {
+		if !now.Before(u) {
+			n++
+		}
+	}
+	return n
+}
+
+// Next 轮询选择下一个健康账号
+// 所有账号都被隔离时返回最早解除隔离的账号（请求仍受全局限流保护）
+// 池为空时返回 false
+func (p *CookiePool) Next() (CookieAccount, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.accounts) == 0 {
+		return CookieAccount{}, false
+	}
+
+	now := time.Now()
+	for i := 0; i < len(p.accounts); i++ {
+		idx := (p.next + i) % len(p.accounts)
+		if !now.Before(p.until[idx]) {
+			p.next = idx + 1
+			return p.accounts[idx], true
+		}
+	}
+
+	earliest := 0
+	for i, u := range p.until {
+		if u.Before(p.until[earliest]) {
+			earliest = i
+		}
+	}
+	return p.accounts[earliest], true
+}
+
+// Quarantine 隔离账号
+// 返回：
+//   - bool: 隔离后是否还有其他健康账号可用
+func (p *CookiePool) Quarantine(account CookieAccount, reason string) bool {
+	p.mu.Lock()
+	now := time.Now()
+	until := now.Add(p.quarantine)
+	found := false
+	for i, a := range p.accounts {
+		if a.ID == account.ID && a.Cookie == account.Cookie {
+			p.until[i] = until
+			found = true
+			break
+		}
+	}
+	healthy := p.healthyLocked(now)
+	callback := p.onQuarantine
+	p.mu.Unlock()
+
+	if found && callback != nil {
+		callback(account, reason, until)
+	}
+	return healthy > 0
+}
+
+// navAPI 用户导航信息接口（返回登录状态和WBI密钥）
+const navAPI = "https://api.bilibili.com/x/web-interface/nav"
+
+// NavInfo 账号登录状态
+type NavInfo struct {
+	IsLogin bool   `json:"is_login"` // 是否已登录（Cookie 是否有效）
+	Uname   string `json:"uname"`    // 用户名
+	Mid     int64  `json:"mid"`      // 用户ID
+}
+
+// CheckLogin 通过 nav 接口检查当前Cookie的登录状态
+// Cookie 过期或无效时 B站返回 code -101，此时返回 IsLogin=false 而不是错误
+//
+// 返回：
+//   - *NavInfo: 登录状态
+//   - error: 请求失败或接口返回其他错误码时返回错误
+func (c *Client) CheckLogin(ctx context.Context) (*NavInfo, error) {
+	resp, err := c.GetWithContext(ctx, navAPI, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	var nav Nav
+	if err := json.Unmarshal(body, &nav); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if nav.Code != 0 && nav.Code != -101 {
+		return nil, fmt.Errorf("nav接口返回错误码: %d", nav.Code)
+	}
+
+	return &NavInfo{
+		IsLogin: nav.Code == 0 && nav.Data.IsLogin,
+		Uname:   nav.Data.Uname,
+		Mid:     nav.Data.Mid,
+	}, nil
+}
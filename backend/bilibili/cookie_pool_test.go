@@ -0,0 +1,176 @@
+package bilibili
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCookiePoolRotation(t *testing.T) {
+	pool := NewCookiePool([]CookieAccount{
+		{ID: 1, Cookie: "SESSDATA=a"},
+		{ID: 2, Cookie: ""}, // 空Cookie会被忽略
+		{ID: 3, Cookie: "SESSDATA=c"},
+	})
+	if pool.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", pool.Len())
+	}
+
+	var got []uint
+	for i := 0; i < 4; i++ {
+		a, ok := pool.Next()
+		if !ok {
+			t.Fatal("Next() returned false")
+		}
+		got = append(got, a.ID)
+	}
+	want := []uint{1, 3, 1, 3}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("rotation = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCookiePoolQuarantine(t *testing.T) {
+	a1 := CookieAccount{ID: 1, Cookie: "SESSDATA=a"}
+	a2 := CookieAccount{ID: 2, Cookie: "SESSDATA=b"}
+	pool := NewCookiePool([]CookieAccount{a1, a2})
+
+	var quarantined []uint
+	pool.SetQuarantineCallback(func(account CookieAccount, reason string, until time.Time) {
+		quarantined = append(quarantined, account.ID)
+		if !until.After(time.Now()) {
+			t.Errorf("until %v should be in the future", until)
+		}
+	})
+
+	if !pool.Quarantine(a1, "code -352") {
+		t.Error("expected another healthy account after quarantining a1")
+	}
+	for i := 0; i < 3; i++ {
+		if a, _ := pool.Next(); a.ID != 2 {
+			t.Errorf("Next() = %d, want 2 while 1 is quarantined", a.ID)
+		}
+	}
+
+	if pool.Quarantine(a2, "code -352") {
+		t.Error("expected no healthy account after quarantining all")
+	}
+	if pool.Healthy() != 0 {
+		t.Errorf("Healthy() = %d, want 0", pool.Healthy())
+	}
+	// 全部隔离时仍返回最早解除隔离的账号
+	if a, ok := pool.Next(); !ok || a.ID != 1 {
+		t.Errorf("Next() = %d, %v, want 1, true", a.ID, ok)
+	}
+	if len(quarantined) != 2 {
+		t.Errorf("callback called %d times, want 2", len(quarantined))
+	}
+
+	if _, ok := NewCookiePool(nil).Next(); ok {
+		t.Error("empty pool should return false")
+	}
+}
+
+func TestCookiePoolExpires(t *testing.T) {
+	a1 := CookieAccount{ID: 1, Cookie: "SESSDATA=a"}
+	pool := NewCookiePool([]CookieAccount{a1})
+	pool.SetQuarantineDuration(10 * time.Millisecond)
+	pool.Quarantine(a1, "code -412")
+	if pool.Healthy() != 0 {
+		t.Fatal("expected account to be quarantined")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if pool.Healthy() != 1 {
+		t.Error("expected account to recover after quarantine expires")
+	}
+}
+
+func TestClientSwitchesAccountOnRiskControl(t *testing.T) {
+	shortBackoff(t)
+
+	var mu sync.Mutex
+	var cookies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		cookies = append(cookies, r.Header.Get("Cookie"))
+		mu.Unlock()
+		if r.Header.Get("Cookie") == "SESSDATA=bad" {
+			_, _ = w.Write([]byte(`{"code":-352,"message":"风控校验失败"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0,"data":{}}`))
+	}))
+	defer server.Close()
+
+	limiter := NewRateLimiter(100, 10)
+	client := newTestClient(server, limiter)
+	client.SetCookiePool(NewCookiePool([]CookieAccount{
+		{ID: 1, Cookie: "SESSDATA=bad"},
+		{ID: 2, Cookie: "SESSDATA=good"},
+	}))
+
+	resp, err := client.Get(server.URL, false)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(cookies) != 2 || cookies[0] != "SESSDATA=bad" || cookies[1] != "SESSDATA=good" {
+		t.Errorf("cookies = %v, want [bad good]", cookies)
+	}
+	// 换号重试不应触发全局暂停
+	if limiter.PauseRemaining() > 0 {
+		t.Error("expected no global pause when another account is available")
+	}
+
+	// 后续请求只使用健康账号
+	resp, err = client.Get(server.URL, false)
+	if err != nil {
+		t.Fatalf("second Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if last := cookies[len(cookies)-1]; last != "SESSDATA=good" {
+		t.Errorf("second request used %q, want good account", last)
+	}
+}
+
+func TestCheckLogin(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantLogin bool
+		wantUname string
+		wantErr   bool
+	}{
+		{"logged in", `{"code":0,"data":{"isLogin":true,"uname":"测试用户","mid":123}}`, true, "测试用户", false},
+		{"expired", `{"code":-101,"message":"账号未登录","data":{"isLogin":false}}`, false, "", false},
+		{"other error", `{"code":-400,"message":"请求错误"}`, false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{
+				httpClient: &http.Client{Transport: &mockTransport{responseBody: tt.body}},
+				limiter:    NewRateLimiter(100, 10),
+			}
+			info, err := client.CheckLogin(context.Background())
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckLogin() error = %v", err)
+			}
+			if info.IsLogin != tt.wantLogin || info.Uname != tt.wantUname {
+				t.Errorf("CheckLogin() = %+v, want login=%v uname=%q", info, tt.wantLogin, tt.wantUname)
+			}
+		})
+	}
+}
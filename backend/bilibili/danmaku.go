@@ -0,0 +1,280 @@
+package bilibili
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// 评论来源
+const (
+	SourceComment = "comment" // 评论区（含楼中楼）
+	SourceDanmaku = "danmaku" // 弹幕
+)
+
+// DefaultDanmakuSegments 默认最多抓取的弹幕分段数（每段6分钟，20段覆盖2小时视频）
+const DefaultDanmakuSegments = 20
+
+// Danmaku 弹幕
+type Danmaku struct {
+	ID       int64  `json:"id"`       // 弹幕ID
+	Progress int    `json:"progress"` // 出现时间（视频内的毫秒数）
+	Mode     int    `json:"mode"`     // 弹幕类型（1-3滚动 4底部 5顶部 7高级 8代码）
+	Content  string `json:"content"`  // 弹幕内容
+	Ctime    int64  `json:"ctime"`    // 发送时间戳（秒）
+	MidHash  string `json:"mid_hash"` // 发送者UID的哈希
+	Weight   int    `json:"weight"`   // 智能屏蔽权重（0-10，越大越不容易被屏蔽）
+}
+
+// ToComment 把弹幕转换为评论结构，便于与评论区评论统一过滤、保存和分析
+// RPID 留空，通过 Source 和 DanmakuID 区分
+func (d Danmaku) ToComment(oid int64) Comment {
+	return Comment{
+		OID:       oid,
+		Ctime:     d.Ctime,
+		Content:   Content{Message: d.Content},
+		Source:    SourceDanmaku,
+		DanmakuID: d.ID,
+		Progress:  d.Progress,
+	}
+}
+
+// GetDanmaku 获取视频的全部弹幕
+// 参数：
+//   - cid: 视频分P的cid（通过 GetVideoInfo 获取）
+//
+// 返回：
+//   - []Danmaku: 弹幕列表（按出现时间排序）
+//   - error: 请求失败时返回错误
+//
+// 示例：
+//
+//	info, _ := client.GetVideoInfo("BV1mH4y1u7UA")
+//	danmaku, err := client.GetDanmaku(info.CID)
+func (c *Client) GetDanmaku(cid int64) ([]Danmaku, error) {
+	return c.GetDanmakuWithContext(context.Background(), cid, DefaultDanmakuSegments)
+}
+
+// GetDanmakuWithContext 获取视频弹幕（支持取消）
+// 优先使用 protobuf 分段接口（每段6分钟，直到返回空段或达到 maxSegments）；
+// 分段接口不可用时退回 XML 接口（只返回部分弹幕）
+func (c *Client) GetDanmakuWithContext(ctx context.Context, cid int64, maxSegments int) ([]Danmaku, error) {
+	if cid <= 0 {
+		return nil, fmt.Errorf("无效的cid: %d", cid)
+	}
+	if maxSegments <= 0 {
+		maxSegments = DefaultDanmakuSegments
+	}
+
+	var all []Danmaku
+	for seg := 1; seg <= maxSegments; seg++ {
+		u := fmt.Sprintf("https://api.bilibili.com/x/v2/dm/web/seg.so?type=1&oid=%d&segment_index=%d", cid, seg)
+		body, err := c.getBody(ctx, u)
+		if err == nil {
+			var items []Danmaku
+			items, err = parseDanmakuSegment(body)
+			if err == nil {
+				if len(items) == 0 {
+					break
+				}
+				all = append(all, items...)
+				continue
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// 第一段就失败：退回XML接口；后续分段失败时保留已获取的弹幕
+		if seg == 1 {
+			return c.getDanmakuXML(ctx, cid)
+		}
+		return all, nil
+	}
+	return all, nil
+}
+
+// getDanmakuXML 通过XML接口获取弹幕
+func (c *Client) getDanmakuXML(ctx context.Context, cid int64) ([]Danmaku, error) {
+	u := fmt.Sprintf("https://api.bilibili.com/x/v1/dm/list.so?oid=%d", cid)
+	body, err := c.getBody(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("获取弹幕失败: %w", err)
+	}
+	return parseDanmakuXML(body)
+}
+
+// getBody 发送GET请求并读取响应体（处理 deflate 压缩）
+func (c *Client) getBody(ctx context.Context, u string) ([]byte, error) {
+	resp, err := c.GetWithContext(ctx, u, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var reader io.Reader = resp.Body
+	// XML弹幕接口返回未声明解压的 deflate 数据，Go 的 http.Client 不会自动解压
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "deflate") {
+		fr := flate.NewReader(resp.Body)
+		defer fr.Close()
+		reader = fr
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return body, nil
+}
+
+// parseDanmakuSegment 解析 protobuf 分段弹幕（DmSegMobileReply）
+// 只解析用到的字段：
+//
+//	message DmSegMobileReply { repeated DanmakuElem elems = 1; }
+//	message DanmakuElem {
+//	  int64 id = 1; int32 progress = 2; int32 mode = 3; string midHash = 6;
+//	  string content = 7; int64 ctime = 8; int32 weight = 9;
+//	}
+func parseDanmakuSegment(data []byte) ([]Danmaku, error) {
+	// 出错时接口返回JSON
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("{")) {
+		var apiErr struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(trimmed, &apiErr) == nil && apiErr.Code != 0 {
+			return nil, fmt.Errorf("API错误: %s (code: %d)", apiErr.Message, apiErr.Code)
+		}
+	}
+
+	var items []Danmaku
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, fmt.Errorf("弹幕数据格式错误: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		if num == 1 && typ == protowire.BytesType {
+			elem, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return nil, fmt.Errorf("弹幕数据格式错误: %w", protowire.ParseError(n))
+			}
+			data = data[n:]
+			d, err := parseDanmakuElem(elem)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, d)
+			continue
+		}
+
+		n = protowire.ConsumeFieldValue(num, typ, data)
+		if n < 0 {
+			return nil, fmt.Errorf("弹幕数据格式错误: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+	}
+	return items, nil
+}
+
+func parseDanmakuElem(data []byte) (Danmaku, error) {
+	var d Danmaku
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return d, fmt.Errorf("弹幕数据格式错误: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		switch {
+		case typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return d, fmt.Errorf("弹幕数据格式错误: %w", protowire.ParseError(n))
+			}
+			data = data[n:]
+			switch num {
+			case 1:
+				d.ID = int64(v)
+			case 2:
+				d.Progress = int(int32(v))
+			case 3:
+				d.Mode = int(int32(v))
+			case 8:
+				d.Ctime = int64(v)
+			case 9:
+				d.Weight = int(int32(v))
+			}
+		case typ == protowire.BytesType && (num == 6 || num == 7):
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return d, fmt.Errorf("弹幕数据格式错误: %w", protowire.ParseError(n))
+			}
+			data = data[n:]
+			if num == 6 {
+				d.MidHash = string(v)
+			} else {
+				d.Content = string(v)
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return d, fmt.Errorf("弹幕数据格式错误: %w", protowire.ParseError(n))
+			}
+			data = data[n:]
+		}
+	}
+	return d, nil
+}
+
+// danmakuXML XML弹幕文件结构
+// <d p="出现时间(秒),类型,字号,颜色,发送时间戳,弹幕池,发送者哈希,弹幕ID,权重">内容</d>
+type danmakuXML struct {
+	Items []struct {
+		P       string `xml:"p,attr"`
+		Content string `xml:",chardata"`
+	} `xml:"d"`
+}
+
+// parseDanmakuXML 解析XML弹幕
+func parseDanmakuXML(data []byte) ([]Danmaku, error) {
+	var doc danmakuXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析弹幕XML失败: %w", err)
+	}
+
+	items := make([]Danmaku, 0, len(doc.Items))
+	for _, item := range doc.Items {
+		fields := strings.Split(item.P, ",")
+		if len(fields) < 8 {
+			continue
+		}
+		seconds, _ := strconv.ParseFloat(fields[0], 64)
+		mode, _ := strconv.Atoi(fields[1])
+		ctime, _ := strconv.ParseInt(fields[4], 10, 64)
+		id, _ := strconv.ParseInt(fields[7], 10, 64)
+		d := Danmaku{
+			ID:       id,
+			Progress: int(seconds * 1000),
+			Mode:     mode,
+			Content:  item.Content,
+			Ctime:    ctime,
+			MidHash:  fields[6],
+		}
+		if len(fields) > 8 {
+			d.Weight, _ = strconv.Atoi(fields[8])
+		}
+		items = append(items, d)
+	}
+	return items, nil
+}
@@ -0,0 +1,180 @@
+package bilibili
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// buildDanmakuElem 按 DanmakuElem 的字段编号构造一条 protobuf 弹幕
+func buildDanmakuElem(id int64, progress int, content string, ctime int64, weight int) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(id))
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(progress))
+	b = protowire.AppendTag(b, 3, protowire.VarintType)
+	b = protowire.AppendVarint(b, 1)
+	b = protowire.AppendTag(b, 4, protowire.VarintType) // fontsize，解析时跳过
+	b = protowire.AppendVarint(b, 25)
+	b = protowire.AppendTag(b, 6, protowire.BytesType)
+	b = protowire.AppendString(b, "abcd1234")
+	b = protowire.AppendTag(b, 7, protowire.BytesType)
+	b = protowire.AppendString(b, content)
+	b = protowire.AppendTag(b, 8, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(ctime))
+	b = protowire.AppendTag(b, 9, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(weight))
+	return b
+}
+
+func buildDanmakuSegment(elems ...[]byte) []byte {
+	var b []byte
+	for _, e := range elems {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, e)
+	}
+	return b
+}
+
+func TestParseDanmakuSegment(t *testing.T) {
+	data := buildDanmakuSegment(
+		buildDanmakuElem(1001, 12500, "吸力好强", 1700000000, 8),
+		buildDanmakuElem(1002, 30000, "续航太短了", 1700000100, 3),
+	)
+	items, err := parseDanmakuSegment(data)
+	if err != nil {
+		t.Fatalf("parseDanmakuSegment() error = %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+	d := items[0]
+	if d.ID != 1001 || d.Progress != 12500 || d.Mode != 1 || d.Content != "吸力好强" ||
+		d.Ctime != 1700000000 || d.MidHash != "abcd1234" || d.Weight != 8 {
+		t.Errorf("unexpected danmaku: %+v", d)
+	}
+
+	if _, err := parseDanmakuSegment([]byte(`{"code":-404,"message":"啥都木有"}`)); err == nil {
+		t.Error("expected error for JSON error body")
+	}
+}
+
+func TestParseDanmakuXML(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?><i><chatserver>chat.bilibili.com</chatserver>` +
+		`<d p="12.50000,1,25,16777215,1700000000,0,abcd1234,1001,8">吸力好强</d>` +
+		`<d p="bad">格式错误</d></i>`)
+	items, err := parseDanmakuXML(data)
+	if err != nil {
+		t.Fatalf("parseDanmakuXML() error = %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	d := items[0]
+	if d.ID != 1001 || d.Progress != 12500 || d.Content != "吸力好强" || d.MidHash != "abcd1234" || d.Weight != 8 {
+		t.Errorf("unexpected danmaku: %+v", d)
+	}
+}
+
+func TestDanmakuToComment(t *testing.T) {
+	c := Danmaku{ID: 1001, Progress: 12500, Content: "吸力好强", Ctime: 1700000000}.ToComment(42)
+	if !c.IsDanmaku() || c.DanmakuID != 1001 || c.OID != 42 || c.Content.Message != "吸力好强" || c.Progress != 12500 {
+		t.Errorf("unexpected comment: %+v", c)
+	}
+	if (Comment{}).IsDanmaku() {
+		t.Error("zero comment should not be danmaku")
+	}
+}
+
+// danmakuTransport 按接口路径返回预设的响应
+type danmakuTransport struct {
+	segments map[string][]byte // segment_index -> body
+	xml      string
+	segErr   bool
+	views    int // 视频详情接口的请求次数
+}
+
+func (m *danmakuTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	status := http.StatusOK
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/seg.so"):
+		if m.segErr {
+			status = http.StatusNotFound
+		}
+		body = m.segments[req.URL.Query().Get("segment_index")]
+	case strings.HasSuffix(req.URL.Path, "/list.so"):
+		body = []byte(m.xml)
+	case strings.HasSuffix(req.URL.Path, "/web-interface/view"):
+		m.views++
+		body = []byte(`{"code": 0, "data": {"bvid": "BV1xx", "aid": 9, "cid": 123}}`)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(string(body))),
+	}, nil
+}
+
+func TestGetDanmaku(t *testing.T) {
+	transport := &danmakuTransport{segments: map[string][]byte{
+		"1": buildDanmakuSegment(buildDanmakuElem(1, 1000, "第一段", 1700000000, 5)),
+		"2": buildDanmakuSegment(buildDanmakuElem(2, 361000, "第二段", 1700000000, 5)),
+	}}
+	client := &Client{httpClient: &http.Client{Transport: transport}, limiter: NewRateLimiter(100, 10)}
+
+	items, err := client.GetDanmaku(123)
+	if err != nil {
+		t.Fatalf("GetDanmaku() error = %v", err)
+	}
+	if len(items) != 2 || items[1].Content != "第二段" {
+		t.Errorf("got %+v, want 2 segments", items)
+	}
+
+	// 分段接口不可用时退回XML接口
+	transport.segErr = true
+	transport.xml = `<i><d p="1.0,1,25,16777215,1700000000,0,abcd,9,5">XML弹幕</d></i>`
+	items, err = client.GetDanmaku(123)
+	if err != nil {
+		t.Fatalf("GetDanmaku() fallback error = %v", err)
+	}
+	if len(items) != 1 || items[0].Content != "XML弹幕" {
+		t.Errorf("got %+v, want XML fallback", items)
+	}
+
+	if _, err := client.GetDanmaku(0); err == nil {
+		t.Error("expected error for invalid cid")
+	}
+}
+
+func TestScrapeVideoDanmakuReusesCID(t *testing.T) {
+	transport := &danmakuTransport{segments: map[string][]byte{
+		"1": buildDanmakuSegment(buildDanmakuElem(1, 1000, "好用", 1700000000, 5)),
+	}}
+	client := &Client{httpClient: &http.Client{Transport: transport}, limiter: NewRateLimiter(100, 10)}
+	scraper := NewScraper(client, nil)
+
+	// 商单识别时已取得 cid，不再请求视频详情
+	comments, err := scraper.scrapeVideoDanmaku(context.Background(), VideoInfo{BVID: "BV1xx", AID: 9, CID: 123}, 0)
+	if err != nil {
+		t.Fatalf("scrapeVideoDanmaku() error = %v", err)
+	}
+	if transport.views != 0 {
+		t.Errorf("video detail requested %d times, want 0", transport.views)
+	}
+	if len(comments) != 1 || comments[0].OID != 9 {
+		t.Errorf("got %+v, want one danmaku of aid 9", comments)
+	}
+
+	if _, err := scraper.scrapeVideoDanmaku(context.Background(), VideoInfo{BVID: "BV1xx"}, 0); err != nil {
+		t.Fatalf("scrapeVideoDanmaku() without cid error = %v", err)
+	}
+	if transport.views != 1 {
+		t.Errorf("video detail requested %d times, want 1", transport.views)
+	}
+}
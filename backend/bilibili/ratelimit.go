@@ -0,0 +1,210 @@
+package bilibili
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 限流和风控退避参数
+const (
+	DefaultRateLimit = 4.0 // 默认每秒请求数（所有任务共享）
+	DefaultRateBurst = 4   // 默认突发请求数
+
+	maxRiskRetries = 4           // 触发风控后单个请求的最大重试次数
+	riskCodeBanned = -412        // 请求被拦截
+	riskCodeRisk   = -352        // 风控校验失败
+	riskPagePrefix = "<!DOCTYPE" // 风控拦截时返回的HTML页面
+)
+
+// 退避时长（测试中会调小）
+var (
+	baseBackoff = 5 * time.Second // 首次触发风控的暂停时长
+	maxBackoff  = 2 * time.Minute // 单次暂停时长上限（不含抖动）
+)
+
+// ErrRiskControl 多次重试后仍被B站风控拦截
+var ErrRiskControl = errors.New("触发B站风控")
+
+// ThrottleEvent 限流事件（触发风控或等待全局暂停时通知调用方）
+type ThrottleEvent struct {
+	Reason  string        // 原因（如 HTTP 412、code -352、风控页面、全局暂停中）
+	Wait    time.Duration // 需要等待的时长
+	Attempt int           // 当前请求的重试次数（0 表示因其他请求触发的全局暂停而等待）
+}
+
+// ThrottleCallback 限流事件回调
+type ThrottleCallback func(event ThrottleEvent)
+
+// RateLimiter 令牌桶限流器
+// 同一进程内的所有 Client 默认共享一个限流器：任何一个请求触发风控时全局暂停，
+// 所有抓取协程都会等待暂停结束后再继续请求
+type RateLimiter struct {
+	mu          sync.Mutex
+	rate        float64   // 每秒补充的令牌数
+	burst       float64   // 令牌桶容量
+	tokens      float64   // 当前令牌数
+	last        time.Time // 上次补充令牌的时间
+	pausedUntil time.Time // 全局暂停截止时间
+	strikes     int       // 连续触发风控的次数（用于指数退避）
+}
+
+// NewRateLimiter 创建令牌桶限流器
+// 参数：
+//   - rate: 每秒请求数（<=0 时使用默认值）
+//   - burst: 突发请求数（<=0 时使用默认值）
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	l := &RateLimiter{last: time.Now()}
+	l.SetRate(rate, burst)
+	l.tokens = l.burst
+	return l
+}
+
+// defaultLimiter 所有 Client 共享的默认限流器
+var defaultLimiter = NewRateLimiter(DefaultRateLimit, DefaultRateBurst)
+
+// DefaultLimiter 获取所有 Client 共享的默认限流器
+func DefaultLimiter() *RateLimiter {
+	return defaultLimiter
+}
+
+// SetRate 调整限流速率
+func (l *RateLimiter) SetRate(rate float64, burst int) {
+	if rate <= 0 {
+		rate = DefaultRateLimit
+	}
+	if burst <= 0 {
+		burst = DefaultRateBurst
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	l.burst = float64(burst)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// Wait 等待可用令牌；处于全局暂停时等待暂停结束
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		var wait time.Duration
+		if now.Before(l.pausedUntil) {
+			wait = l.pausedUntil.Sub(now)
+		} else {
+			l.refill(now)
+			if l.tokens >= 1 {
+				l.tokens--
+				l.mu.Unlock()
+				return nil
+			}
+			wait = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		}
+		l.mu.Unlock()
+
+		if err := sleepWithContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// refill 按经过的时间补充令牌（调用方需持有锁）
+func (l *RateLimiter) refill(now time.Time) {
+	if now.After(l.last) {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+}
+
+// PauseRemaining 全局暂停的剩余时长（未暂停时返回0）
+func (l *RateLimiter) PauseRemaining() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if d := time.Until(l.pausedUntil); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Throttle 记录一次风控，按指数退避加随机抖动设置全局暂停
+// 返回距离暂停结束的时长
+func (l *RateLimiter) Throttle() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.strikes++
+	backoff := baseBackoff << (l.strikes - 1)
+	if backoff > maxBackoff || backoff <= 0 {
+		backoff = maxBackoff
+	}
+	// 抖动：避免暂停结束后所有协程同时发起请求
+	jitter := time.Duration(rand.Int63n(int64(backoff)/2 + 1))
+
+	now := time.Now()
+	if until := now.Add(backoff + jitter); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	l.tokens = 0
+	l.last = l.pausedUntil
+	return l.pausedUntil.Sub(now)
+}
+
+// Success 请求成功后重置退避计数
+func (l *RateLimiter) Success() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.strikes = 0
+}
+
+// accountLevelRisk 风控是否针对账号（JSON错误码），而不是针对IP（HTTP 412、风控页面）
+func accountLevelRisk(reason string) bool {
+	return strings.HasPrefix(reason, "code ")
+}
+
+// detectRiskControl 检查响应是否为风控拦截
+// 会读取完整响应体并重新放回 resp.Body，调用方可以照常读取
+//
+// 返回：
+//   - string: 风控原因（为空表示正常响应）
+//   - error: 读取响应体失败时返回错误
+func detectRiskControl(resp *http.Response) (string, error) {
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		resp.Body.Close()
+		return "HTTP 412", nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("读取响应失败: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	trimmed := bytes.TrimSpace(body)
+	if bytes.HasPrefix(trimmed, []byte(riskPagePrefix)) {
+		return "风控页面", nil
+	}
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var head struct {
+			Code int `json:"code"`
+		}
+		if json.Unmarshal(trimmed, &head) == nil && (head.Code == riskCodeRisk || head.Code == riskCodeBanned) {
+			return fmt.Sprintf("code %d", head.Code), nil
+		}
+	}
+	return "", nil
+}
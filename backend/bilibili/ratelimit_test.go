@@ -0,0 +1,201 @@
+package bilibili
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// shortBackoff 测试期间把退避时长调小
+func shortBackoff(t *testing.T) {
+	t.Helper()
+	oldBase, oldMax := baseBackoff, maxBackoff
+	baseBackoff, maxBackoff = 10*time.Millisecond, 40*time.Millisecond
+	t.Cleanup(func() { baseBackoff, maxBackoff = oldBase, oldMax })
+}
+
+func newTestClient(server *httptest.Server, limiter *RateLimiter) *Client {
+	client := &Client{httpClient: server.Client()}
+	client.SetRateLimiter(limiter)
+	return client
+}
+
+func TestDetectRiskControl(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"ok", http.StatusOK, `{"code":0,"data":{}}`, ""},
+		{"http 412", http.StatusPreconditionFailed, `blocked`, "HTTP 412"},
+		{"code -352", http.StatusOK, `{"code":-352,"message":"风控校验失败"}`, "code -352"},
+		{"code -412", http.StatusOK, `{"code":-412,"message":"请求被拦截"}`, "code -412"},
+		{"html page", http.StatusOK, "\n<!DOCTYPE html><html></html>", "风控页面"},
+		{"other error code", http.StatusOK, `{"code":-404,"message":"啥都木有"}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			reason, err := detectRiskControl(resp)
+			if err != nil {
+				t.Fatalf("detectRiskControl() error = %v", err)
+			}
+			if reason != tt.want {
+				t.Errorf("reason = %q, want %q", reason, tt.want)
+			}
+
+			// 正常响应的响应体必须仍可读取
+			if reason == "" {
+				body, _ := io.ReadAll(resp.Body)
+				if string(body) != tt.body {
+					t.Errorf("body = %q, want %q", body, tt.body)
+				}
+			}
+		})
+	}
+}
+
+func TestGetRetriesAfterRiskControl(t *testing.T) {
+	shortBackoff(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			_, _ = w.Write([]byte(`{"code":-352,"message":"风控校验失败"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0,"data":{}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server, NewRateLimiter(100, 10))
+	var events []ThrottleEvent
+	client.SetThrottleCallback(func(ev ThrottleEvent) { events = append(events, ev) })
+
+	resp, err := client.Get(server.URL, false)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("calls = %d, want 3", n)
+	}
+	var retries int
+	for _, ev := range events {
+		if ev.Attempt > 0 {
+			retries++
+			if ev.Wait <= 0 {
+				t.Errorf("expected positive wait, got %v", ev.Wait)
+			}
+		}
+	}
+	if retries != 2 {
+		t.Errorf("throttle events = %d, want 2", retries)
+	}
+}
+
+func TestGetGivesUpAfterMaxRetries(t *testing.T) {
+	shortBackoff(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusPreconditionFailed)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server, NewRateLimiter(100, 10))
+	_, err := client.Get(server.URL, false)
+	if !errors.Is(err, ErrRiskControl) {
+		t.Fatalf("expected ErrRiskControl, got %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != maxRiskRetries+1 {
+		t.Errorf("calls = %d, want %d", n, maxRiskRetries+1)
+	}
+}
+
+func TestRateLimiterPacing(t *testing.T) {
+	limiter := NewRateLimiter(50, 1)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	}
+	// 突发1个，其余4个按每秒50个补充，至少需要约80ms
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Errorf("5 requests took %v, expected pacing of at least 70ms", elapsed)
+	}
+}
+
+func TestRateLimiterGlobalPause(t *testing.T) {
+	shortBackoff(t)
+
+	limiter := NewRateLimiter(1000, 10)
+	wait := limiter.Throttle()
+	if wait < baseBackoff {
+		t.Fatalf("Throttle() = %v, want >= %v", wait, baseBackoff)
+	}
+	if limiter.PauseRemaining() <= 0 {
+		t.Fatal("expected limiter to be paused")
+	}
+
+	// 暂停期间所有等待者都要等到暂停结束
+	start := time.Now()
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < baseBackoff-2*time.Millisecond {
+		t.Errorf("Wait() returned after %v, expected to wait for pause", elapsed)
+	}
+
+	// 取消的上下文应立即返回
+	limiter.Throttle()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRateLimiterBackoffGrows(t *testing.T) {
+	shortBackoff(t)
+
+	limiter := NewRateLimiter(1000, 10)
+	first := limiter.Throttle()
+	limiter.mu.Lock()
+	limiter.pausedUntil = time.Time{}
+	limiter.mu.Unlock()
+	second := limiter.Throttle()
+	// 第二次退避基数翻倍（抖动最多50%），一定不小于第一次的基数
+	if second < 2*baseBackoff {
+		t.Errorf("second backoff %v, want >= %v (first %v)", second, 2*baseBackoff, first)
+	}
+
+	limiter.Success()
+	limiter.mu.Lock()
+	strikes := limiter.strikes
+	limiter.mu.Unlock()
+	if strikes != 0 {
+		t.Errorf("strikes after Success() = %d, want 0", strikes)
+	}
+}
@@ -2,8 +2,11 @@ package bilibili
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,12 +14,14 @@ import (
 )
 
 // ScraperConfig 抓取器配置
+// 请求速率由 Client 的限流器统一控制，抓取器不再自行设置请求间隔
 type ScraperConfig struct {
-	MaxVideos           int           // 最大视频数量（默认50）
-	MaxCommentsPerVideo int           // 每个视频最大评论数（默认500）
-	MaxConcurrency      int64         // 最大并发数（默认5）
-	FetchReplies        bool          // 是否获取楼中楼（默认true）
-	RequestDelay        time.Duration // 请求间隔（默认200ms）
+	MaxVideos           int   // 最大视频数量（默认50）
+	MaxCommentsPerVideo int   // 每个视频最大评论数（默认500）
+	MaxConcurrency      int64 // 最大并发数（默认5）
+	FetchReplies        bool  // 是否获取楼中楼（默认true）
+	FetchDanmaku        bool  // 是否同时抓取弹幕（默认false）
+	MaxDanmakuPerVideo  int   // 每个视频最多保留的弹幕数（默认200）
 }
 
 // DefaultScraperConfig 默认抓取器配置
@@ -26,7 +31,7 @@ func DefaultScraperConfig() ScraperConfig {
 		MaxCommentsPerVideo: 500,
 		MaxConcurrency:      5,
 		FetchReplies:        true,
-		RequestDelay:        200 * time.Millisecond,
+		MaxDanmakuPerVideo:  200,
 	}
 }
 
@@ -42,8 +47,10 @@ type ScrapeStats struct {
 	TotalVideos   int           // 总视频数
 	TotalComments int           // 总评论数
 	TotalReplies  int           // 总楼中楼数
+	TotalDanmaku  int           // 总弹幕数（弹幕以 Source=danmaku 的评论形式存放在 Comments 中）
 	Duration      time.Duration // 耗时
 	Errors        []string      // 错误列表
+	Throttled     []string      // 因风控未能抓取完整的视频BVID（已抓取的部分评论会保留）
 }
 
 // ProgressCallback 进度回调函数类型
@@ -80,6 +87,9 @@ func NewScraper(client *Client, config *ScraperConfig) *Scraper {
 	if config != nil {
 		cfg = *config
 	}
+	if cfg.FetchDanmaku && cfg.MaxDanmakuPerVideo <= 0 {
+		cfg.MaxDanmakuPerVideo = 200
+	}
 	return &Scraper{
 		client: client,
 		config: cfg,
@@ -209,9 +219,6 @@ func (s *Scraper) ScrapeByKeyword(ctx context.Context, keyword string) (*ScrapeR
 			s.reportProgress("scraping", completedCount, len(videos),
 				fmt.Sprintf("已完成%d/%d，当前视频%d条评论", completedCount, len(videos), commentCount))
 		}(i, video)
-
-		// 添加请求间隔
-		time.Sleep(s.config.RequestDelay)
 	}
 
 	// 等待所有goroutine完成
@@ -239,7 +246,7 @@ func (s *Scraper) scrapeVideoCommentsWithLimit(ctx context.Context, bvid string,
 		}
 
 		// 获取评论
-		comments, _, err := s.client.GetCommentsWithReplies(GetCommentsRequest{
+		comments, _, err := s.client.GetCommentsWithReplies(ctx, GetCommentsRequest{
 			BVID:     bvid,
 			Page:     page,
 			PageSize: pageSize,
@@ -247,6 +254,10 @@ func (s *Scraper) scrapeVideoCommentsWithLimit(ctx context.Context, bvid string,
 		}, s.config.FetchReplies, 10)
 
 		if err != nil {
+			// 风控拦截时返回已抓取的部分，由调用方决定是否保留
+			if errors.Is(err, ErrRiskControl) {
+				return allComments, err
+			}
 			return nil, err
 		}
 
@@ -262,9 +273,6 @@ func (s *Scraper) scrapeVideoCommentsWithLimit(ctx context.Context, bvid string,
 		if page > 50 {
 			break
 		}
-
-		// 添加请求间隔
-		time.Sleep(s.config.RequestDelay)
 	}
 
 	if len(allComments) > maxComments {
@@ -274,8 +282,50 @@ func (s *Scraper) scrapeVideoCommentsWithLimit(ctx context.Context, bvid string,
 	return allComments, nil
 }
 
+// scrapeVideoDanmaku 抓取单个视频的弹幕，转换为评论结构
+// 视频已有 cid（商单识别时请求过视频详情）时直接使用，否则先请求视频详情；
+// 内容相同的弹幕只保留一条；超出数量限制时优先保留权重高（不易被智能屏蔽）的弹幕，
+// 结果按出现时间排序
+func (s *Scraper) scrapeVideoDanmaku(ctx context.Context, v VideoInfo, limit int) ([]Comment, error) {
+	cid, aid := v.CID, v.AID
+	if cid == 0 || aid == 0 {
+		info, err := s.client.GetVideoInfoWithContext(ctx, v.BVID)
+		if err != nil {
+			return nil, err
+		}
+		cid, aid = info.CID, info.AID
+	}
+	items, err := s.client.GetDanmakuWithContext(ctx, cid, DefaultDanmakuSegments)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(items))
+	unique := make([]Danmaku, 0, len(items))
+	for _, d := range items {
+		key := strings.ToLower(strings.TrimSpace(d.Content))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, d)
+	}
+
+	if limit > 0 && len(unique) > limit {
+		sort.SliceStable(unique, func(i, j int) bool { return unique[i].Weight > unique[j].Weight })
+		unique = unique[:limit]
+	}
+	sort.SliceStable(unique, func(i, j int) bool { return unique[i].Progress < unique[j].Progress })
+
+	comments := make([]Comment, len(unique))
+	for i, d := range unique {
+		comments[i] = d.ToComment(aid)
+	}
+	return comments, nil
+}
+
 // ScrapeByVideos 根据视频列表抓取评论
-// 直接抓取指定视频的评论
+// 直接抓取指定视频的评论；ScraperConfig.FetchDanmaku 开启时同时抓取弹幕
 //
 // 参数：
 //   - ctx: 上下文（用于取消）
@@ -359,11 +409,38 @@ func (s *Scraper) ScrapeByVideos(ctx context.Context, videos []VideoInfo, commen
 
 			comments, err := s.scrapeVideoCommentsWithLimit(ctx, v.BVID, maxComments)
 
+			var danmaku []Comment
+			var danmakuErr error
+			if s.config.FetchDanmaku && ctx.Err() == nil {
+				danmaku, danmakuErr = s.scrapeVideoDanmaku(ctx, v, s.config.MaxDanmakuPerVideo)
+			}
+
 			mu.Lock()
 			defer mu.Unlock()
 
+			// 弹幕抓取失败不影响评论
+			if danmakuErr != nil {
+				result.Stats.Errors = append(result.Stats.Errors,
+					fmt.Sprintf("视频%s弹幕抓取失败: %v", v.BVID, danmakuErr))
+			}
+			if len(danmaku) > 0 {
+				result.Comments[v.BVID] = append(result.Comments[v.BVID], danmaku...)
+				result.Stats.TotalDanmaku += len(danmaku)
+			}
+
 			completedCount++
 
+			// 风控重试用尽：记录为受限视频，保留已抓取的部分评论
+			if err != nil && errors.Is(err, ErrRiskControl) {
+				result.Stats.Throttled = append(result.Stats.Throttled, v.BVID)
+				result.Stats.Errors = append(result.Stats.Errors,
+					fmt.Sprintf("视频%s因风控仅抓取到%d条评论: %v", v.BVID, len(comments), err))
+				log.Printf("[Scraper] Throttled video %d/%d: %s, kept %d comments", completedCount, len(videos), v.BVID, len(comments))
+				if len(comments) > 0 {
+					err = nil
+				}
+			}
+
 			if err != nil {
 				result.Stats.Errors = append(result.Stats.Errors,
 					fmt.Sprintf("视频%s抓取失败: %v", v.BVID, err))
@@ -374,7 +451,7 @@ func (s *Scraper) ScrapeByVideos(ctx context.Context, videos []VideoInfo, commen
 				return
 			}
 
-			result.Comments[v.BVID] = comments
+			result.Comments[v.BVID] = append(comments, result.Comments[v.BVID]...)
 
 			commentCount := len(comments)
 			replyCount := 0
@@ -389,12 +466,15 @@ func (s *Scraper) ScrapeByVideos(ctx context.Context, videos []VideoInfo, commen
 			s.reportProgress("scraping", completedCount, len(videos),
 				fmt.Sprintf("已完成 %d/%d，共%d条评论", completedCount, len(videos), result.Stats.TotalComments))
 		}(video)
-
-		time.Sleep(s.config.RequestDelay)
 	}
 
 	wg.Wait()
 
+	// 任务被取消时丢弃不完整的抓取结果
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	log.Printf("[Scraper] Returning result with %d videos", len(result.Videos))
 
 	result.Stats.Duration = time.Since(startTime)
@@ -45,6 +45,12 @@ type VideoInfo struct {
 	Pic         string `json:"pic"`          // 封面图URL
 	Description string `json:"description"`  // 视频简介
 	Pubdate     int64  `json:"pubdate"`      // 发布时间戳
+
+	// 视频详情和商单检测结果（搜索结果不包含，由任务检测后填充）
+	CID            int64    `json:"cid,omitempty"`             // 第一个分P的cid（来自视频详情接口，抓取弹幕时复用）
+	Commercial     bool     `json:"commercial,omitempty"`      // B站标注了商业推广声明（来自视频详情接口）
+	Sponsored      bool     `json:"sponsored,omitempty"`       // 是否判定为商单视频
+	SponsorSignals []string `json:"sponsor_signals,omitempty"` // 判定依据
 }
 
 // SearchVideos 搜索B站视频
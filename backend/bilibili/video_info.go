@@ -1,9 +1,11 @@
 package bilibili
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 )
 
@@ -12,6 +14,8 @@ import (
 // 注意：与 search.go 中的 VideoInfo 不同，这个是详细信息版本
 type VideoDetail struct {
 	BVID         string `json:"bvid"`          // 视频BV号，如 BV1mH4y1u7UA
+	AID          int64  `json:"aid"`           // 视频AV号
+	CID          int64  `json:"cid"`           // 第一个分P的cid（用于获取弹幕）
 	Title        string `json:"title"`         // 视频标题
 	Author       string `json:"author"`        // UP主名称
 	PlayCount    int    `json:"play_count"`    // 播放量
@@ -19,6 +23,7 @@ type VideoDetail struct {
 	PubDate      string `json:"pub_date"`      // 发布时间（格式化后的字符串）
 	Cover        string `json:"cover"`         // 视频封面图URL
 	Description  string `json:"description"`   // 视频简介
+	Commercial   bool   `json:"commercial"`    // B站是否标注了商业推广声明
 }
 
 // videoDetailAPIResponse B站视频信息API的响应结构
@@ -28,6 +33,7 @@ type videoDetailAPIResponse struct {
 	Data    struct {
 		BVID  string `json:"bvid"`  // 视频BV号
 		AID   int64  `json:"aid"`   // 视频AV号
+		CID   int64  `json:"cid"`   // 第一个分P的cid
 		Title string `json:"title"` // 视频标题
 		Desc  string `json:"desc"`  // 视频简介
 		Owner struct {
@@ -39,11 +45,27 @@ type videoDetailAPIResponse struct {
 			Danmaku int `json:"danmaku"` // 弹幕数
 			Reply   int `json:"reply"`   // 评论数
 		} `json:"stat"`
-		PubDate int64  `json:"pubdate"` // 发布时间戳（秒）
-		Pic     string `json:"pic"`     // 封面图URL
+		PubDate   int64  `json:"pubdate"` // 发布时间戳（秒）
+		Pic       string `json:"pic"`     // 封面图URL
+		ArgueInfo struct {
+			ArgueMsg string `json:"argue_msg"` // 视频页顶部的声明（如商业推广、AI合成等提示）
+		} `json:"argue_info"`
 	} `json:"data"`
 }
 
+// commercialArgueCues 视频页声明中表示商业推广的表述
+var commercialArgueCues = []string{"商业推广", "商业合作", "广告"}
+
+// isCommercialArgue 视频页声明是否为商业推广声明
+func isCommercialArgue(msg string) bool {
+	for _, cue := range commercialArgueCues {
+		if strings.Contains(msg, cue) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetVideoInfo 获取B站视频详细信息
 // 参数：
 //   - bvid: 视频的BV号，如 "BV1mH4y1u7UA"
@@ -60,11 +82,16 @@ type videoDetailAPIResponse struct {
 //	}
 //	fmt.Printf("标题: %s, UP主: %s, 播放量: %d\n", info.Title, info.Author, info.PlayCount)
 func (c *Client) GetVideoInfo(bvid string) (*VideoDetail, error) {
+	return c.GetVideoInfoWithContext(context.Background(), bvid)
+}
+
+// GetVideoInfoWithContext 获取B站视频详细信息，ctx 取消时中止请求
+func (c *Client) GetVideoInfoWithContext(ctx context.Context, bvid string) (*VideoDetail, error) {
 	// 构建视频信息API URL（该API不需要WBI签名）
 	u := fmt.Sprintf("https://api.bilibili.com/x/web-interface/view?bvid=%s", bvid)
 
 	// 发送GET请求（不需要WBI签名）
-	resp, err := c.Get(u, false)
+	resp, err := c.GetWithContext(ctx, u, false)
 	if err != nil {
 		return nil, fmt.Errorf("获取视频信息失败: %w", err)
 	}
@@ -98,6 +125,8 @@ func (c *Client) GetVideoInfo(bvid string) (*VideoDetail, error) {
 	// 构建并返回 VideoDetail 结构体
 	return &VideoDetail{
 		BVID:         apiResp.Data.BVID,
+		AID:          apiResp.Data.AID,
+		CID:          apiResp.Data.CID,
 		Title:        apiResp.Data.Title,
 		Author:       apiResp.Data.Owner.Name,
 		PlayCount:    apiResp.Data.Stat.View,
@@ -105,5 +134,6 @@ func (c *Client) GetVideoInfo(bvid string) (*VideoDetail, error) {
 		PubDate:      pubDate,
 		Cover:        apiResp.Data.Pic,
 		Description:  apiResp.Data.Desc,
+		Commercial:   isCommercialArgue(apiResp.Data.ArgueInfo.ArgueMsg),
 	}, nil
 }
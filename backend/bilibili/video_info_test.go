@@ -1,6 +1,7 @@
 package bilibili
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"strings"
@@ -71,6 +72,27 @@ func TestGetVideoInfo_Success(t *testing.T) {
 	}
 }
 
+func TestGetVideoInfo_Commercial(t *testing.T) {
+	client := &Client{
+		httpClient: &http.Client{
+			Transport: &mockTransport{
+				responseBody: `{"code": 0, "data": {"bvid": "BV1xx", "title": "新品体验", "argue_info": {"argue_msg": "该视频含有商业推广内容", "argue_type": 0}}}`,
+			},
+		},
+	}
+
+	info, err := client.GetVideoInfo("BV1xx")
+	if err != nil {
+		t.Fatalf("GetVideoInfo() error = %v", err)
+	}
+	if !info.Commercial {
+		t.Error("Commercial = false, want true for commercial argue message")
+	}
+	if isCommercialArgue("视频内含有AI合成内容") {
+		t.Error("non-commercial argue message should not be treated as commercial")
+	}
+}
+
 func TestGetVideoInfo_NotFound(t *testing.T) {
 	client := &Client{
 		httpClient: &http.Client{
@@ -100,3 +122,12 @@ func TestGetVideoInfo_APIError(t *testing.T) {
 		t.Fatal("GetVideoInfo() expected error for API error")
 	}
 }
+
+func TestGetVideoInfoWithContext_Cancelled(t *testing.T) {
+	client := &Client{httpClient: &http.Client{Transport: &mockTransport{responseBody: `{"code": 0, "data": {"bvid": "BV1xx"}}`}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.GetVideoInfoWithContext(ctx, "BV1xx"); err == nil {
+		t.Error("expected error for cancelled context")
+	}
+}
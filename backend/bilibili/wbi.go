@@ -80,7 +80,7 @@ func (wk *WbiKeys) update(purge bool) error {
 
 	// 从B站nav接口获取密钥
 	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get("https://api.bilibili.com/x/web-interface/nav")
+	resp, err := client.Get(navAPI)
 	if err != nil {
 		return err
 	}
@@ -164,7 +164,10 @@ func removeUnwantedChars(v url.Values, chars ...byte) (url.Values, error) {
 type Nav struct {
 	Code int `json:"code"`
 	Data struct {
-		WbiImg struct {
+		IsLogin bool   `json:"isLogin"` // 是否已登录
+		Uname   string `json:"uname"`   // 用户名（仅登录时返回）
+		Mid     int64  `json:"mid"`     // 用户ID（仅登录时返回）
+		WbiImg  struct {
 			ImgUrl string `json:"img_url"` // img_key URL
 			SubUrl string `json:"sub_url"` // sub_key URL
 		} `json:"wbi_img"`
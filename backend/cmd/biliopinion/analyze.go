@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bilibili-analyzer/backend/api"
+	"bilibili-analyzer/backend/sse"
+	"bilibili-analyzer/backend/task"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// bvidPattern 单独的BV号（video 命令也接受不带链接的BV号）
+var bvidPattern = regexp.MustCompile(`^BV[a-zA-Z0-9]{10}$`)
+
+// runParse 解析需求描述
+// 默认输出 ConfirmRequest 格式的 JSON，可保存后修改再交给 run -f 执行
+func runParse(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("parse", flag.ContinueOnError)
+	raw := fs.Bool("raw", false, "输出AI解析的完整结果（含需求理解、预算、场景等）")
+	output := fs.String("o", "", "输出文件（默认标准输出）")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: biliopinion parse [-raw] [-o 文件] <需求描述>")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	requirement := strings.TrimSpace(strings.Join(positional, " "))
+	if requirement == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	result, err := api.ParseRequirement(ctx, requirement)
+	if err != nil {
+		return err
+	}
+	if result.Understanding != "" {
+		fmt.Fprintln(os.Stderr, result.Understanding)
+	}
+
+	var v any = result
+	if !*raw {
+		req := api.ConfirmRequest{
+			Requirement: requirement,
+			Brands:      result.Brands,
+			Keywords:    result.Keywords,
+		}
+		for _, d := range result.Dimensions {
+			req.Dimensions = append(req.Dimensions, api.ConfirmDimension{Name: d.Name, Description: d.Description})
+		}
+		v = req
+	}
+	return writeJSON(*output, v)
+}
+
+// runAnalyze 执行类目分析
+// 请求可以从 JSON 文件读取（格式与 /api/confirm 请求体相同），命令行参数覆盖文件中的字段
+func runAnalyze(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	file := fs.String("f", "", "请求 JSON 文件（- 表示标准输入），格式与 /api/confirm 请求体相同")
+	autoParse := fs.Bool("parse", false, "先用AI解析需求描述，补全未指定的品牌、维度和关键词")
+	requirement := fs.String("requirement", "", "需求描述")
+	brands := fs.String("brands", "", "品牌列表（逗号分隔）")
+	keywords := fs.String("keywords", "", "搜索关键词（逗号分隔）")
+	var dimensions stringList
+	fs.Var(&dimensions, "dimension", "评价维度，格式为 名称 或 名称=描述（可重复）")
+	videoMonths := fs.Int("video-months", 0, "视频时间范围（月），0表示不限制")
+	minDuration := fs.Int("min-duration", 0, "最小视频时长（秒），0表示不过滤")
+	maxComments := fs.Int("max-comments", 0, "最大分析评论数（默认500）")
+	minVideoComments := fs.Int("min-video-comments", 0, "最小视频评论数过滤")
+	minPerVideo := fs.Int("min-per-video", 0, "每视频最少抓取数（默认10）")
+	maxPerVideo := fs.Int("max-per-video", 0, "每视频最多抓取数（默认200）")
+	noCache := fs.Bool("no-cache", false, "跳过AI响应缓存")
+	tokenBudget := fs.Int64("token-budget", 0, "Token 预算（0 表示使用全局配置）")
+	danmaku := fs.Bool("danmaku", false, "同时抓取视频弹幕参与分析")
+	var out exportTarget
+	out.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: biliopinion run [-f 请求.json] [参数]")
+		fmt.Fprintln(fs.Output(), "完成后在标准输出打印报告ID；指定 -o 时同时导出报告")
+		fs.PrintDefaults()
+	}
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+
+	var req api.ConfirmRequest
+	if *file != "" {
+		if err := readJSON(*file, &req); err != nil {
+			return fmt.Errorf("读取请求文件失败: %w", err)
+		}
+	}
+
+	if err := out.validate(); err != nil {
+		return err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "requirement":
+			req.Requirement = strings.TrimSpace(*requirement)
+		case "brands":
+			req.Brands = splitList(*brands)
+		case "keywords":
+			req.Keywords = splitList(*keywords)
+		case "dimension":
+			req.Dimensions = parseDimensions(dimensions)
+		case "video-months":
+			req.VideoDateRangeMonths = *videoMonths
+		case "min-duration":
+			req.MinVideoDuration = *minDuration
+		case "max-comments":
+			req.MaxComments = *maxComments
+		case "min-video-comments":
+			req.MinVideoComments = *minVideoComments
+		case "min-per-video":
+			req.MinCommentsPerVideo = *minPerVideo
+		case "max-per-video":
+			req.MaxCommentsPerVideoV2 = *maxPerVideo
+		case "no-cache":
+			req.NoCache = *noCache
+		case "token-budget":
+			req.TokenBudget = *tokenBudget
+		case "danmaku":
+			req.IncludeDanmaku = *danmaku
+		}
+	})
+
+	if *autoParse && req.Requirement != "" && (len(req.Brands) == 0 || len(req.Dimensions) == 0 || len(req.Keywords) == 0) {
+		fmt.Fprintln(os.Stderr, "正在解析需求...")
+		parsed, err := api.ParseRequirement(ctx, req.Requirement)
+		if err != nil {
+			return err
+		}
+		if len(req.Brands) == 0 {
+			req.Brands = parsed.Brands
+		}
+		if len(req.Keywords) == 0 {
+			req.Keywords = parsed.Keywords
+		}
+		if len(req.Dimensions) == 0 {
+			for _, d := range parsed.Dimensions {
+				req.Dimensions = append(req.Dimensions, api.ConfirmDimension{Name: d.Name, Description: d.Description})
+			}
+		}
+	}
+	if msg := req.Validate(); msg != "" {
+		return errors.New(msg)
+	}
+
+	taskID := uuid.New().String()
+	watcher := watchTask(taskID)
+	taskReq, config := req.ToTask(taskID)
+
+	// 与队列执行的任务一样登记，Ctrl+C 时取消上下文，执行器会把任务标记为已取消
+	taskCtx, done := task.Register(ctx, taskID)
+	defer done()
+	fmt.Fprintf(os.Stderr, "任务 %s 开始执行\n", taskID)
+	execErr := task.NewExecutor(config).Execute(taskCtx, taskReq)
+
+	reportID, err := watcher.result()
+	if err != nil {
+		return err
+	}
+	if execErr != nil {
+		return execErr
+	}
+	return finishTask(reportID, &out)
+}
+
+// runVideo 分析单个视频的评论
+func runVideo(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("video", flag.ContinueOnError)
+	maxComments := fs.Int("max-comments", 0, "最大分析评论数（默认1000）")
+	var dimensions stringList
+	fs.Var(&dimensions, "dimension", "评价维度，格式为 名称 或 名称=描述（可重复，默认使用通用维度）")
+	noCache := fs.Bool("no-cache", false, "跳过AI响应缓存")
+	tokenBudget := fs.Int64("token-budget", 0, "Token 预算（0 表示使用全局配置）")
+	danmaku := fs.Bool("danmaku", false, "同时抓取视频弹幕参与分析")
+	var out exportTarget
+	out.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: biliopinion video [参数] <视频链接或BV号>")
+		fmt.Fprintln(fs.Output(), "完成后在标准输出打印报告ID；指定 -o 时同时导出报告")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	if err := out.validate(); err != nil {
+		return err
+	}
+
+	videoURL := positional[0]
+	if bvidPattern.MatchString(videoURL) {
+		videoURL = "https://www.bilibili.com/video/" + videoURL
+	}
+	req := api.VideoAnalyzeRequest{
+		VideoURL:       videoURL,
+		MaxComments:    *maxComments,
+		NoCache:        *noCache,
+		TokenBudget:    *tokenBudget,
+		IncludeDanmaku: *danmaku,
+	}
+	for _, d := range parseDimensions(dimensions) {
+		req.Dimensions = append(req.Dimensions, struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		}{d.Name, d.Description})
+	}
+
+	taskID := uuid.New().String()
+	taskReq, config, err := req.ToTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	watcher := watchTask(taskID)
+
+	// 与搜索分析一样登记，Ctrl+C 时取消上下文，执行器会把任务标记为已取消
+	taskCtx, done := task.Register(ctx, taskID)
+	defer done()
+	fmt.Fprintf(os.Stderr, "任务 %s 开始执行\n", taskID)
+	execErr := task.NewExecutor(config).Execute(taskCtx, taskReq)
+
+	reportID, err := watcher.result()
+	if err != nil {
+		return err
+	}
+	if execErr != nil {
+		return execErr
+	}
+	return finishTask(reportID, &out)
+}
+
+// finishTask 打印报告ID，并按需导出报告
+func finishTask(reportID uint, out *exportTarget) error {
+	fmt.Println(reportID)
+	if out.path == "" {
+		return nil
+	}
+	if err := exportReport(reportID, out.format, out.path); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "报告已导出到 %s\n", out.path)
+	return nil
+}
+
+// taskWatcher 监听任务状态，驱动进度条并记录最终状态
+type taskWatcher struct {
+	progress *progressPrinter
+	final    chan sse.TaskStatus
+}
+
+// watchTask 注册任务状态监听（执行器通过 sse.PushStatus 推送进度，与 Web 界面使用同一套进度回调）
+func watchTask(taskID string) *taskWatcher {
+	w := &taskWatcher{
+		progress: newProgressPrinter(os.Stderr),
+		final:    make(chan sse.TaskStatus, 1),
+	}
+	sse.AddListener(func(status sse.TaskStatus) {
+		if status.TaskID != taskID {
+			return
+		}
+		w.progress.update(status)
+		switch status.Status {
+		case sse.StatusCompleted, sse.StatusError, sse.StatusCancelled:
+			select {
+			case w.final <- status:
+			default:
+			}
+		}
+	})
+	return w
+}
+
+// result 返回任务的报告ID（任务同步执行，调用时已经结束）
+func (w *taskWatcher) result() (uint, error) {
+	var status sse.TaskStatus
+	select {
+	case status = <-w.final:
+	default:
+		return 0, errors.New("任务未正常结束")
+	}
+
+	switch status.Status {
+	case sse.StatusCompleted:
+		// 完成状态通过 Progress.Stage 传递报告ID
+		if status.Progress != nil {
+			if id, err := strconv.ParseUint(status.Progress.Stage, 10, 32); err == nil && id > 0 {
+				return uint(id), nil
+			}
+		}
+		return 0, errors.New("任务已完成但没有返回报告ID")
+	case sse.StatusCancelled:
+		return 0, errors.New("任务已取消")
+	default:
+		if status.Error != "" {
+			return 0, errors.New(status.Error)
+		}
+		return 0, errors.New(status.Message)
+	}
+}
+
+// stringList 可重复的字符串参数
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ", ") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// splitList 按中英文逗号拆分列表，忽略空项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '，' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseDimensions 解析 名称=描述 格式的维度参数
+func parseDimensions(values []string) []api.ConfirmDimension {
+	dimensions := make([]api.ConfirmDimension, 0, len(values))
+	for _, v := range values {
+		name, desc, _ := strings.Cut(v, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		dimensions = append(dimensions, api.ConfirmDimension{Name: name, Description: strings.TrimSpace(desc)})
+	}
+	return dimensions
+}
+
+// readJSON 读取 JSON 文件（- 表示标准输入）
+func readJSON(path string, v any) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	return json.NewDecoder(r).Decode(v)
+}
+
+// writeJSON 输出带缩进的 JSON（path 为空时写到标准输出）
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/report"
+	"bilibili-analyzer/backend/task"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// configKeys 可通过 config set 修改的配置项（与设置页面相同）
+var configKeys = []struct {
+	key     string
+	summary string
+}{
+	{models.SettingKeyAIProvider, "AI服务提供方：openai/anthropic/gemini/ollama"},
+	{models.SettingKeyAIAPIBase, "AI API Base URL"},
+	{models.SettingKeyAIAPIKey, "AI API Key"},
+	{models.SettingKeyAIModel, "模型名称"},
+	{models.SettingKeyBilibiliCookie, "B站Cookie"},
+	{models.SettingKeyScrapeMaxConcurrency, "抓取并发数"},
+	{models.SettingKeyAIMaxConcurrency, "AI并发数"},
+	{models.SettingKeyQueueWorkers, "任务队列同时执行的任务数"},
+	{models.SettingKeyQueueOrder, "任务队列排序方式：fifo/priority"},
+	{models.SettingKeyAICacheTTLHours, "AI响应缓存有效期（小时），0表示关闭缓存"},
+	{models.SettingKeyAIPriceTable, "模型价格表JSON"},
+	{models.SettingKeyAITokenBudget, "单个任务默认Token预算，0表示不限制"},
+	{models.SettingKeyBilibiliRateLimit, "B站请求速率（每秒请求数）"},
+	{models.SettingKeyCommentWeighting, "评论权重模型JSON，为空时使用默认模型"},
+	{models.SettingKeySponsoredVideoMode, "商单视频处理方式：off/tag/exclude，为空时不识别商单"},
+	{models.SettingKeySuspiciousMode, "可疑评论处理方式：off/tag/exclude"},
+}
+
+// integerConfigKeys 值必须是非负整数的配置项（空字符串表示使用默认值）
+var integerConfigKeys = []string{
+	models.SettingKeyScrapeMaxConcurrency,
+	models.SettingKeyAIMaxConcurrency,
+	models.SettingKeyQueueWorkers,
+	models.SettingKeyAICacheTTLHours,
+	models.SettingKeyAITokenBudget,
+}
+
+// runConfig 修改配置
+func runConfig(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintln(out, "用法: biliopinion config set <键> <值>")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "配置项:")
+		for _, k := range configKeys {
+			fmt.Fprintf(out, "  %-24s %s\n", k.key, k.summary)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 3 || fs.Arg(0) != "set" {
+		fs.Usage()
+		return errUsage
+	}
+
+	key, value := fs.Arg(1), fs.Arg(2)
+	if !slices.ContainsFunc(configKeys, func(k struct{ key, summary string }) bool { return k.key == key }) {
+		fs.Usage()
+		return fmt.Errorf("未知配置项: %s", key)
+	}
+	if err := validateConfigValue(key, value); err != nil {
+		return err
+	}
+	if err := database.SaveSetting(key, value); err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "已保存 %s\n", key)
+	return nil
+}
+
+// validateConfigValue 校验配置值（规则与设置页面保存时相同，另外检查数值类配置）
+func validateConfigValue(key, value string) error {
+	switch {
+	case key == models.SettingKeyAIProvider && value != "":
+		if _, err := ai.NewProvider(value, "", ""); err != nil {
+			return err
+		}
+	case key == models.SettingKeyAIPriceTable:
+		if _, err := ai.ParsePriceTable(value); err != nil {
+			return fmt.Errorf("模型价格表格式错误: %w", err)
+		}
+	case key == models.SettingKeyCommentWeighting:
+		if _, err := report.ParseCommentWeighting(value); err != nil {
+			return fmt.Errorf("评论权重配置错误: %w", err)
+		}
+	case key == models.SettingKeySponsoredVideoMode:
+		if _, err := task.ParseSponsoredMode(value); err != nil {
+			return fmt.Errorf("%s %w", key, err)
+		}
+	case key == models.SettingKeySuspiciousMode:
+		if _, err := task.ParseSuspiciousMode(value); err != nil {
+			return fmt.Errorf("%s %w", key, err)
+		}
+	case key == models.SettingKeyQueueOrder && value != "":
+		if value != "fifo" && value != "priority" {
+			return fmt.Errorf("%s 只能是 fifo 或 priority", key)
+		}
+	case key == models.SettingKeyBilibiliRateLimit && value != "":
+		if v, err := strconv.ParseFloat(value, 64); err != nil || v <= 0 {
+			return fmt.Errorf("%s 必须是正数", key)
+		}
+	case slices.Contains(integerConfigKeys, key) && value != "":
+		if v, err := strconv.Atoi(value); err != nil || v < 0 {
+			return fmt.Errorf("%s 必须是非负整数", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// biliopinion 命令行工具
+// 不启动 Web 服务，直接调用任务执行器完成解析、分析和报告导出，便于在 CI 或 cron 中批量运行
+//
+// 用法：
+//
+//	biliopinion [-db 数据库路径] [-v] <命令> [参数]
+//
+// 命令：
+//
+//	parse    解析需求描述，输出可直接用于 run 的请求 JSON
+//	run      执行类目分析（参数与 /api/confirm 请求体相同）
+//	video    分析单个视频的评论
+//	history  查看分析历史
+//	export   导出报告（pdf/xlsx/csv/md/html/json）
+//	config   修改配置（config set <键> <值>）
+package main
+
+import (
+	"bilibili-analyzer/backend/database"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"gorm.io/gorm/logger"
+)
+
+// defaultDBPath 默认数据库路径（与 Web 服务相同，在项目根目录下运行时共用同一个数据库）
+const defaultDBPath = "data/bilibili-analyzer.db"
+
+// errUsage 参数错误（已输出用法说明，退出码为2）
+var errUsage = errors.New("usage")
+
+// command 子命令
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"parse", "解析需求描述，输出可直接用于 run 的请求 JSON", runParse},
+	{"run", "执行类目分析（参数与 /api/confirm 请求体相同）", runAnalyze},
+	{"video", "分析单个视频的评论", runVideo},
+	{"history", "查看分析历史", runHistory},
+	{"export", "导出报告（pdf/xlsx/csv/md/html/json）", runExport},
+	{"config", "修改配置（config set <键> <值>）", runConfig},
+}
+
+func main() {
+	global := flag.NewFlagSet("biliopinion", flag.ContinueOnError)
+	dbPath := global.String("db", envOr("BILIOPINION_DB", defaultDBPath), "SQLite 数据库路径（也可通过环境变量 BILIOPINION_DB 指定）")
+	verbose := global.Bool("v", false, "输出详细日志")
+	global.Usage = func() { printUsage(global) }
+	if err := global.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+
+	args := global.Args()
+	if len(args) == 0 {
+		printUsage(global)
+		os.Exit(2)
+	}
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", args[0])
+		printUsage(global)
+		os.Exit(2)
+	}
+
+	// 任务执行过程中的日志较多，默认关闭，避免打乱进度条和标准输出
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	if err := database.InitDB(*dbPath); err != nil {
+		fmt.Fprintf(os.Stderr, "初始化数据库失败: %v\n", err)
+		os.Exit(1)
+	}
+	if !*verbose {
+		database.DB.Logger = logger.Default.LogMode(logger.Silent)
+	}
+
+	// Ctrl+C 取消正在执行的任务（与 /api/task/:id/cancel 效果相同）
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd.run(ctx, args[1:]); err != nil {
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			stop()
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		stop()
+		os.Exit(1)
+	}
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func printUsage(global *flag.FlagSet) {
+	out := global.Output()
+	fmt.Fprintln(out, "用法: biliopinion [-db 数据库路径] [-v] <命令> [参数]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "命令:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "全局参数:")
+	global.PrintDefaults()
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "使用 biliopinion <命令> -h 查看命令参数")
+}
+
+// parseInterspersed 解析参数，允许参数和位置参数混合出现（如 export 12 -format md）
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestSplitList(t *testing.T) {
+	got := splitList(" 戴森, 小米，追觅,, ")
+	want := []string{"戴森", "小米", "追觅"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitList() = %v, want %v", got, want)
+	}
+	if got := splitList(""); len(got) != 0 {
+		t.Errorf("splitList(\"\") = %v, want empty", got)
+	}
+}
+
+func TestParseDimensions(t *testing.T) {
+	got := parseDimensions([]string{"吸力=吸力大小和清洁效果", " 噪音 ", "=无名称", "续航=电池=时长"})
+	if len(got) != 3 {
+		t.Fatalf("expected 3 dimensions, got %+v", got)
+	}
+	if got[0].Name != "吸力" || got[0].Description != "吸力大小和清洁效果" {
+		t.Errorf("unexpected first dimension: %+v", got[0])
+	}
+	if got[1].Name != "噪音" || got[1].Description != "" {
+		t.Errorf("unexpected second dimension: %+v", got[1])
+	}
+	if got[2].Name != "续航" || got[2].Description != "电池=时长" {
+		t.Errorf("description should keep everything after the first '=': %+v", got[2])
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "pdf", "")
+	output := fs.String("o", "", "")
+	positional, err := parseInterspersed(fs, []string{"12", "-format", "md", "-o", "out.md"})
+	if err != nil {
+		t.Fatalf("parseInterspersed() error = %v", err)
+	}
+	if !reflect.DeepEqual(positional, []string{"12"}) || *format != "md" || *output != "out.md" {
+		t.Errorf("got positional=%v format=%s o=%s", positional, *format, *output)
+	}
+}
+
+func TestExportTargetValidate(t *testing.T) {
+	target := exportTarget{format: " MD "}
+	if err := target.validate(); err != nil || target.format != "md" {
+		t.Errorf("validate() = %v, format = %q", err, target.format)
+	}
+	target = exportTarget{format: "docx"}
+	if err := target.validate(); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bilibili-analyzer/backend/sse"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// progressBarWidth 进度条宽度（字符数）
+const progressBarWidth = 30
+
+// stageLabels 任务状态的中文名称
+var stageLabels = map[string]string{
+	sse.StatusQueued:     "排队中",
+	sse.StatusParsing:    "解析",
+	sse.StatusSearching:  "搜索视频",
+	sse.StatusScraping:   "抓取评论",
+	sse.StatusAnalyzing:  "AI分析",
+	sse.StatusGenerating: "生成报告",
+	sse.StatusThrottled:  "风控暂停",
+	sse.StatusCompleted:  "完成",
+	sse.StatusError:      "失败",
+	sse.StatusCancelled:  "已取消",
+}
+
+// progressPrinter 在终端上绘制进度条
+// 输出不是终端时（如 CI 日志）改为状态或消息变化时输出一行，不使用回车覆盖
+type progressPrinter struct {
+	mu       sync.Mutex
+	out      io.Writer
+	tty      bool
+	percent  int
+	lastLine string
+}
+
+func newProgressPrinter(f *os.File) *progressPrinter {
+	return &progressPrinter{out: f, tty: isTerminal(f)}
+}
+
+// update 根据任务状态刷新进度
+func (p *progressPrinter) update(status sse.TaskStatus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// 限流状态沿用上一次的进度；排队状态的 Current/Total 是排队位置，不是进度
+	if status.Progress != nil && status.Progress.Total > 0 &&
+		status.Status != sse.StatusQueued && status.Status != sse.StatusThrottled {
+		p.percent = min(100, status.Progress.Current*100/status.Progress.Total)
+	}
+	if status.Status == sse.StatusCompleted {
+		p.percent = 100
+	}
+
+	label := stageLabels[status.Status]
+	if label == "" {
+		label = status.Status
+	}
+	message := status.Message
+	if status.Error != "" {
+		message = status.Error
+	}
+	terminal := status.Status == sse.StatusCompleted || status.Status == sse.StatusError || status.Status == sse.StatusCancelled
+
+	if !p.tty {
+		line := fmt.Sprintf("[%3d%%] %s %s", p.percent, label, message)
+		if line != p.lastLine {
+			fmt.Fprintln(p.out, line)
+			p.lastLine = line
+		}
+		return
+	}
+
+	filled := p.percent * progressBarWidth / 100
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+	// \033[K 清除上一次输出残留的字符
+	fmt.Fprintf(p.out, "\r[%s] %3d%% %s %s\033[K", bar, p.percent, label, message)
+	if terminal {
+		fmt.Fprintln(p.out)
+	}
+}
+
+// isTerminal 判断文件是否是终端
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
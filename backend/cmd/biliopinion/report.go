@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/export"
+	"bilibili-analyzer/backend/models"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// exportTarget 导出参数（export 命令以及 run/video 完成后导出共用）
+type exportTarget struct {
+	format string
+	path   string
+}
+
+func (t *exportTarget) register(fs *flag.FlagSet) {
+	fs.StringVar(&t.format, "format", "pdf", "导出格式："+strings.Join(export.Formats(), "/"))
+	fs.StringVar(&t.path, "o", "", "导出文件路径")
+}
+
+func (t *exportTarget) validate() error {
+	writer, err := export.Get(t.format)
+	if err != nil {
+		return err
+	}
+	t.format = writer.Format()
+	return nil
+}
+
+// runHistory 查看分析历史
+func runHistory(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "显示条数（0 表示全部）")
+	status := fs.String("status", "", "按状态筛选（pending/processing/completed/failed/cancelled）")
+	asJSON := fs.Bool("json", false, "以 JSON 输出")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: biliopinion history [-limit N] [-status 状态] [-json]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	query := database.DB.Order("created_at DESC")
+	if *status != "" {
+		query = query.Where("status = ?", *status)
+	}
+	if *limit > 0 {
+		query = query.Limit(*limit)
+	}
+	var histories []models.AnalysisHistory
+	if err := query.Find(&histories).Error; err != nil {
+		return fmt.Errorf("查询历史记录失败: %w", err)
+	}
+
+	if *asJSON {
+		type historyItem struct {
+			ID           uint    `json:"id"`
+			TaskID       string  `json:"task_id"`
+			Category     string  `json:"category"`
+			Status       string  `json:"status"`
+			VideoCount   int     `json:"video_count"`
+			CommentCount int     `json:"comment_count"`
+			ReportID     uint    `json:"report_id"`
+			TotalTokens  int64   `json:"total_tokens"`
+			Cost         float64 `json:"cost"`
+			CreatedAt    string  `json:"created_at"`
+		}
+		items := make([]historyItem, 0, len(histories))
+		for _, h := range histories {
+			items = append(items, historyItem{
+				ID:           h.ID,
+				TaskID:       h.TaskID,
+				Category:     h.Category,
+				Status:       h.Status,
+				VideoCount:   h.VideoCount,
+				CommentCount: h.CommentCount,
+				ReportID:     h.ReportID,
+				TotalTokens:  h.TotalTokens,
+				Cost:         h.Cost,
+				CreatedAt:    h.CreatedAt.Format("2006-01-02 15:04:05"),
+			})
+		}
+		return writeJSON("", items)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\t状态\t类目\t视频\t评论\t报告ID\t创建时间")
+	for _, h := range histories {
+		reportID := "-"
+		if h.ReportID > 0 {
+			reportID = strconv.FormatUint(uint64(h.ReportID), 10)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%s\t%s\n",
+			h.ID, h.Status, h.Category, h.VideoCount, h.CommentCount, reportID, h.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+// runExport 导出报告
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	var out exportTarget
+	out.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: biliopinion export [-format %s] [-o 文件] <报告ID>\n", strings.Join(export.Formats(), "|"))
+		fmt.Fprintln(fs.Output(), "未指定 -o 时 PDF 和 Excel 保存为 report_<ID>.pdf/xlsx，其他格式输出到标准输出")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	id, err := strconv.ParseUint(positional[0], 10, 32)
+	if err != nil {
+		return fmt.Errorf("报告ID无效: %s", positional[0])
+	}
+	if err := out.validate(); err != nil {
+		return err
+	}
+
+	path := out.path
+	if path == "" && (out.format == "pdf" || out.format == "xlsx") {
+		path = fmt.Sprintf("report_%d.%s", id, out.format)
+	}
+	if err := exportReport(uint(id), out.format, path); err != nil {
+		return err
+	}
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "报告已导出到 %s\n", path)
+	}
+	return nil
+}
+
+// exportReport 按格式导出报告（path 为空时写到标准输出）
+func exportReport(reportID uint, format, path string) error {
+	writer, err := export.Get(format)
+	if err != nil {
+		return err
+	}
+	in, err := export.Load(reportID)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := writer.Write(&buf, in); err != nil {
+		return fmt.Errorf("生成 %s 失败: %w", format, err)
+	}
+	if path == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
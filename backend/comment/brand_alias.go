@@ -0,0 +1,304 @@
+package comment
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BrandEntry 品牌词典中的一个标准品牌
+type BrandEntry struct {
+	Name    string   // 标准名称（报告中展示的名称）
+	Aliases []string // 其他写法：别名、英文名、拼音等
+}
+
+// BrandDictionary 品牌别名词典
+// 把同一品牌的不同写法（如"追觅"/"Dreame"、"米家"/"小米"）归并到标准名称，
+// 只做完整匹配和有边界的前缀匹配，不做子串匹配，避免"mi"之类的短别名误合并其他品牌。
+// 同时记录每个品牌实际归并了哪些原始写法，供报告展示。零值和 nil 均可直接使用（不做别名归并）
+type BrandDictionary struct {
+	names    map[string]string              // 归一化写法 -> 标准名称
+	aliases  map[string][]string            // 标准名称 -> 全部写法（含标准名称本身）
+	prefixes []brandPrefix                  // 可用于前缀匹配的写法（按长度倒序）
+	merged   map[string]map[string]struct{} // 品牌 -> 归并到该品牌的原始写法
+}
+
+// brandPrefix 前缀匹配用的写法
+type brandPrefix struct {
+	text string // 小写写法（保留空格，用于判断英文单词边界）
+	name string // 标准名称
+}
+
+// NewBrandDictionary 根据词条创建词典
+// 多个词条包含相同写法时后面的词条优先（先传通用词条、再传类目词条，类目词条即可覆盖通用词条）
+func NewBrandDictionary(entries []BrandEntry) *BrandDictionary {
+	d := &BrandDictionary{
+		names:   make(map[string]string),
+		aliases: make(map[string][]string),
+	}
+	prefixOwner := make(map[string]int)
+	for _, e := range entries {
+		name := strings.TrimSpace(e.Name)
+		if name == "" {
+			continue
+		}
+		for _, alias := range append([]string{name}, e.Aliases...) {
+			alias = strings.TrimSpace(alias)
+			key := BrandKey(alias)
+			if key == "" {
+				continue
+			}
+			if previous, ok := d.names[key]; ok && previous != name {
+				d.aliases[previous] = slices.DeleteFunc(d.aliases[previous], func(a string) bool { return BrandKey(a) == key })
+			}
+			d.names[key] = name
+			if !containsFold(d.aliases[name], alias) {
+				d.aliases[name] = append(d.aliases[name], alias)
+			}
+			if usableAsPrefix(alias) {
+				text := strings.ToLower(alias)
+				if i, ok := prefixOwner[text]; ok {
+					d.prefixes[i].name = name
+					continue
+				}
+				prefixOwner[text] = len(d.prefixes)
+				d.prefixes = append(d.prefixes, brandPrefix{text: text, name: name})
+			}
+		}
+	}
+	sort.SliceStable(d.prefixes, func(i, j int) bool {
+		return utf8.RuneCountInString(d.prefixes[i].text) > utf8.RuneCountInString(d.prefixes[j].text)
+	})
+	return d
+}
+
+// BrandKey 品牌写法的归一化 key：小写、全角转半角，去掉空格和常见分隔符
+// 例如 "Dreame" / "DREAME" / "dre-ame" 得到相同的 key
+func BrandKey(brand string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(brand) {
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0 // 全角 ASCII 转半角
+		}
+		if unicode.IsSpace(r) || strings.ContainsRune("-_·.'’&+", r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// Lookup 完整匹配词典中的写法，返回标准名称
+func (d *BrandDictionary) Lookup(brand string) (string, bool) {
+	if d == nil {
+		return "", false
+	}
+	name, ok := d.names[BrandKey(brand)]
+	return name, ok
+}
+
+// Aliases 返回标准品牌的全部写法（含标准名称本身）
+func (d *BrandDictionary) Aliases(name string) []string {
+	if d == nil {
+		return nil
+	}
+	return d.aliases[name]
+}
+
+// Resolve 返回品牌的标准名称
+//
+// 规则：
+//   - 空字符串或"未知"原样返回
+//   - 完整匹配词典中的任一写法时返回标准名称
+//   - 包含"/"时，优先返回词典中的品牌，其次是 knownBrands 中的品牌，否则取第一个分段（与 CleanBrandName 相同）
+//   - 以词典中的写法开头（如"戴森V12"、"Dyson V12"）时返回标准名称：
+//     中文写法至少两个字，英文写法至少三个字母且后面不能紧跟字母
+//   - 都不匹配时去除首尾空白，纯字母品牌转大写
+func (d *BrandDictionary) Resolve(brand string, knownBrands []string) string {
+	brand = strings.TrimSpace(brand)
+	if brand == "" || brand == "未知" {
+		return brand
+	}
+
+	if name, ok := d.Lookup(brand); ok {
+		return name
+	}
+	if strings.Contains(brand, "/") {
+		for _, part := range strings.Split(brand, "/") {
+			if name, ok := d.Lookup(part); ok {
+				return name
+			}
+		}
+		brand = CleanBrandName(brand, knownBrands)
+		if name, ok := d.Lookup(brand); ok {
+			return name
+		}
+	}
+	if name, ok := d.matchPrefix(brand); ok {
+		return name
+	}
+	return FormatBrandName(brand)
+}
+
+// matchPrefix 匹配以词典写法开头的品牌字段
+func (d *BrandDictionary) matchPrefix(brand string) (string, bool) {
+	if d == nil {
+		return "", false
+	}
+	lower := strings.ToLower(brand)
+	for _, p := range d.prefixes {
+		if !strings.HasPrefix(lower, p.text) {
+			continue
+		}
+		// 英文写法后面紧跟字母时是另一个单词（如 "mijia" 不是 "mi"）
+		if !isLatin(p.text) || boundaryAfter(lower, len(p.text)) {
+			return p.name, true
+		}
+	}
+	return "", false
+}
+
+// FindInText 在文本中查找候选品牌（任一写法）的提及，返回第一个命中的候选品牌
+// 英文写法要求前后不是字母（"mi" 不会命中 "midea"），中文写法直接按子串查找
+func (d *BrandDictionary) FindInText(text string, candidates []string) string {
+	lower := strings.ToLower(text)
+	for _, candidate := range candidates {
+		spellings := []string{candidate}
+		if name, ok := d.Lookup(candidate); ok {
+			spellings = append(spellings, d.Aliases(name)...)
+		}
+		for _, s := range spellings {
+			if s = strings.ToLower(strings.TrimSpace(s)); s != "" && containsMention(lower, s) {
+				return candidate
+			}
+		}
+	}
+	return ""
+}
+
+// RecordMerge 记录一次归并：原始写法 raw 计入品牌 brand
+// 与品牌名称仅大小写或分隔符不同的写法不记录
+func (d *BrandDictionary) RecordMerge(brand, raw string) {
+	if d == nil {
+		return
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == "未知" || BrandKey(raw) == BrandKey(brand) {
+		return
+	}
+	if d.merged == nil {
+		d.merged = make(map[string]map[string]struct{})
+	}
+	if d.merged[brand] == nil {
+		d.merged[brand] = make(map[string]struct{})
+	}
+	d.merged[brand][raw] = struct{}{}
+}
+
+// Merged 返回指定品牌归并的原始写法（品牌 -> 排序后的写法列表），没有归并记录的品牌不返回
+func (d *BrandDictionary) Merged(brands []string) map[string][]string {
+	if d == nil {
+		return nil
+	}
+	result := make(map[string][]string)
+	for _, brand := range brands {
+		raws := d.merged[brand]
+		if len(raws) == 0 {
+			continue
+		}
+		list := make([]string, 0, len(raws))
+		for raw := range raws {
+			list = append(list, raw)
+		}
+		sort.Strings(list)
+		result[brand] = list
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// FormatBrandName 格式化品牌名称
+// 纯字母品牌转全大写，中文品牌保持原样
+func FormatBrandName(brand string) string {
+	brand = strings.TrimSpace(brand)
+	if brand == "" {
+		return brand
+	}
+	for _, r := range brand {
+		if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')) {
+			return brand
+		}
+	}
+	return strings.ToUpper(brand)
+}
+
+// usableAsPrefix 写法是否足够长，可以用于前缀匹配
+func usableAsPrefix(alias string) bool {
+	if isLatin(alias) {
+		return len(alias) >= 3
+	}
+	return utf8.RuneCountInString(alias) >= 2
+}
+
+// containsMention 文本中是否提及该写法（英文写法要求单词边界）
+func containsMention(text, spelling string) bool {
+	if !isLatin(spelling) {
+		return strings.Contains(text, spelling)
+	}
+	for start := 0; ; {
+		i := strings.Index(text[start:], spelling)
+		if i < 0 {
+			return false
+		}
+		i += start
+		if boundaryBefore(text, i) && boundaryAfter(text, i+len(spelling)) {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+// boundaryBefore 位置 i 之前不是英文字母
+func boundaryBefore(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return !isLatinLetter(r)
+}
+
+// boundaryAfter 位置 i 起不是英文字母
+func boundaryAfter(s string, i int) bool {
+	if i >= len(s) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return !isLatinLetter(r)
+}
+
+// isLatin 写法是否只由 ASCII 字符组成
+func isLatin(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func isLatinLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
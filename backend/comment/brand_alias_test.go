@@ -0,0 +1,117 @@
+package comment
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testBrandDictionary() *BrandDictionary {
+	return NewBrandDictionary([]BrandEntry{
+		{Name: "小米", Aliases: []string{"Xiaomi", "MI", "米家", "Mijia"}},
+		{Name: "追觅", Aliases: []string{"Dreame", "zhuimi"}},
+		{Name: "美的", Aliases: []string{"Midea"}},
+		{Name: "戴森", Aliases: []string{"Dyson"}},
+	})
+}
+
+func TestBrandDictionary_ResolveAlias(t *testing.T) {
+	d := testBrandDictionary()
+	cases := map[string]string{
+		"Dreame": "追觅",
+		"DREAME": "追觅",
+		"ｄｒｅａｍｅ": "追觅",
+		"米家":     "小米",
+		"mi":     "小米",
+		"Midea":  "美的",
+		"未知":     "未知",
+		"":       "",
+	}
+	for in, want := range cases {
+		if got := d.Resolve(in, nil); got != want {
+			t.Errorf("Resolve(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBrandDictionary_ResolvePrefix(t *testing.T) {
+	d := testBrandDictionary()
+	cases := map[string]string{
+		"戴森V12":      "戴森",
+		"Dyson V12":  "戴森",
+		"Dreame X40": "追觅",
+		"Dysonic":    "DYSONIC", // 英文写法后紧跟字母时不算前缀
+		"minimal":    "MINIMAL", // "mi" 太短，不参与前缀匹配
+	}
+	for in, want := range cases {
+		if got := d.Resolve(in, nil); got != want {
+			t.Errorf("Resolve(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBrandDictionary_ResolveSlash(t *testing.T) {
+	d := testBrandDictionary()
+	if got := d.Resolve("Roborock/Dreame", nil); got != "追觅" {
+		t.Fatalf("expected dictionary brand, got %q", got)
+	}
+	if got := d.Resolve("喵洁易/Catlink", []string{"Catlink"}); got != "CATLINK" {
+		t.Fatalf("expected known brand, got %q", got)
+	}
+}
+
+func TestBrandDictionary_NilUsable(t *testing.T) {
+	var d *BrandDictionary
+	if got := d.Resolve("dyson", nil); got != "DYSON" {
+		t.Fatalf("expected formatted brand, got %q", got)
+	}
+	d.RecordMerge("DYSON", "dyson v12")
+	if d.Merged([]string{"DYSON"}) != nil {
+		t.Fatal("nil dictionary should not record merges")
+	}
+}
+
+func TestBrandDictionary_LaterEntryOverrides(t *testing.T) {
+	d := NewBrandDictionary([]BrandEntry{
+		{Name: "华为", Aliases: []string{"Honor"}},
+		{Name: "荣耀", Aliases: []string{"Honor"}},
+	})
+	if got := d.Resolve("honor", nil); got != "荣耀" {
+		t.Fatalf("expected later entry to win, got %q", got)
+	}
+	if aliases := d.Aliases("华为"); !reflect.DeepEqual(aliases, []string{"华为"}) {
+		t.Fatalf("overridden alias should be removed from previous owner, got %v", aliases)
+	}
+}
+
+func TestBrandDictionary_FindInText(t *testing.T) {
+	d := testBrandDictionary()
+	if got := d.FindInText("美的这台 Midea 扫地机还行", []string{"小米", "美的"}); got != "美的" {
+		t.Fatalf("expected 美的, got %q", got)
+	}
+	if got := d.FindInText("用了两年的米家", []string{"追觅", "小米"}); got != "小米" {
+		t.Fatalf("expected 小米 via alias, got %q", got)
+	}
+	if got := d.FindInText("dreamer 说得对", []string{"追觅"}); got != "" {
+		t.Fatalf("latin alias should require word boundary, got %q", got)
+	}
+}
+
+func TestBrandDictionary_Merged(t *testing.T) {
+	d := testBrandDictionary()
+	d.RecordMerge("追觅", "Dreame")
+	d.RecordMerge("追觅", "dreame x40")
+	d.RecordMerge("追觅", "追觅") // 与品牌名称相同，不记录
+	d.RecordMerge("小米", "未知")
+
+	got := d.Merged([]string{"追觅", "小米"})
+	want := map[string][]string{"追觅": {"Dreame", "dreame x40"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestBrandKey(t *testing.T) {
+	if BrandKey(" Dre-ame ") != BrandKey("dreame") {
+		t.Fatal("separators and case should be ignored")
+	}
+}
@@ -45,6 +45,7 @@ func CleanBrandName(brand string, knownBrands []string) string {
 // - 去除首尾空白
 // - 如果包含“/”，取第一个分段
 // - 描述性文案（如“新款/旧款”等）统一映射为“通用”
+// - 单独的字母或系列后缀（如“V”、“Pro”）无法区分型号，返回空字符串
 func CleanModelName(model string) string {
 	model = strings.TrimSpace(model)
 
@@ -61,6 +62,9 @@ func CleanModelName(model string) string {
 			return "通用"
 		}
 	}
+	if model != "未知" && model != "通用" && IsGenericModel(model) {
+		return ""
+	}
 
 	return model
 }
@@ -64,3 +64,11 @@ func TestCleanModelName_Descriptive(t *testing.T) {
 		t.Fatalf("expected %q, got %q", "通用", out)
 	}
 }
+
+func TestCleanModelName_GenericToken(t *testing.T) {
+	for _, in := range []string{"V", "pro", " Max "} {
+		if out := CleanModelName(in); out != "" {
+			t.Fatalf("CleanModelName(%q) = %q, want empty", in, out)
+		}
+	}
+}
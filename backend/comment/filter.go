@@ -85,6 +85,48 @@ func FilterAndRank(comments []Comment, config FilterConfig) []Comment {
 	return out
 }
 
+// DanmakuMinLength 弹幕的最小有效字符数（按 rune 计数）。
+// 弹幕普遍很短，沿用评论的 10 字门槛会过滤掉几乎所有弹幕。
+const DanmakuMinLength = 4
+
+// FilterAndRankWithSource 评论区评论和弹幕分开过滤排序后合并（评论在前）。
+// 评论使用 config 的规则；弹幕使用 DanmakuMinLength，最多保留 maxDanmaku 条，
+// 保留的弹幕占用 MaxComments 的名额。
+// 输入中没有弹幕时与 FilterAndRank 等价。
+func FilterAndRankWithSource(comments []Comment, config FilterConfig, maxDanmaku int) []Comment {
+	var replies, danmaku []Comment
+	for _, c := range comments {
+		if c.IsDanmaku() {
+			danmaku = append(danmaku, c)
+		} else {
+			replies = append(replies, c)
+		}
+	}
+	if len(danmaku) == 0 || maxDanmaku <= 0 {
+		return FilterAndRank(replies, config)
+	}
+
+	danmakuConfig := config
+	danmakuConfig.MinLength = DanmakuMinLength
+	danmakuConfig.MaxComments = maxDanmaku
+	if config.MaxComments > 0 {
+		danmakuConfig.MaxComments = min(maxDanmaku, config.MaxComments)
+	}
+	keptDanmaku := FilterAndRank(danmaku, danmakuConfig)
+
+	var keptReplies []Comment
+	replyConfig := config
+	if config.MaxComments > 0 {
+		replyConfig.MaxComments = config.MaxComments - len(keptDanmaku)
+	}
+	// MaxComments 为 0 表示不限制，名额被弹幕占满时需要单独处理
+	if config.MaxComments == 0 || replyConfig.MaxComments > 0 {
+		keptReplies = FilterAndRank(replies, replyConfig)
+	}
+
+	return append(keptReplies, keptDanmaku...)
+}
+
 // scoreComment 计算单条评论质量分（0-100）。
 // 总分 = 热度(0-40) + 长度(0-30) + 关键词(0-30)
 func scoreComment(c Comment, keywords []string) float64 {
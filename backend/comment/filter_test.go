@@ -102,3 +102,42 @@ func TestIsValidComment_WhitespaceOnly(t *testing.T) {
 		t.Fatalf("expected whitespace-only comment to be invalid")
 	}
 }
+
+func TestFilterAndRankWithSource_DanmakuQuota(t *testing.T) {
+	comments := []Comment{
+		{RPID: 1, Like: 10, Content: bilibili.Content{Message: "这个吸尘器真的很好用，吸力很强，续航也不错"}},
+		{RPID: 2, Like: 5, Content: bilibili.Content{Message: "噪音有点大，不过清洁效果确实很彻底"}},
+		{Source: bilibili.SourceDanmaku, DanmakuID: 11, Content: bilibili.Content{Message: "吸力好强"}},
+		{Source: bilibili.SourceDanmaku, DanmakuID: 12, Content: bilibili.Content{Message: "续航太短了吧"}},
+		{Source: bilibili.SourceDanmaku, DanmakuID: 13, Content: bilibili.Content{Message: "哈哈"}},
+	}
+
+	out := FilterAndRankWithSource(comments, FilterConfig{MaxComments: 3, MinLength: 10, FilterEmoji: true}, 1)
+	if len(out) != 2+1 {
+		t.Fatalf("expected 3 comments, got %d", len(out))
+	}
+	danmakuCount := 0
+	for _, c := range out {
+		if c.IsDanmaku() {
+			danmakuCount++
+		}
+	}
+	if danmakuCount != 1 {
+		t.Fatalf("expected 1 danmaku kept, got %d", danmakuCount)
+	}
+	if out[0].IsDanmaku() {
+		t.Fatal("expected comments before danmaku")
+	}
+
+	// 弹幕占用名额
+	out = FilterAndRankWithSource(comments, FilterConfig{MaxComments: 2, MinLength: 10, FilterEmoji: true}, 2)
+	if len(out) != 2 || !out[0].IsDanmaku() || !out[1].IsDanmaku() {
+		t.Fatalf("expected 2 danmaku filling the quota, got %+v", out)
+	}
+
+	// 不传弹幕名额时只保留评论
+	out = FilterAndRankWithSource(comments, FilterConfig{MaxComments: 10, MinLength: 10, FilterEmoji: true}, 0)
+	if len(out) != 2 {
+		t.Fatalf("expected only comments, got %d", len(out))
+	}
+}
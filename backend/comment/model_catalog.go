@@ -0,0 +1,229 @@
+package comment
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// genericModelWords 单独出现时不能作为型号的系列后缀
+var genericModelWords = map[string]bool{
+	"pro": true, "max": true, "plus": true, "ultra": true, "lite": true,
+	"mini": true, "se": true, "air": true, "promax": true, "标准版": true,
+}
+
+// ModelEntry 型号库中的一个标准型号
+type ModelEntry struct {
+	ID          uint     `json:"id,omitempty"`           // 型号库记录ID
+	Brand       string   `json:"brand"`                  // 品牌（标准名称）
+	Model       string   `json:"model"`                  // 标准型号名称
+	Aliases     []string `json:"aliases,omitempty"`      // 其他写法
+	ReleaseYear int      `json:"release_year,omitempty"` // 发布年份，0 表示未知
+}
+
+// ModelCatalog 型号库
+// 按"品牌 + 归一化型号"完整匹配，命中时型号统一为标准名称；品牌先经过品牌词典归并，
+// 因此"Dreame X40"和"追觅 x40"可以命中同一个词条。零值和 nil 均可直接使用（不命中任何型号）
+type ModelCatalog struct {
+	brands    *BrandDictionary
+	entries   map[string]*ModelEntry     // 品牌key|型号key -> 词条
+	spellings map[string][]modelSpelling // 品牌key -> 该品牌全部写法（按长度倒序，用于在评论中查找）
+}
+
+// modelSpelling 在评论中查找型号用的写法
+type modelSpelling struct {
+	key   string // 归一化写法
+	entry *ModelEntry
+}
+
+// NewModelCatalog 根据词条创建型号库，brands 用于把词条和查询的品牌归并到标准名称（可为 nil）
+// 同一品牌下多个词条包含相同写法时后面的词条优先
+func NewModelCatalog(entries []ModelEntry, brands *BrandDictionary) *ModelCatalog {
+	c := &ModelCatalog{
+		brands:    brands,
+		entries:   make(map[string]*ModelEntry),
+		spellings: make(map[string][]modelSpelling),
+	}
+	for i := range entries {
+		e := entries[i]
+		e.Brand = brands.Resolve(e.Brand, nil)
+		e.Model = strings.TrimSpace(e.Model)
+		brandKey := BrandKey(e.Brand)
+		if brandKey == "" || e.Model == "" {
+			continue
+		}
+		for _, spelling := range append([]string{e.Model}, e.Aliases...) {
+			key := ModelKey(spelling)
+			if key == "" || IsGenericModel(spelling) {
+				continue
+			}
+			if _, ok := c.entries[brandKey+"|"+key]; !ok {
+				c.spellings[brandKey] = append(c.spellings[brandKey], modelSpelling{key: key})
+			}
+			c.entries[brandKey+"|"+key] = &e
+		}
+	}
+	for brandKey, list := range c.spellings {
+		for i := range list {
+			list[i].entry = c.entries[brandKey+"|"+list[i].key]
+		}
+		sort.SliceStable(list, func(i, j int) bool { return len(list[i].key) > len(list[j].key) })
+	}
+	return c
+}
+
+// ModelKey 型号写法的归一化 key：小写、全角转半角，去掉空格和 "-"、"_"、"·"
+// 例如 "X20 Pro" / "x20pro" / "X20-PRO" 得到相同的 key
+func ModelKey(model string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(model) {
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+		if unicode.IsSpace(r) || strings.ContainsRune("-_·", r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// IsGenericModel 型号字段是否不足以区分具体型号
+// 空值、"未知"/"通用"、单独的系列后缀（Pro、Max 等）以及不超过两个字母的纯字母写法（如 "V"、"X"）都视为泛指
+func IsGenericModel(model string) bool {
+	model = strings.TrimSpace(model)
+	if model == "" || model == "未知" || model == "通用" {
+		return true
+	}
+	key := ModelKey(model)
+	if genericModelWords[key] {
+		return true
+	}
+	if utf8.RuneCountInString(key) > 2 {
+		return false
+	}
+	for _, r := range key {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Len 型号库中的标准型号写法数
+func (c *ModelCatalog) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.entries)
+}
+
+// Entries 返回指定品牌在型号库中的词条（按品牌、型号排序），用于随报告保存生成时的型号库
+func (c *ModelCatalog) Entries(brands []string) []ModelEntry {
+	if c == nil {
+		return nil
+	}
+	seen := make(map[*ModelEntry]bool)
+	result := []ModelEntry{}
+	for _, brand := range brands {
+		for _, s := range c.spellings[BrandKey(c.CanonicalBrand(brand))] {
+			if !seen[s.entry] {
+				seen[s.entry] = true
+				result = append(result, *s.entry)
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Brand != result[j].Brand {
+			return result[i].Brand < result[j].Brand
+		}
+		return result[i].Model < result[j].Model
+	})
+	return result
+}
+
+// CanonicalBrand 返回品牌在型号库中使用的标准名称
+func (c *ModelCatalog) CanonicalBrand(brand string) string {
+	if c == nil {
+		return strings.TrimSpace(brand)
+	}
+	return c.brands.Resolve(brand, nil)
+}
+
+// Match 按品牌和型号完整匹配型号库
+// 型号以品牌名称开头时（如 "小米 X20 Pro"）去掉品牌部分后再匹配
+func (c *ModelCatalog) Match(brand, model string) (ModelEntry, bool) {
+	if c == nil || IsGenericModel(model) {
+		return ModelEntry{}, false
+	}
+	brand = c.CanonicalBrand(brand)
+	brandKey := BrandKey(brand)
+	key := ModelKey(model)
+	if e, ok := c.entries[brandKey+"|"+key]; ok {
+		return *e, true
+	}
+	for _, spelling := range c.brands.Aliases(brand) {
+		prefix := ModelKey(spelling)
+		if prefix == "" || !strings.HasPrefix(key, prefix) || len(key) == len(prefix) {
+			continue
+		}
+		if e, ok := c.entries[brandKey+"|"+key[len(prefix):]]; ok {
+			return *e, true
+		}
+	}
+	if prefix := ModelKey(brand); prefix != "" && strings.HasPrefix(key, prefix) {
+		if e, ok := c.entries[brandKey+"|"+key[len(prefix):]]; ok {
+			return *e, true
+		}
+	}
+	return ModelEntry{}, false
+}
+
+// FindInText 在评论内容中查找该品牌在型号库中的型号，优先匹配最长的写法
+// 比较时忽略空格和分隔符，写法前后不能紧跟字母或数字（"X20" 不会命中 "X200"）
+func (c *ModelCatalog) FindInText(brand, text string) (ModelEntry, bool) {
+	if c == nil {
+		return ModelEntry{}, false
+	}
+	list := c.spellings[BrandKey(c.CanonicalBrand(brand))]
+	if len(list) == 0 {
+		return ModelEntry{}, false
+	}
+	compact := ModelKey(text)
+	for _, s := range list {
+		for start := 0; ; {
+			i := strings.Index(compact[start:], s.key)
+			if i < 0 {
+				break
+			}
+			i += start
+			if modelBoundaryBefore(compact, i) && modelBoundaryAfter(compact, i+len(s.key)) {
+				return *s.entry, true
+			}
+			start = i + 1
+		}
+	}
+	return ModelEntry{}, false
+}
+
+func modelBoundaryBefore(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(s[:i])
+	return !isModelChar(r)
+}
+
+func modelBoundaryAfter(s string, i int) bool {
+	if i >= len(s) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return !isModelChar(r)
+}
+
+// isModelChar 型号中的英文字母或数字
+func isModelChar(r rune) bool {
+	return isLatinLetter(r) || (r >= '0' && r <= '9')
+}
@@ -0,0 +1,99 @@
+package comment
+
+import "testing"
+
+func testModelCatalog() *ModelCatalog {
+	brands := NewBrandDictionary([]BrandEntry{
+		{Name: "追觅", Aliases: []string{"Dreame"}},
+		{Name: "小米", Aliases: []string{"Xiaomi", "米家"}},
+	})
+	return NewModelCatalog([]ModelEntry{
+		{ID: 1, Brand: "Dreame", Model: "X20 Pro", Aliases: []string{"X20Pro Plus"}, ReleaseYear: 2023},
+		{ID: 2, Brand: "追觅", Model: "X200"},
+		{ID: 3, Brand: "小米", Model: "G10", Aliases: []string{"Pro"}}, // 泛指写法不会被收录
+	}, brands)
+}
+
+func TestModelCatalog_Match(t *testing.T) {
+	c := testModelCatalog()
+	cases := []struct {
+		brand, model string
+		want         string
+	}{
+		{"追觅", "X20 Pro", "X20 Pro"},
+		{"dreame", "x20pro", "X20 Pro"},
+		{"追觅", "Ｘ２０－ＰＲＯ", "X20 Pro"},
+		{"追觅", "追觅X20 Pro", "X20 Pro"},
+		{"追觅", "Dreame x20 pro plus", "X20 Pro"},
+		{"米家", "g 10", "G10"},
+	}
+	for _, tc := range cases {
+		entry, ok := c.Match(tc.brand, tc.model)
+		if !ok || entry.Model != tc.want {
+			t.Errorf("Match(%q, %q) = %q, %v; want %q", tc.brand, tc.model, entry.Model, ok, tc.want)
+		}
+	}
+
+	for _, tc := range [][2]string{{"小米", "X20 Pro"}, {"追觅", "X20"}, {"小米", "Pro"}, {"追觅", "V"}} {
+		if entry, ok := c.Match(tc[0], tc[1]); ok {
+			t.Errorf("Match(%q, %q) should miss, got %q", tc[0], tc[1], entry.Model)
+		}
+	}
+}
+
+func TestModelCatalog_FindInText(t *testing.T) {
+	c := testModelCatalog()
+	entry, ok := c.FindInText("追觅", "用了半年x20 pro，吸力不错")
+	if !ok || entry.Model != "X20 Pro" || entry.ReleaseYear != 2023 {
+		t.Fatalf("expected X20 Pro, got %+v, %v", entry, ok)
+	}
+	if entry, ok := c.FindInText("追觅", "我的X2000用着还行"); ok {
+		t.Fatalf("X200 should not match X2000, got %q", entry.Model)
+	}
+	if entry, ok := c.FindInText("追觅", "X200 比 X20 Pro 安静"); !ok || entry.Model != "X20 Pro" {
+		t.Fatalf("longest spelling should win, got %q", entry.Model)
+	}
+	if _, ok := c.FindInText("小米", "X20 Pro"); ok {
+		t.Fatal("models of other brands should not match")
+	}
+}
+
+func TestModelCatalog_Entries(t *testing.T) {
+	c := testModelCatalog()
+	entries := c.Entries([]string{"Dreame", "追觅", "戴森"})
+	if len(entries) != 2 || entries[0].Model != "X20 Pro" || entries[1].Model != "X200" {
+		t.Fatalf("expected X20 Pro and X200 once each, got %+v", entries)
+	}
+	if entries[0].Brand != "追觅" || len(entries[0].Aliases) != 1 {
+		t.Errorf("entry should keep canonical brand and aliases: %+v", entries[0])
+	}
+
+	// 用保存的词条重建型号库，匹配结果不变
+	restored := NewModelCatalog(entries, nil)
+	if entry, ok := restored.Match("追觅", "x20pro plus"); !ok || entry.Model != "X20 Pro" {
+		t.Errorf("restored catalog Match = %+v, %v", entry, ok)
+	}
+}
+
+func TestModelCatalog_NilUsable(t *testing.T) {
+	var c *ModelCatalog
+	if _, ok := c.Match("追觅", "X20 Pro"); ok {
+		t.Fatal("nil catalog should not match")
+	}
+	if c.CanonicalBrand(" 追觅 ") != "追觅" {
+		t.Fatal("nil catalog should keep brand")
+	}
+}
+
+func TestIsGenericModel(t *testing.T) {
+	for _, m := range []string{"", "未知", "通用", "V", "x", "Pro", "pro max", "MI"} {
+		if !IsGenericModel(m) {
+			t.Errorf("IsGenericModel(%q) = false, want true", m)
+		}
+	}
+	for _, m := range []string{"V12", "X20 Pro", "二代", "SE2", "G10"} {
+		if IsGenericModel(m) {
+			t.Errorf("IsGenericModel(%q) = true, want false", m)
+		}
+	}
+}
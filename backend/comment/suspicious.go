@@ -0,0 +1,369 @@
+package comment
+
+import (
+	"bilibili-analyzer/backend/bilibili"
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 可疑评论的判定类型
+const (
+	SuspicionDuplicate    = "duplicate"     // 与其他评论内容高度相似（复制粘贴的好评/差评）
+	SuspicionCrossVideo   = "cross_video"   // 同一账号在多个视频下发表评论
+	SuspicionLowLevel     = "low_level"     // 低等级账号在不同视频下重复发言
+	SuspicionBrandAccount = "brand_account" // 昵称像品牌官方或店铺账号
+	SuspicionBrandName    = "brand_name"    // 昵称只包含品牌名（也可能是粉丝或真实用户，只标记不排除）
+)
+
+// brandAccountCues 昵称中表示官方、店铺或客服账号的字样
+var brandAccountCues = []string{"官方", "旗舰店", "专卖店", "官微", "客服", "小助手"}
+
+// SuspiciousConfig 可疑评论识别规则
+type SuspiciousConfig struct {
+	// DuplicateDistance 两条评论 SimHash 的汉明距离不超过该值即视为近似重复（0-3），默认 3
+	DuplicateDistance int
+	// DuplicateMinCount 近似重复的评论达到该条数才标记，默认 3
+	DuplicateMinCount int
+	// DuplicateMinLength 参与重复检测的最少字符数（去掉符号和空白后），过短的评论天然容易重复，默认 8
+	DuplicateMinLength int
+	// CrossVideoMin 同一账号发言的视频数达到该值时标记，默认 4
+	CrossVideoMin int
+	// LowLevelMax 不超过该等级的账号在两个及以上视频下发言时标记，默认 2
+	LowLevelMax int
+	// Brands 任务指定的品牌，昵称包含品牌名时视为疑似品牌账号
+	Brands []string
+}
+
+// DefaultSuspiciousConfig 默认识别规则
+func DefaultSuspiciousConfig() SuspiciousConfig {
+	return SuspiciousConfig{
+		DuplicateDistance:  3,
+		DuplicateMinCount:  3,
+		DuplicateMinLength: 8,
+		CrossVideoMin:      4,
+		LowLevelMax:        2,
+	}
+}
+
+// SuspicionReason 一条判定依据
+type SuspicionReason struct {
+	Kind   string `json:"kind"`   // 判定类型（Suspicion* 常量）
+	Detail string `json:"detail"` // 说明，如 "与其他4条评论内容高度相似"
+}
+
+// SuspiciousComment 被标记的可疑评论
+type SuspiciousComment struct {
+	VideoBVID string            `json:"video_bvid"`
+	RPID      int64             `json:"rpid"`
+	Mid       int64             `json:"mid"`
+	Author    string            `json:"author"`
+	Level     int               `json:"level"`
+	Like      int               `json:"like"`
+	Content   string            `json:"content"`
+	Reasons   []SuspicionReason `json:"reasons"`
+}
+
+// Excludable 是否应在 exclude 模式下排除（只因昵称含品牌名被标记的评论不排除）
+func (s SuspiciousComment) Excludable() bool {
+	for _, r := range s.Reasons {
+		if r.Kind != SuspicionBrandName {
+			return true
+		}
+	}
+	return false
+}
+
+// HasReason 是否包含指定类型的判定依据
+func (s SuspiciousComment) HasReason(kind string) bool {
+	for _, r := range s.Reasons {
+		if r.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// suspectEntry 参与识别的一条评论及其所属视频
+type suspectEntry struct {
+	bvid    string
+	comment Comment
+	reasons []SuspicionReason
+}
+
+// DetectSuspicious 在AI分析之前识别刷评、水军和品牌账号评论
+// 只检查评论区评论（含楼中楼），弹幕没有可靠的账号信息且天然大量重复，不参与识别。
+// UP主在自己视频下的回复不按账号频次标记。
+// 返回结果按判定依据条数、点赞数降序排列
+func DetectSuspicious(result *bilibili.ScrapeResult, config SuspiciousConfig) []SuspiciousComment {
+	if result == nil {
+		return nil
+	}
+	uploaders := make(map[string]int64, len(result.Videos))
+	for _, v := range result.Videos {
+		uploaders[v.BVID] = v.Mid
+	}
+
+	var entries []*suspectEntry
+	bvids := make([]string, 0, len(result.Comments))
+	for bvid := range result.Comments {
+		bvids = append(bvids, bvid)
+	}
+	sort.Strings(bvids) // 固定遍历顺序，保证结果可复现
+	for _, bvid := range bvids {
+		for _, c := range result.Comments[bvid] {
+			if c.IsDanmaku() {
+				continue
+			}
+			entries = append(entries, &suspectEntry{bvid: bvid, comment: c})
+			for _, r := range c.Replies {
+				entries = append(entries, &suspectEntry{bvid: bvid, comment: r})
+			}
+		}
+	}
+
+	markDuplicates(entries, config)
+	markFrequentAuthors(entries, uploaders, config)
+	markBrandAccounts(entries, config.Brands)
+
+	var flagged []SuspiciousComment
+	for _, e := range entries {
+		if len(e.reasons) == 0 {
+			continue
+		}
+		flagged = append(flagged, SuspiciousComment{
+			VideoBVID: e.bvid,
+			RPID:      e.comment.RPID,
+			Mid:       e.comment.Mid,
+			Author:    e.comment.Member.Uname,
+			Level:     e.comment.Member.LevelInfo.CurrentLevel,
+			Like:      e.comment.Like,
+			Content:   e.comment.Content.Message,
+			Reasons:   e.reasons,
+		})
+	}
+	sort.SliceStable(flagged, func(i, j int) bool {
+		if len(flagged[i].Reasons) != len(flagged[j].Reasons) {
+			return len(flagged[i].Reasons) > len(flagged[j].Reasons)
+		}
+		return flagged[i].Like > flagged[j].Like
+	})
+	return flagged
+}
+
+// markDuplicates 用 SimHash 找出近似重复的评论
+// 64位指纹切成4段，汉明距离不超过3的两条评论至少有一段完全相同，只比较有相同分段的评论
+func markDuplicates(entries []*suspectEntry, config SuspiciousConfig) {
+	distance := min(max(config.DuplicateDistance, 0), 3)
+	minCount := max(config.DuplicateMinCount, 2)
+
+	var indexes []int
+	var hashes []uint64
+	for i, e := range entries {
+		text := normalizeForSimHash(e.comment.Content.Message)
+		if utf8.RuneCountInString(text) < config.DuplicateMinLength {
+			continue
+		}
+		indexes = append(indexes, i)
+		hashes = append(hashes, simHash(text))
+	}
+
+	parent := make([]int, len(hashes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for band := 0; band < 4; band++ {
+		buckets := make(map[uint16][]int)
+		for i, h := range hashes {
+			key := uint16(h >> (16 * band))
+			for _, j := range buckets[key] {
+				if bits.OnesCount64(h^hashes[j]) <= distance {
+					parent[find(i)] = find(j)
+				}
+			}
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range hashes {
+		root := find(i)
+		groups[root] = append(groups[root], i)
+	}
+	for _, members := range groups {
+		if len(members) < minCount {
+			continue
+		}
+		detail := fmt.Sprintf("与其他%d条评论内容高度相似", len(members)-1)
+		for _, i := range members {
+			e := entries[indexes[i]]
+			e.reasons = append(e.reasons, SuspicionReason{Kind: SuspicionDuplicate, Detail: detail})
+		}
+	}
+}
+
+// markFrequentAuthors 按账号统计发言的视频数，标记跨多个视频发言的账号和低等级小号
+func markFrequentAuthors(entries []*suspectEntry, uploaders map[string]int64, config SuspiciousConfig) {
+	videosByMid := make(map[int64]map[string]struct{})
+	for _, e := range entries {
+		mid := e.comment.Mid
+		if mid == 0 || mid == uploaders[e.bvid] {
+			continue
+		}
+		if videosByMid[mid] == nil {
+			videosByMid[mid] = make(map[string]struct{})
+		}
+		videosByMid[mid][e.bvid] = struct{}{}
+	}
+
+	for _, e := range entries {
+		mid := e.comment.Mid
+		if mid == 0 || mid == uploaders[e.bvid] {
+			continue
+		}
+		videoCount := len(videosByMid[mid])
+		if config.CrossVideoMin > 0 && videoCount >= config.CrossVideoMin {
+			e.reasons = append(e.reasons, SuspicionReason{
+				Kind:   SuspicionCrossVideo,
+				Detail: fmt.Sprintf("同一账号在%d个视频下发表评论", videoCount),
+			})
+		}
+		level := e.comment.Member.LevelInfo.CurrentLevel
+		if level > 0 && level <= config.LowLevelMax && videoCount >= 2 {
+			e.reasons = append(e.reasons, SuspicionReason{
+				Kind:   SuspicionLowLevel,
+				Detail: fmt.Sprintf("%d级账号在%d个视频下发表评论", level, videoCount),
+			})
+		}
+	}
+}
+
+// markBrandAccounts 标记昵称包含官方/店铺字样的账号
+// 昵称只包含品牌名（如「小米粉丝」「戴森用户」）时可能是真实用户，单独标记为 brand_name
+func markBrandAccounts(entries []*suspectEntry, brands []string) {
+	for _, e := range entries {
+		name := strings.ToLower(e.comment.Member.Uname)
+		if name == "" {
+			continue
+		}
+		if cue := brandAccountCue(name); cue != "" {
+			e.reasons = append(e.reasons, SuspicionReason{
+				Kind:   SuspicionBrandAccount,
+				Detail: fmt.Sprintf("昵称含「%s」，疑似品牌或店铺账号", cue),
+			})
+		} else if brand := brandNameCue(name, brands); brand != "" {
+			e.reasons = append(e.reasons, SuspicionReason{
+				Kind:   SuspicionBrandName,
+				Detail: fmt.Sprintf("昵称含品牌名「%s」，可能是品牌相关账号", brand),
+			})
+		}
+	}
+}
+
+// brandAccountCue 返回昵称（已转小写）中命中的官方、店铺或客服字样，没有命中时返回空字符串
+func brandAccountCue(name string) string {
+	for _, cue := range brandAccountCues {
+		if strings.Contains(name, cue) {
+			return cue
+		}
+	}
+	return ""
+}
+
+// brandNameCue 返回昵称（已转小写）中包含的任务品牌名，没有命中时返回空字符串
+// 单字品牌名容易误判，不参与匹配
+func brandNameCue(name string, brands []string) string {
+	for _, brand := range brands {
+		brand = strings.TrimSpace(brand)
+		if utf8.RuneCountInString(brand) >= 2 && strings.Contains(name, strings.ToLower(brand)) {
+			return brand
+		}
+	}
+	return ""
+}
+
+// ExcludeSuspicious 返回去掉可疑评论后的抓取结果（不修改输入）
+// 只因昵称含品牌名被标记的评论保留
+// 楼中楼按 RPID 单独排除，被排除的根评论下的回复一并去掉
+func ExcludeSuspicious(result *bilibili.ScrapeResult, flagged []SuspiciousComment) *bilibili.ScrapeResult {
+	if result == nil || len(flagged) == 0 {
+		return result
+	}
+	excluded := make(map[int64]bool, len(flagged))
+	for _, s := range flagged {
+		if s.RPID > 0 && s.Excludable() {
+			excluded[s.RPID] = true
+		}
+	}
+
+	filtered := *result
+	filtered.Comments = make(map[string][]Comment, len(result.Comments))
+	for bvid, comments := range result.Comments {
+		kept := make([]Comment, 0, len(comments))
+		for _, c := range comments {
+			if !c.IsDanmaku() && excluded[c.RPID] {
+				continue
+			}
+			if len(c.Replies) > 0 {
+				replies := make([]Comment, 0, len(c.Replies))
+				for _, r := range c.Replies {
+					if !excluded[r.RPID] {
+						replies = append(replies, r)
+					}
+				}
+				c.Replies = replies
+			}
+			kept = append(kept, c)
+		}
+		filtered.Comments[bvid] = kept
+	}
+	return &filtered
+}
+
+// normalizeForSimHash 去掉符号、表情和空白并转为小写，避免只改动标点的复制评论逃过检测
+func normalizeForSimHash(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(removeEmojiAndSymbols(text)), ""))
+}
+
+// simHash 以相邻两个字符为特征计算64位 SimHash 指纹
+func simHash(text string) uint64 {
+	runes := []rune(text)
+	var weights [64]int
+	add := func(feature string) {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for i := range weights {
+			if sum&(1<<i) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+	if len(runes) == 1 {
+		add(text)
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		add(string(runes[i : i+2]))
+	}
+
+	var fingerprint uint64
+	for i, w := range weights {
+		if w > 0 {
+			fingerprint |= 1 << i
+		}
+	}
+	return fingerprint
+}
@@ -0,0 +1,151 @@
+package comment
+
+import (
+	"bilibili-analyzer/backend/bilibili"
+	"math/bits"
+	"testing"
+)
+
+func suspectComment(rpid, mid int64, level int, uname, message string) Comment {
+	return Comment{
+		RPID:    rpid,
+		Mid:     mid,
+		Content: bilibili.Content{Message: message},
+		Member:  bilibili.Member{Uname: uname, LevelInfo: bilibili.LevelInfo{CurrentLevel: level}},
+	}
+}
+
+func flaggedByRPID(flagged []SuspiciousComment) map[int64]SuspiciousComment {
+	m := make(map[int64]SuspiciousComment, len(flagged))
+	for _, s := range flagged {
+		m[s.RPID] = s
+	}
+	return m
+}
+
+func TestSimHashNearDuplicate(t *testing.T) {
+	a := simHash(normalizeForSimHash("这款吸尘器吸力超强，续航也很给力，强烈推荐大家入手！"))
+	b := simHash(normalizeForSimHash("这款吸尘器吸力超强，续航也很给力，强烈推荐大家入手啊"))
+	c := simHash(normalizeForSimHash("噪音有点大，滚刷容易缠头发，售后态度也一般"))
+	if d := bits.OnesCount64(a ^ b); d > 3 {
+		t.Errorf("near-duplicate distance = %d", d)
+	}
+	if d := bits.OnesCount64(a ^ c); d <= 3 {
+		t.Errorf("unrelated comments should be far apart, distance = %d", d)
+	}
+}
+
+func TestDetectSuspicious(t *testing.T) {
+	praise := "这款吸尘器吸力超强，续航也很给力，强烈推荐大家入手！"
+	result := &bilibili.ScrapeResult{
+		Videos: []bilibili.VideoInfo{{BVID: "BV1", Mid: 900}, {BVID: "BV2"}, {BVID: "BV3"}, {BVID: "BV4"}},
+		Comments: map[string][]Comment{
+			"BV1": {
+				suspectComment(1, 11, 5, "甲", praise),
+				suspectComment(2, 50, 6, "测评爱好者", "用了三个月，噪音有点大，滚刷容易缠头发"),
+				suspectComment(3, 900, 6, "UP主", "置顶：本期视频为自费购买"),
+				suspectComment(4, 60, 6, "戴森官方旗舰店", "感谢支持，有问题可以私信我们"),
+				{Source: bilibili.SourceDanmaku, DanmakuID: 7, Content: bilibili.Content{Message: praise}},
+			},
+			"BV2": {
+				suspectComment(5, 12, 4, "乙", "这款吸尘器吸力超强、续航也很给力，强烈推荐大家入手"),
+				suspectComment(6, 50, 6, "测评爱好者", "对比下来还是更推荐有线款，吸力稳定"),
+				suspectComment(7, 70, 1, "用户123", "买了不后悔，性价比很高，冲就完事"),
+			},
+			"BV3": {
+				suspectComment(8, 13, 3, "丙", praise),
+				suspectComment(9, 50, 6, "测评爱好者", "这个价位段里做工算好的"),
+				suspectComment(10, 70, 1, "用户123", "已经推荐给朋友了，大家放心买"),
+			},
+			"BV4": {
+				suspectComment(11, 50, 6, "测评爱好者", "电池衰减比较明显，一年后续航减半"),
+				suspectComment(12, 900, 6, "UP主", "回复一下大家关心的问题"),
+			},
+		},
+	}
+	result.Comments["BV2"][0].Replies = []Comment{suspectComment(13, 14, 2, "石头用户", "续航确实不错")}
+
+	config := DefaultSuspiciousConfig()
+	config.Brands = []string{"石头", "戴森"}
+	flagged := flaggedByRPID(DetectSuspicious(result, config))
+
+	for _, rpid := range []int64{1, 5, 8} {
+		if !flagged[rpid].HasReason(SuspicionDuplicate) {
+			t.Errorf("comment %d should be flagged as duplicate: %+v", rpid, flagged[rpid])
+		}
+	}
+	for _, rpid := range []int64{2, 6, 9, 11} {
+		if !flagged[rpid].HasReason(SuspicionCrossVideo) {
+			t.Errorf("comment %d should be flagged as cross-video: %+v", rpid, flagged[rpid])
+		}
+	}
+	for _, rpid := range []int64{7, 10} {
+		if !flagged[rpid].HasReason(SuspicionLowLevel) {
+			t.Errorf("comment %d should be flagged as low-level: %+v", rpid, flagged[rpid])
+		}
+	}
+	if !flagged[4].HasReason(SuspicionBrandAccount) {
+		t.Errorf("comment 4 should be flagged as brand account: %+v", flagged[4])
+	}
+	// 昵称只含品牌名的账号可能是真实用户，只标记为 brand_name，exclude 模式下不排除
+	if !flagged[13].HasReason(SuspicionBrandName) || flagged[13].HasReason(SuspicionBrandAccount) || flagged[13].Excludable() {
+		t.Errorf("comment 13 should only be tagged with brand name: %+v", flagged[13])
+	}
+	if _, ok := flagged[3]; ok {
+		t.Errorf("uploader replies should not be flagged: %+v", flagged[3])
+	}
+	if len(flagged) != 11 {
+		t.Errorf("expected 11 flagged comments, got %d: %+v", len(flagged), flagged)
+	}
+
+	filtered := ExcludeSuspicious(result, DetectSuspicious(result, config))
+	if got := len(filtered.Comments["BV1"]); got != 2 {
+		t.Errorf("BV1 should keep the uploader comment and the danmaku, got %d", got)
+	}
+	if len(result.Comments["BV1"]) != 5 || len(result.Comments["BV2"][0].Replies) != 1 {
+		t.Error("ExcludeSuspicious should not modify its input")
+	}
+}
+
+func TestExcludeSuspiciousKeepsBrandNameOnly(t *testing.T) {
+	result := &bilibili.ScrapeResult{
+		Comments: map[string][]Comment{
+			"BV1": {
+				suspectComment(1, 11, 5, "小米粉丝", "用了一年，吸力还是很稳"),
+				suspectComment(2, 12, 5, "小米官方", "感谢支持"),
+				suspectComment(3, 13, 5, "追觅旗舰店", "欢迎进店咨询"),
+			},
+		},
+	}
+	config := DefaultSuspiciousConfig()
+	config.Brands = []string{"小米", "追觅"}
+	flagged := DetectSuspicious(result, config)
+	byRPID := flaggedByRPID(flagged)
+	for _, rpid := range []int64{2, 3} {
+		if !byRPID[rpid].HasReason(SuspicionBrandAccount) {
+			t.Errorf("comment %d should be flagged as brand account: %+v", rpid, byRPID[rpid])
+		}
+	}
+
+	filtered := ExcludeSuspicious(result, flagged)
+	if got := filtered.Comments["BV1"]; len(got) != 1 || got[0].RPID != 1 {
+		t.Errorf("only the brand fan comment should be kept, got %+v", got)
+	}
+}
+
+func TestDetectSuspicious_BelowThresholds(t *testing.T) {
+	result := &bilibili.ScrapeResult{
+		Comments: map[string][]Comment{
+			"BV1": {
+				suspectComment(1, 11, 5, "甲", "真的好用，推荐购买这一款产品"),
+				suspectComment(2, 12, 5, "乙", "真的好用，推荐购买这一款产品"),
+				suspectComment(3, 13, 5, "丙", "好用"),
+				suspectComment(4, 14, 5, "丁", "好用"),
+				suspectComment(5, 15, 5, "戊", "好用"),
+			},
+		},
+	}
+	if flagged := DetectSuspicious(result, DefaultSuspiciousConfig()); len(flagged) != 0 {
+		t.Errorf("two copies and short comments should not be flagged: %+v", flagged)
+	}
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"bilibili-analyzer/backend/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultAICacheTTL AI响应缓存默认有效期（7天）
+const DefaultAICacheTTL = 7 * 24 * time.Hour
+
+// AICacheStore 基于 ai_caches 表的AI响应缓存
+// 实现 ai.Cache 接口，读写失败只记录日志，不影响正常请求
+type AICacheStore struct {
+	ttl time.Duration
+}
+
+// NewAICacheStore 创建AI响应缓存
+// 参数：
+//   - ttl: 缓存有效期
+func NewAICacheStore(ttl time.Duration) *AICacheStore {
+	return &AICacheStore{ttl: ttl}
+}
+
+// Get 读取未过期的缓存，命中时累加命中次数
+func (s *AICacheStore) Get(key string) (string, bool) {
+	// 使用 Find 而不是 First，未命中时不打印 record not found 日志
+	var entry models.AICache
+	result := DB.Where("cache_key = ? AND expires_at > ?", key, time.Now()).Limit(1).Find(&entry)
+	if result.Error != nil {
+		log.Printf("[AICache] Get failed: %v", result.Error)
+		return "", false
+	}
+	if result.RowsAffected == 0 {
+		return "", false
+	}
+
+	DB.Model(&models.AICache{}).Where("id = ?", entry.ID).
+		UpdateColumn("hits", gorm.Expr("hits + 1"))
+	return entry.Response, true
+}
+
+// Set 写入缓存（键已存在时覆盖响应并刷新过期时间）
+func (s *AICacheStore) Set(key, model, response string) {
+	entry := models.AICache{
+		CacheKey:  key,
+		Model:     model,
+		Response:  response,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	err := DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cache_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"model", "response", "expires_at", "updated_at"}),
+	}).Create(&entry).Error
+	if err != nil {
+		log.Printf("[AICache] Set failed: %v", err)
+	}
+}
+
+// Delete 删除缓存
+func (s *AICacheStore) Delete(key string) {
+	if err := DB.Where("cache_key = ?", key).Delete(&models.AICache{}).Error; err != nil {
+		log.Printf("[AICache] Delete failed: %v", err)
+	}
+}
+
+// AICacheSummary AI响应缓存表概况
+type AICacheSummary struct {
+	Entries   int64 `json:"entries"`    // 缓存条数
+	Expired   int64 `json:"expired"`    // 其中已过期的条数
+	TotalHits int64 `json:"total_hits"` // 所有缓存累计命中次数
+}
+
+// GetAICacheSummary 统计AI响应缓存表
+func GetAICacheSummary() (AICacheSummary, error) {
+	var summary AICacheSummary
+	if err := DB.Model(&models.AICache{}).Count(&summary.Entries).Error; err != nil {
+		return summary, err
+	}
+	if err := DB.Model(&models.AICache{}).Where("expires_at <= ?", time.Now()).Count(&summary.Expired).Error; err != nil {
+		return summary, err
+	}
+	if err := DB.Model(&models.AICache{}).Select("COALESCE(SUM(hits), 0)").Scan(&summary.TotalHits).Error; err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// PurgeAICache 清除AI响应缓存
+// 参数：
+//   - expiredOnly: true 只删除已过期的缓存，false 删除全部
+//   - model: 非空时只删除该模型的缓存
+//
+// 返回：
+//   - int64: 删除的条数
+//   - error: 删除失败时返回错误
+func PurgeAICache(expiredOnly bool, model string) (int64, error) {
+	query := DB.Where("1 = 1")
+	if expiredOnly {
+		query = query.Where("expires_at <= ?", time.Now())
+	}
+	if model != "" {
+		query = query.Where("model = ?", model)
+	}
+	result := query.Delete(&models.AICache{})
+	return result.RowsAffected, result.Error
+}
+
+// CleanExpiredAICache 清理已过期的AI响应缓存
+func CleanExpiredAICache() error {
+	n, err := PurgeAICache(true, "")
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("🗑️  Cleaned %d expired AI cache entries", n)
+	}
+	return nil
+}
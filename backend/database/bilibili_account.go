@@ -0,0 +1,75 @@
+package database
+
+import (
+	"bilibili-analyzer/backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListBilibiliAccounts 获取账号池中的全部账号（按ID排序）
+func ListBilibiliAccounts() ([]models.BilibiliAccount, error) {
+	var accounts []models.BilibiliAccount
+	err := DB.Order("id ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+// UsableBilibiliAccounts 获取可用于抓取的账号
+// 排除已禁用、Cookie 已失效以及仍在隔离期内的账号
+func UsableBilibiliAccounts() ([]models.BilibiliAccount, error) {
+	var accounts []models.BilibiliAccount
+	err := DB.Where("enabled = ? AND status <> ?", true, models.AccountStatusExpired).
+		Where("quarantined_until IS NULL OR quarantined_until <= ?", time.Now()).
+		Order("id ASC").Find(&accounts).Error
+	return accounts, err
+}
+
+// QuarantineBilibiliAccount 隔离触发风控的账号，记录失败原因
+func QuarantineBilibiliAccount(id uint, reason string, until time.Time) error {
+	now := time.Now()
+	return DB.Model(&models.BilibiliAccount{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":            models.AccountStatusQuarantined,
+		"quarantined_until": until,
+		"last_failure":      reason,
+		"last_failure_at":   now,
+		"failure_count":     gorm.Expr("failure_count + 1"),
+	}).Error
+}
+
+// SaveBilibiliAccountCheck 保存健康检查结果
+// 参数：
+//   - id: 账号ID
+//   - isLogin, uname, mid: nav 接口返回的登录状态
+//   - checkErr: 检查请求失败时的错误（此时不修改登录状态，只记录失败原因）
+func SaveBilibiliAccountCheck(id uint, isLogin bool, uname string, mid int64, checkErr error) error {
+	now := time.Now()
+	updates := map[string]interface{}{"last_checked_at": now}
+
+	switch {
+	case checkErr != nil:
+		updates["last_failure"] = "健康检查失败: " + checkErr.Error()
+		updates["last_failure_at"] = now
+		updates["failure_count"] = gorm.Expr("failure_count + 1")
+	case !isLogin:
+		updates["status"] = models.AccountStatusExpired
+		updates["is_login"] = false
+		updates["last_failure"] = "Cookie已失效（未登录）"
+		updates["last_failure_at"] = now
+		updates["failure_count"] = gorm.Expr("failure_count + 1")
+	default:
+		updates["is_login"] = true
+		updates["uname"] = uname
+		updates["mid"] = mid
+		// 隔离期已过的账号恢复为健康状态；仍在隔离期内的保持隔离
+		var account models.BilibiliAccount
+		if err := DB.Select("quarantined_until").First(&account, id).Error; err != nil {
+			return err
+		}
+		if account.QuarantinedUntil == nil || !account.QuarantinedUntil.After(now) {
+			updates["status"] = models.AccountStatusHealthy
+			updates["quarantined_until"] = nil
+		}
+	}
+
+	return DB.Model(&models.BilibiliAccount{}).Where("id = ?", id).Updates(updates).Error
+}
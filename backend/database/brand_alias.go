@@ -0,0 +1,155 @@
+package database
+
+import (
+	"bilibili-analyzer/backend/models"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ListBrandAliases 查询品牌别名词条，category 为 nil 时返回全部，否则只返回该类目（空字符串为通用词条）
+func ListBrandAliases(category *string) ([]models.BrandAlias, error) {
+	query := DB.Order("category ASC, name ASC")
+	if category != nil {
+		query = query.Where("category = ?", strings.TrimSpace(*category))
+	}
+	var aliases []models.BrandAlias
+	err := query.Find(&aliases).Error
+	return aliases, err
+}
+
+// BrandAliasesFor 返回对需求描述生效的词条：通用词条在前，需求描述包含其类目的词条在后
+// 例如类目为"扫地机器人"的词条对"3000元扫地机器人推荐"生效
+func BrandAliasesFor(requirement string) ([]models.BrandAlias, error) {
+	var all []models.BrandAlias
+	if err := DB.Order("id ASC").Find(&all).Error; err != nil {
+		return nil, err
+	}
+	requirement = strings.ToLower(requirement)
+	var general, specific []models.BrandAlias
+	for _, a := range all {
+		category := strings.ToLower(strings.TrimSpace(a.Category))
+		switch {
+		case category == "":
+			general = append(general, a)
+		case strings.Contains(requirement, category):
+			specific = append(specific, a)
+		}
+	}
+	return append(general, specific...), nil
+}
+
+// SeedBrandAliases 导入类目的品牌词条
+// 已存在同类目同名的词条时合并别名、补全为空的英文名和拼音，不覆盖手动修改过的内容
+// 返回新增和更新的词条数
+func SeedBrandAliases(category string, seeds []models.BrandAlias) (created, updated int, err error) {
+	category = strings.TrimSpace(category)
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		for _, seed := range seeds {
+			seed.Name = strings.TrimSpace(seed.Name)
+			if seed.Name == "" {
+				continue
+			}
+			var existing models.BrandAlias
+			err := tx.Where("category = ? AND name = ?", category, seed.Name).First(&existing).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				seed.ID = 0
+				seed.Category = category
+				if seed.Source == "" {
+					seed.Source = models.BrandAliasSourceSeed
+				}
+				if err := tx.Create(&seed).Error; err != nil {
+					return err
+				}
+				created++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			changed := false
+			if existing.English == "" && seed.English != "" {
+				existing.English = seed.English
+				changed = true
+			}
+			if existing.Pinyin == "" && seed.Pinyin != "" {
+				existing.Pinyin = seed.Pinyin
+				changed = true
+			}
+			if merged := MergeAliasList(existing.Aliases, seed.Aliases); merged != existing.Aliases {
+				existing.Aliases = merged
+				changed = true
+			}
+			if !changed {
+				continue
+			}
+			if err := tx.Save(&existing).Error; err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	return created, updated, err
+}
+
+// SeedDefaultBrandAliases 词典为空时导入内置的通用词条（首次启动或旧版本升级时）
+func SeedDefaultBrandAliases() error {
+	var count int64
+	if err := DB.Model(&models.BrandAlias{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, _, err := SeedBrandAliases("", BuiltinBrandAliases(""))
+	return err
+}
+
+// SplitAliasList 拆分逗号分隔的别名（支持中英文逗号），去除空白和重复项
+func SplitAliasList(s string) []string {
+	var list []string
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '，' }) {
+		item = strings.TrimSpace(item)
+		if item != "" && !slices.ContainsFunc(list, func(v string) bool { return strings.EqualFold(v, item) }) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// MergeAliasList 合并两个逗号分隔的别名列表（保持原有顺序，新别名追加在后）
+func MergeAliasList(current, extra string) string {
+	list := SplitAliasList(current)
+	for _, item := range SplitAliasList(extra) {
+		if !slices.ContainsFunc(list, func(v string) bool { return strings.EqualFold(v, item) }) {
+			list = append(list, item)
+		}
+	}
+	return strings.Join(list, ",")
+}
+
+// BuiltinBrandAliasCategories 有内置词条的类目（不含通用词条）
+func BuiltinBrandAliasCategories() []string {
+	categories := make([]string, 0, len(builtinBrandAliases))
+	for category := range builtinBrandAliases {
+		if category != "" {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// BuiltinBrandAliases 返回类目的内置词条（空字符串为通用词条），没有内置词条时返回 nil
+func BuiltinBrandAliases(category string) []models.BrandAlias {
+	seeds := builtinBrandAliases[strings.TrimSpace(category)]
+	if seeds == nil {
+		return nil
+	}
+	return slices.Clone(seeds)
+}
@@ -0,0 +1,54 @@
+package database
+
+import "bilibili-analyzer/backend/models"
+
+// builtinBrandAliases 内置品牌词条（类目 -> 词条，空字符串为通用词条）
+// 通用词条在首次启动时自动导入；类目词条通过 /api/brand-aliases/seed 按需导入
+var builtinBrandAliases = map[string][]models.BrandAlias{
+	"": {
+		{Name: "苹果", English: "Apple", Pinyin: "pingguo", Aliases: "iPhone,iPad,Mac,MacBook,AirPods"},
+		{Name: "小米", English: "Xiaomi", Pinyin: "xiaomi", Aliases: "MI,Redmi,红米,米家,Mijia"},
+		{Name: "华为", English: "Huawei", Pinyin: "huawei"},
+		{Name: "荣耀", English: "Honor", Pinyin: "rongyao"},
+		{Name: "三星", English: "Samsung", Pinyin: "sanxing", Aliases: "Galaxy"},
+		{Name: "索尼", English: "Sony", Pinyin: "suoni", Aliases: "PlayStation,PS5"},
+		{Name: "戴森", English: "Dyson", Pinyin: "daisen"},
+		{Name: "追觅", English: "Dreame", Pinyin: "zhuimi"},
+		{Name: "美的", English: "Midea", Pinyin: "meidi"},
+		{Name: "海尔", English: "Haier", Pinyin: "haier"},
+		{Name: "飞利浦", English: "Philips", Pinyin: "feilipu"},
+		{Name: "小佩", English: "PETKIT", Pinyin: "xiaopei"},
+		{Name: "CATLINK", Pinyin: "catlink", Aliases: "猫猫狗狗"},
+	},
+	"扫地机器人": {
+		{Name: "科沃斯", English: "Ecovacs", Pinyin: "kewosi", Aliases: "地宝,Deebot"},
+		{Name: "石头", English: "Roborock", Pinyin: "shitou", Aliases: "石头科技"},
+		{Name: "云鲸", English: "Narwal", Pinyin: "yunjing"},
+		{Name: "追觅", English: "Dreame", Pinyin: "zhuimi"},
+		{Name: "iRobot", Pinyin: "irobot", Aliases: "Roomba"},
+		{Name: "小米", English: "Xiaomi", Pinyin: "xiaomi", Aliases: "米家,Mijia"},
+	},
+	"吸尘器": {
+		{Name: "戴森", English: "Dyson", Pinyin: "daisen"},
+		{Name: "添可", English: "Tineco", Pinyin: "tianke", Aliases: "芙万"},
+		{Name: "莱克", English: "LEXY", Pinyin: "laike"},
+		{Name: "必胜", English: "Bissell", Pinyin: "bisheng"},
+		{Name: "追觅", English: "Dreame", Pinyin: "zhuimi"},
+		{Name: "小米", English: "Xiaomi", Pinyin: "xiaomi", Aliases: "米家,Mijia"},
+	},
+	"手机": {
+		{Name: "苹果", English: "Apple", Pinyin: "pingguo", Aliases: "iPhone"},
+		{Name: "小米", English: "Xiaomi", Pinyin: "xiaomi", Aliases: "MI,Redmi,红米"},
+		{Name: "一加", English: "OnePlus", Pinyin: "yijia"},
+		{Name: "OPPO", Pinyin: "oppo", Aliases: "欧珀"},
+		{Name: "vivo", Pinyin: "vivo", Aliases: "iQOO,维沃"},
+		{Name: "真我", English: "realme", Pinyin: "zhenwo"},
+	},
+	"猫砂盆": {
+		{Name: "小佩", English: "PETKIT", Pinyin: "xiaopei"},
+		{Name: "CATLINK", Pinyin: "catlink"},
+		{Name: "霍曼", English: "Homerun", Pinyin: "huoman"},
+		{Name: "有陪", Pinyin: "youpei"},
+		{Name: "糯雪", Pinyin: "nuoxue"},
+	},
+}
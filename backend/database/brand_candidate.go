@@ -0,0 +1,44 @@
+package database
+
+import (
+	"bilibili-analyzer/backend/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ListBrandCandidates 查询候选品牌（按发现得分倒序），historyID 为 0、status 为空时不筛选
+func ListBrandCandidates(historyID uint, status string) ([]models.BrandCandidate, error) {
+	query := DB.Order("score DESC, id ASC")
+	if historyID != 0 {
+		query = query.Where("history_id = ?", historyID)
+	}
+	if status = strings.TrimSpace(status); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var candidates []models.BrandCandidate
+	err := query.Find(&candidates).Error
+	return candidates, err
+}
+
+// AcceptBrandCandidate 保存接受候选品牌后重新生成的报告：更新报告数据、追加该品牌的证据评论并标记候选品牌为已接受
+func AcceptBrandCandidate(candidate *models.BrandCandidate, reportData string, comments []models.ReportComment) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Report{}).Where("id = ?", candidate.ReportID).
+			Update("report_data", reportData).Error; err != nil {
+			return err
+		}
+		if len(comments) > 0 {
+			if err := tx.CreateInBatches(comments, 100).Error; err != nil {
+				return err
+			}
+		}
+		candidate.Status = models.BrandCandidateAccepted
+		return tx.Save(candidate).Error
+	})
+}
+
+// DeleteBrandCandidates 删除分析历史的全部候选品牌
+func DeleteBrandCandidates(historyID uint) error {
+	return DB.Where("history_id = ?", historyID).Delete(&models.BrandCandidate{}).Error
+}
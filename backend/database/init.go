@@ -48,22 +48,52 @@ func InitDB(dbPath string) error {
 	// 自动迁移表结构（如果表不存在则创建，如果字段有变化则更新）
 	// 迁移顺序：先迁移基础表，再迁移有外键关联的表
 	err = DB.AutoMigrate(
-		&models.Settings{},        // 系统配置表
-		&models.AnalysisHistory{}, // 分析历史记录表
-		&models.Report{},          // 报告数据表
-		&models.RawComment{},      // 原始评论临时表
+		&models.Settings{},           // 系统配置表
+		&models.AnalysisHistory{},    // 分析历史记录表
+		&models.Report{},             // 报告数据表
+		&models.RawComment{},         // 原始评论临时表
+		&models.AICache{},            // AI响应缓存表
+		&models.BilibiliAccount{},    // B站账号池
+		&models.ReportComment{},      // 报告证据评论表
+		&models.ReportCommentScore{}, // 证据评论维度得分表
+		&models.Schedule{},           // 定时分析计划表
+		&models.ScheduleRun{},        // 定时计划运行记录表
+		&models.WebhookEndpoint{},    // Webhook 接收端表
+		&models.WebhookDelivery{},    // Webhook 投递记录表
+		&models.BrandAlias{},         // 品牌别名词典表
+		&models.ModelCatalogEntry{},  // 型号库表
+		&models.ModelReview{},        // 待审核型号表
+		&models.BrandCandidate{},     // 候选品牌表
 	)
 	if err != nil {
 		return err
 	}
 
+	// 旧版本在 raw_comments.comment_id 上建有全局唯一索引，导致同一条评论无法被多个任务保存
+	// 现已改为 (history_id, comment_id) 复合唯一索引，这里删除旧索引
+	if DB.Migrator().HasIndex(&models.RawComment{}, "idx_raw_comments_comment_id") {
+		if err := DB.Migrator().DropIndex(&models.RawComment{}, "idx_raw_comments_comment_id"); err != nil {
+			log.Printf("⚠️  Warning: Failed to drop legacy raw_comments index: %v", err)
+		}
+	}
+
 	log.Println("✅ Database initialized with WAL mode")
 
+	if err := SeedDefaultBrandAliases(); err != nil {
+		log.Printf("⚠️  Warning: Failed to seed brand aliases: %v", err)
+	}
+
 	// 启动时清理3天前的临时数据
 	// 注意：清理失败不影响程序启动，只记录警告日志
 	if err := CleanOldComments(); err != nil {
 		log.Printf("⚠️  Warning: Failed to clean old comments: %v", err)
 	}
+	if err := CleanExpiredAICache(); err != nil {
+		log.Printf("⚠️  Warning: Failed to clean expired AI cache: %v", err)
+	}
+	if err := CleanOldWebhookDeliveries(); err != nil {
+		log.Printf("⚠️  Warning: Failed to clean old webhook deliveries: %v", err)
+	}
 
 	return nil
 }
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"bilibili-analyzer/backend/comment"
+	"bilibili-analyzer/backend/models"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// UnknownModel 报告中未命中型号库的型号
+type UnknownModel struct {
+	Brand    string // 品牌（标准名称）
+	Model    string // 型号写法
+	Mentions int    // 本次报告中的评论数
+}
+
+// ListModelCatalog 查询型号库词条，brand 为空时返回全部
+func ListModelCatalog(brand string) ([]models.ModelCatalogEntry, error) {
+	query := DB.Order("brand ASC, release_year DESC, model ASC")
+	if brand = strings.TrimSpace(brand); brand != "" {
+		query = query.Where("brand = ?", brand)
+	}
+	var entries []models.ModelCatalogEntry
+	err := query.Find(&entries).Error
+	return entries, err
+}
+
+// ListModelReviews 查询待审核型号（按累计评论数倒序），status、brand 为空时不筛选
+func ListModelReviews(status, brand string) ([]models.ModelReview, error) {
+	query := DB.Order("mentions DESC, id ASC")
+	if status = strings.TrimSpace(status); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if brand = strings.TrimSpace(brand); brand != "" {
+		query = query.Where("brand = ?", brand)
+	}
+	var reviews []models.ModelReview
+	err := query.Find(&reviews).Error
+	return reviews, err
+}
+
+// RecordModelReviews 把报告中未命中型号库的型号加入审核队列
+// 已在队列中的型号累加评论数和报告数；已收录的型号再次出现（型号库词条被删除或修改）时重新变为待审核，已忽略的保持忽略
+func RecordModelReviews(reportID uint, category string, unknown []UnknownModel) error {
+	if len(unknown) == 0 {
+		return nil
+	}
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, u := range unknown {
+			key := comment.ModelKey(u.Model)
+			if u.Brand == "" || key == "" {
+				continue
+			}
+			var review models.ModelReview
+			err := tx.Where("brand = ? AND model_key = ?", u.Brand, key).First(&review).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				review = models.ModelReview{
+					Brand:        u.Brand,
+					Model:        strings.TrimSpace(u.Model),
+					ModelKey:     key,
+					Category:     category,
+					Mentions:     u.Mentions,
+					ReportCount:  1,
+					LastReportID: reportID,
+					Status:       models.ModelReviewPending,
+				}
+				if err := tx.Create(&review).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			review.Category = category
+			review.Mentions += u.Mentions
+			if review.LastReportID != reportID {
+				review.ReportCount++
+				review.LastReportID = reportID
+			}
+			if review.Status == models.ModelReviewApproved {
+				review.Status = models.ModelReviewPending
+				review.CatalogID = 0
+			}
+			if err := tx.Save(&review).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ApproveModelReview 把待审核型号收录到型号库
+// entry.ID 不为 0 时更新已有词条（通常是把该写法追加为别名），否则新建词条
+func ApproveModelReview(review *models.ModelReview, entry *models.ModelCatalogEntry) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(entry).Error; err != nil {
+			return err
+		}
+		review.Status = models.ModelReviewApproved
+		review.CatalogID = entry.ID
+		return tx.Save(review).Error
+	})
+}
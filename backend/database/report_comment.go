@@ -0,0 +1,89 @@
+package database
+
+import (
+	"bilibili-analyzer/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ReportCommentFilter 证据评论查询条件，零值字段表示不限制
+type ReportCommentFilter struct {
+	ReportID  uint
+	Brand     string
+	ModelKey  string   // 归一化型号
+	Dimension string   // 维度名称；指定时 Min/Max 作用于该维度得分，否则作用于综合得分
+	Min       *float64 // 最低分（含）
+	Max       *float64 // 最高分（含）
+	Limit     int
+	Offset    int
+}
+
+// SaveReportComments 保存报告的证据评论（连同各维度得分）
+func SaveReportComments(comments []models.ReportComment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	return DB.CreateInBatches(comments, 100).Error
+}
+
+// QueryReportComments 按条件查询证据评论，返回当前页和符合条件的总数
+// 结果按点赞数倒序，附带各维度得分
+func QueryReportComments(filter ReportCommentFilter) ([]models.ReportComment, int64, error) {
+	query := DB.Model(&models.ReportComment{}).Where("report_comments.report_id = ?", filter.ReportID)
+	if filter.Brand != "" {
+		query = query.Where("report_comments.brand = ?", filter.Brand)
+	}
+	if filter.ModelKey != "" {
+		query = query.Where("report_comments.model_key = ?", filter.ModelKey)
+	}
+
+	scoreColumn := "report_comments.overall_score"
+	if filter.Dimension != "" {
+		query = query.Joins("JOIN report_comment_scores ON report_comment_scores.report_comment_id = report_comments.id").
+			Where("report_comment_scores.dimension = ?", filter.Dimension)
+		scoreColumn = "report_comment_scores.score"
+	}
+	if filter.Min != nil {
+		query = query.Where(scoreColumn+" >= ?", *filter.Min)
+	}
+	if filter.Max != nil {
+		query = query.Where(scoreColumn+" <= ?", *filter.Max)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Select("report_comments.*").
+		Preload("Scores").
+		Order("report_comments.likes DESC, report_comments.id ASC").
+		Offset(filter.Offset)
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var comments []models.ReportComment
+	err := query.Find(&comments).Error
+	return comments, total, err
+}
+
+// ListReportComments 查询报告的全部证据评论（用于导出），按品牌和点赞数排序，附带各维度得分
+func ListReportComments(reportID uint) ([]models.ReportComment, error) {
+	var comments []models.ReportComment
+	err := DB.Where("report_id = ?", reportID).
+		Preload("Scores").
+		Order("brand ASC, likes DESC, id ASC").
+		Find(&comments).Error
+	return comments, err
+}
+
+// DeleteReportComments 删除报告的全部证据评论
+func DeleteReportComments(reportID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("report_id = ?", reportID).Delete(&models.ReportCommentScore{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("report_id = ?", reportID).Delete(&models.ReportComment{}).Error
+	})
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"bilibili-analyzer/backend/report"
+	"testing"
+)
+
+func setupReportCommentDB(t *testing.T) {
+	t.Helper()
+	if err := InitDB(":memory:"); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	s := func(v float64) *float64 { return &v }
+	results := map[string][]report.CommentWithScore{
+		"小米": {
+			{Content: "吸力强续航差", RPID: 1, Like: 30, Model: "G10", Scores: map[string]*float64{"吸力": s(9), "续航": s(3)}},
+			{Content: "吸力弱续航好", RPID: 2, Like: 20, Model: "G 10", Scores: map[string]*float64{"吸力": s(4), "续航": s(8)}},
+			{Content: "还行", RPID: 3, Like: 10, Model: "V12", Scores: map[string]*float64{"吸力": s(7)}},
+			{Content: "续航很好", RPID: 4, Like: 5, Model: "g10", Scores: map[string]*float64{"续航": s(9)}},
+		},
+	}
+	for _, reportID := range []uint{1, 2} {
+		if err := SaveReportComments(report.BuildReportComments(reportID, results)); err != nil {
+			t.Fatalf("SaveReportComments failed: %v", err)
+		}
+	}
+}
+
+func TestQueryReportComments(t *testing.T) {
+	setupReportCommentDB(t)
+	v := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name      string
+		filter    ReportCommentFilter
+		wantRPIDs []int64
+		wantTotal int64
+	}{
+		// 指定维度时分数区间作用于该维度得分：评论2综合得分6但吸力只有4，评论4没有吸力得分
+		{"dimension min", ReportCommentFilter{Dimension: "吸力", Min: v(5)}, []int64{1, 3}, 2},
+		{"dimension max", ReportCommentFilter{Dimension: "吸力", Max: v(5)}, []int64{2}, 1},
+		// 未指定维度时作用于综合得分
+		{"overall min", ReportCommentFilter{Min: v(6.5)}, []int64{3, 4}, 2},
+		// 型号按归一化写法匹配（G10、G 10、g10 为同一型号）
+		{"model key", ReportCommentFilter{ModelKey: report.NormalizeModelName("G 10")}, []int64{1, 2, 4}, 3},
+		// 总数不受分页影响
+		{"paged", ReportCommentFilter{Limit: 2, Offset: 1}, []int64{2, 3}, 4},
+		{"paged dimension", ReportCommentFilter{Dimension: "续航", Limit: 1}, []int64{1}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.ReportID = 1
+			comments, total, err := QueryReportComments(tt.filter)
+			if err != nil {
+				t.Fatalf("QueryReportComments failed: %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", total, tt.wantTotal)
+			}
+			var got []int64
+			for _, c := range comments {
+				got = append(got, c.RPID)
+				if len(c.Scores) == 0 {
+					t.Errorf("comment %d should preload its scores", c.RPID)
+				}
+			}
+			if len(got) != len(tt.wantRPIDs) {
+				t.Fatalf("rpids = %v, want %v", got, tt.wantRPIDs)
+			}
+			for i := range got {
+				if got[i] != tt.wantRPIDs[i] {
+					t.Fatalf("rpids = %v, want %v", got, tt.wantRPIDs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"bilibili-analyzer/backend/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListSchedules 获取全部定时计划（按ID排序）
+func ListSchedules() ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	err := DB.Order("id ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// DueSchedules 获取已到运行时间的启用计划
+func DueSchedules(now time.Time) ([]models.Schedule, error) {
+	var schedules []models.Schedule
+	err := DB.Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC").Find(&schedules).Error
+	return schedules, err
+}
+
+// ListScheduleRuns 获取计划的运行记录（按时间倒序）
+// limit <= 0 时不限制条数
+func ListScheduleRuns(scheduleID uint, limit int) ([]models.ScheduleRun, error) {
+	var runs []models.ScheduleRun
+	query := DB.Where("schedule_id = ?", scheduleID).Order("id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Find(&runs).Error
+	return runs, err
+}
+
+// PendingScheduleRuns 获取任务尚未结束的运行记录
+func PendingScheduleRuns() ([]models.ScheduleRun, error) {
+	var runs []models.ScheduleRun
+	err := DB.Where("status = ?", models.StatusPending).Order("id ASC").Find(&runs).Error
+	return runs, err
+}
+
+// PreviousCompletedRun 获取同一计划中早于 runID 的最近一次成功运行
+// 没有时返回 nil
+func PreviousCompletedRun(scheduleID, runID uint) (*models.ScheduleRun, error) {
+	var run models.ScheduleRun
+	err := DB.Where("schedule_id = ? AND id < ? AND status = ?", scheduleID, runID, models.StatusCompleted).
+		Order("id DESC").First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// DeleteSchedule 删除计划及其运行记录和告警专用的 Webhook 接收端（已生成的报告和历史记录保留）
+func DeleteSchedule(schedule *models.Schedule) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("schedule_id = ?", schedule.ID).Delete(&models.ScheduleRun{}).Error; err != nil {
+			return err
+		}
+		if schedule.WebhookID != 0 {
+			if err := tx.Delete(&models.WebhookEndpoint{}, schedule.WebhookID).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&models.Schedule{}, schedule.ID).Error
+	})
+}
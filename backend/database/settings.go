@@ -0,0 +1,25 @@
+package database
+
+import (
+	"bilibili-analyzer/backend/models"
+)
+
+// GetSetting 读取配置项，不存在时返回空字符串
+func GetSetting(key string) string {
+	var setting models.Settings
+	if err := DB.Where("key = ?", key).First(&setting).Error; err != nil {
+		return ""
+	}
+	return setting.Value
+}
+
+// SaveSetting 保存配置项（不存在时创建）
+func SaveSetting(key, value string) error {
+	var setting models.Settings
+	if err := DB.Where("key = ?", key).First(&setting).Error; err != nil {
+		setting = models.Settings{Key: key, Value: value}
+		return DB.Create(&setting).Error
+	}
+	setting.Value = value
+	return DB.Save(&setting).Error
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"bilibili-analyzer/backend/models"
+	"log"
+	"time"
+)
+
+// webhookDeliveryRetention 投递记录保留时间
+const webhookDeliveryRetention = 30 * 24 * time.Hour
+
+// WebhookDeliveryFilter 投递记录查询条件（零值字段不参与筛选）
+type WebhookDeliveryFilter struct {
+	WebhookID uint
+	Event     string
+	Status    string
+	TaskID    string
+	Limit     int
+	Offset    int
+}
+
+// ListWebhookEndpoints 获取全部 Webhook 接收端（按ID排序）
+func ListWebhookEndpoints() ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := DB.Order("id ASC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+// EnabledWebhookEndpoints 获取已启用的 Webhook 接收端
+func EnabledWebhookEndpoints() ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := DB.Where("enabled = ?", true).Order("id ASC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+// DueWebhookDeliveries 获取到达重试时间的待投递记录
+func DueWebhookDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := DB.Where("status = ? AND next_attempt_at <= ?", models.DeliveryStatusPending, now).
+		Order("next_attempt_at ASC, id ASC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// QueryWebhookDeliveries 按条件分页查询投递记录（按时间倒序）
+func QueryWebhookDeliveries(filter WebhookDeliveryFilter) ([]models.WebhookDelivery, int64, error) {
+	query := DB.Model(&models.WebhookDelivery{})
+	if filter.WebhookID > 0 {
+		query = query.Where("webhook_id = ?", filter.WebhookID)
+	}
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.TaskID != "" {
+		query = query.Where("task_id = ?", filter.TaskID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	err := query.Order("id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// CleanOldWebhookDeliveries 清理30天前的投递记录（程序启动时调用）
+func CleanOldWebhookDeliveries() error {
+	result := DB.Where("created_at < ?", time.Now().Add(-webhookDeliveryRetention)).Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("🗑️  Cleaned %d old webhook deliveries (older than 30 days)", result.RowsAffected)
+	}
+	return nil
+}
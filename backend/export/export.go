@@ -0,0 +1,355 @@
+// Package export 报告导出
+// 每种导出格式实现 Writer 接口并在此登记，API（/api/report/:id/export）和命令行工具共用同一套实现
+package export
+
+import (
+	"bilibili-analyzer/backend/bilibili"
+	"bilibili-analyzer/backend/comment"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/pdf"
+	"bilibili-analyzer/backend/report"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrReportNotFound 报告不存在
+var ErrReportNotFound = errors.New("报告不存在")
+
+// Input 导出所需的报告内容
+type Input struct {
+	ReportID  uint
+	CreatedAt time.Time
+	Data      *report.ReportData
+	Comments  []models.ReportComment // 证据评论（旧报告没有证据评论时为空，导出典型评论）
+}
+
+// Writer 导出格式
+type Writer interface {
+	// Format 返回格式标识，同时作为文件扩展名（如 xlsx、md）
+	Format() string
+	// ContentType 返回 HTTP 响应的 Content-Type
+	ContentType() string
+	// Write 将报告写入 w
+	Write(w io.Writer, in *Input) error
+}
+
+// writers 已登记的导出格式（按登记顺序展示）
+var writers = []Writer{
+	pdfWriter{},
+	xlsxWriter{},
+	csvWriter{},
+	markdownWriter{},
+	htmlWriter{},
+	jsonWriter{},
+}
+
+// Register 登记导出格式，格式标识相同时替换已有实现
+func Register(w Writer) {
+	for i, existing := range writers {
+		if existing.Format() == w.Format() {
+			writers[i] = w
+			return
+		}
+	}
+	writers = append(writers, w)
+}
+
+// Get 按格式标识查找导出格式（忽略大小写和首尾空格）
+func Get(format string) (Writer, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	for _, w := range writers {
+		if w.Format() == format {
+			return w, nil
+		}
+	}
+	return nil, fmt.Errorf("不支持的导出格式: %s（可选 %s）", format, strings.Join(Formats(), "/"))
+}
+
+// Formats 返回全部格式标识
+func Formats() []string {
+	formats := make([]string, 0, len(writers))
+	for _, w := range writers {
+		formats = append(formats, w.Format())
+	}
+	return formats
+}
+
+// Filename 导出文件名（如 report_12.xlsx）
+func Filename(reportID uint, w Writer) string {
+	return fmt.Sprintf("report_%d.%s", reportID, w.Format())
+}
+
+// Load 从数据库读取报告及其证据评论
+func Load(reportID uint) (*Input, error) {
+	var reportModel models.Report
+	if err := database.DB.First(&reportModel, reportID).Error; err != nil {
+		return nil, ErrReportNotFound
+	}
+	var data report.ReportData
+	if err := json.Unmarshal([]byte(reportModel.ReportData), &data); err != nil {
+		return nil, fmt.Errorf("解析报告数据失败: %w", err)
+	}
+	comments, err := database.ListReportComments(reportModel.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询证据评论失败: %w", err)
+	}
+	return &Input{
+		ReportID:  reportModel.ID,
+		CreatedAt: reportModel.CreatedAt,
+		Data:      &data,
+		Comments:  comments,
+	}, nil
+}
+
+// pdfWriter PDF 报告（与 /api/report/:id/pdf 相同）
+type pdfWriter struct{}
+
+func (pdfWriter) Format() string      { return "pdf" }
+func (pdfWriter) ContentType() string { return "application/pdf" }
+
+func (pdfWriter) Write(w io.Writer, in *Input) error {
+	content, err := pdf.GeneratePDF(in.Data, in.ReportID)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// jsonWriter 报告原始数据（与 /api/report/:id 的 data 字段相同）
+type jsonWriter struct{}
+
+func (jsonWriter) Format() string      { return "json" }
+func (jsonWriter) ContentType() string { return "application/json; charset=utf-8" }
+
+func (jsonWriter) Write(w io.Writer, in *Input) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(in.Data)
+}
+
+// dimensionNames 报告的维度名称列表
+func dimensionNames(data *report.ReportData) []string {
+	names := make([]string, 0, len(data.Dimensions))
+	for _, d := range data.Dimensions {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+// commentRow 导出的单条评论
+type commentRow struct {
+	Brand       string
+	Model       string
+	Source      string
+	Author      string
+	Like        int
+	Score       float64
+	Scores      map[string]float64
+	PublishTime time.Time
+	Content     string
+	URL         string
+}
+
+// commentRows 导出的评论列表
+// 优先使用证据评论；旧报告没有证据评论时使用报告中的好评和差评
+func commentRows(in *Input) []commentRow {
+	rows := make([]commentRow, 0, len(in.Comments))
+	for _, c := range in.Comments {
+		scores := make(map[string]float64, len(c.Scores))
+		for _, s := range c.Scores {
+			scores[s.Dimension] = s.Score
+		}
+		row := commentRow{
+			Brand:       c.Brand,
+			Model:       c.Model,
+			Source:      c.Source,
+			Author:      c.Author,
+			Like:        c.Likes,
+			Score:       c.OverallScore,
+			Scores:      scores,
+			PublishTime: c.PublishTime,
+			Content:     c.Content,
+		}
+		if c.VideoBVID != "" {
+			row.URL = bilibili.CommentURL(c.VideoBVID, c.RPID)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) > 0 {
+		return rows
+	}
+
+	for _, r := range in.Data.Rankings {
+		for _, group := range [][]report.TypicalComment{in.Data.TopComments[r.Brand], in.Data.BadComments[r.Brand]} {
+			for _, c := range group {
+				rows = append(rows, commentRow{
+					Brand:   r.Brand,
+					Author:  c.Author,
+					Like:    c.Like,
+					Score:   c.Score,
+					Content: c.Content,
+					URL:     c.URL,
+				})
+			}
+		}
+	}
+	return rows
+}
+
+// sourceLabel 评论来源的展示名称
+func sourceLabel(source string) string {
+	if source == bilibili.SourceDanmaku {
+		return "弹幕"
+	}
+	return "评论"
+}
+
+// verifiedLabel 型号是否收录在型号库中的展示文字（未收录为空）
+func verifiedLabel(verified bool) string {
+	if verified {
+		return "已收录"
+	}
+	return ""
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', 1, 64)
+}
+
+func joinOrDash(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, "、")
+}
+
+// intervalLabel 置信区间（旧报告没有统计数据时为 "-"）
+func intervalLabel(lo, hi float64, sampleSize int) string {
+	if sampleSize == 0 {
+		return "-"
+	}
+	return formatScore(lo) + " ~ " + formatScore(hi)
+}
+
+// shrunkScoreLabel 品牌排名依据的收缩后得分，没有参与评分的评论时为 "-"
+func shrunkScoreLabel(r report.BrandRanking) string {
+	if r.SampleSize == 0 {
+		return "-"
+	}
+	return formatScore(r.ShrunkScore)
+}
+
+// rankingNote 品牌排名备注：样本不足、与哪些品牌差异不显著
+func rankingNote(r report.BrandRanking) string {
+	var notes []string
+	if r.LowSample {
+		notes = append(notes, lowSampleLabel(true))
+	}
+	if len(r.TiedWith) > 0 {
+		notes = append(notes, "与"+strings.Join(r.TiedWith, "、")+"差异不显著")
+	}
+	return strings.Join(notes, "；")
+}
+
+func lowSampleLabel(low bool) string {
+	if low {
+		return "样本不足"
+	}
+	return ""
+}
+
+// quoteSpans 引文片段（加「」）
+func quoteSpans(quotes []report.AspectQuote) []string {
+	spans := make([]string, 0, len(quotes))
+	for _, q := range quotes {
+		spans = append(spans, "「"+q.Span+"」")
+	}
+	return spans
+}
+
+// sponsorGroup 商单对比中的一组视频
+type sponsorGroup struct {
+	Label string
+	report.SponsorGroupStats
+}
+
+// sponsorGroups 商单对比的两组（商单在前）
+func sponsorGroups(c *report.SponsoredComparison) []sponsorGroup {
+	return []sponsorGroup{{"商单视频", c.Sponsored}, {"非商单视频", c.Organic}}
+}
+
+// sponsorSummary 商单对比的结论：两组的得分差，以及商单视频下的评论是否计入排名
+func sponsorSummary(c *report.SponsoredComparison) string {
+	var summary string
+	switch {
+	case c.Sponsored.CommentCount == 0:
+		summary = "商单视频下没有参与评分的评论"
+	case c.Organic.CommentCount == 0:
+		summary = "所有参与评分的评论都来自商单视频"
+	case c.ScoreGap > 0:
+		summary = "商单视频下评论的综合得分比非商单视频高 " + formatScore(c.ScoreGap) + " 分"
+	case c.ScoreGap < 0:
+		summary = "商单视频下评论的综合得分比非商单视频低 " + formatScore(-c.ScoreGap) + " 分"
+	default:
+		summary = "商单视频和非商单视频下评论的综合得分相同"
+	}
+	if c.Excluded {
+		summary += "；商单视频下的评论未计入得分和排名"
+	}
+	return summary
+}
+
+// suspicionKinds 可疑评论判定类型的展示顺序和名称
+var suspicionKinds = []struct{ kind, label string }{
+	{comment.SuspicionDuplicate, "内容重复"},
+	{comment.SuspicionCrossVideo, "跨视频发言"},
+	{comment.SuspicionLowLevel, "低等级小号"},
+	{comment.SuspicionBrandAccount, "品牌账号"},
+	{comment.SuspicionBrandName, "昵称含品牌名"},
+}
+
+// suspiciousSummary 可疑评论的总数、各类型条数以及是否已排除
+func suspiciousSummary(s *report.SuspiciousSummary) string {
+	summary := fmt.Sprintf("共识别 %d 条可疑评论", s.Total)
+	var parts []string
+	for _, k := range suspicionKinds {
+		if n := s.ReasonCounts[k.kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d 条", k.label, n))
+		}
+	}
+	if len(parts) > 0 {
+		summary += "（" + strings.Join(parts, "、") + "）"
+	}
+	if s.Excluded {
+		summary += "，已在AI分析前排除"
+	} else {
+		summary += "，仅标记，照常参与分析"
+	}
+	if len(s.Comments) < s.Total {
+		summary += fmt.Sprintf("；以下列出前 %d 条", len(s.Comments))
+	}
+	return summary
+}
+
+// suspiciousReasons 可疑评论的判定依据
+func suspiciousReasons(c comment.SuspiciousComment) string {
+	details := make([]string, len(c.Reasons))
+	for i, r := range c.Reasons {
+		details[i] = r.Detail
+	}
+	return strings.Join(details, "；")
+}
+
+func sponsoredLabel(sponsored bool) string {
+	if sponsored {
+		return "是"
+	}
+	return ""
+}
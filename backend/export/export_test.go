@@ -0,0 +1,321 @@
+package export
+
+import (
+	"archive/zip"
+	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/comment"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/report"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleInput() *Input {
+	return &Input{
+		ReportID:  7,
+		CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local),
+		Data: &report.ReportData{
+			Category:   "吸尘器",
+			Brands:     []string{"戴森", "小米"},
+			Dimensions: []ai.Dimension{{Name: "吸力", Description: "吸力大小"}, {Name: "噪音", Description: "运行噪音"}},
+			Scores: map[string]map[string]float64{
+				"戴森": {"吸力": 9.1, "噪音": 6.5},
+				"小米": {"吸力": 7.8},
+			},
+			Rankings: []report.BrandRanking{
+				{Brand: "戴森", OverallScore: 7.8, Rank: 1, Scores: map[string]float64{"吸力": 9.1, "噪音": 6.5}},
+				{Brand: "小米", OverallScore: 7.8, Rank: 2, Scores: map[string]float64{"吸力": 7.8}},
+			},
+			Recommendation: "预算充足选 <戴森>",
+			Stats: report.ReportStats{
+				TotalVideos:     3,
+				TotalComments:   120,
+				CommentsByBrand: map[string]int{"戴森": 80, "小米": 40},
+			},
+			SentimentDistribution: report.SentimentStats{PositiveCount: 60, NeutralCount: 40, NegativeCount: 20},
+			TopComments: map[string][]report.TypicalComment{
+				"戴森": {{Content: "吸力很强", Score: 9, Author: "用户A", URL: "https://www.bilibili.com/video/BV1xx#reply1"}},
+			},
+			BadComments: map[string][]report.TypicalComment{
+				"小米": {{Content: "噪音大", Score: 4}},
+			},
+			BrandAnalysis: map[string]report.BrandAnalysis{
+				"戴森": {Strengths: []string{"吸力"}},
+			},
+			ModelRankings: []report.ModelRanking{
+				{Model: "V12", Brand: "戴森", OverallScore: 8.2, Rank: 1, Scores: map[string]float64{"吸力": 9}, CommentCount: 30},
+			},
+			VideoSources: []report.VideoSource{{BVID: "BV1xx", Title: "吸尘器横评", Author: "UP主", Play: 1000, VideoReview: 50}},
+			Trends: []report.BrandTrend{
+				{Brand: "戴森", Overall: []report.TrendPoint{{Month: "2024-03", Score: 8, CommentCount: 5}, {Month: "2024-04", Score: 8.5, CommentCount: 6}}},
+			},
+		},
+	}
+}
+
+func render(t *testing.T, format string, in *Input) []byte {
+	t.Helper()
+	w, err := Get(format)
+	if err != nil {
+		t.Fatalf("Get(%q) error = %v", format, err)
+	}
+	var buf bytes.Buffer
+	if err := w.Write(&buf, in); err != nil {
+		t.Fatalf("%s Write() error = %v", format, err)
+	}
+	return buf.Bytes()
+}
+
+func TestGet(t *testing.T) {
+	w, err := Get(" XLSX ")
+	if err != nil || w.Format() != "xlsx" {
+		t.Fatalf("Get should ignore case and spaces, got %v, %v", w, err)
+	}
+	if Filename(12, w) != "report_12.xlsx" {
+		t.Errorf("unexpected filename %s", Filename(12, w))
+	}
+	if _, err := Get("docx"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+	for _, format := range []string{"pdf", "xlsx", "csv", "md", "html", "json"} {
+		if _, err := Get(format); err != nil {
+			t.Errorf("format %s should be registered", format)
+		}
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	in := sampleInput()
+	in.Comments = []models.ReportComment{{
+		Brand:        "戴森",
+		Model:        "V12",
+		Source:       "comment",
+		RPID:         99,
+		VideoBVID:    "BV1xx",
+		Author:       "用户<B>",
+		Likes:        12,
+		Content:      "吸力 & 续航都不错",
+		OverallScore: 8.5,
+		Scores:       []models.ReportCommentScore{{Dimension: "吸力", Score: 9}, {Dimension: "噪音", Score: 8}},
+	}}
+	content := render(t, "xlsx", in)
+
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("xlsx is not a valid zip: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet5.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+	for _, sheet := range []string{"品牌排名", "型号排名", "维度矩阵", "评论", "视频来源"} {
+		if !strings.Contains(files["xl/workbook.xml"], `name="`+sheet+`"`) {
+			t.Errorf("workbook missing sheet %s", sheet)
+		}
+	}
+	if !strings.Contains(files["xl/worksheets/sheet1.xml"], `<c r="C2" s="2"><v>7.8</v></c>`) {
+		t.Errorf("ranking sheet should store overall score as number:\n%s", files["xl/worksheets/sheet1.xml"])
+	}
+	comments := files["xl/worksheets/sheet4.xml"]
+	if !strings.Contains(comments, "用户&lt;B&gt;") || !strings.Contains(comments, "吸力 &amp; 续航都不错") {
+		t.Errorf("comment sheet should contain escaped evidence comment:\n%s", comments)
+	}
+	if !strings.Contains(comments, "https://www.bilibili.com/video/BV1xx#reply99") {
+		t.Error("comment sheet should contain comment URL")
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	cases := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for index, want := range cases {
+		if got := columnName(index); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", index, got, want)
+		}
+	}
+}
+
+func TestCSV(t *testing.T) {
+	content := render(t, "csv", sampleInput())
+	if !bytes.HasPrefix(content, []byte("\ufeff")) {
+		t.Fatal("csv should start with UTF-8 BOM")
+	}
+	records, err := csv.NewReader(bytes.NewReader(content[3:])).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	if strings.Join(records[0], ",") != "排名,品牌,综合得分,吸力,噪音,评论数" {
+		t.Errorf("unexpected header %v", records[0])
+	}
+	if strings.Join(records[2], ",") != "2,小米,7.8,7.8,,40" {
+		t.Errorf("missing dimension should be empty, got %v", records[2])
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	md := string(render(t, "md", sampleInput()))
+	for _, want := range []string{"# 吸尘器 评论分析报告", "| 1 | 戴森 | 7.8 | 9.1 | 6.5 |", "| 2 | 小米 | 7.8 | 7.8 | - |", "## 型号排名", "**戴森**：优势 吸力；劣势 -"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	page := string(render(t, "html", sampleInput()))
+	for _, want := range []string{"<svg", "品牌综合得分", "月度口碑趋势", "情感分布", "预算充足选 &lt;戴森&gt;", "https://www.bilibili.com/video/BV1xx"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("html missing %q", want)
+		}
+	}
+	for _, external := range []string{"<script", "<link", "src=\"http"} {
+		if strings.Contains(page, external) {
+			t.Errorf("html should be self-contained, found %q", external)
+		}
+	}
+}
+
+func TestAspectSentimentExport(t *testing.T) {
+	in := sampleInput()
+	in.Data.AspectSentiment = []report.DimensionSentiment{{
+		Dimension: "噪音", PositiveCount: 2, NeutralCount: 1, NegativeCount: 5,
+		NegativeQuotes: []report.AspectQuote{{Span: "太<吵>", Content: "吸力不错就是太<吵>了", Brand: "戴森", Confidence: 0.9}},
+	}}
+
+	md := string(render(t, "md", in))
+	if !strings.Contains(md, "| 噪音 | 2 | 1 | 5 | - | 「太<吵>」 |") {
+		t.Errorf("markdown missing aspect sentiment row:\n%s", md)
+	}
+	page := string(render(t, "html", in))
+	if !strings.Contains(page, "吸力不错就是<mark>太&lt;吵&gt;</mark>了") {
+		t.Error("html should highlight escaped quote span")
+	}
+	content := render(t, "xlsx", in)
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("xlsx is not a valid zip: %v", err)
+	}
+	if len(zr.File) != 11 || zr.File[len(zr.File)-1].Name != "xl/worksheets/sheet6.xml" {
+		t.Errorf("expected aspect sentiment as sixth sheet, got %d files", len(zr.File))
+	}
+}
+
+func TestCommentRowsFallback(t *testing.T) {
+	rows := commentRows(sampleInput())
+	if len(rows) != 2 {
+		t.Fatalf("expected typical comments as fallback, got %+v", rows)
+	}
+	if rows[0].Brand != "戴森" || rows[1].Brand != "小米" || rows[1].Content != "噪音大" {
+		t.Errorf("fallback should follow ranking order: %+v", rows)
+	}
+}
+
+func TestWeightedScoreExport(t *testing.T) {
+	in := sampleInput()
+	if strings.Contains(string(render(t, "md", in)), "加权得分") {
+		t.Error("weighted column should only appear when weighting is enabled")
+	}
+
+	w := report.DefaultCommentWeighting()
+	in.Data.Weighting = &w
+	in.Data.Rankings[0].WeightedScore = 8.4
+	md := string(render(t, "md", in))
+	if !strings.Contains(md, "| 备注 | 加权得分 |") || !strings.Contains(md, "| 1 | 戴森 | 7.8 | 9.1 | 6.5 | - | - | - | 8.4 |") {
+		t.Errorf("markdown missing weighted score:\n%s", md)
+	}
+}
+
+func TestShrunkScoreExport(t *testing.T) {
+	in := sampleInput()
+	r := &in.Data.Rankings[0]
+	r.ShrunkScore, r.CILow, r.CIHigh, r.SampleSize = 7.6, 7.1, 8.2, 40
+
+	// 综合得分保持原始均分，收缩后得分单独一列
+	md := string(render(t, "md", in))
+	if !strings.Contains(md, "| 收缩得分 | 95%区间 |") || !strings.Contains(md, "| 1 | 戴森 | 7.8 | 9.1 | 6.5 | 7.6 | 7.1 ~ 8.2 | - |") {
+		t.Errorf("markdown missing shrunk score:\n%s", md)
+	}
+}
+
+func TestSponsoredComparisonExport(t *testing.T) {
+	in := sampleInput()
+	in.Data.VideoSources[0].Sponsored = true
+	in.Data.VideoSources[0].SponsorSignals = []string{"标题含「恰饭」"}
+	in.Data.SponsoredComparison = &report.SponsoredComparison{
+		Sponsored: report.SponsorGroupStats{VideoCount: 1, CommentCount: 40, OverallScore: 8.9, Sentiment: report.SentimentStats{PositivePct: 80}},
+		Organic:   report.SponsorGroupStats{VideoCount: 2, CommentCount: 80, OverallScore: 7.4, BrandScores: map[string]float64{"小米": 7.1}},
+		ScoreGap:  1.5,
+		Excluded:  true,
+	}
+
+	md := string(render(t, "md", in))
+	for _, want := range []string{"## 商单与非商单视频对比", "| 商单视频 | 1 | 40 | 8.9 | 80.0% |", "高 1.5 分；商单视频下的评论未计入得分和排名"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	page := string(render(t, "html", in))
+	if !strings.Contains(page, "商单与非商单视频对比") || !strings.Contains(page, `title="标题含「恰饭」"`) {
+		t.Error("html missing sponsored comparison or video tag")
+	}
+	content := render(t, "xlsx", in)
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("xlsx is not a valid zip: %v", err)
+	}
+	if len(zr.File) != 11 {
+		t.Errorf("expected sponsored comparison as sixth sheet, got %d files", len(zr.File))
+	}
+}
+
+func TestSuspiciousCommentsExport(t *testing.T) {
+	in := sampleInput()
+	in.Data.SuspiciousComments = &report.SuspiciousSummary{
+		Total:        3,
+		ReasonCounts: map[string]int{comment.SuspicionDuplicate: 2, comment.SuspicionBrandAccount: 1},
+		Excluded:     true,
+		Comments: []comment.SuspiciousComment{
+			{VideoBVID: "BV1", RPID: 42, Author: "某某官方旗舰店", Level: 5, Content: "感谢支持",
+				Reasons: []comment.SuspicionReason{{Kind: comment.SuspicionBrandAccount, Detail: "昵称含「官方」，疑似品牌或店铺账号"}}},
+		},
+	}
+
+	md := string(render(t, "md", in))
+	for _, want := range []string{"## 可疑评论", "内容重复 2 条、品牌账号 1 条", "已在AI分析前排除；以下列出前 1 条", "| 某某官方旗舰店 | 5 | 0 | 感谢支持 |", "#reply42"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+	page := string(render(t, "html", in))
+	if !strings.Contains(page, "<h2>可疑评论</h2>") || !strings.Contains(page, "疑似品牌或店铺账号") {
+		t.Error("html missing suspicious comments section")
+	}
+	content := render(t, "xlsx", in)
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatalf("xlsx is not a valid zip: %v", err)
+	}
+	if len(zr.File) != 11 {
+		t.Errorf("expected suspicious comments as sixth sheet, got %d files", len(zr.File))
+	}
+
+	in.Data.SuspiciousComments = &report.SuspiciousSummary{}
+	if md := string(render(t, "md", in)); strings.Contains(md, "可疑评论") {
+		t.Error("empty summary should not produce a section")
+	}
+}
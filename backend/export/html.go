@@ -0,0 +1,498 @@
+package export
+
+import (
+	"bilibili-analyzer/backend/bilibili"
+	"bilibili-analyzer/backend/report"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+)
+
+// htmlWriter 单文件 HTML 报告
+// 样式和图表（SVG）全部内联，不引用外部脚本、字体或图片，可离线打开或作为邮件附件发送
+type htmlWriter struct{}
+
+func (htmlWriter) Format() string      { return "html" }
+func (htmlWriter) ContentType() string { return "text/html; charset=utf-8" }
+
+func (htmlWriter) Write(w io.Writer, in *Input) error {
+	return htmlTemplate.Execute(w, newHTMLView(in))
+}
+
+// htmlTypicalCommentLimit 每个品牌展示的好评/差评条数
+const htmlTypicalCommentLimit = 3
+
+// chartPalette 趋势图中各品牌的线条颜色（超出时循环使用）
+var chartPalette = []string{"#2563eb", "#dc2626", "#16a34a", "#d97706", "#7c3aed", "#0891b2", "#db2777", "#4b5563"}
+
+// htmlView 模板数据
+type htmlView struct {
+	ReportID       uint
+	CreatedAt      string
+	Data           *report.ReportData
+	Dimensions     []string
+	Matrix         []htmlMatrixRow
+	ScoreChart     template.HTML
+	SentimentChart template.HTML
+	TrendChart     template.HTML
+	Brands         []htmlBrandDetail
+	Videos         []htmlVideo
+}
+
+type htmlMatrixRow struct {
+	Rank   int
+	Brand  string
+	Scores []htmlScoreCell
+}
+
+type htmlScoreCell struct {
+	Text  string
+	Style template.CSS
+}
+
+type htmlBrandDetail struct {
+	Brand      string
+	Strengths  string
+	Weaknesses string
+	Top        []report.TypicalComment
+	Bad        []report.TypicalComment
+}
+
+type htmlVideo struct {
+	report.VideoSource
+	URL string
+}
+
+func newHTMLView(in *Input) *htmlView {
+	data := in.Data
+	view := &htmlView{
+		ReportID:       in.ReportID,
+		Data:           data,
+		Dimensions:     dimensionNames(data),
+		ScoreChart:     scoreBarChart(data.Rankings),
+		SentimentChart: sentimentChart(data),
+		TrendChart:     trendChart(data.Trends),
+	}
+	if !in.CreatedAt.IsZero() {
+		view.CreatedAt = in.CreatedAt.Format("2006-01-02 15:04")
+	}
+
+	for _, r := range data.Rankings {
+		row := htmlMatrixRow{Rank: r.Rank, Brand: r.Brand}
+		for _, d := range view.Dimensions {
+			score, ok := r.Scores[d]
+			if !ok {
+				row.Scores = append(row.Scores, htmlScoreCell{Text: "-"})
+				continue
+			}
+			row.Scores = append(row.Scores, htmlScoreCell{Text: formatScore(score), Style: scoreCellStyle(score)})
+		}
+		view.Matrix = append(view.Matrix, row)
+
+		analysis := data.BrandAnalysis[r.Brand]
+		view.Brands = append(view.Brands, htmlBrandDetail{
+			Brand:      r.Brand,
+			Strengths:  joinOrDash(analysis.Strengths),
+			Weaknesses: joinOrDash(analysis.Weaknesses),
+			Top:        data.TopComments[r.Brand][:min(len(data.TopComments[r.Brand]), htmlTypicalCommentLimit)],
+			Bad:        data.BadComments[r.Brand][:min(len(data.BadComments[r.Brand]), htmlTypicalCommentLimit)],
+		})
+	}
+
+	for _, v := range data.VideoSources {
+		view.Videos = append(view.Videos, htmlVideo{VideoSource: v, URL: bilibili.CommentURL(v.BVID, 0)})
+	}
+	return view
+}
+
+// scoreCellStyle 得分单元格配色（与 PDF 维度矩阵相同：≥8 绿、≥6 黄、其余红）
+func scoreCellStyle(score float64) template.CSS {
+	switch {
+	case score >= 8.0:
+		return "background:#dcfce7;color:#065f46"
+	case score >= 6.0:
+		return "background:#fffbeb;color:#92400e"
+	default:
+		return "background:#fee2e2;color:#991b1b"
+	}
+}
+
+// scoreBarChart 品牌综合得分横向条形图（满分10分）
+func scoreBarChart(rankings []report.BrandRanking) template.HTML {
+	if len(rankings) == 0 {
+		return ""
+	}
+	const (
+		labelW = 120
+		barW   = 480
+		rowH   = 30
+	)
+	height := len(rankings)*rowH + 10
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" width="100%%" role="img" aria-label="品牌综合得分">`, labelW+barW+50, height)
+	for i, r := range rankings {
+		y := i*rowH + 5
+		width := int(min(max(r.OverallScore, 0), 10) / 10 * barW)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end" class="label">%s</text>`, labelW-8, y+18, svgText(r.Brand))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="20" rx="3" fill="#e5e7eb"/>`, labelW, y+4, barW)
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="20" rx="3" fill="%s"/>`, labelW, y+4, width, barColor(r.OverallScore))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" class="value">%s</text>`, labelW+barW+8, y+18, formatScore(r.OverallScore))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+func barColor(score float64) string {
+	switch {
+	case score >= 8.0:
+		return "#16a34a"
+	case score >= 6.0:
+		return "#d97706"
+	default:
+		return "#dc2626"
+	}
+}
+
+// sentimentChart 好评/中性/差评堆叠条形图：第一行为整体，其后为各品牌（报告包含品牌情感分布时）
+func sentimentChart(data *report.ReportData) template.HTML {
+	overall := data.SentimentDistribution
+	if overall.PositiveCount+overall.NeutralCount+overall.NegativeCount == 0 {
+		return ""
+	}
+	type bar struct {
+		label string
+		stats report.SentimentStats
+	}
+	bars := []bar{{"整体", overall}}
+	for _, r := range data.Rankings {
+		if stats, ok := data.BrandSentiment[r.Brand]; ok {
+			bars = append(bars, bar{r.Brand, stats})
+		}
+	}
+
+	const (
+		labelW = 120
+		barW   = 480
+		rowH   = 30
+	)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" width="100%%" role="img" aria-label="情感分布">`, labelW+barW+10, len(bars)*rowH+30)
+	for i, item := range bars {
+		y := i*rowH + 5
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="end" class="label">%s</text>`, labelW-8, y+18, svgText(item.label))
+		total := item.stats.PositiveCount + item.stats.NeutralCount + item.stats.NegativeCount
+		if total == 0 {
+			continue
+		}
+		x := float64(labelW)
+		for _, seg := range []struct {
+			count int
+			color string
+		}{
+			{item.stats.PositiveCount, "#16a34a"},
+			{item.stats.NeutralCount, "#9ca3af"},
+			{item.stats.NegativeCount, "#dc2626"},
+		} {
+			width := float64(seg.count) / float64(total) * barW
+			if width <= 0 {
+				continue
+			}
+			fmt.Fprintf(&b, `<rect x="%.1f" y="%d" width="%.1f" height="20" fill="%s"><title>%d</title></rect>`, x, y+4, width, seg.color, seg.count)
+			if width >= 36 {
+				fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" class="inbar">%.0f%%</text>`, x+width/2, y+18, float64(seg.count)/float64(total)*100)
+			}
+			x += width
+		}
+	}
+	legendY := len(bars)*rowH + 20
+	for i, legend := range []struct{ label, color string }{{"好评", "#16a34a"}, {"中性", "#9ca3af"}, {"差评", "#dc2626"}} {
+		x := labelW + i*80
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/><text x="%d" y="%d" class="label">%s</text>`, x, legendY-10, legend.color, x+16, legendY, legend.label)
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// trendChart 各品牌月度综合得分折线图（纵轴0-10分），少于两个月时不绘制
+func trendChart(trends []report.BrandTrend) template.HTML {
+	var months []string
+	for _, t := range trends {
+		for _, p := range t.Overall {
+			if !slices.Contains(months, p.Month) {
+				months = append(months, p.Month)
+			}
+		}
+	}
+	if len(months) < 2 {
+		return ""
+	}
+	slices.Sort(months)
+
+	const (
+		left   = 40
+		top    = 10
+		plotW  = 560
+		plotH  = 200
+		bottom = 30
+	)
+	xOf := func(month string) float64 {
+		return left + float64(slices.Index(months, month))*plotW/float64(len(months)-1)
+	}
+	yOf := func(score float64) float64 {
+		return top + (10-min(max(score, 0), 10))/10*plotH
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" width="100%%" role="img" aria-label="月度口碑趋势">`, left+plotW+20, top+plotH+bottom+len(trends)*18)
+	for score := 0; score <= 10; score += 2 {
+		y := yOf(float64(score))
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#e5e7eb"/>`, left, y, left+plotW, y)
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end" class="axis">%d</text>`, left-6, y+4, score)
+	}
+	// 月份较多时只标注部分月份，避免文字重叠
+	step := max(1, (len(months)+7)/8)
+	for i, month := range months {
+		if i%step == 0 || i == len(months)-1 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" class="axis">%s</text>`, xOf(month), top+plotH+18, month)
+		}
+	}
+	for i, t := range trends {
+		color := chartPalette[i%len(chartPalette)]
+		points := make([]string, 0, len(t.Overall))
+		for _, p := range t.Overall {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", xOf(p.Month), yOf(p.Score)))
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.Join(points, " "), color)
+		for _, p := range t.Overall {
+			fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"><title>%s %s：%s（%d条）</title></circle>`,
+				xOf(p.Month), yOf(p.Score), color, svgText(t.Brand), p.Month, formatScore(p.Score), p.CommentCount)
+		}
+		legendY := top + plotH + bottom + i*18 + 10
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/><text x="%d" y="%d" class="label">%s</text>`, left, legendY-10, color, left+16, legendY, svgText(t.Brand))
+	}
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// highlightSpan 转义评论内容并用 <mark> 标出原文片段（片段不在内容中时只转义）
+func highlightSpan(content, span string) template.HTML {
+	i := strings.Index(content, span)
+	if span == "" || i < 0 {
+		return template.HTML(template.HTMLEscapeString(content))
+	}
+	return template.HTML(template.HTMLEscapeString(content[:i]) + "<mark>" + template.HTMLEscapeString(span) + "</mark>" +
+		template.HTMLEscapeString(content[i+len(span):]))
+}
+
+// svgText 转义 SVG 中的文本
+func svgText(s string) string {
+	return template.HTMLEscapeString(s)
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"score":          formatScore,
+	"highlight":      highlightSpan,
+	"sponsorGroups":  sponsorGroups,
+	"sponsorSummary": sponsorSummary,
+	"suspicious":     suspiciousSummary,
+	"reasons":        suspiciousReasons,
+	"commentURL":     bilibili.CommentURL,
+	"join":           func(items []string) string { return strings.Join(items, "；") },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Data.Category}} 评论分析报告</title>
+<style>
+body{margin:0;background:#f3f4f6;color:#111827;font-family:-apple-system,"PingFang SC","Microsoft YaHei","Noto Sans CJK SC",sans-serif;line-height:1.6}
+main{max-width:960px;margin:0 auto;padding:32px 20px}
+h1{margin:0 0 4px;font-size:28px}
+h2{margin:0 0 16px;font-size:20px}
+section{background:#fff;border-radius:12px;padding:24px;margin-top:20px;box-shadow:0 1px 3px rgba(0,0,0,.08)}
+.meta{color:#6b7280;font-size:14px}
+.cards{display:grid;grid-template-columns:repeat(auto-fit,minmax(160px,1fr));gap:12px;margin-top:20px}
+.card{background:#fff;border-radius:12px;padding:16px;box-shadow:0 1px 3px rgba(0,0,0,.08)}
+.card b{display:block;font-size:24px}
+.card span{color:#6b7280;font-size:13px}
+table{width:100%;border-collapse:collapse;font-size:14px}
+th,td{padding:8px 10px;border-bottom:1px solid #e5e7eb;text-align:left}
+th{background:#f9fafb;font-weight:600}
+td.num,th.num{text-align:center}
+svg .label{font-size:13px;fill:#374151}
+svg .value{font-size:13px;font-weight:600;fill:#111827}
+svg .axis{font-size:11px;fill:#6b7280}
+svg .inbar{font-size:11px;fill:#fff}
+.brand{border-top:1px solid #e5e7eb;padding-top:12px;margin-top:12px}
+.brand:first-of-type{border-top:none;margin-top:0;padding-top:0}
+.quote{margin:6px 0;padding:6px 10px;border-left:3px solid #d1d5db;font-size:14px;color:#374151}
+.quote.good{border-color:#16a34a}
+.quote.bad{border-color:#dc2626}
+.quote small{color:#6b7280}
+.quote mark{background:#fef08a;padding:0 2px}
+.recommendation{white-space:pre-wrap}
+a{color:#2563eb;text-decoration:none}
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Data.Category}} 评论分析报告</h1>
+<div class="meta">报告ID {{.ReportID}}{{if .CreatedAt}} · 生成于 {{.CreatedAt}}{{end}}</div>
+
+<div class="cards">
+<div class="card"><b>{{.Data.Stats.TotalVideos}}</b><span>分析视频</span></div>
+<div class="card"><b>{{.Data.Stats.TotalComments}}</b><span>抓取评论</span></div>
+{{- if .Data.Stats.TotalDanmaku}}
+<div class="card"><b>{{.Data.Stats.TotalDanmaku}}</b><span>抓取弹幕</span></div>
+{{- end}}
+<div class="card"><b>{{len .Data.Rankings}}</b><span>对比品牌</span></div>
+{{- with .Data.Rankings}}
+<div class="card"><b>{{(index . 0).Brand}}</b><span>综合第一（{{score (index . 0).OverallScore}}分）</span></div>
+{{- end}}
+</div>
+
+{{- if .ScoreChart}}
+<section>
+<h2>品牌综合得分</h2>
+{{.ScoreChart}}
+</section>
+{{- end}}
+
+<section>
+<h2>维度得分</h2>
+<table>
+<thead><tr><th class="num">排名</th><th>品牌</th>{{range .Dimensions}}<th class="num">{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Matrix}}
+<tr><td class="num">{{.Rank}}</td><td>{{.Brand}}</td>{{range .Scores}}<td class="num" style="{{.Style}}">{{.Text}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+</section>
+
+{{- if .SentimentChart}}
+<section>
+<h2>情感分布</h2>
+{{.SentimentChart}}
+</section>
+{{- end}}
+
+{{- with .Data.SponsoredComparison}}
+<section>
+<h2>商单与非商单视频对比</h2>
+<table>
+<thead><tr><th>分组</th><th class="num">视频数</th><th class="num">评论数</th><th class="num">综合得分</th><th class="num">好评</th><th class="num">中性</th><th class="num">差评</th></tr></thead>
+<tbody>
+{{- range sponsorGroups .}}
+<tr><td>{{.Label}}</td><td class="num">{{.VideoCount}}</td><td class="num">{{.CommentCount}}</td><td class="num">{{score .OverallScore}}</td><td class="num">{{score .Sentiment.PositivePct}}%</td><td class="num">{{score .Sentiment.NeutralPct}}%</td><td class="num">{{score .Sentiment.NegativePct}}%</td></tr>
+{{- end}}
+</tbody>
+</table>
+<div class="meta">{{sponsorSummary .}}</div>
+</section>
+{{- end}}
+
+{{- with .Data.SuspiciousComments}}{{if .Total}}
+<section>
+<h2>可疑评论</h2>
+<div class="meta">{{suspicious .}}</div>
+<table>
+<thead><tr><th>作者</th><th class="num">等级</th><th class="num">点赞数</th><th>内容</th><th>判定依据</th></tr></thead>
+<tbody>
+{{- range .Comments}}
+<tr><td>{{.Author}}</td><td class="num">{{.Level}}</td><td class="num">{{.Like}}</td><td><a href="{{commentURL .VideoBVID .RPID}}">{{.Content}}</a></td><td>{{reasons .}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+</section>
+{{- end}}{{end}}
+
+{{- with .Data.AspectSentiment}}
+<section>
+<h2>维度情感</h2>
+<table>
+<thead><tr><th>维度</th><th class="num">正面</th><th class="num">中性</th><th class="num">负面</th></tr></thead>
+<tbody>
+{{- range .}}
+<tr><td>{{.Dimension}}</td><td class="num">{{.PositiveCount}}</td><td class="num">{{.NeutralCount}}</td><td class="num">{{.NegativeCount}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{- range .}}
+{{- $dim := .Dimension}}
+{{- range .PositiveQuotes}}
+<div class="quote good">{{highlight .Content .Span}} <small>{{$dim}} · {{.Brand}}{{if .URL}} · <a href="{{.URL}}">原评论</a>{{end}}</small></div>
+{{- end}}
+{{- range .NegativeQuotes}}
+<div class="quote bad">{{highlight .Content .Span}} <small>{{$dim}} · {{.Brand}}{{if .URL}} · <a href="{{.URL}}">原评论</a>{{end}}</small></div>
+{{- end}}
+{{- end}}
+</section>
+{{- end}}
+
+{{- if .TrendChart}}
+<section>
+<h2>月度口碑趋势</h2>
+{{.TrendChart}}
+</section>
+{{- end}}
+
+{{- with .Data.ModelRankings}}
+<section>
+<h2>型号排名</h2>
+<table>
+<thead><tr><th class="num">排名</th><th>型号</th><th>品牌</th><th class="num">综合得分</th><th class="num">评论数</th><th>型号库</th></tr></thead>
+<tbody>
+{{- range .}}
+<tr><td class="num">{{.Rank}}</td><td>{{.Model}}</td><td>{{.Brand}}</td><td class="num">{{score .OverallScore}}</td><td class="num">{{.CommentCount}}</td><td>{{if .Verified}}已收录{{if .ReleaseYear}}（{{.ReleaseYear}}）{{end}}{{end}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+</section>
+{{- end}}
+
+{{- with .Brands}}
+<section>
+<h2>品牌详情</h2>
+{{- range .}}
+<div class="brand">
+<h3>{{.Brand}}</h3>
+<div>优势：{{.Strengths}}</div>
+<div>劣势：{{.Weaknesses}}</div>
+{{- range .Top}}
+<div class="quote good">{{.Content}} <small>{{score .Score}}分{{if .Author}} · {{.Author}}{{end}}{{if .URL}} · <a href="{{.URL}}">原评论</a>{{end}}</small></div>
+{{- end}}
+{{- range .Bad}}
+<div class="quote bad">{{.Content}} <small>{{score .Score}}分{{if .Author}} · {{.Author}}{{end}}{{if .URL}} · <a href="{{.URL}}">原评论</a>{{end}}</small></div>
+{{- end}}
+</div>
+{{- end}}
+</section>
+{{- end}}
+
+{{- with .Data.Recommendation}}
+<section>
+<h2>购买建议</h2>
+<div class="recommendation">{{.}}</div>
+</section>
+{{- end}}
+
+{{- with .Videos}}
+<section>
+<h2>视频来源</h2>
+<table>
+<thead><tr><th>标题</th><th>UP主</th><th class="num">播放量</th><th class="num">评论数</th><th>商单</th></tr></thead>
+<tbody>
+{{- range .}}
+<tr><td><a href="{{.URL}}">{{.Title}}</a></td><td>{{.Author}}</td><td class="num">{{.Play}}</td><td class="num">{{.VideoReview}}</td><td>{{if .Sponsored}}<span title="{{join .SponsorSignals}}">是</span>{{end}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+</section>
+{{- end}}
+</main>
+</body>
+</html>
+`))
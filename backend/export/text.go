@@ -0,0 +1,176 @@
+package export
+
+import (
+	"bilibili-analyzer/backend/bilibili"
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// csvWriter 品牌排名表：排名、品牌、综合得分、各维度得分、评论数
+// 开头写入 UTF-8 BOM，Excel 直接打开时中文不乱码
+type csvWriter struct{}
+
+func (csvWriter) Format() string      { return "csv" }
+func (csvWriter) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (csvWriter) Write(w io.Writer, in *Input) error {
+	data := in.Data
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+
+	dimensions := dimensionNames(data)
+	header := append([]string{"排名", "品牌", "综合得分"}, dimensions...)
+	header = append(header, "评论数")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range data.Rankings {
+		row := []string{strconv.Itoa(r.Rank), r.Brand, formatScore(r.OverallScore)}
+		for _, d := range dimensions {
+			score, ok := r.Scores[d]
+			if !ok {
+				row = append(row, "")
+				continue
+			}
+			row = append(row, formatScore(score))
+		}
+		row = append(row, strconv.Itoa(data.Stats.CommentsByBrand[r.Brand]))
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// markdownWriter 报告摘要：统计、品牌排名、型号排名、优劣势、维度情感、商单对比和购买建议
+type markdownWriter struct{}
+
+func (markdownWriter) Format() string      { return "md" }
+func (markdownWriter) ContentType() string { return "text/markdown; charset=utf-8" }
+
+func (markdownWriter) Write(w io.Writer, in *Input) error {
+	data := in.Data
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s 评论分析报告\n\n", data.Category)
+	fmt.Fprintf(&b, "> 报告ID %d · 视频 %d 个 · 评论 %d 条", in.ReportID, data.Stats.TotalVideos, data.Stats.TotalComments)
+	if data.Stats.TotalDanmaku > 0 {
+		fmt.Fprintf(&b, " · 弹幕 %d 条", data.Stats.TotalDanmaku)
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString("## 品牌排名\n\n")
+	dimensions := dimensionNames(data)
+	header := append([]string{"排名", "品牌", "综合得分"}, dimensions...)
+	header = append(header, "收缩得分", "95%区间", "备注")
+	weighted := data.Weighting != nil
+	if weighted {
+		header = append(header, "加权得分")
+	}
+	writeMarkdownRow(&b, header)
+	writeMarkdownRow(&b, slices.Repeat([]string{"---"}, len(header)))
+	for _, r := range data.Rankings {
+		row := []string{strconv.Itoa(r.Rank), r.Brand, formatScore(r.OverallScore)}
+		for _, d := range dimensions {
+			if score, ok := r.Scores[d]; ok {
+				row = append(row, formatScore(score))
+			} else {
+				row = append(row, "-")
+			}
+		}
+		row = append(row, shrunkScoreLabel(r), intervalLabel(r.CILow, r.CIHigh, r.SampleSize), cmp.Or(rankingNote(r), "-"))
+		if weighted {
+			row = append(row, formatScore(r.WeightedScore))
+		}
+		writeMarkdownRow(&b, row)
+	}
+	b.WriteString("\n")
+	if weighted {
+		b.WriteString("> 加权得分按点赞数、回复数、亲身体验表述和账号等级对评论加权，楼中楼回复和同一账号的多条评论降权；排名按未加权得分。\n\n")
+	}
+
+	if len(data.ModelRankings) > 0 {
+		b.WriteString("## 型号排名\n\n")
+		writeMarkdownRow(&b, []string{"排名", "型号", "品牌", "综合得分", "评论数", "型号库"})
+		writeMarkdownRow(&b, slices.Repeat([]string{"---"}, 6))
+		for _, m := range data.ModelRankings {
+			writeMarkdownRow(&b, []string{strconv.Itoa(m.Rank), m.Model, m.Brand, formatScore(m.OverallScore), strconv.Itoa(m.CommentCount), cmp.Or(verifiedLabel(m.Verified), "-")})
+		}
+		b.WriteString("\n")
+	}
+
+	if len(data.BrandAnalysis) > 0 {
+		b.WriteString("## 品牌优劣势\n\n")
+		for _, r := range data.Rankings {
+			analysis, ok := data.BrandAnalysis[r.Brand]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(&b, "- **%s**：优势 %s；劣势 %s\n", r.Brand, joinOrDash(analysis.Strengths), joinOrDash(analysis.Weaknesses))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(data.AspectSentiment) > 0 {
+		b.WriteString("## 维度情感\n\n")
+		writeMarkdownRow(&b, []string{"维度", "正面", "中性", "负面", "正面引文", "负面引文"})
+		writeMarkdownRow(&b, slices.Repeat([]string{"---"}, 6))
+		for _, d := range data.AspectSentiment {
+			writeMarkdownRow(&b, []string{d.Dimension, strconv.Itoa(d.PositiveCount), strconv.Itoa(d.NeutralCount), strconv.Itoa(d.NegativeCount),
+				joinOrDash(quoteSpans(d.PositiveQuotes)), joinOrDash(quoteSpans(d.NegativeQuotes))})
+		}
+		b.WriteString("\n")
+	}
+
+	if c := data.SponsoredComparison; c != nil {
+		b.WriteString("## 商单与非商单视频对比\n\n")
+		writeMarkdownRow(&b, []string{"分组", "视频数", "评论数", "综合得分", "好评", "中性", "差评"})
+		writeMarkdownRow(&b, slices.Repeat([]string{"---"}, 7))
+		for _, g := range sponsorGroups(c) {
+			writeMarkdownRow(&b, []string{g.Label, strconv.Itoa(g.VideoCount), strconv.Itoa(g.CommentCount), formatScore(g.OverallScore),
+				formatScore(g.Sentiment.PositivePct) + "%", formatScore(g.Sentiment.NeutralPct) + "%", formatScore(g.Sentiment.NegativePct) + "%"})
+		}
+		b.WriteString("\n> " + sponsorSummary(c) + "\n\n")
+	}
+
+	if s := data.SuspiciousComments; s != nil && s.Total > 0 {
+		b.WriteString("## 可疑评论\n\n")
+		b.WriteString("> " + suspiciousSummary(s) + "\n\n")
+		writeMarkdownRow(&b, []string{"作者", "等级", "点赞数", "内容", "判定依据", "链接"})
+		writeMarkdownRow(&b, slices.Repeat([]string{"---"}, 6))
+		for _, c := range s.Comments {
+			writeMarkdownRow(&b, []string{c.Author, strconv.Itoa(c.Level), strconv.Itoa(c.Like), c.Content,
+				suspiciousReasons(c), bilibili.CommentURL(c.VideoBVID, c.RPID)})
+		}
+		b.WriteString("\n")
+	}
+
+	if data.Recommendation != "" {
+		b.WriteString("## 购买建议\n\n")
+		b.WriteString(strings.TrimSpace(data.Recommendation))
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownRow 输出表格行（转义单元格中的竖线，换行替换为空格）
+func writeMarkdownRow(b *strings.Builder, cells []string) {
+	b.WriteString("|")
+	for _, cell := range cells {
+		cell = strings.ReplaceAll(cell, "|", "\\|")
+		cell = strings.ReplaceAll(cell, "\n", " ")
+		b.WriteString(" ")
+		b.WriteString(cell)
+		b.WriteString(" |")
+	}
+	b.WriteString("\n")
+}
@@ -0,0 +1,440 @@
+package export
+
+import (
+	"archive/zip"
+	"bilibili-analyzer/backend/bilibili"
+	"bilibili-analyzer/backend/report"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// xlsxWriter Excel 工作簿：品牌排名、型号排名、维度矩阵、评论和视频来源各一个工作表，
+// 报告包含维度情感、商单对比、可疑评论时分别追加"维度情感"、"商单对比"、"可疑评论"工作表
+// 不依赖第三方库，直接按 Office Open XML 格式写出最小工作簿（内联字符串、首行加粗并冻结）
+type xlsxWriter struct{}
+
+func (xlsxWriter) Format() string { return "xlsx" }
+func (xlsxWriter) ContentType() string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+func (xlsxWriter) Write(w io.Writer, in *Input) error {
+	sheets := []xlsxSheet{
+		rankingSheet(in),
+		modelRankingSheet(in),
+		dimensionMatrixSheet(in),
+		commentSheet(in),
+		videoSourceSheet(in),
+	}
+	if len(in.Data.AspectSentiment) > 0 {
+		sheets = append(sheets, aspectSentimentSheet(in))
+	}
+	if in.Data.SponsoredComparison != nil {
+		sheets = append(sheets, sponsoredComparisonSheet(in))
+	}
+	if s := in.Data.SuspiciousComments; s != nil && s.Total > 0 {
+		sheets = append(sheets, suspiciousSheet(s))
+	}
+	return writeWorkbook(w, sheets)
+}
+
+// xlsxSheet 工作表，首行为表头
+// 单元格取值：string 写为文本，int 写为整数，float64 写为保留一位小数的得分，nil 为空单元格
+type xlsxSheet struct {
+	name string
+	rows [][]any
+}
+
+// 单元格样式序号（对应 xlsxStyles 中 cellXfs 的顺序）
+const (
+	xlsxStyleDefault = 0
+	xlsxStyleHeader  = 1
+	xlsxStyleScore   = 2
+)
+
+// xlsxMaxColumnWidth 自动列宽的上限（字符数），评论内容等长文本不撑满屏幕
+const xlsxMaxColumnWidth = 60
+
+func rankingSheet(in *Input) xlsxSheet {
+	data := in.Data
+	dimensions := dimensionNames(data)
+	header := []any{"排名", "品牌", "综合得分", "评论数"}
+	for _, d := range dimensions {
+		header = append(header, d)
+	}
+	header = append(header, "优势", "劣势", "收缩得分", "区间下限", "区间上限", "备注")
+	weighted := data.Weighting != nil
+	if weighted {
+		header = append(header, "加权得分")
+	}
+
+	rows := [][]any{header}
+	for _, r := range data.Rankings {
+		row := []any{r.Rank, r.Brand, r.OverallScore, data.Stats.CommentsByBrand[r.Brand]}
+		row = appendScores(row, r.Scores, dimensions)
+		analysis := data.BrandAnalysis[r.Brand]
+		row = append(row, strings.Join(analysis.Strengths, "、"), strings.Join(analysis.Weaknesses, "、"))
+		if r.SampleSize > 0 {
+			row = append(row, r.ShrunkScore, r.CILow, r.CIHigh, rankingNote(r))
+		} else if weighted {
+			row = append(row, nil, nil, nil, nil)
+		}
+		if weighted {
+			row = append(row, r.WeightedScore)
+		}
+		rows = append(rows, row)
+	}
+	return xlsxSheet{name: "品牌排名", rows: rows}
+}
+
+func modelRankingSheet(in *Input) xlsxSheet {
+	dimensions := dimensionNames(in.Data)
+	header := []any{"排名", "型号", "品牌", "综合得分", "评论数", "型号库", "发布年份"}
+	for _, d := range dimensions {
+		header = append(header, d)
+	}
+	header = append(header, "收缩得分", "区间下限", "区间上限", "样本不足")
+	weighted := in.Data.Weighting != nil
+	if weighted {
+		header = append(header, "加权得分")
+	}
+
+	rows := [][]any{header}
+	for _, m := range in.Data.ModelRankings {
+		row := []any{m.Rank, m.Model, m.Brand, m.OverallScore, m.CommentCount, verifiedLabel(m.Verified), nil}
+		if m.ReleaseYear > 0 {
+			row[6] = m.ReleaseYear
+		}
+		row = appendScores(row, m.Scores, dimensions)
+		if m.CIHigh > 0 {
+			row = append(row, m.ShrunkScore, m.CILow, m.CIHigh, lowSampleLabel(m.LowSample))
+		} else if weighted {
+			row = append(row, nil, nil, nil, nil)
+		}
+		if weighted {
+			row = append(row, m.WeightedScore)
+		}
+		rows = append(rows, row)
+	}
+	return xlsxSheet{name: "型号排名", rows: rows}
+}
+
+// dimensionMatrixSheet 维度 × 品牌得分矩阵（品牌按排名顺序排列）
+func dimensionMatrixSheet(in *Input) xlsxSheet {
+	data := in.Data
+	header := []any{"维度", "说明"}
+	for _, r := range data.Rankings {
+		header = append(header, r.Brand)
+	}
+
+	rows := [][]any{header}
+	for _, d := range data.Dimensions {
+		row := []any{d.Name, d.Description}
+		for _, r := range data.Rankings {
+			if score, ok := data.Scores[r.Brand][d.Name]; ok {
+				row = append(row, score)
+			} else {
+				row = append(row, nil)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return xlsxSheet{name: "维度矩阵", rows: rows}
+}
+
+func commentSheet(in *Input) xlsxSheet {
+	dimensions := dimensionNames(in.Data)
+	header := []any{"品牌", "型号", "来源", "作者", "点赞数", "综合得分"}
+	for _, d := range dimensions {
+		header = append(header, d)
+	}
+	header = append(header, "发布时间", "内容", "链接")
+
+	rows := [][]any{header}
+	for _, c := range commentRows(in) {
+		row := []any{c.Brand, c.Model, sourceLabel(c.Source), c.Author, c.Like, c.Score}
+		row = appendScores(row, c.Scores, dimensions)
+		publishTime := ""
+		if !c.PublishTime.IsZero() {
+			publishTime = c.PublishTime.Format("2006-01-02 15:04")
+		}
+		rows = append(rows, append(row, publishTime, c.Content, c.URL))
+	}
+	return xlsxSheet{name: "评论", rows: rows}
+}
+
+func videoSourceSheet(in *Input) xlsxSheet {
+	rows := [][]any{{"BV号", "标题", "UP主", "播放量", "评论数", "链接", "商单", "商单依据"}}
+	for _, v := range in.Data.VideoSources {
+		rows = append(rows, []any{v.BVID, v.Title, v.Author, v.Play, v.VideoReview, bilibili.CommentURL(v.BVID, 0),
+			sponsoredLabel(v.Sponsored), strings.Join(v.SponsorSignals, "；")})
+	}
+	return xlsxSheet{name: "视频来源", rows: rows}
+}
+
+// sponsoredComparisonSheet 商单与非商单视频下评论的得分、情感分布和各品牌得分
+func sponsoredComparisonSheet(in *Input) xlsxSheet {
+	c := in.Data.SponsoredComparison
+	header := []any{"分组", "视频数", "评论数", "综合得分", "好评率(%)", "中性率(%)", "差评率(%)"}
+	for _, r := range in.Data.Rankings {
+		header = append(header, r.Brand)
+	}
+	rows := [][]any{header}
+	for _, g := range sponsorGroups(c) {
+		row := []any{g.Label, g.VideoCount, g.CommentCount, g.OverallScore, g.Sentiment.PositivePct, g.Sentiment.NeutralPct, g.Sentiment.NegativePct}
+		for _, r := range in.Data.Rankings {
+			if score, ok := g.BrandScores[r.Brand]; ok {
+				row = append(row, score)
+			} else {
+				row = append(row, nil)
+			}
+		}
+		rows = append(rows, row)
+	}
+	rows = append(rows, []any{sponsorSummary(c)})
+	return xlsxSheet{name: "商单对比", rows: rows}
+}
+
+// suspiciousSheet AI分析前识别出的可疑评论及判定依据，末行为汇总
+func suspiciousSheet(s *report.SuspiciousSummary) xlsxSheet {
+	rows := [][]any{{"BV号", "作者", "UID", "等级", "点赞数", "内容", "判定依据", "链接"}}
+	for _, c := range s.Comments {
+		rows = append(rows, []any{c.VideoBVID, c.Author, strconv.FormatInt(c.Mid, 10), c.Level, c.Like, c.Content,
+			suspiciousReasons(c), bilibili.CommentURL(c.VideoBVID, c.RPID)})
+	}
+	rows = append(rows, []any{suspiciousSummary(s)})
+	return xlsxSheet{name: "可疑评论", rows: rows}
+}
+
+// aspectSentimentSheet 各维度正面/中性/负面评论数，以及品牌维度明细和引文
+func aspectSentimentSheet(in *Input) xlsxSheet {
+	rows := [][]any{{"品牌", "维度", "正面", "中性", "负面", "正面引文", "负面引文"}}
+	appendRows := func(brand string, stats []report.DimensionSentiment) {
+		for _, d := range stats {
+			rows = append(rows, []any{brand, d.Dimension, d.PositiveCount, d.NeutralCount, d.NegativeCount,
+				strings.Join(quoteSpans(d.PositiveQuotes), "\n"), strings.Join(quoteSpans(d.NegativeQuotes), "\n")})
+		}
+	}
+	appendRows("全部", in.Data.AspectSentiment)
+	for _, r := range in.Data.Rankings {
+		appendRows(r.Brand, in.Data.BrandAspectSentiment[r.Brand])
+	}
+	return xlsxSheet{name: "维度情感", rows: rows}
+}
+
+// appendScores 按维度顺序追加得分，缺少的维度留空
+func appendScores(row []any, scores map[string]float64, dimensions []string) []any {
+	for _, d := range dimensions {
+		if score, ok := scores[d]; ok {
+			row = append(row, score)
+		} else {
+			row = append(row, nil)
+		}
+	}
+	return row
+}
+
+// xlsxPart 压缩包中的一个文件
+type xlsxPart struct {
+	name  string
+	write func(io.Writer) error
+}
+
+// writeWorkbook 写出 xlsx 压缩包
+func writeWorkbook(w io.Writer, sheets []xlsxSheet) error {
+	zw := zip.NewWriter(w)
+	parts := []xlsxPart{
+		{"[Content_Types].xml", func(w io.Writer) error { return writeContentTypes(w, len(sheets)) }},
+		{"_rels/.rels", writeString(xlsxRootRels)},
+		{"xl/workbook.xml", func(w io.Writer) error { return writeWorkbookXML(w, sheets) }},
+		{"xl/_rels/workbook.xml.rels", func(w io.Writer) error { return writeWorkbookRels(w, len(sheets)) }},
+		{"xl/styles.xml", writeString(xlsxStyles)},
+	}
+	for i := range sheets {
+		sheet := sheets[i]
+		parts = append(parts, xlsxPart{
+			name:  fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1),
+			write: func(w io.Writer) error { return writeSheetXML(w, sheet) },
+		})
+	}
+
+	for _, f := range parts {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		bw := bufio.NewWriter(fw)
+		if err := f.write(bw); err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", f.name, err)
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeString(s string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	}
+}
+
+const xlsxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+const xlsxRootRels = xlsxHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles 样式表：默认、表头加粗、得分保留一位小数
+const xlsxStyles = xlsxHeader + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="0.0"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+func writeContentTypes(w io.Writer, sheetCount int) error {
+	var b strings.Builder
+	b.WriteString(xlsxHeader)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheetCount; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeWorkbookXML(w io.Writer, sheets []xlsxSheet) error {
+	var b strings.Builder
+	b.WriteString(xlsxHeader)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(s.name), i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeWorkbookRels 工作簿关系：rId1..N 为工作表，rId(N+1) 为样式表
+func writeWorkbookRels(w io.Writer, sheetCount int) error {
+	var b strings.Builder
+	b.WriteString(xlsxHeader)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheetCount; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheetCount+1)
+	b.WriteString(`</Relationships>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeSheetXML(w io.Writer, sheet xlsxSheet) error {
+	var b strings.Builder
+	b.WriteString(xlsxHeader)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+
+	if widths := columnWidths(sheet.rows); len(widths) > 0 {
+		b.WriteString(`<cols>`)
+		for i, width := range widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+		}
+		b.WriteString(`</cols>`)
+	}
+
+	b.WriteString(`<sheetData>`)
+	for r, row := range sheet.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, value := range row {
+			ref := columnName(c) + strconv.Itoa(r+1)
+			style := xlsxStyleDefault
+			if r == 0 {
+				style = xlsxStyleHeader
+			}
+			switch v := value.(type) {
+			case string:
+				if v == "" {
+					continue
+				}
+				fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(v))
+			case int:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%d</v></c>`, ref, style, v)
+			case float64:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, xlsxStyleScore, strconv.FormatFloat(v, 'f', -1, 64))
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// columnWidths 按每列最长的文本估算列宽（中文等宽字符按两个字符计算）
+func columnWidths(rows [][]any) []int {
+	var widths []int
+	for _, row := range rows {
+		for c, value := range row {
+			for len(widths) <= c {
+				widths = append(widths, 8)
+			}
+			var text string
+			switch v := value.(type) {
+			case string:
+				text = v
+			case int:
+				text = strconv.Itoa(v)
+			case float64:
+				text = formatScore(v)
+			}
+			width := 2
+			for _, r := range text {
+				if utf8.RuneLen(r) > 1 {
+					width += 2
+				} else {
+					width++
+				}
+			}
+			widths[c] = max(widths[c], min(width, xlsxMaxColumnWidth))
+		}
+	}
+	return widths
+}
+
+// columnName 列序号（从0开始）转换为 Excel 列名（A、B…Z、AA…）
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// xmlEscape 转义 XML 文本（XML 不允许的控制字符替换为 U+FFFD）
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -3,10 +3,11 @@ package main
 import (
 	"bilibili-analyzer/backend/api"
 	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/scheduler"
 	"bilibili-analyzer/backend/sse"
 	"bilibili-analyzer/backend/task"
+	"bilibili-analyzer/backend/webhook"
 	"log"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,17 +21,20 @@ func main() {
 
 	log.Println("🚀 Bilibili Analyzer - Backend Server Starting...")
 
-	// 恢复未完成的任务（后端重启后）
-	go task.RecoverIncompleteTasks()
+	// 恢复未完成的任务（后端重启后重新放回队列）
+	task.RecoverIncompleteTasks()
 
-	// 启动定时清理任务（每5分钟检查一次超时任务）
-	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-		for range ticker.C {
-			task.CleanupTimedOutTasks()
-		}
-	}()
+	// 启动任务队列调度器（按配置的并发数依次执行排队任务）
+	go task.StartQueue()
+
+	// 启动调度器：
+	//   - 每5分钟清理超时任务
+	//   - 启动时及之后每小时检查B站账号池的登录状态
+	//   - 按 cron 表达式触发定时分析计划，并在报告指标变化超过阈值时告警
+	go scheduler.Start()
+
+	// 启动 Webhook 投递：任务开始/阶段变化/完成/失败时向配置的接收端发送签名通知，失败按退避间隔重试
+	go webhook.StartDispatcher()
 
 	// 创建Gin路由器
 	r := gin.Default()
@@ -61,6 +65,9 @@ func main() {
 		apiGroup.POST("/video/dimensions", api.HandleVideoDimensions)
 		apiGroup.POST("/video/analyze", api.HandleVideoAnalyze)
 
+		// 任务API - 取消正在执行的任务
+		apiGroup.POST("/task/:id/cancel", api.HandleCancelTask)
+
 		// SSE接口 - 前端通过此接口接收任务实时进度
 		apiGroup.GET("/sse", sse.HandleSSE)
 
@@ -70,12 +77,66 @@ func main() {
 		apiGroup.DELETE("/history/:id", api.HandleDeleteHistory) // 删除历史记录
 
 		// 报告API
-		apiGroup.GET("/report/:id", api.HandleGetReport)     // 获取报告详情
-		apiGroup.GET("/report/:id/pdf", api.HandleExportPDF) // 导出PDF
+		apiGroup.GET("/report/:id", api.HandleGetReport)                  // 获取报告详情
+		apiGroup.GET("/report/:id/pdf", api.HandleExportPDF)              // 导出PDF
+		apiGroup.GET("/report/:id/export", api.HandleExportReport)        // 按格式导出（pdf/xlsx/csv/md/html/json）
+		apiGroup.GET("/report/:id/comments", api.HandleGetReportComments) // 查询评分依据的评论（可按品牌/型号/维度/分数筛选）
+		apiGroup.GET("/compare", api.HandleCompareReports)                // 对比两份报告（ids=a,b）
+		apiGroup.GET("/compare/pdf", api.HandleExportComparePDF)          // 导出报告对比PDF
+
+		// 定时分析计划API
+		apiGroup.GET("/schedules", api.HandleListSchedules)            // 获取计划列表
+		apiGroup.POST("/schedules", api.HandleCreateSchedule)          // 创建计划
+		apiGroup.PUT("/schedules/:id", api.HandleUpdateSchedule)       // 修改计划
+		apiGroup.DELETE("/schedules/:id", api.HandleDeleteSchedule)    // 删除计划
+		apiGroup.POST("/schedules/:id/run", api.HandleRunSchedule)     // 立即运行一次
+		apiGroup.GET("/schedules/:id/runs", api.HandleGetScheduleRuns) // 运行记录（报告序列、指标快照和告警）
 
 		// 配置API
 		apiGroup.GET("/config", api.HandleGetConfig)   // 获取配置
 		apiGroup.POST("/config", api.HandleSaveConfig) // 保存配置
+
+		// B站账号池API
+		apiGroup.GET("/config/accounts", api.HandleListAccounts)            // 获取账号池（含登录状态和最近失败原因）
+		apiGroup.POST("/config/accounts", api.HandleCreateAccount)          // 添加账号
+		apiGroup.PUT("/config/accounts/:id", api.HandleUpdateAccount)       // 修改账号
+		apiGroup.DELETE("/config/accounts/:id", api.HandleDeleteAccount)    // 删除账号
+		apiGroup.POST("/config/accounts/:id/check", api.HandleCheckAccount) // 立即检查登录状态
+
+		// Webhook API - 接收端管理和投递记录
+		apiGroup.GET("/webhooks", api.HandleListWebhooks)                              // 获取接收端列表（含可订阅的事件）
+		apiGroup.POST("/webhooks", api.HandleCreateWebhook)                            // 添加接收端
+		apiGroup.PUT("/webhooks/:id", api.HandleUpdateWebhook)                         // 修改接收端（可重新生成密钥）
+		apiGroup.DELETE("/webhooks/:id", api.HandleDeleteWebhook)                      // 删除接收端
+		apiGroup.POST("/webhooks/:id/test", api.HandleTestWebhook)                     // 发送测试事件
+		apiGroup.GET("/webhook-deliveries", api.HandleListWebhookDeliveries)           // 投递记录（可按接收端/事件/状态/任务筛选）
+		apiGroup.POST("/webhook-deliveries/:id/retry", api.HandleRetryWebhookDelivery) // 立即重新投递
+
+		// 品牌别名词典API
+		apiGroup.GET("/brand-aliases", api.HandleListBrandAliases)        // 获取词条（可按类目筛选）
+		apiGroup.POST("/brand-aliases", api.HandleCreateBrandAlias)       // 添加词条
+		apiGroup.PUT("/brand-aliases/:id", api.HandleUpdateBrandAlias)    // 修改词条
+		apiGroup.DELETE("/brand-aliases/:id", api.HandleDeleteBrandAlias) // 删除词条
+		apiGroup.POST("/brand-aliases/seed", api.HandleSeedBrandAliases)  // 按类目导入内置或自定义词条
+		apiGroup.GET("/brand-aliases/resolve", api.HandleResolveBrand)    // 查看品牌名称的归并结果
+
+		// 候选品牌API - 发现品牌中得分介于候选阈值和主榜阈值之间的品牌，人工审核后可加入报告
+		apiGroup.GET("/brand-candidates", api.HandleListBrandCandidates)              // 获取候选品牌（可按任务/状态筛选）
+		apiGroup.POST("/brand-candidates/:id/accept", api.HandleAcceptBrandCandidate) // 接受：重新生成报告并写入品牌词典
+		apiGroup.POST("/brand-candidates/:id/reject", api.HandleRejectBrandCandidate) // 拒绝
+
+		// 型号库API
+		apiGroup.GET("/model-catalog", api.HandleListModelCatalog)                // 获取型号库（可按品牌筛选）
+		apiGroup.POST("/model-catalog", api.HandleCreateModelCatalog)             // 添加型号
+		apiGroup.PUT("/model-catalog/:id", api.HandleUpdateModelCatalog)          // 修改型号
+		apiGroup.DELETE("/model-catalog/:id", api.HandleDeleteModelCatalog)       // 删除型号
+		apiGroup.GET("/model-reviews", api.HandleListModelReviews)                // 未命中型号库的待审核型号
+		apiGroup.POST("/model-reviews/:id/approve", api.HandleApproveModelReview) // 收录到型号库（新建或作为别名）
+		apiGroup.POST("/model-reviews/:id/ignore", api.HandleIgnoreModelReview)   // 忽略
+
+		// AI调用统计和缓存管理API
+		apiGroup.GET("/ai/stats", api.HandleGetAIStats)      // 解析统计和缓存命中统计
+		apiGroup.DELETE("/ai/cache", api.HandlePurgeAICache) // 清除AI响应缓存
 	}
 
 	// 启动服务器
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// AICache AI响应缓存表
+// 以 模型+消息+输出结构 的哈希为键保存AI响应，重复分析相同评论时直接复用，避免重复计费
+// 过期记录在读取时忽略，并在程序启动时删除
+type AICache struct {
+	ID        uint      `gorm:"primaryKey"`           // 主键ID
+	CacheKey  string    `gorm:"uniqueIndex;not null"` // 缓存键（SHA-256十六进制）
+	Model     string    `gorm:"index"`                // 模型名称（便于按模型统计和清理）
+	Response  string    `gorm:"type:text;not null"`   // AI原始响应文本
+	Hits      int       `gorm:"default:0"`            // 命中次数
+	ExpiresAt time.Time `gorm:"index"`                // 过期时间
+	CreatedAt time.Time // 创建时间
+	UpdatedAt time.Time // 更新时间（最近一次写入或命中）
+}
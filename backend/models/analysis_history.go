@@ -15,7 +15,7 @@ type AnalysisHistory struct {
 	Dimensions   string    `gorm:"type:text"`               // 评价维度JSON数组（如：["吸力","续航","噪音"]）
 	VideoCount   int       `gorm:"default:0"`               // 抓取的视频数量
 	CommentCount int       `gorm:"default:0"`               // 抓取的评论数量
-	Status       string    `gorm:"index;default:'pending'"` // 任务状态：pending/processing/completed/failed
+	Status       string    `gorm:"index;default:'pending'"` // 任务状态：pending/processing/completed/failed/cancelled
 	ReportID     uint      `gorm:"index"`                   // 关联的报告ID（外键引用reports表）
 	CreatedAt    time.Time `gorm:"index"`                   // 创建时间（用于时间范围查询）
 	UpdatedAt    time.Time // 更新时间
@@ -26,12 +26,25 @@ type AnalysisHistory struct {
 	ProgressMsg   string    `gorm:"size:200"`  // 进度消息
 	TaskConfig    string    `gorm:"type:text"` // 任务配置 JSON（用于恢复）
 	LastHeartbeat time.Time `gorm:"index"`     // 最后心跳时间（用于超时检测）
+	VideoList     string    `gorm:"type:text"` // 相关性过滤后的视频列表 JSON（搜索阶段完成后写入，用于恢复）
+
+	// 任务队列
+	Priority int    `gorm:"index;default:0"` // 队列优先级（数值越大越先执行，仅在 priority 排序模式下生效）
+	Request  string `gorm:"type:text"`       // 完整任务请求 JSON（含维度描述，排队任务出队时使用）
+
+	// Token 用量
+	PromptTokens     int64   `gorm:"default:0"` // 输入 Token 合计
+	CompletionTokens int64   `gorm:"default:0"` // 输出 Token 合计
+	TotalTokens      int64   `gorm:"default:0"` // Token 合计
+	Cost             float64 `gorm:"default:0"` // 费用（按模型价格表计算）
+	TokenUsage       string  `gorm:"type:text"` // 用量明细 JSON（按阶段统计，恢复任务时累加）
 }
 
 // 任务状态常量
 const (
-	StatusPending    = "pending"    // 待处理
+	StatusPending    = "pending"    // 待处理（排队中）
 	StatusProcessing = "processing" // 处理中
 	StatusCompleted  = "completed"  // 已完成
 	StatusFailed     = "failed"     // 失败
+	StatusCancelled  = "cancelled"  // 已取消
 )
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// BilibiliAccount B站账号池
+// 保存多个账号的Cookie，抓取时在健康账号间轮换；
+// 登录状态通过 nav 接口定期检查，触发风控的账号会被隔离一段时间
+type BilibiliAccount struct {
+	ID               uint       `gorm:"primaryKey"`              // 主键ID
+	Name             string     `gorm:"size:50"`                 // 备注名
+	Cookie           string     `gorm:"type:text;not null"`      // 完整Cookie字符串
+	Enabled          bool       `gorm:"default:true"`            // 是否启用
+	Status           string     `gorm:"index;default:'unknown'"` // 账号状态：unknown/healthy/expired/quarantined
+	IsLogin          bool       `gorm:"default:false"`           // 最近一次检查是否处于登录状态
+	Uname            string     `gorm:"size:50"`                 // B站用户名（检查登录状态时获取）
+	Mid              int64      `gorm:"default:0"`               // B站用户ID
+	LastCheckedAt    *time.Time // 最近一次健康检查时间
+	LastFailure      string     `gorm:"type:text"` // 最近一次失败原因
+	LastFailureAt    *time.Time // 最近一次失败时间
+	FailureCount     int        `gorm:"default:0"` // 累计失败次数
+	QuarantinedUntil *time.Time `gorm:"index"`     // 隔离截止时间（到期后自动恢复使用）
+	CreatedAt        time.Time  // 创建时间
+	UpdatedAt        time.Time  // 更新时间
+}
+
+// 账号状态常量
+const (
+	AccountStatusUnknown     = "unknown"     // 未检查
+	AccountStatusHealthy     = "healthy"     // 已登录，可正常使用
+	AccountStatusExpired     = "expired"     // Cookie 已失效（未登录）
+	AccountStatusQuarantined = "quarantined" // 触发风控，隔离中
+)
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// 品牌别名词条来源
+const (
+	BrandAliasSourceSeed   = "seed"   // 内置词条导入
+	BrandAliasSourceManual = "manual" // 手动添加或修改
+)
+
+// BrandAlias 品牌别名词典
+// 一条记录对应一个标准品牌，Aliases、English、Pinyin 中的任一写法在分析时都会归并到 Name
+type BrandAlias struct {
+	ID        uint      `gorm:"primaryKey"`               // 主键ID
+	Category  string    `gorm:"size:100;index"`           // 适用类目（为空表示所有类目；需求描述包含该类目时生效）
+	Name      string    `gorm:"size:100;not null"`        // 标准名称（报告中展示的名称）
+	English   string    `gorm:"size:100"`                 // 英文名
+	Pinyin    string    `gorm:"size:200"`                 // 拼音
+	Aliases   string    `gorm:"type:text"`                // 其他别名（逗号分隔）
+	Source    string    `gorm:"size:20;default:'manual'"` // 来源：seed/manual
+	CreatedAt time.Time // 创建时间
+	UpdatedAt time.Time // 更新时间
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// 候选品牌审核状态
+const (
+	BrandCandidatePending  = "pending"  // 待审核
+	BrandCandidateAccepted = "accepted" // 已接受（报告已重新生成）
+	BrandCandidateRejected = "rejected" // 已拒绝
+)
+
+// BrandCandidate 候选品牌
+// 发现新品牌模式下，综合得分介于候选阈值和主榜阈值之间的品牌不进入报告，保存在这里等待人工审核。
+// Comments 保存该品牌全部评论及得分，接受后直接用于重新生成报告，无需重新抓取和分析
+type BrandCandidate struct {
+	ID               uint      `gorm:"primaryKey"`        // 主键ID
+	HistoryID        uint      `gorm:"index;not null"`    // 关联的分析历史ID
+	ReportID         uint      `gorm:"index"`             // 关联的报告ID
+	Category         string    `gorm:"size:200"`          // 需求描述（接受时按此加载品牌词典）
+	Brand            string    `gorm:"size:100;not null"` // 品牌名称（品牌词典归并后）
+	Spellings        string    `gorm:"type:text"`         // AI 返回的原始写法（逗号分隔）
+	Score            float64   // 发现品牌综合得分
+	CommentCount     int       // 评论数
+	VideoCount       int       // 覆盖视频数
+	CategoryHitRatio float64   // 评论中提及类目的比例
+	ModelHitRatio    float64   // 评论中带有效型号的比例
+	Comments         string    `gorm:"type:text"`                       // 评论及得分（JSON）
+	Status           string    `gorm:"size:20;default:'pending';index"` // 状态：pending/accepted/rejected
+	AcceptedAs       string    `gorm:"size:100"`                        // 接受时使用的品牌名称
+	CreatedAt        time.Time // 创建时间
+	UpdatedAt        time.Time // 审核时间
+}
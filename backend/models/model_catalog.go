@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// 待审核型号状态
+const (
+	ModelReviewPending  = "pending"  // 待审核
+	ModelReviewApproved = "approved" // 已收录到型号库
+	ModelReviewIgnored  = "ignored"  // 已忽略（不是有效型号）
+)
+
+// ModelCatalogEntry 型号库词条
+// 一条记录对应一个品牌下的标准型号，Aliases 中的写法在分析时都会统一为 Model
+type ModelCatalogEntry struct {
+	ID          uint      `gorm:"primaryKey"`              // 主键ID
+	Brand       string    `gorm:"size:100;not null;index"` // 品牌（按品牌词典归并后匹配）
+	Model       string    `gorm:"size:100;not null"`       // 标准型号名称
+	Aliases     string    `gorm:"type:text"`               // 其他写法（逗号分隔）
+	ReleaseYear int       // 发布年份，0 表示未知
+	CreatedAt   time.Time // 创建时间
+	UpdatedAt   time.Time // 更新时间
+}
+
+// ModelReview 待审核型号
+// 报告中未命中型号库的型号按"品牌 + 归一化型号"汇总到这里，审核后收录到型号库或忽略
+type ModelReview struct {
+	ID           uint      `gorm:"primaryKey"`                          // 主键ID
+	Brand        string    `gorm:"size:100;index:idx_model_review_key"` // 品牌（标准名称）
+	Model        string    `gorm:"size:100"`                            // 首次出现时的型号写法
+	ModelKey     string    `gorm:"size:200;index:idx_model_review_key"` // 归一化型号
+	Category     string    `gorm:"size:200"`                            // 最近一次出现的需求描述
+	Mentions     int       // 累计评论数
+	ReportCount  int       // 出现过的报告数
+	LastReportID uint      // 最近一次出现的报告ID
+	Status       string    `gorm:"size:20;default:'pending';index"` // 状态：pending/approved/ignored
+	CatalogID    uint      // 收录后对应的型号库词条ID
+	CreatedAt    time.Time // 首次出现时间
+	UpdatedAt    time.Time // 最近更新时间
+}
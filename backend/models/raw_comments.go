@@ -5,18 +5,22 @@ import (
 )
 
 // RawComment 原始评论数据表
-// 临时存储抓取的原始评论，3天后自动清理以节省存储空间
+// 临时存储抓取的原始评论及其AI分析结果，用于任务中断后从断点恢复
+// 3天后自动清理以节省存储空间
 type RawComment struct {
-	ID          uint      `gorm:"primaryKey"`     // 主键ID
-	HistoryID   uint      `gorm:"index;not null"` // 关联的分析历史ID（外键引用analysis_history表）
-	VideoID     string    `gorm:"index"`          // B站视频BV号（如：BV1xx411c7mD）
-	CommentID   string    `gorm:"uniqueIndex"`    // 评论唯一ID（B站的rpid）
-	Content     string    `gorm:"type:text"`      // 评论内容（完整文本）
-	Author      string    `gorm:"index"`          // 评论作者昵称
-	Likes       int       `gorm:"default:0"`      // 点赞数
-	ReplyCount  int       `gorm:"default:0"`      // 回复数
-	PublishTime time.Time `gorm:"index"`          // 评论发布时间（B站原始时间）
-	CreatedAt   time.Time `gorm:"index"`          // 数据抓取时间（用于3天清理判断）
+	ID             uint      `gorm:"primaryKey"`                                            // 主键ID
+	HistoryID      uint      `gorm:"uniqueIndex:idx_raw_comments_history_comment;not null"` // 关联的分析历史ID（外键引用analysis_history表）
+	VideoID        string    `gorm:"index"`                                                 // B站视频BV号（如：BV1xx411c7mD）
+	CommentID      string    `gorm:"uniqueIndex:idx_raw_comments_history_comment"`          // 评论唯一键（rpid_xxx，同一任务内唯一）
+	RootID         int64     `gorm:"default:0"`                                             // 根评论rpid（0表示根评论，用于恢复楼中楼结构）
+	Content        string    `gorm:"type:text"`                                             // 评论内容（完整文本）
+	Author         string    `gorm:"index"`                                                 // 评论作者昵称
+	Likes          int       `gorm:"default:0"`                                             // 点赞数
+	ReplyCount     int       `gorm:"default:0"`                                             // 回复数
+	PublishTime    time.Time `gorm:"index"`                                                 // 评论发布时间（B站原始时间）
+	Payload        string    `gorm:"type:text"`                                             // 完整评论JSON（不含楼中楼，用于恢复抓取结果）
+	AnalysisResult string    `gorm:"type:text"`                                             // AI分析结果JSON（为空表示尚未分析）
+	CreatedAt      time.Time `gorm:"index"`                                                 // 数据抓取时间（用于3天清理判断）
 }
 
 // 注意：此表数据会在程序启动时自动清理超过3天的记录
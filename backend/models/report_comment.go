@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// ReportComment 报告证据评论表
+// 保存报告中每条参与评分的评论及其来源（视频、作者、点赞数），
+// 用于从报告的品牌 × 维度得分下钻到具体评论。随报告永久保存，不参与3天清理
+type ReportComment struct {
+	ID           uint                 `gorm:"primaryKey"`     // 主键ID
+	ReportID     uint                 `gorm:"index;not null"` // 关联的报告ID
+	Brand        string               `gorm:"index"`          // 报告中归属的品牌（与 scores 中的品牌一致）
+	Model        string               // AI提取的型号（原始写法）
+	ModelKey     string               `gorm:"index"` // 归一化型号（小写、去空格），用于按型号筛选
+	Source       string               // 来源：comment/danmaku
+	RPID         int64                `gorm:"column:rpid;index"`       // B站评论ID（弹幕为0）
+	VideoBVID    string               `gorm:"column:video_bvid;index"` // 评论所属视频BV号
+	Mid          int64                // 评论者UID
+	Author       string               // 评论者昵称
+	Likes        int                  // 点赞数
+	ReplyCount   int                  // 回复数
+	IsReply      bool                 // 是否为楼中楼回复
+	AuthorLevel  int                  // 评论者账号等级（0表示未知）
+	Content      string               `gorm:"type:text"` // 评论内容
+	OverallScore float64              `gorm:"index"`     // 各维度得分的平均值
+	PublishTime  time.Time            // 评论发布时间（B站 ctime）
+	Scores       []ReportCommentScore `gorm:"foreignKey:ReportCommentID"` // 各维度得分（未提及的维度不保存）
+	CreatedAt    time.Time
+}
+
+// ReportCommentScore 证据评论的单维度得分
+// 单独成表以便按维度和分数区间查询
+type ReportCommentScore struct {
+	ID              uint    `gorm:"primaryKey"`
+	ReportCommentID uint    `gorm:"index;not null"`                               // 关联的证据评论ID
+	ReportID        uint    `gorm:"index:idx_report_comment_scores_dim;not null"` // 冗余的报告ID，便于按报告删除
+	Dimension       string  `gorm:"index:idx_report_comment_scores_dim"`          // 维度名称
+	Score           float64 // 得分（1-10）
+	Polarity        string  // AI给出的情感倾向（positive/neutral/negative），旧数据为空
+	Confidence      float64 // 情感判断的置信度（0-1）
+	Span            string  // 支撑判断的原文片段
+}
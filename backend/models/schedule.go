@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// Schedule 定时分析计划
+// 按 cron 表达式定期把保存的分析请求加入任务队列，
+// 每次运行生成的报告记录在 ScheduleRun 中，前后两次报告的得分变化超过阈值时通过 Webhook 告警
+type Schedule struct {
+	ID                uint       `gorm:"primaryKey"`        // 主键ID
+	Name              string     `gorm:"size:100"`          // 计划名称
+	Cron              string     `gorm:"size:100;not null"` // cron 表达式（分 时 日 月 周，或 @daily、@every 6h 等）
+	Enabled           bool       `gorm:"index"`             // 是否启用
+	Category          string     `gorm:"index"`             // 商品类目（取自请求的需求描述，便于列表展示）
+	Request           string     `gorm:"type:text"`         // 任务请求 JSON（与 AnalysisHistory.Request 格式相同，不含任务ID）
+	TaskConfig        string     `gorm:"type:text"`         // 任务配置 JSON
+	Priority          int        `gorm:"default:0"`         // 入队优先级
+	WebhookURL        string     `gorm:"size:500"`          // 告警 Webhook 地址（为空时只记录告警）
+	WebhookID         uint       `gorm:"default:0"`         // 告警地址对应的专用 Webhook 接收端ID（保存签名密钥）
+	ScoreThreshold    float64    `gorm:"default:0"`         // 品牌综合得分变化阈值（绝对值，0 表示不检查）
+	NegativeThreshold float64    `gorm:"default:0"`         // 品牌差评占比变化阈值（百分点，0 表示不检查）
+	NextRunAt         *time.Time `gorm:"index"`             // 下次运行时间（停用时为空）
+	LastRunAt         *time.Time // 最近一次触发时间
+	LastError         string     `gorm:"size:500"` // 最近一次触发失败的原因
+	CreatedAt         time.Time  // 创建时间
+	UpdatedAt         time.Time  // 更新时间
+}
+
+// ScheduleRun 定时计划的一次运行
+// Status 与 AnalysisHistory 的任务状态一致：任务未结束时为 pending
+type ScheduleRun struct {
+	ID         uint       `gorm:"primaryKey"`              // 主键ID
+	ScheduleID uint       `gorm:"index;not null"`          // 所属计划ID
+	HistoryID  uint       `gorm:"index"`                   // 对应的分析历史记录ID
+	TaskID     string     `gorm:"size:36"`                 // 任务ID
+	Status     string     `gorm:"index;default:'pending'"` // 运行状态：pending/completed/failed/cancelled
+	ReportID   uint       `gorm:"index"`                   // 生成的报告ID
+	Metrics    string     `gorm:"type:text"`               // 各品牌指标快照 JSON（综合得分、差评占比）
+	Alerts     string     `gorm:"type:text"`               // 与上一次报告相比触发的告警 JSON
+	Error      string     `gorm:"size:500"`                // 失败原因或告警发送失败原因
+	CreatedAt  time.Time  // 触发时间
+	FinishedAt *time.Time // 任务结束时间
+}
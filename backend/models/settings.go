@@ -16,10 +16,20 @@ type Settings struct {
 
 // 常用配置键常量
 const (
-	SettingKeyAIAPIKey             = "ai_api_key"             // OpenAI API Key
-	SettingKeyAIAPIBase            = "ai_api_base"            // API Base URL
-	SettingKeyAIModel              = "ai_model"               // 模型名称
-	SettingKeyBilibiliCookie       = "bilibili_cookie"        // B站完整Cookie字符串
-	SettingKeyScrapeMaxConcurrency = "scrape_max_concurrency" // 抓取并发数
-	SettingKeyAIMaxConcurrency     = "ai_max_concurrency"     // AI并发数
+	SettingKeyAIProvider           = "ai_provider"             // AI服务提供方：openai/anthropic/gemini/ollama
+	SettingKeyAIAPIKey             = "ai_api_key"              // OpenAI API Key
+	SettingKeyAIAPIBase            = "ai_api_base"             // API Base URL
+	SettingKeyAIModel              = "ai_model"                // 模型名称
+	SettingKeyBilibiliCookie       = "bilibili_cookie"         // B站完整Cookie字符串
+	SettingKeyScrapeMaxConcurrency = "scrape_max_concurrency"  // 抓取并发数
+	SettingKeyAIMaxConcurrency     = "ai_max_concurrency"      // AI并发数
+	SettingKeyQueueWorkers         = "queue_workers"           // 任务队列同时执行的任务数
+	SettingKeyQueueOrder           = "queue_order"             // 任务队列排序方式：fifo/priority
+	SettingKeyAICacheTTLHours      = "ai_cache_ttl_hours"      // AI响应缓存有效期（小时），0表示关闭缓存
+	SettingKeyAIPriceTable         = "ai_price_table"          // 模型价格表JSON（每百万Token单价）
+	SettingKeyAITokenBudget        = "ai_token_budget"         // 单个任务默认Token预算，0表示不限制
+	SettingKeyBilibiliRateLimit    = "bilibili_rate_limit"     // B站请求速率（每秒请求数，所有任务共享）
+	SettingKeyCommentWeighting     = "comment_weighting"       // 评论权重模型JSON，为空时使用默认模型
+	SettingKeySponsoredVideoMode   = "sponsored_video_mode"    // 商单视频处理方式：off/tag/exclude（默认off）
+	SettingKeySuspiciousMode       = "suspicious_comment_mode" // 可疑评论处理方式：off/tag/exclude（默认tag）
 )
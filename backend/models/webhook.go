@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// WebhookEndpoint Webhook 接收端
+// 任务开始、阶段变化、完成、失败等事件以签名 JSON POST 到 URL
+type WebhookEndpoint struct {
+	ID        uint      `gorm:"primaryKey"`        // 主键ID
+	Name      string    `gorm:"size:100"`          // 备注名
+	URL       string    `gorm:"size:500;not null"` // 接收地址
+	Secret    string    `gorm:"size:100"`          // 签名密钥（HMAC-SHA256）
+	Events    string    `gorm:"size:500"`          // 订阅的事件（逗号分隔，为空表示全部事件）
+	Enabled   bool      `gorm:"index"`             // 是否启用
+	CreatedAt time.Time // 创建时间
+	UpdatedAt time.Time // 更新时间
+}
+
+// WebhookDelivery Webhook 投递记录
+// 每个事件对每个订阅的接收端生成一条记录，失败后按退避间隔重试
+type WebhookDelivery struct {
+	ID            uint       `gorm:"primaryKey"`              // 主键ID
+	WebhookID     uint       `gorm:"index"`                   // 接收端ID
+	Event         string     `gorm:"size:50;index"`           // 事件类型（如 task.completed）
+	PayloadID     string     `gorm:"size:36;index"`           // 事件ID（同一事件投递到多个接收端时相同，接收方可用于去重）
+	TaskID        string     `gorm:"size:36;index"`           // 关联的任务ID（非任务事件为空）
+	URL           string     `gorm:"size:500"`                // 投递时的接收地址
+	Payload       string     `gorm:"type:text"`               // 请求体 JSON
+	Status        string     `gorm:"index;default:'pending'"` // 投递状态：pending/success/failed
+	Attempts      int        `gorm:"default:0"`               // 已尝试次数
+	ResponseCode  int        `gorm:"default:0"`               // 最近一次响应的 HTTP 状态码
+	ResponseBody  string     `gorm:"type:text"`               // 最近一次响应内容（截断）
+	Error         string     `gorm:"type:text"`               // 最近一次失败原因
+	NextAttemptAt *time.Time `gorm:"index"`                   // 下次尝试时间（投递结束后为空）
+	DeliveredAt   *time.Time // 投递成功时间
+	CreatedAt     time.Time  // 创建时间
+	UpdatedAt     time.Time  // 更新时间
+}
+
+// Webhook 投递状态常量
+const (
+	DeliveryStatusPending = "pending" // 等待投递或等待重试
+	DeliveryStatusSuccess = "success" // 投递成功
+	DeliveryStatusFailed  = "failed"  // 重试次数用尽
+)
@@ -0,0 +1,340 @@
+package pdf
+
+import (
+	"bilibili-analyzer/backend/report"
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// GenerateComparisonPDF 生成两份报告的对比PDF
+// 包含品牌得分变化、维度得分变化、型号变化以及新发现/已消失的品牌和型号
+func GenerateComparisonPDF(cmp *report.Comparison) ([]byte, error) {
+	if cmp == nil {
+		return nil, fmt.Errorf("comparison is nil")
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	fontFamily, useEnglish := loadFont(pdf)
+
+	// 标题
+	pdf.SetTextColor(17, 24, 39)
+	setFont(pdf, fontFamily, "B", 20)
+	pdf.CellFormat(0, 12, useEnglishOrFallback(useEnglish, "报告对比", "Report Comparison"), "", 1, "C", false, 0, "")
+	pdf.Ln(2)
+
+	// 元信息：两份报告的类目、ID、时间和评论数
+	setFont(pdf, fontFamily, "", 11)
+	pdf.SetTextColor(55, 65, 81)
+	for _, side := range []struct {
+		zh, en string
+		info   report.ReportInfo
+	}{
+		{"基准", "Base", cmp.Base},
+		{"对比", "Target", cmp.Target},
+	} {
+		var line string
+		if useEnglish {
+			line = fmt.Sprintf("%s: #%d %s | %s | Comments: %s", side.en, side.info.ID, safeText(side.info.Category),
+				side.info.CreatedAt.Format("2006-01-02 15:04"), formatNumber(side.info.TotalComments))
+		} else {
+			line = fmt.Sprintf("%s: #%d %s | %s | 评论数: %s", side.zh, side.info.ID, safeText(side.info.Category),
+				side.info.CreatedAt.Format("2006-01-02 15:04"), formatNumber(side.info.TotalComments))
+		}
+		pdf.CellFormat(0, 6, line, "", 1, "L", false, 0, "")
+	}
+	generated := fmt.Sprintf("%s: %s", useEnglishOrFallback(useEnglish, "生成时间", "Generated"), time.Now().Format("2006-01-02 15:04"))
+	pdf.CellFormat(0, 6, generated, "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	sectionHeader(pdf, fontFamily, useEnglishOrFallback(useEnglish, "品牌得分变化", "Brand Score Changes"))
+	drawBrandDeltaTable(pdf, fontFamily, cmp, useEnglish)
+	pdf.Ln(4)
+
+	sectionHeader(pdf, fontFamily, useEnglishOrFallback(useEnglish, "维度得分变化", "Dimension Score Changes"))
+	drawDimensionDeltaMatrix(pdf, fontFamily, cmp, useEnglish)
+	pdf.Ln(4)
+
+	if len(cmp.Models) > 0 {
+		sectionHeader(pdf, fontFamily, useEnglishOrFallback(useEnglish, "型号变化", "Model Changes"))
+		drawModelDeltaTable(pdf, fontFamily, cmp, useEnglish)
+		pdf.Ln(4)
+	}
+
+	sectionHeader(pdf, fontFamily, useEnglishOrFallback(useEnglish, "新发现与已消失", "New and Vanished"))
+	drawChangeList(pdf, fontFamily, useEnglishOrFallback(useEnglish, "新发现品牌", "New brands"), cmp.NewBrands, useEnglish)
+	drawChangeList(pdf, fontFamily, useEnglishOrFallback(useEnglish, "已消失品牌", "Vanished brands"), cmp.VanishedBrands, useEnglish)
+	drawChangeList(pdf, fontFamily, useEnglishOrFallback(useEnglish, "新出现型号", "New models"), cmp.NewModels, useEnglish)
+	drawChangeList(pdf, fontFamily, useEnglishOrFallback(useEnglish, "已消失型号", "Vanished models"), cmp.VanishedModels, useEnglish)
+
+	if pdf.Error() != nil {
+		return nil, fmt.Errorf("PDF generation failed: %w", pdf.Error())
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("PDF output failed: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawBrandDeltaTable 绘制品牌得分变化表格
+// 显示品牌、前后得分、得分变化和排名变化
+func drawBrandDeltaTable(pdf *fpdf.Fpdf, family string, cmp *report.Comparison, useEnglish bool) {
+	if len(cmp.Brands) == 0 {
+		drawEmptyNotice(pdf, family, useEnglishOrFallback(useEnglish, "暂无品牌数据", "No brand data available"))
+		return
+	}
+
+	left, _, right, _ := pdf.GetMargins()
+	pageW, _ := pdf.GetPageSize()
+	usableW := pageW - left - right
+
+	colBrand := usableW * 0.32
+	colScore := usableW * 0.15
+	colDelta := usableW * 0.14
+	colRank := usableW - colBrand - colScore*2 - colDelta
+	rowH := 8.0
+
+	drawTableHeader(pdf, family, rowH,
+		[]float64{colBrand, colScore, colScore, colDelta, colRank},
+		useEnglish,
+		[]string{"品牌", "基准得分", "对比得分", "变化", "排名"},
+		[]string{"Brand", "Base", "Target", "Delta", "Rank"})
+
+	setFont(pdf, family, "", 11)
+	for _, b := range cmp.Brands {
+		ensureSpace(pdf, rowH)
+
+		name := b.Brand
+		if b.BaseName != "" {
+			name = fmt.Sprintf("%s (%s)", b.Brand, b.BaseName)
+		}
+		switch b.Status {
+		case report.CompareStatusNew:
+			name += useEnglishOrFallback(useEnglish, " [新]", " [new]")
+		case report.CompareStatusVanished:
+			name += useEnglishOrFallback(useEnglish, " [消失]", " [gone]")
+		}
+		pdf.SetTextColor(17, 24, 39)
+		pdf.SetFillColor(255, 255, 255)
+		pdf.CellFormat(colBrand, rowH, safeText(name), "1", 0, "L", false, 0, "")
+
+		drawScoreCell(pdf, colScore, rowH, b.BaseScore)
+		drawScoreCell(pdf, colScore, rowH, b.TargetScore)
+		drawDeltaCell(pdf, colDelta, rowH, b.Delta)
+
+		pdf.SetTextColor(17, 24, 39)
+		pdf.SetFillColor(255, 255, 255)
+		pdf.CellFormat(colRank, rowH, formatRankChange(b.BaseRank, b.TargetRank, b.RankChange), "1", 1, "C", false, 0, "")
+	}
+
+	pdf.SetTextColor(17, 24, 39)
+}
+
+// drawDimensionDeltaMatrix 绘制两份报告都有的品牌在各维度上的得分变化
+func drawDimensionDeltaMatrix(pdf *fpdf.Fpdf, family string, cmp *report.Comparison, useEnglish bool) {
+	var brands []report.BrandDelta
+	for _, b := range cmp.Brands {
+		if b.Status == report.CompareStatusBoth {
+			brands = append(brands, b)
+		}
+	}
+	if len(brands) == 0 || len(cmp.Dimensions) == 0 {
+		drawEmptyNotice(pdf, family, useEnglishOrFallback(useEnglish, "没有可对比的品牌", "No brands present in both reports"))
+		return
+	}
+
+	// 维度名称：对齐后名称不同时显示为"新名称/旧名称"
+	dimNames := make([]string, len(cmp.Dimensions))
+	for i, d := range cmp.Dimensions {
+		switch {
+		case d.Target == "":
+			dimNames[i] = d.Base
+		case d.Base != "" && d.Base != d.Target:
+			dimNames[i] = d.Target + "/" + d.Base
+		default:
+			dimNames[i] = d.Target
+		}
+	}
+
+	left, _, right, _ := pdf.GetMargins()
+	pageW, _ := pdf.GetPageSize()
+	usableW := pageW - left - right
+
+	// 维度较多时分多张表，每张最多6列
+	const maxCols = 6
+	colBrand := 36.0
+	rowH := 8.0
+	for start := 0; start < len(dimNames); start += maxCols {
+		end := min(start+maxCols, len(dimNames))
+		colDim := (usableW - colBrand) / float64(end-start)
+
+		ensureSpace(pdf, rowH*2)
+		setFont(pdf, family, "B", 9.5)
+		pdf.SetFillColor(241, 245, 249)
+		pdf.SetDrawColor(203, 213, 225)
+		pdf.SetTextColor(15, 23, 42)
+		pdf.CellFormat(colBrand, rowH, useEnglishOrFallback(useEnglish, "品牌", "Brand"), "1", 0, "L", true, 0, "")
+		for i := start; i < end; i++ {
+			ln := 0
+			if i == end-1 {
+				ln = 1
+			}
+			pdf.CellFormat(colDim, rowH, safeText(dimNames[i]), "1", ln, "C", true, 0, "")
+		}
+
+		setFont(pdf, family, "", 10)
+		for _, b := range brands {
+			ensureSpace(pdf, rowH)
+			pdf.SetTextColor(17, 24, 39)
+			pdf.SetFillColor(255, 255, 255)
+			pdf.CellFormat(colBrand, rowH, safeText(b.Brand), "1", 0, "L", false, 0, "")
+			for i := start; i < end; i++ {
+				drawDeltaCell(pdf, colDim, rowH, b.Dimensions[i].Delta)
+				if i == end-1 {
+					pdf.Ln(-1)
+				}
+			}
+		}
+		pdf.Ln(2)
+	}
+
+	pdf.SetTextColor(17, 24, 39)
+}
+
+// drawModelDeltaTable 绘制型号得分变化表格
+func drawModelDeltaTable(pdf *fpdf.Fpdf, family string, cmp *report.Comparison, useEnglish bool) {
+	left, _, right, _ := pdf.GetMargins()
+	pageW, _ := pdf.GetPageSize()
+	usableW := pageW - left - right
+
+	colModel := usableW * 0.34
+	colScore := usableW * 0.15
+	colDelta := usableW * 0.14
+	colRank := usableW - colModel - colScore*2 - colDelta
+	rowH := 8.0
+
+	drawTableHeader(pdf, family, rowH,
+		[]float64{colModel, colScore, colScore, colDelta, colRank},
+		useEnglish,
+		[]string{"型号", "基准得分", "对比得分", "变化", "排名"},
+		[]string{"Model", "Base", "Target", "Delta", "Rank"})
+
+	setFont(pdf, family, "", 11)
+	for _, m := range cmp.Models {
+		ensureSpace(pdf, rowH)
+
+		pdf.SetTextColor(17, 24, 39)
+		pdf.SetFillColor(255, 255, 255)
+		pdf.CellFormat(colModel, rowH, safeText(m.Brand+" "+m.Model), "1", 0, "L", false, 0, "")
+		drawScoreCell(pdf, colScore, rowH, m.BaseScore)
+		drawScoreCell(pdf, colScore, rowH, m.TargetScore)
+		drawDeltaCell(pdf, colDelta, rowH, m.Delta)
+
+		pdf.SetTextColor(17, 24, 39)
+		pdf.SetFillColor(255, 255, 255)
+		pdf.CellFormat(colRank, rowH, formatRankChange(m.BaseRank, m.TargetRank, m.RankChange), "1", 1, "C", false, 0, "")
+	}
+
+	pdf.SetTextColor(17, 24, 39)
+}
+
+// drawChangeList 绘制一行"标题: 名称1、名称2"
+func drawChangeList(pdf *fpdf.Fpdf, family, title string, names []string, useEnglish bool) {
+	ensureSpace(pdf, 7)
+	setFont(pdf, family, "B", 10.5)
+	pdf.SetTextColor(30, 41, 59)
+	pdf.CellFormat(34, 7, title, "", 0, "L", false, 0, "")
+
+	setFont(pdf, family, "", 10.5)
+	pdf.SetTextColor(55, 65, 81)
+	text := useEnglishOrFallback(useEnglish, "无", "None")
+	if len(names) > 0 {
+		text = strings.Join(names, useEnglishOrFallback(useEnglish, "、", ", "))
+	}
+	pdf.MultiCell(0, 7, text, "", "L", false)
+}
+
+// drawTableHeader 绘制表头（按语言选择列名）
+func drawTableHeader(pdf *fpdf.Fpdf, family string, rowH float64, widths []float64, useEnglish bool, zh, en []string) {
+	setFont(pdf, family, "B", 11)
+	pdf.SetFillColor(241, 245, 249)
+	pdf.SetDrawColor(203, 213, 225)
+	pdf.SetTextColor(15, 23, 42)
+
+	titles := zh
+	if useEnglish {
+		titles = en
+	}
+	for i, w := range widths {
+		ln, align := 0, "C"
+		if i == 0 {
+			align = "L"
+		}
+		if i == len(widths)-1 {
+			ln = 1
+		}
+		pdf.CellFormat(w, rowH, titles[i], "1", ln, align, true, 0, "")
+	}
+}
+
+func drawEmptyNotice(pdf *fpdf.Fpdf, family, text string) {
+	setFont(pdf, family, "", 11)
+	pdf.SetTextColor(75, 85, 99)
+	pdf.MultiCell(0, 6, text, "1", "L", false)
+}
+
+// drawScoreCell 绘制得分单元格（按得分着色，没有数据时显示"-"）
+func drawScoreCell(pdf *fpdf.Fpdf, w, h float64, score *float64) {
+	if score == nil {
+		pdf.SetTextColor(156, 163, 175)
+		pdf.SetFillColor(255, 255, 255)
+		pdf.CellFormat(w, h, "-", "1", 0, "C", false, 0, "")
+		return
+	}
+	fillR, fillG, fillB, txtR, txtG, txtB := scoreColors(*score)
+	pdf.SetFillColor(fillR, fillG, fillB)
+	pdf.SetTextColor(txtR, txtG, txtB)
+	pdf.CellFormat(w, h, fmt.Sprintf("%.1f", *score), "1", 0, "C", true, 0, "")
+}
+
+// drawDeltaCell 绘制得分变化单元格：上升为绿色，下降为红色
+func drawDeltaCell(pdf *fpdf.Fpdf, w, h float64, delta *float64) {
+	pdf.SetFillColor(255, 255, 255)
+	switch {
+	case delta == nil:
+		pdf.SetTextColor(156, 163, 175)
+		pdf.CellFormat(w, h, "-", "1", 0, "C", false, 0, "")
+		return
+	case *delta > 0:
+		pdf.SetTextColor(6, 95, 70)
+	case *delta < 0:
+		pdf.SetTextColor(153, 27, 27)
+	default:
+		pdf.SetTextColor(75, 85, 99)
+	}
+	pdf.CellFormat(w, h, fmt.Sprintf("%+.1f", *delta), "1", 0, "C", false, 0, "")
+}
+
+// formatRankChange 排名变化文本，如"3 -> 1 (+2)"，未参与排名的一方显示"-"
+func formatRankChange(baseRank, targetRank, change int) string {
+	rankText := func(r int) string {
+		if r <= 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%d", r)
+	}
+	text := rankText(baseRank) + " -> " + rankText(targetRank)
+	if baseRank > 0 && targetRank > 0 {
+		text += fmt.Sprintf(" (%+d)", change)
+	}
+	return text
+}
@@ -0,0 +1,198 @@
+package report
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/bilibili"
+	"sort"
+)
+
+// aspectQuoteLimit 每个维度每种倾向最多展示的引文数
+const aspectQuoteLimit = 3
+
+// DimensionSentiment 单个维度的情感统计（按AI给出的维度情感倾向计数，不按分数推断）
+type DimensionSentiment struct {
+	Dimension      string        `json:"dimension"`                 // 维度名称
+	PositiveCount  int           `json:"positive_count"`            // 对该维度正面评价的评论数
+	NeutralCount   int           `json:"neutral_count"`             // 中性评价的评论数
+	NegativeCount  int           `json:"negative_count"`            // 负面评价的评论数
+	PositiveQuotes []AspectQuote `json:"positive_quotes,omitempty"` // 正面引文（按置信度、点赞数排序）
+	NegativeQuotes []AspectQuote `json:"negative_quotes,omitempty"` // 负面引文
+}
+
+// AspectQuote 维度情感的原文引用
+// Span 逐字摘自 Content，前端可在评论全文中高亮
+type AspectQuote struct {
+	Span       string  `json:"span"`             // 支撑判断的原文片段
+	Content    string  `json:"content"`          // 评论全文
+	Brand      string  `json:"brand"`            // 品牌
+	Score      float64 `json:"score"`            // 该维度得分
+	Confidence float64 `json:"confidence"`       // AI给出的置信度
+	Like       int     `json:"like,omitempty"`   // 点赞数
+	URL        string  `json:"url,omitempty"`    // 原评论链接
+	Author     string  `json:"author,omitempty"` // 评论者昵称
+}
+
+// AspectHighlight 典型评论中需要高亮的片段
+type AspectHighlight struct {
+	Dimension string `json:"dimension"` // 维度名称
+	Polarity  string `json:"polarity"`  // 情感倾向：positive/neutral/negative
+	Span      string `json:"span"`      // 原文片段
+}
+
+// calculateAspectSentiment 按维度统计情感倾向并挑选引文，维度顺序与 dimensions 一致
+// 没有任何评论带维度情感时（旧报告或AI未返回）返回 nil
+func calculateAspectSentiment(analysisResults map[string][]CommentWithScore, dimensions []ai.Dimension) []DimensionSentiment {
+	byDim := make(map[string]*DimensionSentiment)
+	for _, results := range analysisResults {
+		for _, r := range results {
+			for dim, a := range r.Aspects {
+				score := r.Scores[dim]
+				if score == nil {
+					continue
+				}
+				stats := byDim[dim]
+				if stats == nil {
+					stats = &DimensionSentiment{Dimension: dim}
+					byDim[dim] = stats
+				}
+				switch a.Polarity {
+				case ai.PolarityPositive:
+					stats.PositiveCount++
+					if a.Span != "" {
+						stats.PositiveQuotes = append(stats.PositiveQuotes, newAspectQuote(r, a, *score))
+					}
+				case ai.PolarityNegative:
+					stats.NegativeCount++
+					if a.Span != "" {
+						stats.NegativeQuotes = append(stats.NegativeQuotes, newAspectQuote(r, a, *score))
+					}
+				case ai.PolarityNeutral:
+					stats.NeutralCount++
+				}
+			}
+		}
+	}
+	if len(byDim) == 0 {
+		return nil
+	}
+
+	result := make([]DimensionSentiment, 0, len(byDim))
+	for _, dim := range dimensions {
+		if stats, ok := byDim[dim.Name]; ok {
+			stats.PositiveQuotes = topAspectQuotes(stats.PositiveQuotes)
+			stats.NegativeQuotes = topAspectQuotes(stats.NegativeQuotes)
+			result = append(result, *stats)
+		}
+	}
+	return result
+}
+
+// calculateBrandAspectSentiment 分品牌统计维度情感，跳过没有维度情感的品牌
+func calculateBrandAspectSentiment(analysisResults map[string][]CommentWithScore, dimensions []ai.Dimension) map[string][]DimensionSentiment {
+	result := make(map[string][]DimensionSentiment)
+	for brand, results := range analysisResults {
+		if brand == "" {
+			continue
+		}
+		if stats := calculateAspectSentiment(map[string][]CommentWithScore{brand: results}, dimensions); len(stats) > 0 {
+			result[brand] = stats
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+func newAspectQuote(r CommentWithScore, a ai.AspectSentiment, score float64) AspectQuote {
+	q := AspectQuote{
+		Span:       a.Span,
+		Content:    r.Content,
+		Brand:      r.Brand,
+		Score:      score,
+		Confidence: a.Confidence,
+		Like:       r.Like,
+		Author:     r.Author,
+	}
+	if r.VideoBVID != "" {
+		q.URL = bilibili.CommentURL(r.VideoBVID, r.RPID)
+	}
+	return q
+}
+
+// topAspectQuotes 按置信度、点赞数倒序取前 aspectQuoteLimit 条，同一片段只保留一条
+func topAspectQuotes(quotes []AspectQuote) []AspectQuote {
+	sort.SliceStable(quotes, func(i, j int) bool {
+		if quotes[i].Confidence != quotes[j].Confidence {
+			return quotes[i].Confidence > quotes[j].Confidence
+		}
+		return quotes[i].Like > quotes[j].Like
+	})
+	seen := make(map[string]bool)
+	var result []AspectQuote
+	for _, q := range quotes {
+		if seen[q.Span] {
+			continue
+		}
+		seen[q.Span] = true
+		result = append(result, q)
+		if len(result) == aspectQuoteLimit {
+			break
+		}
+	}
+	return result
+}
+
+// commentPolarity 评论的整体情感倾向
+// 有维度情感时按维度判断：只有正面为好评，只有负面为差评，正负都有或全为中性为中性；
+// 没有维度情感时（旧报告或AI未返回）按平均分划分：>=8 好评，5-8 中性，<5 差评。
+// 没有有效评分时返回空字符串
+func commentPolarity(r CommentWithScore) string {
+	var positive, negative, neutral bool
+	for dim, a := range r.Aspects {
+		if r.Scores[dim] == nil {
+			continue
+		}
+		switch a.Polarity {
+		case ai.PolarityPositive:
+			positive = true
+		case ai.PolarityNegative:
+			negative = true
+		case ai.PolarityNeutral:
+			neutral = true
+		}
+	}
+	switch {
+	case positive && !negative:
+		return ai.PolarityPositive
+	case negative && !positive:
+		return ai.PolarityNegative
+	case positive || neutral:
+		return ai.PolarityNeutral
+	}
+
+	avgScore := calculateAverageScore(r.Scores)
+	switch {
+	case avgScore <= 0:
+		return ""
+	case avgScore >= 8.0:
+		return ai.PolarityPositive
+	case avgScore >= 5.0:
+		return ai.PolarityNeutral
+	default:
+		return ai.PolarityNegative
+	}
+}
+
+// commentHighlights 评论中带原文片段的维度情感，按维度名排序
+func commentHighlights(r CommentWithScore) []AspectHighlight {
+	var highlights []AspectHighlight
+	for dim, a := range r.Aspects {
+		if a.Span == "" || r.Scores[dim] == nil {
+			continue
+		}
+		highlights = append(highlights, AspectHighlight{Dimension: dim, Polarity: a.Polarity, Span: a.Span})
+	}
+	sort.Slice(highlights, func(i, j int) bool { return highlights[i].Dimension < highlights[j].Dimension })
+	return highlights
+}
@@ -0,0 +1,113 @@
+package report
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"testing"
+)
+
+func TestCalculateAspectSentiment(t *testing.T) {
+	s := func(v float64) *float64 { return &v }
+	pos := func(span string, confidence float64) ai.AspectSentiment {
+		return ai.AspectSentiment{Polarity: ai.PolarityPositive, Confidence: confidence, Span: span}
+	}
+	neg := func(span string, confidence float64) ai.AspectSentiment {
+		return ai.AspectSentiment{Polarity: ai.PolarityNegative, Confidence: confidence, Span: span}
+	}
+	results := map[string][]CommentWithScore{
+		"戴森": {
+			{
+				Content: "吸力很猛，就是太吵", Brand: "戴森", Scores: map[string]*float64{"吸力": s(9), "噪音": s(3)},
+				Aspects:   map[string]ai.AspectSentiment{"吸力": pos("吸力很猛", 0.9), "噪音": neg("太吵", 0.8)},
+				VideoBVID: "BV1xx", RPID: 5,
+			},
+			{
+				Content: "吸力一般般", Brand: "戴森", Scores: map[string]*float64{"吸力": s(5)},
+				Aspects: map[string]ai.AspectSentiment{"吸力": {Polarity: ai.PolarityNeutral, Confidence: 0.6, Span: "吸力一般般"}},
+			},
+		},
+		"小米": {
+			{
+				Content: "吸力够用了", Brand: "小米", Scores: map[string]*float64{"吸力": s(7)},
+				Aspects: map[string]ai.AspectSentiment{"吸力": pos("吸力够用", 0.95)},
+			},
+			{Content: "旧数据没有维度情感", Brand: "小米", Scores: map[string]*float64{"吸力": s(2)}},
+		},
+	}
+	dimensions := []ai.Dimension{{Name: "吸力"}, {Name: "续航"}, {Name: "噪音"}}
+
+	stats := calculateAspectSentiment(results, dimensions)
+	if len(stats) != 2 || stats[0].Dimension != "吸力" || stats[1].Dimension != "噪音" {
+		t.Fatalf("expected dimensions in input order without 续航, got %+v", stats)
+	}
+	suction := stats[0]
+	if suction.PositiveCount != 2 || suction.NeutralCount != 1 || suction.NegativeCount != 0 {
+		t.Errorf("unexpected 吸力 counts: %+v", suction)
+	}
+	if len(suction.PositiveQuotes) != 2 || suction.PositiveQuotes[0].Span != "吸力够用" || suction.PositiveQuotes[0].Brand != "小米" {
+		t.Errorf("quotes should be ordered by confidence: %+v", suction.PositiveQuotes)
+	}
+	if q := stats[1].NegativeQuotes; len(q) != 1 || q[0].Span != "太吵" || q[0].Score != 3 || q[0].URL == "" {
+		t.Errorf("unexpected 噪音 quotes: %+v", q)
+	}
+
+	brands := calculateBrandAspectSentiment(results, dimensions)
+	if len(brands["小米"]) != 1 || brands["小米"][0].PositiveCount != 1 {
+		t.Errorf("unexpected 小米 aspect sentiment: %+v", brands["小米"])
+	}
+	if calculateAspectSentiment(map[string][]CommentWithScore{"小米": {results["小米"][1]}}, dimensions) != nil {
+		t.Error("comments without aspects should produce no aspect sentiment")
+	}
+}
+
+func TestCommentPolarity(t *testing.T) {
+	s := func(v float64) *float64 { return &v }
+	aspect := func(polarity string) ai.AspectSentiment { return ai.AspectSentiment{Polarity: polarity} }
+	tests := []struct {
+		name string
+		c    CommentWithScore
+		want string
+	}{
+		{"only positive", CommentWithScore{
+			Scores:  map[string]*float64{"吸力": s(6), "续航": s(6)},
+			Aspects: map[string]ai.AspectSentiment{"吸力": aspect(ai.PolarityPositive), "续航": aspect(ai.PolarityNeutral)},
+		}, ai.PolarityPositive},
+		{"mixed", CommentWithScore{
+			Scores:  map[string]*float64{"吸力": s(9), "噪音": s(2)},
+			Aspects: map[string]ai.AspectSentiment{"吸力": aspect(ai.PolarityPositive), "噪音": aspect(ai.PolarityNegative)},
+		}, ai.PolarityNeutral},
+		{"only negative despite score", CommentWithScore{
+			Scores:  map[string]*float64{"吸力": s(8.5)},
+			Aspects: map[string]ai.AspectSentiment{"吸力": aspect(ai.PolarityNegative)},
+		}, ai.PolarityNegative},
+		{"threshold fallback", CommentWithScore{Scores: map[string]*float64{"吸力": s(8)}}, ai.PolarityPositive},
+		{"no score", CommentWithScore{Scores: map[string]*float64{"吸力": nil}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commentPolarity(tt.c); got != tt.want {
+				t.Errorf("commentPolarity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypicalCommentHighlights(t *testing.T) {
+	s := func(v float64) *float64 { return &v }
+	c := CommentWithScore{
+		Content: "吸力很猛，就是太吵",
+		Scores:  map[string]*float64{"吸力": s(9), "噪音": s(3)},
+		Aspects: map[string]ai.AspectSentiment{
+			"噪音": {Polarity: ai.PolarityNegative, Span: "太吵"},
+			"吸力": {Polarity: ai.PolarityPositive, Span: "吸力很猛"},
+		},
+	}
+	tc := newTypicalComment(c, 6)
+	if len(tc.Highlights) != 2 || tc.Highlights[0].Dimension != "吸力" || tc.Highlights[1].Polarity != ai.PolarityNegative {
+		t.Errorf("unexpected highlights: %+v", tc.Highlights)
+	}
+
+	back := CommentsFromReport(BuildReportComments(1, map[string][]CommentWithScore{"戴森": {c}}))
+	if a := back["戴森"][0].Aspects["噪音"]; a.Polarity != ai.PolarityNegative || a.Span != "太吵" {
+		t.Errorf("aspects should survive evidence round trip: %+v", back["戴森"][0].Aspects)
+	}
+}
@@ -0,0 +1,431 @@
+package report
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 对比结果中品牌/型号的状态
+const (
+	CompareStatusBoth     = "both"     // 两份报告中都有
+	CompareStatusNew      = "new"      // 只在新报告中出现（新发现）
+	CompareStatusVanished = "vanished" // 只在旧报告中出现（已消失）
+)
+
+// 名称对齐方式
+const (
+	MatchExact      = "exact"      // 名称完全相同
+	MatchNormalized = "normalized" // 忽略大小写、空格和标点后相同
+	MatchFuzzy      = "fuzzy"      // 包含关系或编辑距离相近
+)
+
+// fuzzyMatchThreshold 模糊对齐的最低相似度
+const fuzzyMatchThreshold = 0.75
+
+// ReportInfo 参与对比的报告信息（ID 和创建时间由调用方填充）
+type ReportInfo struct {
+	ID            uint      `json:"id"`
+	Category      string    `json:"category"`
+	CreatedAt     time.Time `json:"created_at"`
+	TotalComments int       `json:"total_comments"`
+}
+
+// NameMatch 两份报告之间的名称对齐结果
+// Base 或 Target 为空表示该名称只在一份报告中出现
+type NameMatch struct {
+	Base   string `json:"base"`
+	Target string `json:"target"`
+	Match  string `json:"match,omitempty"` // exact/normalized/fuzzy，未对齐时为空
+}
+
+// DimensionDelta 单个维度的得分变化
+type DimensionDelta struct {
+	Dimension   string   `json:"dimension"`    // 维度名称（以新报告为准）
+	BaseScore   *float64 `json:"base_score"`   // 旧报告得分（没有数据时为null）
+	TargetScore *float64 `json:"target_score"` // 新报告得分
+	Delta       *float64 `json:"delta"`        // 新 - 旧（任一方没有数据时为null）
+}
+
+// BrandDelta 品牌在两份报告之间的变化
+type BrandDelta struct {
+	Brand       string           `json:"brand"`               // 品牌名称（以新报告为准，已消失的品牌使用旧名称）
+	BaseName    string           `json:"base_name,omitempty"` // 旧报告中的名称（与 Brand 不同时填写）
+	Match       string           `json:"match,omitempty"`     // 名称对齐方式
+	Status      string           `json:"status"`              // both/new/vanished
+	BaseScore   *float64         `json:"base_score"`          // 旧报告综合得分
+	TargetScore *float64         `json:"target_score"`        // 新报告综合得分
+	Delta       *float64         `json:"delta"`               // 综合得分变化
+	BaseRank    int              `json:"base_rank"`           // 旧报告排名（0 表示未参与排名）
+	TargetRank  int              `json:"target_rank"`         // 新报告排名
+	RankChange  int              `json:"rank_change"`         // 排名变化（正数表示上升）
+	Dimensions  []DimensionDelta `json:"dimensions"`          // 各维度得分变化
+}
+
+// ModelDelta 型号在两份报告之间的变化
+type ModelDelta struct {
+	Brand       string   `json:"brand"`
+	Model       string   `json:"model"`
+	Status      string   `json:"status"`
+	BaseScore   *float64 `json:"base_score"`
+	TargetScore *float64 `json:"target_score"`
+	Delta       *float64 `json:"delta"`
+	BaseRank    int      `json:"base_rank"`
+	TargetRank  int      `json:"target_rank"`
+	RankChange  int      `json:"rank_change"`
+}
+
+// Comparison 两份报告的对比结果
+type Comparison struct {
+	Base           ReportInfo   `json:"base"`            // 旧报告
+	Target         ReportInfo   `json:"target"`          // 新报告
+	Dimensions     []NameMatch  `json:"dimensions"`      // 维度对齐结果
+	Brands         []BrandDelta `json:"brands"`          // 品牌变化（共有品牌按新排名在前，其次新发现、已消失）
+	NewBrands      []string     `json:"new_brands"`      // 新发现的品牌
+	VanishedBrands []string     `json:"vanished_brands"` // 已消失的品牌
+	Models         []ModelDelta `json:"models"`          // 型号变化
+	NewModels      []string     `json:"new_models"`      // 新出现的型号（品牌 型号）
+	VanishedModels []string     `json:"vanished_models"` // 已消失的型号（品牌 型号）
+}
+
+// CompareReports 对比两份报告
+// 品牌和维度名称先精确匹配，再忽略大小写和标点匹配，最后按包含关系/编辑距离模糊匹配；
+// 型号在对齐后的同一品牌下按归一化名称匹配
+func CompareReports(base, target *ReportData) *Comparison {
+	c := &Comparison{
+		Base:           ReportInfo{Category: base.Category, TotalComments: base.Stats.TotalComments},
+		Target:         ReportInfo{Category: target.Category, TotalComments: target.Stats.TotalComments},
+		NewBrands:      []string{},
+		VanishedBrands: []string{},
+		NewModels:      []string{},
+		VanishedModels: []string{},
+	}
+
+	baseDims := make([]string, 0, len(base.Dimensions))
+	for _, d := range base.Dimensions {
+		baseDims = append(baseDims, d.Name)
+	}
+	targetDims := make([]string, 0, len(target.Dimensions))
+	for _, d := range target.Dimensions {
+		targetDims = append(targetDims, d.Name)
+	}
+	c.Dimensions = alignNames(baseDims, targetDims)
+
+	baseBrands := newBrandIndex(base)
+	targetBrands := newBrandIndex(target)
+	brandMatches := alignNames(baseBrands.names, targetBrands.names)
+	brandMap := make(map[string]string, len(brandMatches)) // 旧品牌名 -> 新品牌名
+
+	for _, m := range brandMatches {
+		d := BrandDelta{Brand: m.Target, Match: m.Match}
+		switch {
+		case m.Base == "":
+			d.Status = CompareStatusNew
+			c.NewBrands = append(c.NewBrands, m.Target)
+		case m.Target == "":
+			d.Brand = m.Base
+			d.Status = CompareStatusVanished
+			c.VanishedBrands = append(c.VanishedBrands, m.Base)
+		default:
+			d.Status = CompareStatusBoth
+			brandMap[m.Base] = m.Target
+			if m.Base != m.Target {
+				d.BaseName = m.Base
+			}
+		}
+		if m.Base != "" {
+			d.BaseScore = scorePtr(baseBrands.overall[m.Base])
+			d.BaseRank = baseBrands.rank[m.Base]
+		}
+		if m.Target != "" {
+			d.TargetScore = scorePtr(targetBrands.overall[m.Target])
+			d.TargetRank = targetBrands.rank[m.Target]
+		}
+		d.Delta = scoreDelta(d.BaseScore, d.TargetScore)
+		if d.BaseRank > 0 && d.TargetRank > 0 {
+			d.RankChange = d.BaseRank - d.TargetRank
+		}
+
+		for _, dm := range c.Dimensions {
+			dd := DimensionDelta{Dimension: dm.Target}
+			if dm.Target == "" {
+				dd.Dimension = dm.Base
+			}
+			if m.Base != "" && dm.Base != "" {
+				dd.BaseScore = lookupDimensionScore(base, m.Base, dm.Base)
+			}
+			if m.Target != "" && dm.Target != "" {
+				dd.TargetScore = lookupDimensionScore(target, m.Target, dm.Target)
+			}
+			dd.Delta = scoreDelta(dd.BaseScore, dd.TargetScore)
+			d.Dimensions = append(d.Dimensions, dd)
+		}
+		c.Brands = append(c.Brands, d)
+	}
+
+	statusOrder := map[string]int{CompareStatusBoth: 0, CompareStatusNew: 1, CompareStatusVanished: 2}
+	sort.SliceStable(c.Brands, func(i, j int) bool {
+		a, b := c.Brands[i], c.Brands[j]
+		if statusOrder[a.Status] != statusOrder[b.Status] {
+			return statusOrder[a.Status] < statusOrder[b.Status]
+		}
+		return scoreValue(a.TargetScore, a.BaseScore) > scoreValue(b.TargetScore, b.BaseScore)
+	})
+
+	c.Models, c.NewModels, c.VanishedModels = compareModels(base.ModelRankings, target.ModelRankings, brandMap)
+	return c
+}
+
+// brandIndex 报告中的品牌综合得分和排名
+type brandIndex struct {
+	names   []string
+	overall map[string]float64
+	rank    map[string]int
+}
+
+// newBrandIndex 收集报告中的品牌：排名中的品牌在前，其余 scores 中出现的品牌按名称排序
+// 不在排名中的品牌使用各维度得分的平均值作为综合得分
+func newBrandIndex(data *ReportData) brandIndex {
+	idx := brandIndex{overall: make(map[string]float64), rank: make(map[string]int)}
+	for _, r := range data.Rankings {
+		if _, ok := idx.overall[r.Brand]; ok {
+			continue
+		}
+		idx.names = append(idx.names, r.Brand)
+		idx.overall[r.Brand] = r.OverallScore
+		idx.rank[r.Brand] = r.Rank
+	}
+	var rest []string
+	for brand, dims := range data.Scores {
+		if _, ok := idx.overall[brand]; ok || brand == "" || len(dims) == 0 {
+			continue
+		}
+		var sum float64
+		for _, s := range dims {
+			sum += s
+		}
+		idx.overall[brand] = math.Round(sum/float64(len(dims))*10) / 10
+		rest = append(rest, brand)
+	}
+	sort.Strings(rest)
+	idx.names = append(idx.names, rest...)
+	return idx
+}
+
+// compareModels 对比型号排名
+// brandMap 为旧品牌名 -> 新品牌名，旧报告的型号先换算到新品牌名下再按归一化型号匹配
+func compareModels(base, target []ModelRanking, brandMap map[string]string) ([]ModelDelta, []string, []string) {
+	baseByKey := make(map[string]ModelRanking, len(base))
+	for _, m := range base {
+		brand := m.Brand
+		if mapped, ok := brandMap[brand]; ok {
+			brand = mapped
+		}
+		baseByKey[normalizeModelKey(brand, m.Model)] = m
+	}
+
+	deltas := make([]ModelDelta, 0, len(base)+len(target))
+	newModels, vanishedModels := []string{}, []string{}
+	matched := make(map[string]bool)
+	for _, m := range target {
+		key := normalizeModelKey(m.Brand, m.Model)
+		d := ModelDelta{
+			Brand:       m.Brand,
+			Model:       m.Model,
+			Status:      CompareStatusNew,
+			TargetScore: scorePtr(m.OverallScore),
+			TargetRank:  m.Rank,
+		}
+		if b, ok := baseByKey[key]; ok && !matched[key] {
+			matched[key] = true
+			d.Status = CompareStatusBoth
+			d.BaseScore = scorePtr(b.OverallScore)
+			d.BaseRank = b.Rank
+			if d.BaseRank > 0 && d.TargetRank > 0 {
+				d.RankChange = d.BaseRank - d.TargetRank
+			}
+		} else {
+			newModels = append(newModels, m.Brand+" "+m.Model)
+		}
+		d.Delta = scoreDelta(d.BaseScore, d.TargetScore)
+		deltas = append(deltas, d)
+	}
+	for _, m := range base {
+		brand := m.Brand
+		if mapped, ok := brandMap[brand]; ok {
+			brand = mapped
+		}
+		if matched[normalizeModelKey(brand, m.Model)] {
+			continue
+		}
+		deltas = append(deltas, ModelDelta{
+			Brand:     m.Brand,
+			Model:     m.Model,
+			Status:    CompareStatusVanished,
+			BaseScore: scorePtr(m.OverallScore),
+			BaseRank:  m.Rank,
+		})
+		vanishedModels = append(vanishedModels, m.Brand+" "+m.Model)
+	}
+	return deltas, newModels, vanishedModels
+}
+
+// lookupDimensionScore 获取品牌在某维度的得分，没有数据时返回 nil
+func lookupDimensionScore(data *ReportData, brand, dim string) *float64 {
+	dims, ok := data.Scores[brand]
+	if !ok {
+		return nil
+	}
+	score, ok := dims[dim]
+	if !ok || score <= 0 {
+		return nil
+	}
+	return scorePtr(score)
+}
+
+func scorePtr(v float64) *float64 {
+	return &v
+}
+
+// scoreDelta 计算得分变化，任一方没有数据时返回 nil
+func scoreDelta(base, target *float64) *float64 {
+	if base == nil || target == nil {
+		return nil
+	}
+	return scorePtr(math.Round((*target-*base)*10) / 10)
+}
+
+// scoreValue 排序用得分：优先使用新报告得分
+func scoreValue(primary, fallback *float64) float64 {
+	if primary != nil {
+		return *primary
+	}
+	if fallback != nil {
+		return *fallback
+	}
+	return 0
+}
+
+// alignNames 对齐两组名称（一对一）
+// 依次按精确、归一化、模糊匹配；结果按新名称顺序排列，未匹配的旧名称追加在最后
+func alignNames(base, target []string) []NameMatch {
+	baseUsed := make([]bool, len(base))
+	targetMatch := make([]NameMatch, len(target))
+	for i, t := range target {
+		targetMatch[i] = NameMatch{Target: t}
+	}
+
+	assign := func(bi, ti int, kind string) {
+		baseUsed[bi] = true
+		targetMatch[ti].Base = base[bi]
+		targetMatch[ti].Match = kind
+	}
+
+	// 精确匹配和归一化匹配
+	for _, kind := range []string{MatchExact, MatchNormalized} {
+		for ti, t := range target {
+			if targetMatch[ti].Match != "" {
+				continue
+			}
+			for bi, b := range base {
+				if baseUsed[bi] {
+					continue
+				}
+				if (kind == MatchExact && b == t) || (kind == MatchNormalized && normalizeName(b) == normalizeName(t)) {
+					assign(bi, ti, kind)
+					break
+				}
+			}
+		}
+	}
+
+	// 模糊匹配：相似度从高到低贪心分配
+	type candidate struct {
+		bi, ti int
+		score  float64
+	}
+	var candidates []candidate
+	for ti, t := range target {
+		if targetMatch[ti].Match != "" {
+			continue
+		}
+		for bi, b := range base {
+			if baseUsed[bi] {
+				continue
+			}
+			if s := nameSimilarity(b, t); s >= fuzzyMatchThreshold {
+				candidates = append(candidates, candidate{bi, ti, s})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	for _, cand := range candidates {
+		if baseUsed[cand.bi] || targetMatch[cand.ti].Match != "" {
+			continue
+		}
+		assign(cand.bi, cand.ti, MatchFuzzy)
+	}
+
+	result := targetMatch
+	for bi, b := range base {
+		if !baseUsed[bi] {
+			result = append(result, NameMatch{Base: b})
+		}
+	}
+	return result
+}
+
+// normalizeName 名称归一化：小写，只保留字母、数字和汉字
+func normalizeName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// nameSimilarity 名称相似度（0-1）
+// 一方包含另一方（且较短的一方至少2个字符）视为0.9，否则按编辑距离计算
+func nameSimilarity(a, b string) float64 {
+	ra, rb := []rune(normalizeName(a)), []rune(normalizeName(b))
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	if string(ra) == string(rb) {
+		return 1
+	}
+	shorter, longer := string(ra), string(rb)
+	if len(ra) > len(rb) {
+		shorter, longer = longer, shorter
+	}
+	if len([]rune(shorter)) >= 2 && strings.Contains(longer, shorter) {
+		return 0.9
+	}
+	dist := levenshtein(ra, rb)
+	return 1 - float64(dist)/float64(max(len(ra), len(rb)))
+}
+
+// levenshtein 编辑距离（按字符计算）
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(min(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,142 @@
+package report
+
+import (
+	"testing"
+
+	"bilibili-analyzer/backend/ai"
+)
+
+func TestAlignNames(t *testing.T) {
+	base := []string{"小米", "Dyson", "追觅科技", "石头"}
+	target := []string{"戴森", "dyson", "小米", "追觅", "添可"}
+
+	got := alignNames(base, target)
+	want := []NameMatch{
+		{Base: "", Target: "戴森"},
+		{Base: "Dyson", Target: "dyson", Match: MatchNormalized},
+		{Base: "小米", Target: "小米", Match: MatchExact},
+		{Base: "追觅科技", Target: "追觅", Match: MatchFuzzy},
+		{Base: "", Target: "添可"},
+		{Base: "石头", Target: ""},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("alignNames() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("alignNames()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{"续航能力", "续航", 0.9, 0.9},
+		{"Roborock", "roborock!", 1, 1},
+		{"吸力", "噪音", 0, 0},
+		{"性价比", "性价", 0.9, 0.9},
+		{"石", "石头", 0, 0.6}, // 单字不按包含关系匹配
+	}
+	for _, tt := range tests {
+		got := nameSimilarity(tt.a, tt.b)
+		if got < tt.min || got > tt.max {
+			t.Errorf("nameSimilarity(%q, %q) = %v, want [%v, %v]", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestCompareReports(t *testing.T) {
+	base := &ReportData{
+		Category:   "吸尘器",
+		Dimensions: []ai.Dimension{{Name: "吸力"}, {Name: "续航能力"}, {Name: "噪音"}},
+		Scores: map[string]map[string]float64{
+			"小米":    {"吸力": 7, "续航能力": 6, "噪音": 5},
+			"Dyson": {"吸力": 9, "续航能力": 7, "噪音": 6},
+			"石头":    {"吸力": 6, "续航能力": 6, "噪音": 6},
+		},
+		Rankings: []BrandRanking{
+			{Brand: "Dyson", OverallScore: 7.3, Rank: 1},
+			{Brand: "小米", OverallScore: 6, Rank: 2},
+			{Brand: "石头", OverallScore: 6, Rank: 3},
+		},
+		ModelRankings: []ModelRanking{
+			{Brand: "小米", Model: "G10", OverallScore: 6, Rank: 2},
+			{Brand: "Dyson", Model: "V12", OverallScore: 7.3, Rank: 1},
+			{Brand: "石头", Model: "H7", OverallScore: 6, Rank: 3},
+		},
+	}
+	target := &ReportData{
+		Category:   "吸尘器",
+		Dimensions: []ai.Dimension{{Name: "吸力"}, {Name: "续航"}, {Name: "价格"}},
+		Scores: map[string]map[string]float64{
+			"小米":    {"吸力": 8, "续航": 7, "价格": 9},
+			"dyson": {"吸力": 8.5, "续航": 7},
+			"追觅":    {"吸力": 7},
+		},
+		Rankings: []BrandRanking{
+			{Brand: "小米", OverallScore: 8, Rank: 1},
+			{Brand: "dyson", OverallScore: 7.8, Rank: 2},
+		},
+		ModelRankings: []ModelRanking{
+			{Brand: "小米", Model: "G 10", OverallScore: 8, Rank: 1},
+			{Brand: "dyson", Model: "V15", OverallScore: 7.8, Rank: 2},
+		},
+	}
+
+	c := CompareReports(base, target)
+
+	if len(c.Dimensions) != 4 || c.Dimensions[1].Base != "续航能力" || c.Dimensions[1].Match != MatchFuzzy {
+		t.Errorf("unexpected dimension alignment: %+v", c.Dimensions)
+	}
+	if len(c.NewBrands) != 1 || c.NewBrands[0] != "追觅" {
+		t.Errorf("NewBrands = %v, want [追觅]", c.NewBrands)
+	}
+	if len(c.VanishedBrands) != 1 || c.VanishedBrands[0] != "石头" {
+		t.Errorf("VanishedBrands = %v, want [石头]", c.VanishedBrands)
+	}
+
+	if len(c.Brands) != 4 {
+		t.Fatalf("expected 4 brands, got %+v", c.Brands)
+	}
+	xiaomi := c.Brands[0]
+	if xiaomi.Brand != "小米" || xiaomi.Status != CompareStatusBoth || *xiaomi.Delta != 2 || xiaomi.RankChange != 1 {
+		t.Errorf("unexpected 小米 delta: %+v", xiaomi)
+	}
+	if d := xiaomi.Dimensions[1]; d.Dimension != "续航" || *d.BaseScore != 6 || *d.Delta != 1 {
+		t.Errorf("unexpected 续航 delta: %+v", d)
+	}
+	if d := xiaomi.Dimensions[2]; d.Dimension != "价格" || d.BaseScore != nil || d.Delta != nil || *d.TargetScore != 9 {
+		t.Errorf("new dimension should have no base score: %+v", d)
+	}
+
+	dyson := c.Brands[1]
+	if dyson.Brand != "dyson" || dyson.BaseName != "Dyson" || dyson.Match != MatchNormalized || dyson.RankChange != -1 {
+		t.Errorf("unexpected dyson delta: %+v", dyson)
+	}
+	if *dyson.Delta != 0.5 {
+		t.Errorf("dyson delta = %v, want 0.5", *dyson.Delta)
+	}
+
+	// 不在排名中的新品牌使用维度均分，排名为0
+	if b := c.Brands[2]; b.Brand != "追觅" || b.Status != CompareStatusNew || *b.TargetScore != 7 || b.TargetRank != 0 || b.Delta != nil {
+		t.Errorf("unexpected new brand: %+v", b)
+	}
+	if b := c.Brands[3]; b.Brand != "石头" || b.Status != CompareStatusVanished || b.TargetScore != nil {
+		t.Errorf("unexpected vanished brand: %+v", b)
+	}
+
+	// 型号：G10 与 G 10 视为同一型号；V12 消失、V15 新出现（品牌名按对齐结果换算）
+	if len(c.NewModels) != 1 || c.NewModels[0] != "dyson V15" {
+		t.Errorf("NewModels = %v, want [dyson V15]", c.NewModels)
+	}
+	if len(c.VanishedModels) != 2 {
+		t.Errorf("VanishedModels = %v, want 2 items", c.VanishedModels)
+	}
+	if m := c.Models[0]; m.Status != CompareStatusBoth || *m.Delta != 2 || m.RankChange != 1 {
+		t.Errorf("unexpected G 10 delta: %+v", m)
+	}
+}
@@ -0,0 +1,132 @@
+package report
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/bilibili"
+	"bilibili-analyzer/backend/models"
+	"math"
+	"sort"
+	"strings"
+)
+
+// BuildReportComments 把报告的分析结果转换为证据评论记录
+// 品牌取分析结果的分组键（与报告 scores 中的品牌一致），没有任何有效得分的评论不保存
+func BuildReportComments(reportID uint, analysisResults map[string][]CommentWithScore) []models.ReportComment {
+	brands := make([]string, 0, len(analysisResults))
+	for brand := range analysisResults {
+		brands = append(brands, brand)
+	}
+	sort.Strings(brands)
+
+	var rows []models.ReportComment
+	for _, brand := range brands {
+		for _, r := range analysisResults[brand] {
+			var scores []models.ReportCommentScore
+			for dim, score := range r.Scores {
+				if score == nil {
+					continue
+				}
+				aspect := r.Aspects[dim]
+				scores = append(scores, models.ReportCommentScore{
+					ReportID:   reportID,
+					Dimension:  dim,
+					Score:      *score,
+					Polarity:   aspect.Polarity,
+					Confidence: aspect.Confidence,
+					Span:       aspect.Span,
+				})
+			}
+			if len(scores) == 0 {
+				continue
+			}
+			sort.Slice(scores, func(i, j int) bool { return scores[i].Dimension < scores[j].Dimension })
+
+			source := r.Source
+			if source == "" {
+				source = bilibili.SourceComment
+			}
+			rows = append(rows, models.ReportComment{
+				ReportID:     reportID,
+				Brand:        brand,
+				Model:        r.Model,
+				ModelKey:     NormalizeModelName(r.Model),
+				Source:       source,
+				RPID:         r.RPID,
+				VideoBVID:    r.VideoBVID,
+				Mid:          r.Mid,
+				Author:       r.Author,
+				Likes:        r.Like,
+				ReplyCount:   r.ReplyCount,
+				IsReply:      r.IsReply,
+				AuthorLevel:  r.AuthorLevel,
+				Content:      r.Content,
+				OverallScore: math.Round(calculateAverageScore(r.Scores)*100) / 100,
+				PublishTime:  r.PublishTime,
+				Scores:       scores,
+			})
+		}
+	}
+	return rows
+}
+
+// CommentsFromReport 把证据评论还原为分析结果（品牌 -> 评论及得分），用于不重新抓取和分析而重新生成报告
+// 没有有效得分的评论在保存时已被跳过，因此只影响评论数，不影响得分
+func CommentsFromReport(rows []models.ReportComment) map[string][]CommentWithScore {
+	results := make(map[string][]CommentWithScore)
+	for _, row := range rows {
+		scores := make(map[string]*float64, len(row.Scores))
+		var aspects map[string]ai.AspectSentiment
+		for _, s := range row.Scores {
+			score := s.Score
+			scores[s.Dimension] = &score
+			if s.Polarity != "" {
+				if aspects == nil {
+					aspects = make(map[string]ai.AspectSentiment)
+				}
+				aspects[s.Dimension] = ai.AspectSentiment{Polarity: s.Polarity, Confidence: s.Confidence, Span: s.Span}
+			}
+		}
+		results[row.Brand] = append(results[row.Brand], CommentWithScore{
+			Content:     row.Content,
+			Scores:      scores,
+			Aspects:     aspects,
+			Brand:       row.Brand,
+			Model:       row.Model,
+			PublishTime: row.PublishTime,
+			Source:      row.Source,
+			RPID:        row.RPID,
+			VideoBVID:   row.VideoBVID,
+			Mid:         row.Mid,
+			Author:      row.Author,
+			Like:        row.Likes,
+			ReplyCount:  row.ReplyCount,
+			IsReply:     row.IsReply,
+			AuthorLevel: row.AuthorLevel,
+		})
+	}
+	return results
+}
+
+// SampleComments 选取有代表性的评论（按点赞数、内容长度倒序），用于候选品牌等审核场景展示
+func SampleComments(comments []CommentWithScore, n int) []TypicalComment {
+	sorted := make([]CommentWithScore, 0, len(comments))
+	for _, c := range comments {
+		if strings.TrimSpace(c.Content) != "" {
+			sorted = append(sorted, c)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Like != sorted[j].Like {
+			return sorted[i].Like > sorted[j].Like
+		}
+		return len([]rune(sorted[i].Content)) > len([]rune(sorted[j].Content))
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	samples := make([]TypicalComment, 0, len(sorted))
+	for _, c := range sorted {
+		samples = append(samples, newTypicalComment(c, math.Round(calculateAverageScore(c.Scores)*10)/10))
+	}
+	return samples
+}
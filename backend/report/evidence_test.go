@@ -0,0 +1,70 @@
+package report
+
+import (
+	"testing"
+)
+
+func TestBuildReportComments(t *testing.T) {
+	s := func(v float64) *float64 { return &v }
+	results := map[string][]CommentWithScore{
+		"戴森": {
+			{
+				Content: "V12吸力很强", Model: "V 12", Scores: map[string]*float64{"吸力": s(9), "续航": s(6)},
+				RPID: 123, VideoBVID: "BV1xx", Mid: 42, Author: "用户A", Like: 7,
+			},
+			{Content: "没提到任何维度", Scores: map[string]*float64{"吸力": nil}},
+		},
+		"小米": {
+			{Content: "弹幕", Scores: map[string]*float64{"吸力": s(5)}, Source: "danmaku", VideoBVID: "BV2yy"},
+		},
+	}
+
+	rows := BuildReportComments(9, results)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows (comment without scores skipped), got %d", len(rows))
+	}
+	// 按品牌名排序：小米 < 戴森
+	dyson := rows[1]
+	if dyson.ReportID != 9 || dyson.Brand != "戴森" || dyson.ModelKey != "v12" || dyson.Source != "comment" {
+		t.Errorf("unexpected row: %+v", dyson)
+	}
+	if dyson.RPID != 123 || dyson.VideoBVID != "BV1xx" || dyson.Mid != 42 || dyson.Likes != 7 || dyson.OverallScore != 7.5 {
+		t.Errorf("unexpected evidence fields: %+v", dyson)
+	}
+	if len(dyson.Scores) != 2 || dyson.Scores[0].Dimension != "吸力" || dyson.Scores[0].ReportID != 9 {
+		t.Errorf("unexpected scores: %+v", dyson.Scores)
+	}
+	if rows[0].Source != "danmaku" {
+		t.Errorf("expected danmaku source, got %q", rows[0].Source)
+	}
+}
+
+func TestCommentsFromReportRoundTrip(t *testing.T) {
+	s := func(v float64) *float64 { return &v }
+	results := map[string][]CommentWithScore{
+		"戴森": {{Content: "V12吸力很强", Brand: "戴森", Model: "V12", Scores: map[string]*float64{"吸力": s(9), "续航": s(6)}, RPID: 123, VideoBVID: "BV1xx", Author: "用户A", Like: 7}},
+	}
+	back := CommentsFromReport(BuildReportComments(9, results))
+	got := back["戴森"]
+	if len(got) != 1 {
+		t.Fatalf("expected 1 comment, got %+v", back)
+	}
+	c := got[0]
+	if c.Content != "V12吸力很强" || c.Model != "V12" || c.RPID != 123 || c.Like != 7 || c.Source != "comment" {
+		t.Errorf("unexpected comment: %+v", c)
+	}
+	if len(c.Scores) != 2 || *c.Scores["吸力"] != 9 || *c.Scores["续航"] != 6 {
+		t.Errorf("unexpected scores: %+v", c.Scores)
+	}
+}
+
+func TestTypicalCommentEvidence(t *testing.T) {
+	s := func(v float64) *float64 { return &v }
+	top, _ := selectTypicalComments(map[string][]CommentWithScore{
+		"戴森": {{Content: "吸力很强", Scores: map[string]*float64{"吸力": s(9)}, RPID: 123, VideoBVID: "BV1xx", Author: "用户A", Like: 7}},
+	})
+	tc := top["戴森"][0]
+	if tc.RPID != 123 || tc.Author != "用户A" || tc.Like != 7 || tc.URL != "https://www.bilibili.com/video/BV1xx#reply123" {
+		t.Errorf("unexpected typical comment: %+v", tc)
+	}
+}
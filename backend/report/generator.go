@@ -3,11 +3,13 @@ package report
 import (
 	"bilibili-analyzer/backend/ai"
 	"bilibili-analyzer/backend/bilibili"
+	"bilibili-analyzer/backend/comment"
 	"bilibili-analyzer/backend/models"
 	"encoding/json"
 	"fmt"
 	"log"
 	"math"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -15,15 +17,16 @@ import (
 )
 
 // normalizeModelKey 生成归一化的型号key用于比对
-// 规则：品牌小写 + "|" + 型号去空格小写
+// 规则：品牌归一化key + "|" + 型号归一化key（见 comment.BrandKey、comment.ModelKey）
 // 例如：("OPPO", "TWS 5") -> "oppo|tws5"
 func normalizeModelKey(brand, model string) string {
-	brandKey := strings.ToLower(strings.TrimSpace(brand))
-	modelKey := strings.ToLower(strings.TrimSpace(model))
-	modelKey = strings.ReplaceAll(modelKey, " ", "")
-	modelKey = strings.ReplaceAll(modelKey, "-", "")
-	modelKey = strings.ReplaceAll(modelKey, "_", "")
-	return brandKey + "|" + modelKey
+	return comment.BrandKey(brand) + "|" + NormalizeModelName(model)
+}
+
+// NormalizeModelName 归一化型号名称：小写、全角转半角，并去掉空格、"-"、"_"、"·"
+// 例如："TWS 5" -> "tws5"
+func NormalizeModelName(model string) string {
+	return comment.ModelKey(model)
 }
 
 // getDisplayModel 从多个型号变体中选择最佳显示名称
@@ -55,6 +58,9 @@ type VideoSource struct {
 	Author      string `json:"author"`       // UP主
 	Play        int    `json:"play"`         // 播放量
 	VideoReview int    `json:"video_review"` // 评论数
+
+	Sponsored      bool     `json:"sponsored"`                 // 是否判定为商单视频
+	SponsorSignals []string `json:"sponsor_signals,omitempty"` // 商单判定依据
 }
 
 // KeywordItem 关键词词频项
@@ -74,14 +80,32 @@ type ReportData struct {
 	Rankings       []BrandRanking                `json:"rankings"`       // 品牌排名列表
 	Recommendation string                        `json:"recommendation"` // 购买建议文本
 	// 新增字段
-	Stats                 ReportStats                 `json:"stats"`                  // 统计数据
-	SentimentDistribution SentimentStats              `json:"sentiment_distribution"` // 情感分布（基于评分阈值统计）
-	TopComments           map[string][]TypicalComment `json:"top_comments"`           // 品牌 -> 好评列表
-	BadComments           map[string][]TypicalComment `json:"bad_comments"`           // 品牌 -> 差评列表
-	BrandAnalysis         map[string]BrandAnalysis    `json:"brand_analysis"`         // 品牌 -> 优劣势分析
-	ModelRankings         []ModelRanking              `json:"model_rankings"`         // 型号排名列表
-	VideoSources          []VideoSource               `json:"video_sources"`          // 视频来源列表
-	KeywordFrequency      []KeywordItem               `json:"keyword_frequency"`      // 关键词词频（用于词云）
+	Stats                 ReportStats                 `json:"stats"`                      // 统计数据
+	SentimentDistribution SentimentStats              `json:"sentiment_distribution"`     // 情感分布（按维度情感倾向统计，缺少时按评分阈值）
+	TopComments           map[string][]TypicalComment `json:"top_comments"`               // 品牌 -> 好评列表
+	BadComments           map[string][]TypicalComment `json:"bad_comments"`               // 品牌 -> 差评列表
+	BrandAnalysis         map[string]BrandAnalysis    `json:"brand_analysis"`             // 品牌 -> 优劣势分析
+	ModelRankings         []ModelRanking              `json:"model_rankings"`             // 型号排名列表
+	VideoSources          []VideoSource               `json:"video_sources"`              // 视频来源列表
+	KeywordFrequency      []KeywordItem               `json:"keyword_frequency"`          // 关键词词频（用于词云）
+	TokenUsage            *ai.UsageSummary            `json:"token_usage,omitempty"`      // AI Token 用量和费用（生成报告时由任务填充）
+	SourceBreakdown       []SourceStats               `json:"source_breakdown,omitempty"` // 按评论来源（评论区/弹幕）拆分的得分，仅包含弹幕时生成
+	Trends                []BrandTrend                `json:"trends,omitempty"`           // 各品牌按月的得分趋势（按评论发布时间统计）
+	BrandSentiment        map[string]SentimentStats   `json:"brand_sentiment,omitempty"`  // 品牌 -> 情感分布（规则与整体情感分布相同）
+	MergedAliases         map[string][]string         `json:"merged_aliases,omitempty"`   // 品牌 -> 通过品牌词典归并到该品牌的其他写法
+	AspectSentiment       []DimensionSentiment        `json:"aspect_sentiment,omitempty"` // 各维度正面/中性/负面评论数及原文引文（按维度顺序）
+
+	BrandAspectSentiment map[string][]DimensionSentiment `json:"brand_aspect_sentiment,omitempty"` // 品牌 -> 各维度情感统计
+
+	WeightedScores map[string]map[string]float64 `json:"weighted_scores,omitempty"` // 品牌 -> 维度 -> 加权得分（未开启评论加权时为空）
+	Weighting      *CommentWeighting             `json:"weighting,omitempty"`       // 计算加权得分使用的权重模型
+
+	SponsoredComparison *SponsoredComparison `json:"sponsored_comparison,omitempty"` // 商单与非商单视频下评论的对比（没有商单视频时为空）
+	SuspiciousComments  *SuspiciousSummary   `json:"suspicious_comments,omitempty"`  // AI分析前识别出的可疑评论（未开启识别时为空）
+
+	// 生成报告时使用的配置，接受候选品牌重新生成报告时沿用（评论权重见 Weighting）
+	ExcludeSponsored bool                 `json:"exclude_sponsored,omitempty"` // 商单视频下的评论是否不计入得分和排名
+	CatalogModels    []comment.ModelEntry `json:"catalog_models"`              // 型号库中报告品牌和候选品牌的词条（由任务填充，旧报告为 null）
 }
 
 // BrandRanking 品牌排名信息
@@ -90,19 +114,29 @@ type BrandRanking struct {
 	Brand        string             `json:"brand"`         // 品牌名称
 	OverallScore float64            `json:"overall_score"` // 综合得分（所有维度平均）
 	Rank         int                `json:"rank"`          // 排名（1表示第一名）
-	Scores       map[string]float64 `json:"scores"`        // 各维度得分
+	Scores       map[string]float64 `json:"scores"`        // 各维度得分（原始平均分）
+
+	ShrunkScore    float64               `json:"shrunk_score"`              // 按评论数收缩后的综合得分，排名依据（见 applyRankingStatistics）
+	CILow          float64               `json:"ci_low"`                    // 综合得分 95% 置信区间下限
+	CIHigh         float64               `json:"ci_high"`                   // 综合得分 95% 置信区间上限
+	SampleSize     int                   `json:"sample_size"`               // 参与评分的评论数
+	LowSample      bool                  `json:"low_sample"`                // 评论数不足，排名仅供参考
+	TiedWith       []string              `json:"tied_with,omitempty"`       // 置信区间重叠、差异不显著的品牌
+	DimensionStats map[string]ScoreStats `json:"dimension_stats,omitempty"` // 维度 -> 收缩后得分、置信区间和样本量
+	WeightedScore  float64               `json:"weighted_score,omitempty"`  // 按评论权重加权的综合得分（未开启评论加权时为0）
 }
 
 // ReportStats 报告统计数据
 // 包含视频数、评论数、各品牌评论数等统计信息
 type ReportStats struct {
-	TotalVideos     int            `json:"total_videos"`      // 搜索到的视频总数
-	TotalComments   int            `json:"total_comments"`    // 抓取的评论总数
-	CommentsByBrand map[string]int `json:"comments_by_brand"` // 各品牌评论数
+	TotalVideos     int            `json:"total_videos"`            // 搜索到的视频总数
+	TotalComments   int            `json:"total_comments"`          // 抓取的评论总数
+	TotalDanmaku    int            `json:"total_danmaku,omitempty"` // 抓取的弹幕总数（未开启弹幕抓取时为0）
+	CommentsByBrand map[string]int `json:"comments_by_brand"`       // 各品牌评论数
 }
 
 // SentimentStats 情感分布统计
-// 注意：这里不做任何AI情感分析，只按评分阈值划分好评/中性/差评
+// 按评论的维度情感倾向划分好评/中性/差评（规则见 commentPolarity），没有维度情感的评论按评分阈值划分
 type SentimentStats struct {
 	PositiveCount int     `json:"positive_count"`
 	NeutralCount  int     `json:"neutral_count"`
@@ -113,10 +147,18 @@ type SentimentStats struct {
 }
 
 // TypicalComment 典型评论
-// 包含评论内容和平均得分
+// 包含评论内容、平均得分和来源信息（可跳转到B站原评论核实）
 type TypicalComment struct {
-	Content string  `json:"content"` // 评论内容
-	Score   float64 `json:"score"`   // 平均得分
+	Content   string  `json:"content"`          // 评论内容
+	Score     float64 `json:"score"`            // 平均得分
+	RPID      int64   `json:"rpid,omitempty"`   // 评论ID
+	VideoBVID string  `json:"bvid,omitempty"`   // 所属视频BV号
+	Author    string  `json:"author,omitempty"` // 评论者昵称
+	Mid       int64   `json:"mid,omitempty"`    // 评论者UID
+	Like      int     `json:"like,omitempty"`   // 点赞数
+	URL       string  `json:"url,omitempty"`    // 原评论链接
+
+	Highlights []AspectHighlight `json:"highlights,omitempty"` // 支撑各维度判断的原文片段
 }
 
 // BrandAnalysis 品牌优劣势分析
@@ -128,31 +170,67 @@ type BrandAnalysis struct {
 
 // ModelRanking 型号排名信息
 type ModelRanking struct {
-	Model        string             `json:"model"`         // 型号名称
-	Brand        string             `json:"brand"`         // 品牌名称
-	OverallScore float64            `json:"overall_score"` // 综合得分
-	Rank         int                `json:"rank"`          // 排名
-	Scores       map[string]float64 `json:"scores"`        // 各维度得分
-	CommentCount int                `json:"comment_count"` // 评论数量
+	Model         string             `json:"model"`                    // 型号名称
+	Brand         string             `json:"brand"`                    // 品牌名称
+	OverallScore  float64            `json:"overall_score"`            // 综合得分
+	Rank          int                `json:"rank"`                     // 排名
+	Scores        map[string]float64 `json:"scores"`                   // 各维度得分
+	CommentCount  int                `json:"comment_count"`            // 评论数量
+	Verified      bool               `json:"verified"`                 // 是否命中型号库
+	ReleaseYear   int                `json:"release_year,omitempty"`   // 发布年份（来自型号库）
+	ShrunkScore   float64            `json:"shrunk_score"`             // 按评论数收缩后的综合得分，排名依据
+	CILow         float64            `json:"ci_low"`                   // 综合得分 95% 置信区间下限
+	CIHigh        float64            `json:"ci_high"`                  // 综合得分 95% 置信区间上限
+	LowSample     bool               `json:"low_sample"`               // 评论数不足，排名仅供参考
+	WeightedScore float64            `json:"weighted_score,omitempty"` // 按评论权重加权的综合得分
+}
+
+// SourceStats 单一评论来源的得分统计
+type SourceStats struct {
+	Source       string                        `json:"source"`        // 来源（comment/danmaku）
+	Label        string                        `json:"label"`         // 展示名称（评论/弹幕）
+	CommentCount int                           `json:"comment_count"` // 该来源参与评分的评论数
+	OverallScore float64                       `json:"overall_score"` // 所有维度得分的平均值
+	Scores       map[string]float64            `json:"scores"`        // 维度 -> 得分
+	BrandScores  map[string]map[string]float64 `json:"brand_scores"`  // 品牌 -> 维度 -> 得分
 }
 
 // CommentWithScore 带得分的评论
 type CommentWithScore struct {
 	Content     string
 	Scores      map[string]*float64
+	Aspects     map[string]ai.AspectSentiment // 维度 -> 情感倾向及原文片段（旧数据可能为空）
 	Brand       string
 	Model       string
 	PublishTime time.Time
+	Source      string // 评论来源（bilibili.SourceComment/SourceDanmaku），为空视为评论区评论
+
+	// 来源信息，用于从报告追溯到原评论
+	RPID      int64  // 评论ID（弹幕为0）
+	VideoBVID string // 所属视频BV号
+	Mid       int64  // 评论者UID
+	Author    string // 评论者昵称
+	Like      int    // 点赞数
+
+	// 权重信息（见 CommentWeighting）
+	ReplyCount  int  // 该评论收到的回复数
+	IsReply     bool // 是否为楼中楼回复
+	AuthorLevel int  // 评论者账号等级（1-6，0表示未知）
+	Sponsored   bool // 是否来自商单视频（生成报告时按视频标记）
 }
 
 // GenerateReportInput 报告生成输入参数
 type GenerateReportInput struct {
-	Category        string
-	Brands          []string
-	Dimensions      []ai.Dimension
-	AnalysisResults map[string][]CommentWithScore // brand -> 评论及得分列表
-	Stats           ReportStats
-	Videos          []bilibili.VideoInfo
+	Category         string
+	Brands           []string
+	Dimensions       []ai.Dimension
+	AnalysisResults  map[string][]CommentWithScore // brand -> 评论及得分列表
+	Stats            ReportStats
+	Videos           []bilibili.VideoInfo
+	ModelCatalog     *comment.ModelCatalog // 型号库，用于标记型号排名中已收录的型号（可为 nil）
+	Weighting        *CommentWeighting     // 评论权重模型，为 nil 或未启用时不计算加权得分
+	ExcludeSponsored bool                  // 商单视频下的评论不计入得分和排名（仍参与商单对比）
+	Suspicious       *SuspiciousSummary    // 可疑评论识别结果，原样写入报告
 }
 
 // GenerateReport 生成分析报告
@@ -192,6 +270,13 @@ func GenerateReport(
 
 // GenerateReportWithInput 使用完整输入生成报告（支持典型评论筛选）
 func GenerateReportWithInput(input GenerateReportInput) (*ReportData, error) {
+	// 标记商单视频下的评论；需要排除时先完成商单对比，再去掉这些评论
+	input.AnalysisResults = markSponsoredComments(input.AnalysisResults, sponsoredVideoSet(input.Videos))
+	sponsoredComparison := generateSponsoredComparison(input.AnalysisResults, input.Videos, input.ExcludeSponsored)
+	if sponsoredComparison != nil && input.ExcludeSponsored {
+		input.AnalysisResults, input.Stats.CommentsByBrand = excludeSponsored(input.AnalysisResults)
+	}
+
 	scores := make(map[string]map[string]float64)
 	for brand, results := range input.AnalysisResults {
 		brandScores := make(map[string]float64)
@@ -216,6 +301,7 @@ func GenerateReportWithInput(input GenerateReportInput) (*ReportData, error) {
 	}
 
 	rankings := generateRankings(input.Brands, input.Dimensions, scores)
+	applyRankingStatistics(rankings, input.AnalysisResults, input.Dimensions)
 	recommendation := generateRecommendation(rankings, input.Dimensions)
 
 	// 收集所有发现的品牌（用于品牌分析）
@@ -230,6 +316,17 @@ func GenerateReportWithInput(input GenerateReportInput) (*ReportData, error) {
 
 	// 生成型号排名（使用归一化key合并相似型号）
 	modelRankings := generateModelRankings(input.AnalysisResults, input.Dimensions)
+	applyModelStatistics(modelRankings, input.AnalysisResults, input.Dimensions)
+	markCatalogModels(modelRankings, input.ModelCatalog)
+
+	// 加权得分与未加权得分并列展示，不影响排名顺序
+	var weightedScores map[string]map[string]float64
+	var weighting *CommentWeighting
+	if input.Weighting != nil && input.Weighting.Enabled {
+		w := *input.Weighting
+		weighting = &w
+		weightedScores = applyWeightedScores(rankings, modelRankings, input.AnalysisResults, w)
+	}
 
 	// 收集所有品牌名称用于报告（按排名顺序）
 	allBrandNames := make([]string, 0, len(rankings))
@@ -242,15 +339,17 @@ func GenerateReportWithInput(input GenerateReportInput) (*ReportData, error) {
 	videoSources := make([]VideoSource, len(input.Videos))
 	for i, v := range input.Videos {
 		videoSources[i] = VideoSource{
-			BVID:        v.BVID,
-			Title:       v.Title,
-			Author:      v.Author,
-			Play:        v.Play,
-			VideoReview: v.VideoReview,
+			BVID:           v.BVID,
+			Title:          v.Title,
+			Author:         v.Author,
+			Play:           v.Play,
+			VideoReview:    v.VideoReview,
+			Sponsored:      v.Sponsored,
+			SponsorSignals: v.SponsorSignals,
 		}
 	}
 
-	// 计算整体情感分布：仅按评分阈值划分，不做任何AI情感分析
+	// 计算整体情感分布：按AI给出的维度情感倾向划分，缺少维度情感的评论按评分阈值划分
 	sentimentDistribution := calculateSentiment(input.AnalysisResults)
 	log.Printf("[GenerateReport] SentimentDistribution: %+v", sentimentDistribution)
 
@@ -274,9 +373,105 @@ func GenerateReportWithInput(input GenerateReportInput) (*ReportData, error) {
 		ModelRankings:         modelRankings,
 		VideoSources:          videoSources,
 		KeywordFrequency:      keywordFrequency,
+		SourceBreakdown:       generateSourceBreakdown(input.AnalysisResults, input.Dimensions),
+		Trends:                generateTrends(input.AnalysisResults, allBrandNames),
+		BrandSentiment:        calculateBrandSentiment(input.AnalysisResults),
+		AspectSentiment:       calculateAspectSentiment(input.AnalysisResults, input.Dimensions),
+		BrandAspectSentiment:  calculateBrandAspectSentiment(input.AnalysisResults, input.Dimensions),
+		WeightedScores:        weightedScores,
+		Weighting:             weighting,
+		SponsoredComparison:   sponsoredComparison,
+		SuspiciousComments:    input.Suspicious,
+		ExcludeSponsored:      input.ExcludeSponsored,
 	}, nil
 }
 
+// generateSourceBreakdown 按评论来源分别计算各维度得分
+// 只有评论区评论时返回 nil，避免报告中出现只有一项的拆分
+func generateSourceBreakdown(analysisResults map[string][]CommentWithScore, dimensions []ai.Dimension) []SourceStats {
+	type accumulator struct {
+		count  int
+		sums   map[string]float64
+		counts map[string]int
+		brands map[string]map[string][]float64
+	}
+	bySource := make(map[string]*accumulator)
+	for brand, results := range analysisResults {
+		for _, r := range results {
+			source := r.Source
+			if source == "" {
+				source = bilibili.SourceComment
+			}
+			acc := bySource[source]
+			if acc == nil {
+				acc = &accumulator{
+					sums:   make(map[string]float64),
+					counts: make(map[string]int),
+					brands: make(map[string]map[string][]float64),
+				}
+				bySource[source] = acc
+			}
+			acc.count++
+			for dim, score := range r.Scores {
+				if score == nil {
+					continue
+				}
+				acc.sums[dim] += *score
+				acc.counts[dim]++
+				if acc.brands[brand] == nil {
+					acc.brands[brand] = make(map[string][]float64)
+				}
+				acc.brands[brand][dim] = append(acc.brands[brand][dim], *score)
+			}
+		}
+	}
+	if bySource[bilibili.SourceDanmaku] == nil {
+		return nil
+	}
+
+	labels := map[string]string{bilibili.SourceComment: "评论", bilibili.SourceDanmaku: "弹幕"}
+	var breakdown []SourceStats
+	for _, source := range []string{bilibili.SourceComment, bilibili.SourceDanmaku} {
+		acc := bySource[source]
+		if acc == nil {
+			continue
+		}
+		stats := SourceStats{
+			Source:       source,
+			Label:        labels[source],
+			CommentCount: acc.count,
+			Scores:       make(map[string]float64),
+			BrandScores:  make(map[string]map[string]float64),
+		}
+		var total float64
+		var dimCount int
+		for _, dim := range dimensions {
+			if n := acc.counts[dim.Name]; n > 0 {
+				avg := acc.sums[dim.Name] / float64(n)
+				stats.Scores[dim.Name] = math.Round(avg*10) / 10
+				total += avg
+				dimCount++
+			}
+		}
+		if dimCount > 0 {
+			stats.OverallScore = math.Round(total/float64(dimCount)*10) / 10
+		}
+		for brand, dims := range acc.brands {
+			brandScores := make(map[string]float64, len(dims))
+			for dim, values := range dims {
+				var sum float64
+				for _, v := range values {
+					sum += v
+				}
+				brandScores[dim] = math.Round(sum/float64(len(values))*10) / 10
+			}
+			stats.BrandScores[brand] = brandScores
+		}
+		breakdown = append(breakdown, stats)
+	}
+	return breakdown
+}
+
 // generateModelRankings 生成型号排名
 // 按"品牌+型号"聚合，使用归一化key合并相似型号（如TWS5、TWS 5、Tws5）
 func generateModelRankings(analysisResults map[string][]CommentWithScore, dimensions []ai.Dimension) []ModelRanking {
@@ -375,6 +570,19 @@ func generateModelRankings(analysisResults map[string][]CommentWithScore, dimens
 	return modelRankings
 }
 
+// markCatalogModels 标记型号库中已收录的型号，并统一为型号库中的标准名称
+func markCatalogModels(rankings []ModelRanking, catalog *comment.ModelCatalog) {
+	for i := range rankings {
+		entry, ok := catalog.Match(rankings[i].Brand, rankings[i].Model)
+		if !ok {
+			continue
+		}
+		rankings[i].Model = entry.Model
+		rankings[i].Verified = true
+		rankings[i].ReleaseYear = entry.ReleaseYear
+	}
+}
+
 // generateRankings 生成品牌排名
 // 根据各维度得分计算综合得分，并按综合得分排序
 // 注意：遍历 scores 中的所有品牌（包括AI发现的新品牌），而不仅仅是用户指定的品牌
@@ -450,6 +658,12 @@ func generateRecommendation(rankings []BrandRanking, dimensions []ai.Dimension)
 	if len(rankings) > 1 {
 		secondBrand := rankings[1]
 		recommendation += fmt.Sprintf("。%s（%.1f分）紧随其后", secondBrand.Brand, secondBrand.OverallScore)
+		if slices.Contains(topBrand.TiedWith, secondBrand.Brand) {
+			recommendation += "，两者差异不显著"
+		}
+	}
+	if topBrand.LowSample {
+		recommendation += fmt.Sprintf("。%s 的评论数较少（%d条），排名仅供参考", topBrand.Brand, topBrand.SampleSize)
 	}
 
 	recommendation += "。建议根据个人需求和预算选择合适的产品。"
@@ -523,12 +737,12 @@ func selectTypicalComments(analysisResults map[string][]CommentWithScore) (map[s
 			}
 			avgScore := calculateAverageScore(r.Scores)
 			if avgScore >= 7.0 {
-				goodList = append(goodList, TypicalComment{Content: r.Content, Score: avgScore})
+				goodList = append(goodList, newTypicalComment(r, avgScore))
 			} else if avgScore < 6.0 && avgScore > 0 {
-				badList = append(badList, TypicalComment{Content: r.Content, Score: avgScore})
+				badList = append(badList, newTypicalComment(r, avgScore))
 			} else if avgScore >= 5.0 && avgScore < 7.0 {
 				// 中性评论作为fallback
-				neutralList = append(neutralList, TypicalComment{Content: r.Content, Score: avgScore})
+				neutralList = append(neutralList, newTypicalComment(r, avgScore))
 			}
 		}
 
@@ -563,6 +777,25 @@ func selectTypicalComments(analysisResults map[string][]CommentWithScore) (map[s
 	return topComments, badComments
 }
 
+// newTypicalComment 构建典型评论（带来源信息）
+func newTypicalComment(r CommentWithScore, score float64) TypicalComment {
+	tc := TypicalComment{
+		Content:   r.Content,
+		Score:     score,
+		RPID:      r.RPID,
+		VideoBVID: r.VideoBVID,
+		Author:    r.Author,
+		Mid:       r.Mid,
+		Like:      r.Like,
+
+		Highlights: commentHighlights(r),
+	}
+	if r.VideoBVID != "" {
+		tc.URL = bilibili.CommentURL(r.VideoBVID, r.RPID)
+	}
+	return tc
+}
+
 func calculateAverageScore(scores map[string]*float64) float64 {
 	var total float64
 	var count int
@@ -578,26 +811,42 @@ func calculateAverageScore(scores map[string]*float64) float64 {
 	return total / float64(count)
 }
 
-// calculateSentiment 计算情感分布（仅基于评分阈值）
-// 规则：评分 >= 8 为好评；评分 5-8 为中性；评分 < 5 为差评
-// 注意：不做任何AI情感分析；当评论没有有效评分（平均分<=0）时跳过
-// 百分比保留1位小数
+// calculateBrandSentiment 分品牌计算情感分布，跳过没有有效评分的品牌
+func calculateBrandSentiment(analysisResults map[string][]CommentWithScore) map[string]SentimentStats {
+	result := make(map[string]SentimentStats, len(analysisResults))
+	for brand, results := range analysisResults {
+		if brand == "" {
+			continue
+		}
+		stats := calculateSentiment(map[string][]CommentWithScore{brand: results})
+		if stats.PositiveCount+stats.NeutralCount+stats.NegativeCount == 0 {
+			continue
+		}
+		result[brand] = stats
+	}
+	return result
+}
+
+// calculateSentiment 计算情感分布
+// 规则：按 commentPolarity 判断每条评论的倾向（优先使用AI给出的维度情感，缺少时按评分阈值 8/5 划分）
+// 当评论没有有效评分时跳过；百分比保留1位小数
 func calculateSentiment(analysisResults map[string][]CommentWithScore) SentimentStats {
 	var positiveCount, neutralCount, negativeCount int
 	var total int
 
 	for _, results := range analysisResults {
 		for _, r := range results {
-			avgScore := calculateAverageScore(r.Scores)
-			if avgScore <= 0 {
+			polarity := commentPolarity(r)
+			if polarity == "" {
 				continue
 			}
 			total++
-			if avgScore >= 8.0 {
+			switch polarity {
+			case ai.PolarityPositive:
 				positiveCount++
-			} else if avgScore >= 5.0 {
+			case ai.PolarityNeutral:
 				neutralCount++
-			} else {
+			default:
 				negativeCount++
 			}
 		}
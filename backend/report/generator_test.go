@@ -206,3 +206,69 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+// TestGenerateSourceBreakdown 测试按评论来源拆分得分
+func TestGenerateSourceBreakdown(t *testing.T) {
+	dimensions := []ai.Dimension{{Name: "吸力"}, {Name: "续航"}}
+	s := func(v float64) *float64 { return &v }
+
+	commentsOnly := map[string][]CommentWithScore{
+		"戴森": {{Scores: map[string]*float64{"吸力": s(8)}}},
+	}
+	if got := generateSourceBreakdown(commentsOnly, dimensions); got != nil {
+		t.Errorf("expected no breakdown without danmaku, got %+v", got)
+	}
+
+	results := map[string][]CommentWithScore{
+		"戴森": {
+			{Scores: map[string]*float64{"吸力": s(8), "续航": s(6)}},
+			{Scores: map[string]*float64{"吸力": s(10)}, Source: "danmaku"},
+		},
+		"小米": {
+			{Scores: map[string]*float64{"吸力": s(7), "续航": nil}, Source: "comment"},
+			{Scores: map[string]*float64{"续航": s(4)}, Source: "danmaku"},
+		},
+	}
+	breakdown := generateSourceBreakdown(results, dimensions)
+	if len(breakdown) != 2 {
+		t.Fatalf("expected 2 sources, got %d", len(breakdown))
+	}
+	comment, danmaku := breakdown[0], breakdown[1]
+	if comment.Source != "comment" || comment.Label != "评论" || comment.CommentCount != 2 {
+		t.Errorf("unexpected comment stats: %+v", comment)
+	}
+	if comment.Scores["吸力"] != 7.5 || comment.Scores["续航"] != 6 || comment.OverallScore != 6.8 {
+		t.Errorf("unexpected comment scores: %+v", comment)
+	}
+	if danmaku.Source != "danmaku" || danmaku.CommentCount != 2 || danmaku.BrandScores["戴森"]["吸力"] != 10 {
+		t.Errorf("unexpected danmaku stats: %+v", danmaku)
+	}
+	if danmaku.OverallScore != 7 {
+		t.Errorf("expected danmaku overall 7, got %v", danmaku.OverallScore)
+	}
+}
+
+// TestCalculateBrandSentiment 测试分品牌情感分布
+func TestCalculateBrandSentiment(t *testing.T) {
+	s := func(v float64) *float64 { return &v }
+	results := map[string][]CommentWithScore{
+		"小米": {
+			{Scores: map[string]*float64{"吸力": s(9)}},
+			{Scores: map[string]*float64{"吸力": s(6)}},
+			{Scores: map[string]*float64{"吸力": s(3)}},
+			{Scores: map[string]*float64{"吸力": s(2)}},
+		},
+		"戴森": {
+			{Scores: map[string]*float64{"吸力": nil}}, // 没有有效评分
+		},
+	}
+
+	stats := calculateBrandSentiment(results)
+	if _, ok := stats["戴森"]; ok {
+		t.Error("brand without valid scores should be skipped")
+	}
+	xiaomi := stats["小米"]
+	if xiaomi.PositiveCount != 1 || xiaomi.NeutralCount != 1 || xiaomi.NegativeCount != 2 || xiaomi.NegativePct != 50 {
+		t.Errorf("unexpected 小米 sentiment: %+v", xiaomi)
+	}
+}
@@ -2,6 +2,7 @@ package report
 
 import (
 	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/comment"
 	"testing"
 )
 
@@ -91,3 +92,41 @@ func TestModelLevelAggregation(t *testing.T) {
 		t.Errorf("Expected ModelY to be third (6.0), got %s (%f)", rankings[2].Model, rankings[2].OverallScore)
 	}
 }
+
+func TestModelRankingsMarkCatalogModels(t *testing.T) {
+	s := 8.0
+	catalog := comment.NewModelCatalog([]comment.ModelEntry{
+		{Brand: "追觅", Model: "X20 Pro", ReleaseYear: 2023},
+	}, nil)
+	data, err := GenerateReportWithInput(GenerateReportInput{
+		Dimensions: []ai.Dimension{{Name: "清洁"}},
+		AnalysisResults: map[string][]CommentWithScore{
+			"追觅": {
+				{Brand: "追觅", Model: "x20pro", Scores: map[string]*float64{"清洁": &s}},
+				{Brand: "追觅", Model: "X20 Pro", Scores: map[string]*float64{"清洁": &s}},
+				{Brand: "追觅", Model: "S10", Scores: map[string]*float64{"清洁": &s}},
+			},
+		},
+		ModelCatalog: catalog,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.ModelRankings) != 2 {
+		t.Fatalf("expected 2 models, got %+v", data.ModelRankings)
+	}
+	for _, m := range data.ModelRankings {
+		switch m.Model {
+		case "X20 Pro":
+			if !m.Verified || m.ReleaseYear != 2023 || m.CommentCount != 2 {
+				t.Errorf("X20 Pro should be verified with 2 comments, got %+v", m)
+			}
+		case "S10":
+			if m.Verified {
+				t.Errorf("S10 is not in catalog, got %+v", m)
+			}
+		default:
+			t.Errorf("unexpected model %q", m.Model)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package report
+
+import (
+	"bilibili-analyzer/backend/bilibili"
+	"math"
+)
+
+// SponsoredComparison 商单视频与非商单视频下评论的对比
+type SponsoredComparison struct {
+	Sponsored SponsorGroupStats `json:"sponsored"` // 商单视频
+	Organic   SponsorGroupStats `json:"organic"`   // 非商单视频
+	ScoreGap  float64           `json:"score_gap"` // 商单视频下的综合得分减去非商单视频下的综合得分
+	Excluded  bool              `json:"excluded"`  // 商单视频下的评论是否已从得分和排名中排除
+}
+
+// SponsorGroupStats 一组视频下评论的得分和情感分布
+type SponsorGroupStats struct {
+	VideoCount   int                `json:"video_count"`   // 视频数
+	CommentCount int                `json:"comment_count"` // 参与评分的评论数
+	OverallScore float64            `json:"overall_score"` // 各维度平均分的平均
+	Scores       map[string]float64 `json:"scores"`        // 维度 -> 平均分
+	BrandScores  map[string]float64 `json:"brand_scores"`  // 品牌 -> 综合得分
+	Sentiment    SentimentStats     `json:"sentiment"`     // 情感分布
+}
+
+// sponsoredVideoSet 商单视频的BV号集合
+func sponsoredVideoSet(videos []bilibili.VideoInfo) map[string]bool {
+	set := make(map[string]bool)
+	for _, v := range videos {
+		if v.Sponsored {
+			set[v.BVID] = true
+		}
+	}
+	return set
+}
+
+// markSponsoredComments 标记商单视频下的评论（返回新的结果集，不修改输入）
+// 没有商单视频时原样返回
+func markSponsoredComments(analysisResults map[string][]CommentWithScore, sponsored map[string]bool) map[string][]CommentWithScore {
+	if len(sponsored) == 0 {
+		return analysisResults
+	}
+	marked := make(map[string][]CommentWithScore, len(analysisResults))
+	for brand, results := range analysisResults {
+		list := make([]CommentWithScore, len(results))
+		for i, c := range results {
+			c.Sponsored = sponsored[c.VideoBVID]
+			list[i] = c
+		}
+		marked[brand] = list
+	}
+	return marked
+}
+
+// splitSponsored 按评论是否来自商单视频拆分结果集
+func splitSponsored(analysisResults map[string][]CommentWithScore) (sponsored, organic map[string][]CommentWithScore) {
+	sponsored = make(map[string][]CommentWithScore)
+	organic = make(map[string][]CommentWithScore)
+	for brand, results := range analysisResults {
+		for _, c := range results {
+			if c.Sponsored {
+				sponsored[brand] = append(sponsored[brand], c)
+			} else {
+				organic[brand] = append(organic[brand], c)
+			}
+		}
+	}
+	return sponsored, organic
+}
+
+// generateSponsoredComparison 对比商单视频和非商单视频下评论的得分和情感分布
+// 没有商单视频时返回 nil
+func generateSponsoredComparison(analysisResults map[string][]CommentWithScore, videos []bilibili.VideoInfo, excluded bool) *SponsoredComparison {
+	sponsoredVideos := sponsoredVideoSet(videos)
+	if len(sponsoredVideos) == 0 {
+		return nil
+	}
+	sponsored, organic := splitSponsored(analysisResults)
+	comparison := &SponsoredComparison{
+		Sponsored: sponsorGroupStats(sponsored, len(sponsoredVideos)),
+		Organic:   sponsorGroupStats(organic, len(videos)-len(sponsoredVideos)),
+		Excluded:  excluded,
+	}
+	if comparison.Sponsored.CommentCount > 0 && comparison.Organic.CommentCount > 0 {
+		comparison.ScoreGap = roundScore(comparison.Sponsored.OverallScore - comparison.Organic.OverallScore)
+	}
+	return comparison
+}
+
+func sponsorGroupStats(analysisResults map[string][]CommentWithScore, videoCount int) SponsorGroupStats {
+	stats := SponsorGroupStats{
+		VideoCount:  videoCount,
+		Scores:      make(map[string]float64),
+		BrandScores: make(map[string]float64),
+		Sentiment:   calculateSentiment(analysisResults),
+	}
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	for brand, results := range analysisResults {
+		brandSums := make(map[string]float64)
+		brandCounts := make(map[string]int)
+		for _, c := range results {
+			scored := false
+			for dim, score := range c.Scores {
+				if score == nil {
+					continue
+				}
+				scored = true
+				sums[dim] += *score
+				counts[dim]++
+				brandSums[dim] += *score
+				brandCounts[dim]++
+			}
+			if scored {
+				stats.CommentCount++
+			}
+		}
+		if brand != "" && len(brandSums) > 0 {
+			stats.BrandScores[brand] = meanOfAverages(brandSums, brandCounts)
+		}
+	}
+	for dim, sum := range sums {
+		stats.Scores[dim] = math.Round(sum/float64(counts[dim])*10) / 10
+	}
+	stats.OverallScore = meanOfAverages(sums, counts)
+	return stats
+}
+
+// meanOfAverages 各维度平均分的平均（保留1位小数）
+func meanOfAverages(sums map[string]float64, counts map[string]int) float64 {
+	if len(sums) == 0 {
+		return 0
+	}
+	var total float64
+	for dim, sum := range sums {
+		total += sum / float64(counts[dim])
+	}
+	return roundScore(total / float64(len(sums)))
+}
+
+// excludeSponsored 去掉商单视频下的评论，返回新的结果集和各品牌剩余评论数
+func excludeSponsored(analysisResults map[string][]CommentWithScore) (map[string][]CommentWithScore, map[string]int) {
+	_, organic := splitSponsored(analysisResults)
+	counts := make(map[string]int, len(organic))
+	for brand, results := range organic {
+		counts[brand] = len(results)
+	}
+	return organic, counts
+}
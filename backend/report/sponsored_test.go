@@ -0,0 +1,85 @@
+package report
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/bilibili"
+	"testing"
+)
+
+func sponsoredTestInput() GenerateReportInput {
+	score := func(v float64) map[string]*float64 { return map[string]*float64{"吸力": &v} }
+	return GenerateReportInput{
+		Brands:     []string{"甲", "乙"},
+		Dimensions: []ai.Dimension{{Name: "吸力"}},
+		AnalysisResults: map[string][]CommentWithScore{
+			"甲": {
+				{Brand: "甲", VideoBVID: "BV_ad", Content: "太强了", Scores: score(10)},
+				{Brand: "甲", VideoBVID: "BV_ad", Content: "完美", Scores: score(10)},
+				{Brand: "甲", VideoBVID: "BV_org", Content: "一般", Scores: score(6)},
+			},
+			"乙": {
+				{Brand: "乙", VideoBVID: "BV_org", Content: "还不错", Scores: score(8)},
+			},
+		},
+		Stats:  ReportStats{CommentsByBrand: map[string]int{"甲": 3, "乙": 1}},
+		Videos: []bilibili.VideoInfo{{BVID: "BV_ad", Sponsored: true, SponsorSignals: []string{"标题含「恰饭」"}}, {BVID: "BV_org"}},
+	}
+}
+
+func TestSponsoredComparison(t *testing.T) {
+	input := sponsoredTestInput()
+	data, err := GenerateReportWithInput(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := data.SponsoredComparison
+	if c == nil || c.Excluded {
+		t.Fatalf("expected comparison without exclusion: %+v", c)
+	}
+	if c.Sponsored.VideoCount != 1 || c.Sponsored.CommentCount != 2 || c.Sponsored.OverallScore != 10 || c.Sponsored.Sentiment.PositiveCount != 2 {
+		t.Errorf("unexpected sponsored group: %+v", c.Sponsored)
+	}
+	if c.Organic.CommentCount != 2 || c.Organic.OverallScore != 7 || c.Organic.BrandScores["乙"] != 8 {
+		t.Errorf("unexpected organic group: %+v", c.Organic)
+	}
+	if c.ScoreGap != 3 {
+		t.Errorf("score gap = %v, want 3", c.ScoreGap)
+	}
+	if data.Scores["甲"]["吸力"] != 8.7 || !data.VideoSources[0].Sponsored || len(data.VideoSources[0].SponsorSignals) != 1 {
+		t.Errorf("tag mode should keep sponsored comments and tag video sources: %v %+v", data.Scores, data.VideoSources)
+	}
+	for _, c := range input.AnalysisResults["甲"] {
+		if c.Sponsored {
+			t.Fatal("input comments should not be modified")
+		}
+	}
+
+	input.ExcludeSponsored = true
+	data, err = GenerateReportWithInput(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !data.SponsoredComparison.Excluded || data.SponsoredComparison.Sponsored.CommentCount != 2 {
+		t.Errorf("comparison should still include sponsored comments: %+v", data.SponsoredComparison)
+	}
+	if data.Scores["甲"]["吸力"] != 6 || data.Stats.CommentsByBrand["甲"] != 1 {
+		t.Errorf("sponsored comments should be excluded from scores: %v %v", data.Scores, data.Stats.CommentsByBrand)
+	}
+}
+
+func TestSponsoredWeightAndNoSponsoredVideos(t *testing.T) {
+	w := DefaultCommentWeighting()
+	if got := w.Weight(CommentWithScore{Content: "还行", Sponsored: true}, 1); got != w.SponsoredWeight {
+		t.Errorf("sponsored comment weight = %v", got)
+	}
+
+	input := sponsoredTestInput()
+	input.Videos[0].Sponsored = false
+	data, err := GenerateReportWithInput(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.SponsoredComparison != nil {
+		t.Errorf("no comparison without sponsored videos: %+v", data.SponsoredComparison)
+	}
+}
@@ -0,0 +1,315 @@
+package report
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// shrinkagePriorWeight 贝叶斯收缩的先验权重（相当于多少条评论）
+	// 品牌或型号的维度均分按 (n·均分 + k·类目均分) / (n + k) 向类目均分收缩：
+	// 3 条评论时自身数据只占 3/8，300 条评论时几乎不受影响
+	shrinkagePriorWeight = 5.0
+
+	// minSampleSize 评论数低于该值的品牌、型号和维度标记为样本不足
+	minSampleSize = 10
+
+	// bootstrapIterations 自助法重抽样次数
+	bootstrapIterations = 1000
+
+	// bootstrapSeed 重抽样的固定随机种子，同一份数据重新生成报告时区间保持不变
+	bootstrapSeed = 20240501
+)
+
+// ScoreStats 单个维度的统计量
+type ScoreStats struct {
+	Score      float64 `json:"score"`       // 收缩后得分
+	RawScore   float64 `json:"raw_score"`   // 原始平均分
+	SampleSize int     `json:"sample_size"` // 该维度有评分的评论数
+	CILow      float64 `json:"ci_low"`      // 95% 置信区间下限（自助法，基于收缩后得分）
+	CIHigh     float64 `json:"ci_high"`     // 95% 置信区间上限
+	LowSample  bool    `json:"low_sample"`  // 评论数不足 minSampleSize
+}
+
+// scoreSample 参与统计的一组评论（每条评论只保留报告维度的有效评分）
+type scoreSample [][]dimScore
+
+type dimScore struct {
+	dim   int // 维度在 dimensions 中的下标
+	score float64
+}
+
+// newScoreSample 提取评论在各报告维度上的有效评分，跳过没有任何有效评分的评论
+func newScoreSample(comments []CommentWithScore, dimIndex map[string]int) scoreSample {
+	var sample scoreSample
+	for _, c := range comments {
+		var scores []dimScore
+		for dim, score := range c.Scores {
+			if i, ok := dimIndex[dim]; ok && score != nil {
+				scores = append(scores, dimScore{dim: i, score: *score})
+			}
+		}
+		if len(scores) > 0 {
+			sample = append(sample, scores)
+		}
+	}
+	return sample
+}
+
+// shrunkScores 计算样本（按 indices 取评论）的各维度收缩后得分和综合得分
+// 综合得分为 present 中各维度收缩后得分的平均值；indices 为 nil 时使用全部评论
+func (s scoreSample) shrunkScores(indices []int, prior []float64, present []bool, sums []float64, counts []int) ([]float64, float64) {
+	clear(sums)
+	clear(counts)
+	add := func(c []dimScore) {
+		for _, d := range c {
+			sums[d.dim] += d.score
+			counts[d.dim]++
+		}
+	}
+	if indices == nil {
+		for _, c := range s {
+			add(c)
+		}
+	} else {
+		for _, i := range indices {
+			add(s[i])
+		}
+	}
+
+	dims := make([]float64, len(prior))
+	var total float64
+	var n int
+	for i := range prior {
+		if !present[i] {
+			continue
+		}
+		dims[i] = (sums[i] + shrinkagePriorWeight*prior[i]) / (float64(counts[i]) + shrinkagePriorWeight)
+		total += dims[i]
+		n++
+	}
+	if n == 0 {
+		return dims, 0
+	}
+	return dims, total / float64(n)
+}
+
+// sampleStatistics 样本的收缩后得分及自助法置信区间
+type sampleStatistics struct {
+	Overall    ScoreStats
+	Dimensions map[string]ScoreStats
+}
+
+// computeSampleStatistics 计算一组评论的收缩后得分、原始平均分和 95% 置信区间
+// 置信区间按评论重抽样 bootstrapIterations 次，取收缩后得分的 2.5% 和 97.5% 分位数
+func computeSampleStatistics(sample scoreSample, dimensions []ai.Dimension, prior []float64) sampleStatistics {
+	sums := make([]float64, len(dimensions))
+	counts := make([]int, len(dimensions))
+	present := make([]bool, len(dimensions))
+	for _, c := range sample {
+		for _, d := range c {
+			present[d.dim] = true
+		}
+	}
+
+	dimScores, overall := sample.shrunkScores(nil, prior, present, sums, counts)
+	var rawTotal float64
+	var rawDims int
+	result := sampleStatistics{Dimensions: make(map[string]ScoreStats)}
+	for i, dim := range dimensions {
+		if !present[i] {
+			continue
+		}
+		raw := sums[i] / float64(counts[i])
+		rawTotal += raw
+		rawDims++
+		result.Dimensions[dim.Name] = ScoreStats{
+			Score:      roundScore(dimScores[i]),
+			RawScore:   roundScore(raw),
+			SampleSize: counts[i],
+			LowSample:  counts[i] < minSampleSize,
+		}
+	}
+	result.Overall = ScoreStats{
+		Score:      roundScore(overall),
+		SampleSize: len(sample),
+		LowSample:  len(sample) < minSampleSize,
+	}
+	if rawDims > 0 {
+		result.Overall.RawScore = roundScore(rawTotal / float64(rawDims))
+	}
+	if len(sample) == 0 {
+		return result
+	}
+
+	rng := rand.New(rand.NewPCG(bootstrapSeed, uint64(len(sample))))
+	indices := make([]int, len(sample))
+	overalls := make([]float64, bootstrapIterations)
+	dimBoot := make([][]float64, len(dimensions))
+	for it := range bootstrapIterations {
+		for j := range indices {
+			indices[j] = rng.IntN(len(sample))
+		}
+		dims, o := sample.shrunkScores(indices, prior, present, sums, counts)
+		overalls[it] = o
+		for i := range dimensions {
+			if present[i] {
+				dimBoot[i] = append(dimBoot[i], dims[i])
+			}
+		}
+	}
+
+	result.Overall.CILow, result.Overall.CIHigh = percentileInterval(overalls)
+	for i, dim := range dimensions {
+		if !present[i] {
+			continue
+		}
+		stats := result.Dimensions[dim.Name]
+		stats.CILow, stats.CIHigh = percentileInterval(dimBoot[i])
+		result.Dimensions[dim.Name] = stats
+	}
+	return result
+}
+
+// categoryPriors 类目各维度的平均分（所有品牌所有评论的有效评分平均），作为收缩的先验
+func categoryPriors(analysisResults map[string][]CommentWithScore, dimIndex map[string]int, dimCount int) []float64 {
+	sums := make([]float64, dimCount)
+	counts := make([]int, dimCount)
+	for _, results := range analysisResults {
+		for _, c := range results {
+			for dim, score := range c.Scores {
+				if i, ok := dimIndex[dim]; ok && score != nil {
+					sums[i] += *score
+					counts[i]++
+				}
+			}
+		}
+	}
+	priors := make([]float64, dimCount)
+	for i := range priors {
+		if counts[i] > 0 {
+			priors[i] = sums[i] / float64(counts[i])
+		}
+	}
+	return priors
+}
+
+// applyRankingStatistics 计算品牌的收缩后得分（ShrunkScore）并按它排序，填充置信区间、样本量和差异不显著的品牌
+// 综合得分（OverallScore）和各维度得分（Scores）仍为原始平均分
+func applyRankingStatistics(rankings []BrandRanking, analysisResults map[string][]CommentWithScore, dimensions []ai.Dimension) {
+	dimIndex := make(map[string]int, len(dimensions))
+	for i, dim := range dimensions {
+		dimIndex[dim.Name] = i
+	}
+	prior := categoryPriors(analysisResults, dimIndex, len(dimensions))
+
+	for i := range rankings {
+		r := &rankings[i]
+		stats := computeSampleStatistics(newScoreSample(analysisResults[r.Brand], dimIndex), dimensions, prior)
+		r.ShrunkScore = r.OverallScore
+		if stats.Overall.SampleSize > 0 {
+			r.ShrunkScore = stats.Overall.Score
+		}
+		r.CILow = stats.Overall.CILow
+		r.CIHigh = stats.Overall.CIHigh
+		r.SampleSize = stats.Overall.SampleSize
+		r.LowSample = stats.Overall.LowSample
+		r.DimensionStats = stats.Dimensions
+	}
+
+	sort.SliceStable(rankings, func(i, j int) bool {
+		if rankings[i].ShrunkScore != rankings[j].ShrunkScore {
+			return rankings[i].ShrunkScore > rankings[j].ShrunkScore
+		}
+		if rankings[i].SampleSize != rankings[j].SampleSize {
+			return rankings[i].SampleSize > rankings[j].SampleSize
+		}
+		return rankings[i].Brand < rankings[j].Brand
+	})
+	for i := range rankings {
+		rankings[i].Rank = i + 1
+		rankings[i].TiedWith = nil
+		if rankings[i].SampleSize == 0 {
+			continue
+		}
+		for j := range rankings {
+			if i != j && rankings[j].SampleSize > 0 && intervalsOverlap(rankings[i].CILow, rankings[i].CIHigh, rankings[j].CILow, rankings[j].CIHigh) {
+				rankings[i].TiedWith = append(rankings[i].TiedWith, rankings[j].Brand)
+			}
+		}
+	}
+}
+
+// applyModelStatistics 型号排名按评论数收缩：计算收缩后得分（ShrunkScore）并按它重新排序，填充置信区间和样本不足标记
+func applyModelStatistics(rankings []ModelRanking, analysisResults map[string][]CommentWithScore, dimensions []ai.Dimension) {
+	dimIndex := make(map[string]int, len(dimensions))
+	for i, dim := range dimensions {
+		dimIndex[dim.Name] = i
+	}
+	prior := categoryPriors(analysisResults, dimIndex, len(dimensions))
+
+	// 按与 generateModelRankings 相同的 key 分组评论
+	byKey := make(map[string][]CommentWithScore)
+	for brandKey, results := range analysisResults {
+		for _, c := range results {
+			key := modelGroupKey(brandKey, c)
+			byKey[key] = append(byKey[key], c)
+		}
+	}
+
+	for i := range rankings {
+		m := &rankings[i]
+		stats := computeSampleStatistics(newScoreSample(byKey[normalizeModelKey(m.Brand, m.Model)], dimIndex), dimensions, prior)
+		m.ShrunkScore = m.OverallScore
+		if stats.Overall.SampleSize > 0 {
+			m.ShrunkScore = stats.Overall.Score
+		}
+		m.CILow = stats.Overall.CILow
+		m.CIHigh = stats.Overall.CIHigh
+		m.LowSample = m.CommentCount < minSampleSize
+	}
+
+	sort.SliceStable(rankings, func(i, j int) bool {
+		if rankings[i].ShrunkScore != rankings[j].ShrunkScore {
+			return rankings[i].ShrunkScore > rankings[j].ShrunkScore
+		}
+		return rankings[i].CommentCount > rankings[j].CommentCount
+	})
+	for i := range rankings {
+		rankings[i].Rank = i + 1
+	}
+}
+
+// modelGroupKey 评论所属型号的分组 key，评论未标注品牌时使用所在分组的品牌
+func modelGroupKey(brandKey string, c CommentWithScore) string {
+	brand := strings.TrimSpace(c.Brand)
+	if brand == "" {
+		brand = strings.TrimSpace(brandKey)
+	}
+	return normalizeModelKey(brand, strings.TrimSpace(c.Model))
+}
+
+// percentileInterval 取 2.5% 和 97.5% 分位数（保留1位小数）
+func percentileInterval(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sorted := slices.Clone(values)
+	sort.Float64s(sorted)
+	lo := sorted[int(math.Floor(0.025*float64(len(sorted)-1)))]
+	hi := sorted[int(math.Ceil(0.975*float64(len(sorted)-1)))]
+	return roundScore(lo), roundScore(hi)
+}
+
+// intervalsOverlap 两个置信区间是否重叠（重叠视为差异不显著）
+func intervalsOverlap(lo1, hi1, lo2, hi2 float64) bool {
+	return lo1 <= hi2 && lo2 <= hi1
+}
+
+func roundScore(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,118 @@
+package report
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"reflect"
+	"testing"
+)
+
+// commentsWithScores 按给定得分构造评论（单维度"吸力"）
+func commentsWithScores(brand, model string, scores ...float64) []CommentWithScore {
+	comments := make([]CommentWithScore, 0, len(scores))
+	for _, v := range scores {
+		score := v
+		comments = append(comments, CommentWithScore{Brand: brand, Model: model, Scores: map[string]*float64{"吸力": &score}})
+	}
+	return comments
+}
+
+func repeatScore(score float64, n int) []float64 {
+	scores := make([]float64, n)
+	for i := range scores {
+		scores[i] = score
+	}
+	return scores
+}
+
+func TestRankingShrinkageAndLowSample(t *testing.T) {
+	results := map[string][]CommentWithScore{
+		"小众": commentsWithScores("小众", "", 10, 10, 10),
+		"大牌": commentsWithScores("大牌", "", append(repeatScore(9, 30), repeatScore(8, 30)...)...),
+		"其他": commentsWithScores("其他", "", append(repeatScore(5, 20), repeatScore(6, 20)...)...),
+	}
+	data, err := GenerateReportWithInput(GenerateReportInput{
+		Brands:          []string{"小众", "大牌", "其他"},
+		Dimensions:      []ai.Dimension{{Name: "吸力"}},
+		AnalysisResults: results,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := data.Rankings[0], data.Rankings[1]
+	if first.Brand != "大牌" || second.Brand != "小众" {
+		t.Fatalf("3 perfect comments should not outrank 60 good ones: %+v", data.Rankings)
+	}
+	if second.OverallScore != 10 || second.ShrunkScore >= 9 || !second.LowSample || second.SampleSize != 3 {
+		t.Errorf("unexpected shrunk ranking: %+v", second)
+	}
+	if first.LowSample || first.SampleSize != 60 || first.OverallScore != 8.5 {
+		t.Errorf("unexpected large-sample ranking: %+v", first)
+	}
+	for _, r := range data.Rankings {
+		if r.CILow > r.ShrunkScore || r.CIHigh < r.ShrunkScore {
+			t.Errorf("%s: score %.1f outside interval [%.1f, %.1f]", r.Brand, r.ShrunkScore, r.CILow, r.CIHigh)
+		}
+		if r.DimensionStats["吸力"].SampleSize != r.SampleSize {
+			t.Errorf("%s: unexpected dimension stats %+v", r.Brand, r.DimensionStats)
+		}
+	}
+	if data.Scores["小众"]["吸力"] != 10 {
+		t.Errorf("dimension scores should stay raw averages, got %v", data.Scores["小众"])
+	}
+
+	again, _ := GenerateReportWithInput(GenerateReportInput{Dimensions: []ai.Dimension{{Name: "吸力"}}, AnalysisResults: results})
+	if !reflect.DeepEqual(again.Rankings, data.Rankings) {
+		t.Error("bootstrap intervals should be deterministic")
+	}
+}
+
+func TestRankingTies(t *testing.T) {
+	results := map[string][]CommentWithScore{
+		"甲": commentsWithScores("甲", "", 9, 6, 8, 7, 9, 5, 8, 9, 6, 8, 7, 9),
+		"乙": commentsWithScores("乙", "", 8, 7, 9, 6, 8, 7, 8, 6, 9, 7, 8, 8),
+		"丙": commentsWithScores("丙", "", repeatScore(2, 40)...),
+	}
+	dimensions := []ai.Dimension{{Name: "吸力"}}
+	rankings := generateRankings(nil, dimensions, map[string]map[string]float64{"甲": {"吸力": 7.6}, "乙": {"吸力": 7.6}, "丙": {"吸力": 2}})
+	applyRankingStatistics(rankings, results, dimensions)
+
+	tied := make(map[string][]string)
+	for _, r := range rankings {
+		tied[r.Brand] = r.TiedWith
+	}
+	if !reflect.DeepEqual(tied["甲"], []string{"乙"}) || len(tied["丙"]) != 0 {
+		t.Errorf("unexpected ties: %+v", tied)
+	}
+	if rankings[2].Brand != "丙" || rankings[2].Rank != 3 {
+		t.Errorf("unexpected order: %+v", rankings)
+	}
+
+	recommendation := generateRecommendation(rankings, dimensions)
+	if !contains(recommendation, "差异不显著") {
+		t.Errorf("recommendation should mention the tie: %s", recommendation)
+	}
+}
+
+func TestModelRankingsUseCommentCount(t *testing.T) {
+	results := map[string][]CommentWithScore{
+		"戴森": append(commentsWithScores("戴森", "V8", 10), commentsWithScores("戴森", "V12", repeatScore(9, 25)...)...),
+		"小米": commentsWithScores("小米", "G10", repeatScore(4, 25)...),
+	}
+	dimensions := []ai.Dimension{{Name: "吸力"}}
+	rankings := generateModelRankings(results, dimensions)
+	if rankings[0].Model != "V8" {
+		t.Fatalf("raw ranking should put the single 10-point comment first: %+v", rankings)
+	}
+
+	applyModelStatistics(rankings, results, dimensions)
+	if rankings[0].Model != "V12" || rankings[0].Rank != 1 || rankings[1].Model != "V8" {
+		t.Fatalf("shrunk ranking should favour the well-sampled model: %+v", rankings)
+	}
+	if v8 := rankings[1]; v8.OverallScore != 10 || !v8.LowSample || v8.CILow != v8.CIHigh {
+		t.Errorf("unexpected V8 statistics: %+v", v8)
+	}
+	if rankings[0].LowSample {
+		t.Errorf("V12 has enough comments: %+v", rankings[0])
+	}
+}
@@ -0,0 +1,30 @@
+package report
+
+import "bilibili-analyzer/backend/comment"
+
+// maxSuspiciousListed 报告中列出的可疑评论条数上限，超出部分只计入统计
+const maxSuspiciousListed = 200
+
+// SuspiciousSummary AI分析前识别出的可疑评论（复制粘贴、跨视频刷评、品牌账号等）
+type SuspiciousSummary struct {
+	Total        int                         `json:"total"`         // 可疑评论总数
+	ReasonCounts map[string]int              `json:"reason_counts"` // 判定类型 -> 评论数（一条评论可能命中多种类型）
+	Excluded     bool                        `json:"excluded"`      // 是否已在AI分析前排除（否则只标记，照常参与分析）
+	Comments     []comment.SuspiciousComment `json:"comments"`      // 可疑评论明细（最多 maxSuspiciousListed 条）
+}
+
+// NewSuspiciousSummary 汇总可疑评论识别结果，flagged 应已按可疑程度排序
+func NewSuspiciousSummary(flagged []comment.SuspiciousComment, excluded bool) *SuspiciousSummary {
+	summary := &SuspiciousSummary{
+		Total:        len(flagged),
+		ReasonCounts: make(map[string]int),
+		Excluded:     excluded,
+		Comments:     flagged[:min(len(flagged), maxSuspiciousListed)],
+	}
+	for _, s := range flagged {
+		for _, r := range s.Reasons {
+			summary.ReasonCounts[r.Kind]++
+		}
+	}
+	return summary
+}
@@ -0,0 +1,30 @@
+package report
+
+import (
+	"bilibili-analyzer/backend/comment"
+	"testing"
+)
+
+func TestNewSuspiciousSummary(t *testing.T) {
+	flagged := make([]comment.SuspiciousComment, maxSuspiciousListed+5)
+	for i := range flagged {
+		flagged[i].Reasons = []comment.SuspicionReason{{Kind: comment.SuspicionDuplicate}}
+	}
+	flagged[0].Reasons = append(flagged[0].Reasons, comment.SuspicionReason{Kind: comment.SuspicionBrandAccount})
+
+	s := NewSuspiciousSummary(flagged, true)
+	if s.Total != len(flagged) || len(s.Comments) != maxSuspiciousListed || !s.Excluded {
+		t.Fatalf("summary should count all comments and list at most %d: %+v", maxSuspiciousListed, s)
+	}
+	if s.ReasonCounts[comment.SuspicionDuplicate] != len(flagged) || s.ReasonCounts[comment.SuspicionBrandAccount] != 1 {
+		t.Errorf("reason counts: %v", s.ReasonCounts)
+	}
+
+	data, err := GenerateReportWithInput(GenerateReportInput{Brands: []string{"甲"}, Suspicious: s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.SuspiciousComments != s {
+		t.Error("report should carry the suspicious comment summary")
+	}
+}
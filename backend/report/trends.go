@@ -0,0 +1,174 @@
+package report
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// trendMonthFormat 趋势统计的月份格式
+const trendMonthFormat = "2006-01"
+
+// trendLocation 按月分桶使用的时区（B站所在的北京时间，无夏令时）
+// 固定时区保证同一份报告在不同服务器上得到相同的月份划分
+var trendLocation = time.FixedZone("CST", 8*60*60)
+
+// TrendPoint 单月得分
+type TrendPoint struct {
+	Month        string  `json:"month"`         // 月份（如"2024-05"）
+	Score        float64 `json:"score"`         // 当月平均得分
+	CommentCount int     `json:"comment_count"` // 当月参与计算的评论数
+}
+
+// BrandTrend 品牌口碑的月度变化
+// 用于观察固件更新、降价等事件前后评价的变化
+type BrandTrend struct {
+	Brand      string                  `json:"brand"`            // 品牌名称
+	Overall    []TrendPoint            `json:"overall"`          // 综合得分（每条评论各维度均分的月平均）
+	Dimensions map[string][]TrendPoint `json:"dimensions"`       // 维度 -> 月度得分
+	Models     map[string][]TrendPoint `json:"models,omitempty"` // 型号 -> 月度综合得分
+}
+
+// monthlyScore 单月得分累计
+type monthlyScore struct {
+	sum   float64
+	count int
+}
+
+// trendSeries 按月累计得分（月份 -> 累计值）
+type trendSeries map[string]*monthlyScore
+
+func (s trendSeries) add(month string, score float64) {
+	acc := s[month]
+	if acc == nil {
+		acc = &monthlyScore{}
+		s[month] = acc
+	}
+	acc.sum += score
+	acc.count++
+}
+
+// points 按月份升序输出
+func (s trendSeries) points() []TrendPoint {
+	points := make([]TrendPoint, 0, len(s))
+	for month, acc := range s {
+		points = append(points, TrendPoint{
+			Month:        month,
+			Score:        math.Round(acc.sum/float64(acc.count)*10) / 10,
+			CommentCount: acc.count,
+		})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Month < points[j].Month })
+	return points
+}
+
+// generateTrends 生成各品牌的月度得分趋势
+// 参数：
+//   - analysisResults: 品牌 -> 评论及得分列表
+//   - brandOrder: 品牌输出顺序（一般为排名顺序），未列出的品牌按名称排在后面
+//
+// 没有发布时间的评论不参与统计；所有评论都没有发布时间时返回 nil
+func generateTrends(analysisResults map[string][]CommentWithScore, brandOrder []string) []BrandTrend {
+	type brandAcc struct {
+		overall    trendSeries
+		dimensions map[string]trendSeries
+		models     map[string]trendSeries // normalizedKey -> 月度得分
+		variants   map[string][]string    // normalizedKey -> 型号变体
+	}
+	byBrand := make(map[string]*brandAcc)
+
+	for brand, results := range analysisResults {
+		if brand == "" {
+			continue
+		}
+		for _, r := range results {
+			if r.PublishTime.IsZero() {
+				continue
+			}
+			overall := calculateAverageScore(r.Scores)
+			if overall <= 0 {
+				continue
+			}
+			month := r.PublishTime.In(trendLocation).Format(trendMonthFormat)
+
+			acc := byBrand[brand]
+			if acc == nil {
+				acc = &brandAcc{
+					overall:    trendSeries{},
+					dimensions: make(map[string]trendSeries),
+					models:     make(map[string]trendSeries),
+					variants:   make(map[string][]string),
+				}
+				byBrand[brand] = acc
+			}
+
+			acc.overall.add(month, overall)
+			for dim, score := range r.Scores {
+				if score == nil {
+					continue
+				}
+				if acc.dimensions[dim] == nil {
+					acc.dimensions[dim] = trendSeries{}
+				}
+				acc.dimensions[dim].add(month, *score)
+			}
+
+			model := strings.TrimSpace(r.Model)
+			if model == "" || model == "未知" || model == "通用" {
+				continue
+			}
+			key := normalizeModelKey(brand, model)
+			if acc.models[key] == nil {
+				acc.models[key] = trendSeries{}
+			}
+			acc.models[key].add(month, overall)
+			if !slices.Contains(acc.variants[key], model) {
+				acc.variants[key] = append(acc.variants[key], model)
+			}
+		}
+	}
+	if len(byBrand) == 0 {
+		return nil
+	}
+
+	// 排名中的品牌在前，其余品牌按名称排序
+	ordered := make([]string, 0, len(byBrand))
+	seen := make(map[string]bool, len(byBrand))
+	for _, brand := range brandOrder {
+		if byBrand[brand] != nil && !seen[brand] {
+			ordered = append(ordered, brand)
+			seen[brand] = true
+		}
+	}
+	var rest []string
+	for brand := range byBrand {
+		if !seen[brand] {
+			rest = append(rest, brand)
+		}
+	}
+	sort.Strings(rest)
+	ordered = append(ordered, rest...)
+
+	trends := make([]BrandTrend, 0, len(ordered))
+	for _, brand := range ordered {
+		acc := byBrand[brand]
+		trend := BrandTrend{
+			Brand:      brand,
+			Overall:    acc.overall.points(),
+			Dimensions: make(map[string][]TrendPoint, len(acc.dimensions)),
+		}
+		for dim, series := range acc.dimensions {
+			trend.Dimensions[dim] = series.points()
+		}
+		if len(acc.models) > 0 {
+			trend.Models = make(map[string][]TrendPoint, len(acc.models))
+			for key, series := range acc.models {
+				trend.Models[getDisplayModel(acc.variants[key])] = series.points()
+			}
+		}
+		trends = append(trends, trend)
+	}
+	return trends
+}
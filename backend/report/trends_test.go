@@ -0,0 +1,75 @@
+package report
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenerateTrends(t *testing.T) {
+	s := func(v float64) *float64 { return &v }
+	jan := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 5, 12, 0, 0, 0, time.UTC)
+
+	results := map[string][]CommentWithScore{
+		"小米": {
+			{Scores: map[string]*float64{"吸力": s(8), "续航": s(6)}, Model: "G10", PublishTime: jan},
+			{Scores: map[string]*float64{"吸力": s(6)}, Model: "G 10", PublishTime: jan},
+			{Scores: map[string]*float64{"吸力": s(9), "续航": nil}, Model: "通用", PublishTime: feb},
+			{Scores: map[string]*float64{"吸力": s(2)}}, // 没有发布时间，不参与统计
+		},
+		"戴森": {
+			{Scores: map[string]*float64{"吸力": s(10)}, Model: "V12", PublishTime: feb},
+		},
+	}
+
+	trends := generateTrends(results, []string{"戴森", "小米"})
+	if len(trends) != 2 || trends[0].Brand != "戴森" || trends[1].Brand != "小米" {
+		t.Fatalf("unexpected brand order: %+v", trends)
+	}
+
+	xiaomi := trends[1]
+	if len(xiaomi.Overall) != 2 {
+		t.Fatalf("expected 2 months, got %+v", xiaomi.Overall)
+	}
+	if p := xiaomi.Overall[0]; p.Month != "2024-01" || p.Score != 6.5 || p.CommentCount != 2 {
+		t.Errorf("unexpected January point: %+v", p)
+	}
+	if p := xiaomi.Overall[1]; p.Month != "2024-02" || p.Score != 9 {
+		t.Errorf("unexpected February point: %+v", p)
+	}
+	if suction := xiaomi.Dimensions["吸力"]; len(suction) != 2 || suction[0].Score != 7 {
+		t.Errorf("unexpected 吸力 trend: %+v", suction)
+	}
+	if battery := xiaomi.Dimensions["续航"]; len(battery) != 1 || battery[0].Month != "2024-01" {
+		t.Errorf("unexpected 续航 trend: %+v", battery)
+	}
+	// 型号变体合并，"通用"不计入型号趋势
+	if len(xiaomi.Models) != 1 || len(xiaomi.Models["G 10"]) != 1 || xiaomi.Models["G 10"][0].CommentCount != 2 {
+		t.Errorf("unexpected model trends: %+v", xiaomi.Models)
+	}
+
+	noTime := map[string][]CommentWithScore{"小米": {{Scores: map[string]*float64{"吸力": s(8)}}}}
+	if got := generateTrends(noTime, nil); got != nil {
+		t.Errorf("expected nil trends without publish time, got %+v", got)
+	}
+}
+
+// TestGenerateTrendsFixedTimezone 测试按北京时间分桶，与服务器时区无关
+func TestGenerateTrendsFixedTimezone(t *testing.T) {
+	// UTC 1月31日17点 = 北京时间2月1日1点
+	boundary := time.Date(2024, 1, 31, 17, 0, 0, 0, time.UTC)
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		newYork = time.FixedZone("EST", -5*60*60)
+	}
+
+	for _, publishTime := range []time.Time{boundary, boundary.In(newYork)} {
+		results := map[string][]CommentWithScore{
+			"小米": {{Scores: map[string]*float64{"吸力": floatPtr(8)}, PublishTime: publishTime}},
+		}
+		trends := generateTrends(results, nil)
+		if len(trends) != 1 || len(trends[0].Overall) != 1 || trends[0].Overall[0].Month != "2024-02" {
+			t.Errorf("expected bucket 2024-02 for %v, got %+v", publishTime, trends)
+		}
+	}
+}
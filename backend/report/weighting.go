@@ -0,0 +1,207 @@
+package report
+
+import (
+	"bilibili-analyzer/backend/bilibili"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// defaultFirstHandCues 表示亲身使用经历的常见表述
+var defaultFirstHandCues = []string{"用了", "入手", "买了", "到手", "自用", "亲测", "实测", "用下来", "我家", "使用了"}
+
+// CommentWeighting 评论权重模型
+// 每条评论的权重 = 互动加成 × 楼中楼系数 × 亲身体验加成 × 低等级账号系数 × 商单系数 × 重复账号系数，
+// 加权得分为各评论得分按权重的加权平均，与未加权得分一起写入报告
+type CommentWeighting struct {
+	Enabled          bool     `json:"enabled"`            // 是否计算加权得分
+	LikeFactor       float64  `json:"like_factor"`        // 点赞加成：互动加成 = 1 + like_factor·ln(1+点赞数) + reply_count_factor·ln(1+回复数)
+	ReplyCountFactor float64  `json:"reply_count_factor"` // 被回复数加成
+	MaxEngagement    float64  `json:"max_engagement"`     // 互动加成上限（倍数），0 表示不限制
+	ReplyWeight      float64  `json:"reply_weight"`       // 楼中楼回复的系数
+	FirstHandBoost   float64  `json:"first_hand_boost"`   // 含亲身体验表述的评论的系数
+	FirstHandCues    []string `json:"first_hand_cues"`    // 亲身体验表述，为空时使用默认列表
+	LowLevelMax      int      `json:"low_level_max"`      // 账号等级不超过该值视为低等级（等级未知的评论和弹幕不参与）
+	LowLevelWeight   float64  `json:"low_level_weight"`   // 低等级账号的系数
+	RepeatPenalty    float64  `json:"repeat_penalty"`     // 同一账号有 n 条评论时每条乘以 n^(-repeat_penalty)，1 表示该账号合计只算一条
+	SponsoredWeight  float64  `json:"sponsored_weight"`   // 商单视频下评论的系数
+}
+
+// DefaultCommentWeighting 默认权重模型
+func DefaultCommentWeighting() CommentWeighting {
+	return CommentWeighting{
+		Enabled:          true,
+		LikeFactor:       0.3,
+		ReplyCountFactor: 0.1,
+		MaxEngagement:    3,
+		ReplyWeight:      0.8,
+		FirstHandBoost:   1.3,
+		LowLevelMax:      2,
+		LowLevelWeight:   0.6,
+		RepeatPenalty:    0.5,
+		SponsoredWeight:  0.5,
+	}
+}
+
+// ParseCommentWeighting 解析权重模型配置（JSON 对象），未填写的字段使用默认值，空字符串返回默认模型
+func ParseCommentWeighting(s string) (CommentWeighting, error) {
+	w := DefaultCommentWeighting()
+	if strings.TrimSpace(s) == "" {
+		return w, nil
+	}
+	if err := json.Unmarshal([]byte(s), &w); err != nil {
+		return w, err
+	}
+	for name, v := range map[string]float64{
+		"like_factor":        w.LikeFactor,
+		"reply_count_factor": w.ReplyCountFactor,
+		"max_engagement":     w.MaxEngagement,
+		"repeat_penalty":     w.RepeatPenalty,
+	} {
+		if v < 0 {
+			return w, fmt.Errorf("%s 不能为负数", name)
+		}
+	}
+	for name, v := range map[string]float64{
+		"reply_weight":     w.ReplyWeight,
+		"first_hand_boost": w.FirstHandBoost,
+		"low_level_weight": w.LowLevelWeight,
+		"sponsored_weight": w.SponsoredWeight,
+	} {
+		if v <= 0 {
+			return w, fmt.Errorf("%s 必须大于0", name)
+		}
+	}
+	if w.MaxEngagement > 0 && w.MaxEngagement < 1 {
+		return w, fmt.Errorf("max_engagement 不能小于1")
+	}
+	return w, nil
+}
+
+// Weight 计算单条评论的权重，authorComments 为该评论作者在本报告中的评论数
+func (w CommentWeighting) Weight(c CommentWithScore, authorComments int) float64 {
+	engagement := 1 + w.LikeFactor*math.Log1p(float64(max(c.Like, 0))) + w.ReplyCountFactor*math.Log1p(float64(max(c.ReplyCount, 0)))
+	if w.MaxEngagement > 0 {
+		engagement = math.Min(engagement, w.MaxEngagement)
+	}
+
+	weight := engagement
+	if c.IsReply {
+		weight *= w.ReplyWeight
+	}
+	if w.isFirstHand(c.Content) {
+		weight *= w.FirstHandBoost
+	}
+	if c.Source != bilibili.SourceDanmaku && c.AuthorLevel > 0 && c.AuthorLevel <= w.LowLevelMax {
+		weight *= w.LowLevelWeight
+	}
+	if c.Sponsored {
+		weight *= w.SponsoredWeight
+	}
+	if authorComments > 1 {
+		weight *= math.Pow(float64(authorComments), -w.RepeatPenalty)
+	}
+	return weight
+}
+
+func (w CommentWeighting) isFirstHand(content string) bool {
+	cues := w.FirstHandCues
+	if len(cues) == 0 {
+		cues = defaultFirstHandCues
+	}
+	for _, cue := range cues {
+		if cue != "" && strings.Contains(content, cue) {
+			return true
+		}
+	}
+	return false
+}
+
+// commentWeights 计算所有评论的权重（品牌 -> 与评论列表下标对应的权重）
+// 重复账号按评论者UID在整份报告中统计，UID为0（弹幕等）的评论不参与
+func commentWeights(analysisResults map[string][]CommentWithScore, w CommentWeighting) map[string][]float64 {
+	authorCounts := make(map[int64]int)
+	for _, results := range analysisResults {
+		for _, c := range results {
+			if c.Mid != 0 {
+				authorCounts[c.Mid]++
+			}
+		}
+	}
+
+	weights := make(map[string][]float64, len(analysisResults))
+	for brand, results := range analysisResults {
+		list := make([]float64, len(results))
+		for i, c := range results {
+			list[i] = w.Weight(c, authorCounts[c.Mid])
+		}
+		weights[brand] = list
+	}
+	return weights
+}
+
+// weightedBrandScores 计算品牌各维度的加权平均分（保留1位小数）
+func weightedBrandScores(analysisResults map[string][]CommentWithScore, weights map[string][]float64) map[string]map[string]float64 {
+	result := make(map[string]map[string]float64, len(analysisResults))
+	for brand, results := range analysisResults {
+		sums := make(map[string]float64)
+		totals := make(map[string]float64)
+		for i, c := range results {
+			for dim, score := range c.Scores {
+				if score == nil {
+					continue
+				}
+				sums[dim] += weights[brand][i] * *score
+				totals[dim] += weights[brand][i]
+			}
+		}
+		scores := make(map[string]float64, len(sums))
+		for dim, sum := range sums {
+			if totals[dim] > 0 {
+				scores[dim] = roundScore(sum / totals[dim])
+			}
+		}
+		result[brand] = scores
+	}
+	return result
+}
+
+// applyWeightedScores 计算加权得分：品牌排名和型号排名的 WeightedScore 为加权维度均分的平均
+// 排名顺序不变，返回品牌 -> 维度 -> 加权得分
+func applyWeightedScores(rankings []BrandRanking, modelRankings []ModelRanking, analysisResults map[string][]CommentWithScore, w CommentWeighting) map[string]map[string]float64 {
+	weights := commentWeights(analysisResults, w)
+	brandScores := weightedBrandScores(analysisResults, weights)
+	for i := range rankings {
+		rankings[i].WeightedScore = averageOf(brandScores[rankings[i].Brand])
+	}
+
+	// 型号按与 generateModelRankings 相同的 key 分组
+	byModel := make(map[string][]CommentWithScore)
+	modelWeights := make(map[string][]float64)
+	for brandKey, results := range analysisResults {
+		for i, c := range results {
+			key := modelGroupKey(brandKey, c)
+			byModel[key] = append(byModel[key], c)
+			modelWeights[key] = append(modelWeights[key], weights[brandKey][i])
+		}
+	}
+	for i := range modelRankings {
+		key := normalizeModelKey(modelRankings[i].Brand, modelRankings[i].Model)
+		scores := weightedBrandScores(map[string][]CommentWithScore{key: byModel[key]}, map[string][]float64{key: modelWeights[key]})
+		modelRankings[i].WeightedScore = averageOf(scores[key])
+	}
+	return brandScores
+}
+
+// averageOf 各维度得分的平均（保留1位小数），没有得分时返回0
+func averageOf(scores map[string]float64) float64 {
+	if len(scores) == 0 {
+		return 0
+	}
+	var total float64
+	for _, s := range scores {
+		total += s
+	}
+	return roundScore(total / float64(len(scores)))
+}
@@ -0,0 +1,104 @@
+package report
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/bilibili"
+	"reflect"
+	"testing"
+)
+
+func TestParseCommentWeighting(t *testing.T) {
+	w, err := ParseCommentWeighting("")
+	if err != nil || !reflect.DeepEqual(w, DefaultCommentWeighting()) {
+		t.Fatalf("empty config should use defaults: %+v, %v", w, err)
+	}
+
+	w, err = ParseCommentWeighting(`{"repeat_penalty": 1, "first_hand_cues": ["回购"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.RepeatPenalty != 1 || w.LikeFactor != 0.3 || !w.Enabled || len(w.FirstHandCues) != 1 {
+		t.Errorf("unset fields should keep defaults: %+v", w)
+	}
+
+	for _, s := range []string{`{"like_factor": -1}`, `{"reply_weight": 0}`, `{"max_engagement": 0.5}`, `[1]`} {
+		if _, err := ParseCommentWeighting(s); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+}
+
+func TestCommentWeight(t *testing.T) {
+	w := DefaultCommentWeighting()
+	base := w.Weight(CommentWithScore{Content: "还行"}, 1)
+	if base != 1 {
+		t.Fatalf("plain comment should have weight 1, got %v", base)
+	}
+
+	liked := w.Weight(CommentWithScore{Content: "还行", Like: 500, ReplyCount: 40}, 1)
+	capped := w.Weight(CommentWithScore{Content: "还行", Like: 1e9, ReplyCount: 1e9}, 1)
+	if liked <= base || capped != w.MaxEngagement {
+		t.Errorf("engagement boost: liked=%v capped=%v", liked, capped)
+	}
+
+	if got := w.Weight(CommentWithScore{Content: "入手三个月了，还行"}, 1); got != w.FirstHandBoost {
+		t.Errorf("first-hand comment weight = %v", got)
+	}
+	if got := w.Weight(CommentWithScore{Content: "还行", IsReply: true}, 1); got != w.ReplyWeight {
+		t.Errorf("reply weight = %v", got)
+	}
+	if got := w.Weight(CommentWithScore{Content: "还行", AuthorLevel: 1}, 1); got != w.LowLevelWeight {
+		t.Errorf("low-level weight = %v", got)
+	}
+	if got := w.Weight(CommentWithScore{Content: "还行", AuthorLevel: 1, Source: bilibili.SourceDanmaku}, 1); got != 1 {
+		t.Errorf("danmaku has no level and should not be discounted, got %v", got)
+	}
+	if got := w.Weight(CommentWithScore{Content: "还行"}, 4); got != 0.5 {
+		t.Errorf("4 comments from one account should each weigh 4^-0.5, got %v", got)
+	}
+}
+
+func TestWeightedScores(t *testing.T) {
+	score := func(v float64) map[string]*float64 { return map[string]*float64{"吸力": &v} }
+	results := map[string][]CommentWithScore{
+		"甲": {
+			{Brand: "甲", Model: "X1", Content: "用了半年，吸力很强", Like: 2000, Mid: 1, AuthorLevel: 6, Scores: score(9)},
+			{Brand: "甲", Model: "X1", Content: "垃圾", Mid: 2, AuthorLevel: 1, Scores: score(3)},
+			{Brand: "甲", Model: "X1", Content: "垃圾", Mid: 2, AuthorLevel: 1, Scores: score(3)},
+			{Brand: "甲", Model: "X1", Content: "垃圾", Mid: 2, AuthorLevel: 1, Scores: score(3)},
+		},
+	}
+	input := GenerateReportInput{
+		Brands:          []string{"甲"},
+		Dimensions:      []ai.Dimension{{Name: "吸力"}},
+		AnalysisResults: results,
+	}
+
+	data, err := GenerateReportWithInput(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.WeightedScores != nil || data.Weighting != nil || data.Rankings[0].WeightedScore != 0 {
+		t.Fatalf("weighting should be off without a model: %+v", data.Rankings[0])
+	}
+
+	w := DefaultCommentWeighting()
+	input.Weighting = &w
+	data, err = GenerateReportWithInput(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := data.Rankings[0]
+	if r.OverallScore != 4.5 {
+		t.Fatalf("unweighted score should be unchanged: %+v", r)
+	}
+	if r.WeightedScore <= 7 || data.WeightedScores["甲"]["吸力"] != r.WeightedScore {
+		t.Errorf("liked first-hand comment should dominate repeated low-level account: %+v %v", r, data.WeightedScores)
+	}
+	if len(data.ModelRankings) != 1 || data.ModelRankings[0].WeightedScore != r.WeightedScore {
+		t.Errorf("model weighted score: %+v", data.ModelRankings)
+	}
+	if data.Weighting == nil || data.Weighting.RepeatPenalty != w.RepeatPenalty {
+		t.Errorf("report should record the weighting model: %+v", data.Weighting)
+	}
+}
@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"bilibili-analyzer/backend/report"
+	"math"
+	"sort"
+	"time"
+)
+
+// 告警指标
+const (
+	MetricOverallScore = "overall_score" // 品牌综合得分
+	MetricNegativePct  = "negative_pct"  // 品牌差评占比（%）
+)
+
+// BrandMetrics 一次运行中单个品牌的指标快照
+type BrandMetrics struct {
+	OverallScore float64  `json:"overall_score"`          // 综合得分
+	NegativePct  *float64 `json:"negative_pct,omitempty"` // 差评占比（旧报告没有分品牌情感分布时为空）
+}
+
+// Alert 品牌指标变化告警
+type Alert struct {
+	Brand     string  `json:"brand"`     // 品牌
+	Metric    string  `json:"metric"`    // overall_score/negative_pct
+	Previous  float64 `json:"previous"`  // 上一次运行的值
+	Current   float64 `json:"current"`   // 本次运行的值
+	Delta     float64 `json:"delta"`     // 变化量（本次 - 上次）
+	Threshold float64 `json:"threshold"` // 触发阈值
+}
+
+// AlertPayload 告警 Webhook 请求体
+type AlertPayload struct {
+	Event            string    `json:"event"` // 固定为 schedule.alert
+	ScheduleID       uint      `json:"schedule_id"`
+	ScheduleName     string    `json:"schedule_name"`
+	Category         string    `json:"category"`
+	ReportID         uint      `json:"report_id"`
+	PreviousReportID uint      `json:"previous_report_id"`
+	Alerts           []Alert   `json:"alerts"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// metricsFromReport 从报告中提取各品牌的指标
+// 综合得分取品牌排名，差评占比取分品牌情感分布
+func metricsFromReport(data *report.ReportData) map[string]BrandMetrics {
+	metrics := make(map[string]BrandMetrics, len(data.Rankings))
+	for _, r := range data.Rankings {
+		m := BrandMetrics{OverallScore: r.OverallScore}
+		if s, ok := data.BrandSentiment[r.Brand]; ok {
+			pct := s.NegativePct
+			m.NegativePct = &pct
+		}
+		metrics[r.Brand] = m
+	}
+	return metrics
+}
+
+// detectAlerts 对比前后两次运行的品牌指标，返回变化量达到阈值的告警
+// 阈值 <= 0 表示不检查该指标；只比较两次都出现的品牌
+func detectAlerts(previous, current map[string]BrandMetrics, scoreThreshold, negativeThreshold float64) []Alert {
+	brands := make([]string, 0, len(current))
+	for brand := range current {
+		if _, ok := previous[brand]; ok {
+			brands = append(brands, brand)
+		}
+	}
+	sort.Strings(brands)
+
+	var alerts []Alert
+	for _, brand := range brands {
+		prev, curr := previous[brand], current[brand]
+		if scoreThreshold > 0 {
+			if a, ok := newAlert(brand, MetricOverallScore, prev.OverallScore, curr.OverallScore, scoreThreshold); ok {
+				alerts = append(alerts, a)
+			}
+		}
+		if negativeThreshold > 0 && prev.NegativePct != nil && curr.NegativePct != nil {
+			if a, ok := newAlert(brand, MetricNegativePct, *prev.NegativePct, *curr.NegativePct, negativeThreshold); ok {
+				alerts = append(alerts, a)
+			}
+		}
+	}
+	return alerts
+}
+
+func newAlert(brand, metric string, previous, current, threshold float64) (Alert, bool) {
+	delta := math.Round((current-previous)*10) / 10
+	if math.Abs(delta) < threshold {
+		return Alert{}, false
+	}
+	return Alert{
+		Brand:     brand,
+		Metric:    metric,
+		Previous:  previous,
+		Current:   current,
+		Delta:     delta,
+		Threshold: threshold,
+	}, true
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"testing"
+
+	"bilibili-analyzer/backend/report"
+)
+
+func TestDetectAlerts(t *testing.T) {
+	pct := func(v float64) *float64 { return &v }
+	previous := map[string]BrandMetrics{
+		"小米": {OverallScore: 8.2, NegativePct: pct(10)},
+		"戴森": {OverallScore: 7.5, NegativePct: pct(20)},
+		"石头": {OverallScore: 6.0},
+	}
+	current := map[string]BrandMetrics{
+		"小米": {OverallScore: 7.6, NegativePct: pct(18.5)},
+		"戴森": {OverallScore: 7.8, NegativePct: pct(17)},
+		"石头": {OverallScore: 7.0, NegativePct: pct(50)}, // 上次没有差评占比，不比较
+		"追觅": {OverallScore: 5.0},                       // 新品牌不比较
+	}
+
+	alerts := detectAlerts(previous, current, 0.5, 5)
+	if len(alerts) != 3 {
+		t.Fatalf("expected 3 alerts, got %+v", alerts)
+	}
+	if a := alerts[0]; a.Brand != "小米" || a.Metric != MetricOverallScore || a.Delta != -0.6 {
+		t.Errorf("unexpected alert: %+v", a)
+	}
+	if a := alerts[1]; a.Brand != "小米" || a.Metric != MetricNegativePct || a.Delta != 8.5 {
+		t.Errorf("unexpected alert: %+v", a)
+	}
+	if a := alerts[2]; a.Brand != "石头" || a.Metric != MetricOverallScore || a.Delta != 1 {
+		t.Errorf("unexpected alert: %+v", a)
+	}
+
+	// 阈值为0时不检查
+	if alerts := detectAlerts(previous, current, 0, 0); len(alerts) != 0 {
+		t.Errorf("expected no alerts with zero thresholds, got %+v", alerts)
+	}
+}
+
+func TestMetricsFromReport(t *testing.T) {
+	data := &report.ReportData{
+		Rankings: []report.BrandRanking{{Brand: "小米", OverallScore: 8}, {Brand: "戴森", OverallScore: 7}},
+		BrandSentiment: map[string]report.SentimentStats{
+			"小米": {NegativePct: 12.5},
+		},
+	}
+	metrics := metricsFromReport(data)
+	if m := metrics["小米"]; m.OverallScore != 8 || m.NegativePct == nil || *m.NegativePct != 12.5 {
+		t.Errorf("unexpected metrics for 小米: %+v", m)
+	}
+	if m := metrics["戴森"]; m.OverallScore != 7 || m.NegativePct != nil {
+		t.Errorf("unexpected metrics for 戴森: %+v", m)
+	}
+}
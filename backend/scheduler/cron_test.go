@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// 2024-05-15 是星期三
+	base := time.Date(2024, 5, 15, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 15, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2024, 5, 16, 9, 0, 0, 0, time.UTC)},
+		{"30 8-18/5 * * *", time.Date(2024, 5, 15, 13, 30, 0, 0, time.UTC)},
+		{"0 9 * * 1", time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC)}, // 7 与 0 都表示周日
+		{"0 0 1,20 * *", time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 5", time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC)}, // 日和周都有限制时满足其一即可
+		{"@monthly", time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC)},
+		{"@every 6h", base.Add(6 * time.Hour)},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.spec)
+		if err != nil {
+			t.Errorf("ParseCron(%q) error = %v", tt.spec, err)
+			continue
+		}
+		if got := c.Next(base); !got.Equal(tt.want) {
+			t.Errorf("ParseCron(%q).Next() = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+
+	// 2月30日永远不会出现
+	c, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseCron() error = %v", err)
+	}
+	if got := c.Next(base); !got.IsZero() {
+		t.Errorf("expected zero time for impossible date, got %v", got)
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every 10s",
+		"@every soon",
+	} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("ParseCron(%q) expected error", spec)
+		}
+	}
+}
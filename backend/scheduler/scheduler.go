@@ -0,0 +1,286 @@
+package scheduler
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/report"
+	"bilibili-analyzer/backend/task"
+	"bilibili-analyzer/backend/webhook"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// tickInterval 调度器检查间隔（小于 cron 的最小粒度1分钟，保证不漏掉触发时间）
+const tickInterval = 30 * time.Second
+
+// ErrRunInProgress 计划的上一次运行尚未结束
+var ErrRunInProgress = errors.New("上一次运行尚未结束")
+
+// job 固定间隔执行的系统任务
+type job struct {
+	name       string
+	interval   time.Duration
+	runAtStart bool
+	fn         func()
+	nextRun    time.Time
+}
+
+// systemJobs 随调度器运行的系统维护任务
+var systemJobs = []*job{
+	// 清理超时任务（心跳超过1小时的 processing 任务标记为失败）
+	{name: "cleanup-timed-out-tasks", interval: 5 * time.Minute, fn: task.CleanupTimedOutTasks},
+	// 检查B站账号池的登录状态
+	{name: "check-bilibili-accounts", interval: time.Hour, runAtStart: true, fn: task.CheckBilibiliAccounts},
+}
+
+// triggerMu 保证同一时刻只有一个地方在触发计划（调度循环与手动触发）
+var triggerMu sync.Mutex
+
+// Start 启动调度器（阻塞运行，应在 goroutine 中调用）
+// 每轮检查：
+//   - 到期的系统维护任务
+//   - 到期的定时分析计划：把保存的请求加入任务队列
+//   - 未结束的计划运行：任务完成后记录指标快照，与上一次运行对比并发送告警
+func Start() {
+	log.Println("[Scheduler] Started")
+
+	now := time.Now()
+	for _, j := range systemJobs {
+		j.nextRun = now.Add(j.interval)
+		if j.runAtStart {
+			j.nextRun = now
+		}
+	}
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+	for {
+		tick(time.Now())
+		<-ticker.C
+	}
+}
+
+// tick 执行一轮调度
+func tick(now time.Time) {
+	for _, j := range systemJobs {
+		if now.Before(j.nextRun) {
+			continue
+		}
+		j.nextRun = now.Add(j.interval)
+		// 系统任务可能耗时较长（如逐个检查账号），不阻塞计划触发
+		go j.fn()
+	}
+
+	runDueSchedules(now)
+	collectRuns()
+}
+
+// runDueSchedules 触发到期的定时计划
+func runDueSchedules(now time.Time) {
+	schedules, err := database.DueSchedules(now)
+	if err != nil {
+		log.Printf("[Scheduler] Failed to load due schedules: %v", err)
+		return
+	}
+
+	for i := range schedules {
+		s := &schedules[i]
+		updates := map[string]interface{}{"next_run_at": NextRunAt(s.Cron, now)}
+		if _, err := trigger(s); err != nil {
+			log.Printf("[Scheduler] Schedule %d (%s) not triggered: %v", s.ID, s.Name, err)
+			updates["last_error"] = err.Error()
+		}
+		if err := database.DB.Model(s).Updates(updates).Error; err != nil {
+			log.Printf("[Scheduler] Failed to update schedule %d: %v", s.ID, err)
+		}
+	}
+}
+
+// RunNow 立即运行一次计划（不影响下次运行时间）
+// 上一次运行尚未结束时返回 ErrRunInProgress
+func RunNow(schedule *models.Schedule) (*models.ScheduleRun, error) {
+	run, err := trigger(schedule)
+	if err != nil && !errors.Is(err, ErrRunInProgress) {
+		database.DB.Model(schedule).Update("last_error", err.Error())
+	}
+	return run, err
+}
+
+// NextRunAt 计算下次运行时间，表达式无效或无法满足时返回 nil
+func NextRunAt(spec string, after time.Time) *time.Time {
+	c, err := ParseCron(spec)
+	if err != nil {
+		return nil
+	}
+	next := c.Next(after)
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// trigger 把计划的请求加入任务队列并记录运行
+// 上一次运行还未结束时跳过，避免任务堆积
+func trigger(schedule *models.Schedule) (*models.ScheduleRun, error) {
+	triggerMu.Lock()
+	defer triggerMu.Unlock()
+
+	var running int64
+	if err := database.DB.Model(&models.ScheduleRun{}).
+		Where("schedule_id = ? AND status = ?", schedule.ID, models.StatusPending).
+		Count(&running).Error; err != nil {
+		return nil, err
+	}
+	if running > 0 {
+		return nil, ErrRunInProgress
+	}
+
+	var req task.TaskRequest
+	if err := json.Unmarshal([]byte(schedule.Request), &req); err != nil {
+		return nil, fmt.Errorf("解析计划请求失败: %w", err)
+	}
+	config := task.DefaultTaskConfig()
+	if schedule.TaskConfig != "" {
+		if err := json.Unmarshal([]byte(schedule.TaskConfig), &config); err != nil {
+			return nil, fmt.Errorf("解析计划配置失败: %w", err)
+		}
+	}
+
+	req.TaskID = uuid.New().String()
+	history, err := task.Enqueue(req, &config, schedule.Priority)
+	if err != nil {
+		return nil, fmt.Errorf("创建任务失败: %w", err)
+	}
+
+	run := models.ScheduleRun{
+		ScheduleID: schedule.ID,
+		HistoryID:  history.ID,
+		TaskID:     req.TaskID,
+		Status:     models.StatusPending,
+	}
+	if err := database.DB.Create(&run).Error; err != nil {
+		return nil, fmt.Errorf("保存运行记录失败: %w", err)
+	}
+
+	now := time.Now()
+	database.DB.Model(schedule).Updates(map[string]interface{}{"last_run_at": now, "last_error": ""})
+	log.Printf("[Scheduler] Schedule %d (%s) triggered task %s", schedule.ID, schedule.Name, req.TaskID)
+	return &run, nil
+}
+
+// collectRuns 检查未结束的运行，任务结束后更新运行状态
+func collectRuns() {
+	runs, err := database.PendingScheduleRuns()
+	if err != nil {
+		log.Printf("[Scheduler] Failed to load pending runs: %v", err)
+		return
+	}
+
+	for i := range runs {
+		run := &runs[i]
+		var history models.AnalysisHistory
+		if err := database.DB.First(&history, run.HistoryID).Error; err != nil {
+			finishRun(run, models.StatusFailed, map[string]interface{}{"error": "分析历史记录不存在"})
+			continue
+		}
+
+		switch history.Status {
+		case models.StatusCompleted:
+			completeRun(run, &history)
+		case models.StatusFailed, models.StatusCancelled:
+			finishRun(run, history.Status, map[string]interface{}{"error": history.ProgressMsg})
+		}
+	}
+}
+
+// completeRun 记录本次运行的指标快照，与上一次成功运行对比并发送告警
+func completeRun(run *models.ScheduleRun, history *models.AnalysisHistory) {
+	var reportModel models.Report
+	if err := database.DB.First(&reportModel, history.ReportID).Error; err != nil {
+		finishRun(run, models.StatusFailed, map[string]interface{}{"error": "报告不存在"})
+		return
+	}
+	var data report.ReportData
+	if err := json.Unmarshal([]byte(reportModel.ReportData), &data); err != nil {
+		finishRun(run, models.StatusFailed, map[string]interface{}{"error": "解析报告数据失败"})
+		return
+	}
+
+	metrics := metricsFromReport(&data)
+	metricsJSON, _ := json.Marshal(metrics)
+	updates := map[string]interface{}{
+		"report_id": reportModel.ID,
+		"metrics":   string(metricsJSON),
+	}
+
+	var schedule models.Schedule
+	if err := database.DB.First(&schedule, run.ScheduleID).Error; err != nil {
+		finishRun(run, models.StatusCompleted, updates)
+		return
+	}
+
+	previous, err := database.PreviousCompletedRun(run.ScheduleID, run.ID)
+	if err != nil {
+		log.Printf("[Scheduler] Failed to load previous run of schedule %d: %v", run.ScheduleID, err)
+	}
+	var alerts []Alert
+	if previous != nil {
+		var prevMetrics map[string]BrandMetrics
+		if err := json.Unmarshal([]byte(previous.Metrics), &prevMetrics); err == nil {
+			alerts = detectAlerts(prevMetrics, metrics, schedule.ScoreThreshold, schedule.NegativeThreshold)
+		}
+	}
+
+	if len(alerts) > 0 {
+		alertsJSON, _ := json.Marshal(alerts)
+		updates["alerts"] = string(alertsJSON)
+		log.Printf("[Scheduler] Schedule %d run %d raised %d alerts", schedule.ID, run.ID, len(alerts))
+
+		payload := AlertPayload{
+			Event:            webhook.EventScheduleAlert,
+			ScheduleID:       schedule.ID,
+			ScheduleName:     schedule.Name,
+			Category:         schedule.Category,
+			ReportID:         reportModel.ID,
+			PreviousReportID: previous.ReportID,
+			Alerts:           alerts,
+			CreatedAt:        time.Now(),
+		}
+		// 计划自己的告警地址通过专用接收端投递，与全局 Webhook 接收端一样签名和重试；
+		// 广播时跳过该地址，避免同一地址也配置为全局接收端时收到两次告警
+		if schedule.WebhookURL != "" {
+			if err := sendScheduleAlert(&schedule, payload); err != nil {
+				log.Printf("[Scheduler] Failed to enqueue alert webhook for schedule %d: %v", schedule.ID, err)
+				updates["error"] = "告警发送失败: " + err.Error()
+			}
+		}
+		webhook.EmitExceptURL(webhook.EventScheduleAlert, &webhook.Payload{Data: payload}, schedule.WebhookURL)
+	}
+
+	finishRun(run, models.StatusCompleted, updates)
+}
+
+// sendScheduleAlert 通过计划的专用接收端发送告警
+func sendScheduleAlert(schedule *models.Schedule, payload AlertPayload) error {
+	var endpoint models.WebhookEndpoint
+	if err := database.DB.First(&endpoint, schedule.WebhookID).Error; err != nil {
+		return fmt.Errorf("告警接收端不存在: %w", err)
+	}
+	_, err := webhook.EmitTo(&endpoint, webhook.EventScheduleAlert, &webhook.Payload{Data: payload})
+	return err
+}
+
+// finishRun 标记运行结束
+func finishRun(run *models.ScheduleRun, status string, updates map[string]interface{}) {
+	updates["status"] = status
+	updates["finished_at"] = time.Now()
+	if err := database.DB.Model(run).Updates(updates).Error; err != nil {
+		log.Printf("[Scheduler] Failed to update run %d: %v", run.ID, err)
+	}
+}
@@ -14,6 +14,9 @@ var taskLastStatus = make(map[string]TaskStatus)
 // mu 保护taskChannels和taskLastStatus的读写锁
 var mu sync.RWMutex
 
+// listeners 任务状态监听器，每次 PushStatus 都会调用（用于 Webhook 等外部通知）
+var listeners []func(TaskStatus)
+
 // TaskStatus 任务状态结构
 // 用于SSE推送任务执行进度
 type TaskStatus struct {
@@ -26,6 +29,7 @@ type TaskStatus struct {
 
 // 任务状态常量
 const (
+	StatusQueued         = "queued"          // 排队等待执行
 	StatusParsing        = "parsing"         // 正在解析用户输入
 	StatusWaitingConfirm = "waiting_confirm" // 等待用户确认
 	StatusSearching      = "searching"       // 正在搜索视频
@@ -35,6 +39,7 @@ const (
 	StatusCompleted      = "completed"       // 任务完成
 	StatusError          = "error"           // 任务出错
 	StatusCancelled      = "cancelled"       // 任务取消
+	StatusThrottled      = "throttled"       // 触发B站风控，暂停等待中（结束后恢复原状态）
 )
 
 // Progress 进度信息结构
@@ -151,6 +156,30 @@ func PushStatus(taskID string, status TaskStatus) {
 		default:
 		}
 	}
+
+	mu.RLock()
+	fns := listeners
+	mu.RUnlock()
+	for _, fn := range fns {
+		fn(status)
+	}
+}
+
+// AddListener 注册任务状态监听器
+// 监听器在推送方的 goroutine 中同步调用，不应阻塞（耗时操作应转交给其他 goroutine）
+//
+// 示例：
+//
+//	AddListener(func(status TaskStatus) {
+//	    select {
+//	    case events <- status:
+//	    default:
+//	    }
+//	})
+func AddListener(fn func(TaskStatus)) {
+	mu.Lock()
+	defer mu.Unlock()
+	listeners = append(listeners, fn)
 }
 
 // PushProgress 推送进度更新（便捷方法）
@@ -178,6 +207,29 @@ func PushProgress(taskID, status string, current, total int, message string) {
 	})
 }
 
+// PushThrottled 推送限流状态（便捷方法）
+// 沿用上一次推送的进度，前端进度条不会因限流而回退
+//
+// 参数：
+//   - taskID: 任务ID
+//   - message: 状态消息
+//
+// 示例：
+//
+//	PushThrottled("task_123", "触发B站风控，暂停 10s 后继续")
+func PushThrottled(taskID, message string) {
+	mu.Lock()
+	progress := taskLastStatus[taskID].Progress
+	mu.Unlock()
+
+	PushStatus(taskID, TaskStatus{
+		TaskID:   taskID,
+		Status:   StatusThrottled,
+		Progress: progress,
+		Message:  message,
+	})
+}
+
 // PushError 推送错误状态（便捷方法）
 // 快速推送错误信息
 //
@@ -0,0 +1,85 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/bilibili"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"context"
+	"log"
+	"time"
+)
+
+// accountCheckTimeout 单个账号健康检查的超时时间
+const accountCheckTimeout = 15 * time.Second
+
+// cookieAccounts 获取可用于抓取的账号
+// 账号池中的可用账号在前；旧版单Cookie配置不在池中时追加在最后（ID 为0，不持久化隔离状态）
+func cookieAccounts(legacyCookie string) []bilibili.CookieAccount {
+	var accounts []bilibili.CookieAccount
+	rows, err := database.UsableBilibiliAccounts()
+	if err != nil {
+		log.Printf("[Accounts] Failed to load account pool: %v", err)
+	}
+	for _, a := range rows {
+		if legacyCookie == a.Cookie {
+			legacyCookie = ""
+		}
+		accounts = append(accounts, bilibili.CookieAccount{ID: a.ID, Name: a.Name, Cookie: a.Cookie})
+	}
+	if legacyCookie != "" {
+		accounts = append(accounts, bilibili.CookieAccount{Name: "默认Cookie", Cookie: legacyCookie})
+	}
+	return accounts
+}
+
+// HasBilibiliCookie 是否配置了可用的B站Cookie（单Cookie配置或账号池中有可用账号）
+func HasBilibiliCookie(legacyCookie string) bool {
+	return len(cookieAccounts(legacyCookie)) > 0
+}
+
+// newCookiePool 创建任务使用的Cookie池，账号被隔离时写回数据库
+func newCookiePool(legacyCookie string) *bilibili.CookiePool {
+	pool := bilibili.NewCookiePool(cookieAccounts(legacyCookie))
+	pool.SetQuarantineCallback(func(account bilibili.CookieAccount, reason string, until time.Time) {
+		log.Printf("[Accounts] Quarantined account %d %s until %s: %s",
+			account.ID, account.Name, until.Format("15:04:05"), reason)
+		if account.ID == 0 {
+			return
+		}
+		if err := database.QuarantineBilibiliAccount(account.ID, "触发风控: "+reason, until); err != nil {
+			log.Printf("[Accounts] Failed to save quarantine of account %d: %v", account.ID, err)
+		}
+	})
+	return pool
+}
+
+// CheckBilibiliAccount 通过 nav 接口检查账号的登录状态并保存结果
+func CheckBilibiliAccount(ctx context.Context, account *models.BilibiliAccount) error {
+	ctx, cancel := context.WithTimeout(ctx, accountCheckTimeout)
+	defer cancel()
+
+	applyRateLimit()
+	info, err := bilibili.NewClient(account.Cookie).CheckLogin(ctx)
+	if err != nil {
+		log.Printf("[Accounts] Health check of account %d failed: %v", account.ID, err)
+		return database.SaveBilibiliAccountCheck(account.ID, false, "", 0, err)
+	}
+	return database.SaveBilibiliAccountCheck(account.ID, info.IsLogin, info.Uname, info.Mid, nil)
+}
+
+// CheckBilibiliAccounts 检查所有启用账号的登录状态（由定时任务调用）
+func CheckBilibiliAccounts() {
+	accounts, err := database.ListBilibiliAccounts()
+	if err != nil {
+		log.Printf("[Accounts] Failed to list accounts: %v", err)
+		return
+	}
+	for i := range accounts {
+		if !accounts[i].Enabled {
+			continue
+		}
+		if err := CheckBilibiliAccount(context.Background(), &accounts[i]); err != nil {
+			log.Printf("[Accounts] Failed to save health check of account %d: %v", accounts[i].ID, err)
+		}
+	}
+}
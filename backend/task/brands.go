@@ -0,0 +1,35 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/comment"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"log"
+)
+
+// LoadBrandDictionary 加载对需求描述生效的品牌词典（通用词条 + 类目词条）
+// 读取失败时返回空词典，分析照常进行，只是不做别名归并
+func LoadBrandDictionary(requirement string) *comment.BrandDictionary {
+	aliases, err := database.BrandAliasesFor(requirement)
+	if err != nil {
+		log.Printf("[Brand] 加载品牌词典失败: %v", err)
+		return comment.NewBrandDictionary(nil)
+	}
+	return comment.NewBrandDictionary(BrandEntries(aliases))
+}
+
+// BrandEntries 把数据库词条转换为词典条目（英文名、拼音和别名都作为写法）
+func BrandEntries(aliases []models.BrandAlias) []comment.BrandEntry {
+	entries := make([]comment.BrandEntry, 0, len(aliases))
+	for _, a := range aliases {
+		entry := comment.BrandEntry{Name: a.Name}
+		for _, spelling := range []string{a.English, a.Pinyin} {
+			if spelling != "" {
+				entry.Aliases = append(entry.Aliases, spelling)
+			}
+		}
+		entry.Aliases = append(entry.Aliases, database.SplitAliasList(a.Aliases)...)
+		entries = append(entries, entry)
+	}
+	return entries
+}
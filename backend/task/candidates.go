@@ -0,0 +1,260 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/bilibili"
+	"bilibili-analyzer/backend/comment"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/report"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrCandidateReviewed 候选品牌已审核过
+var ErrCandidateReviewed = errors.New("候选品牌已审核")
+
+// reportLocks 按报告ID串行化接受候选品牌（读取报告、重新生成、写回需要整体完成，
+// 同一报告的两个候选品牌同时接受时后写入的一方不能覆盖先写入的品牌）
+var (
+	reportLocksMu sync.Mutex
+	reportLocks   = make(map[uint]*sync.Mutex)
+)
+
+// lockReport 锁定报告，返回解锁函数
+func lockReport(reportID uint) func() {
+	reportLocksMu.Lock()
+	lock, ok := reportLocks[reportID]
+	if !ok {
+		lock = &sync.Mutex{}
+		reportLocks[reportID] = lock
+	}
+	reportLocksMu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// brandCandidate 进入候选池的发现品牌（评论已按品牌分组，信号已计算）
+type brandCandidate struct {
+	Brand    string
+	Signal   brandDiscoverySignal
+	Comments []report.CommentWithScore
+}
+
+// AcceptCandidateOptions 接受候选品牌的选项
+type AcceptCandidateOptions struct {
+	Name     string   // 报告中使用的品牌名称，为空时使用候选品牌名称；与报告中已有品牌相同时评论并入该品牌
+	Category string   // 写入品牌词典的类目，为空表示通用词条
+	Aliases  []string // 额外写入品牌词典的别名
+}
+
+// saveBrandCandidates 保存任务的候选品牌，失败只记录日志，不影响报告
+func saveBrandCandidates(historyID, reportID uint, category string, candidates []brandCandidate, brandDict *comment.BrandDictionary) {
+	if len(candidates) == 0 {
+		return
+	}
+	rows := make([]models.BrandCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		data, err := json.Marshal(c.Comments)
+		if err != nil {
+			log.Printf("[Candidate] 序列化候选品牌 %s 的评论失败: %v", c.Brand, err)
+			continue
+		}
+		rows = append(rows, models.BrandCandidate{
+			HistoryID:        historyID,
+			ReportID:         reportID,
+			Category:         category,
+			Brand:            c.Brand,
+			Spellings:        strings.Join(brandDict.Merged([]string{c.Brand})[c.Brand], ","),
+			Score:            c.Signal.Score,
+			CommentCount:     c.Signal.CommentCount,
+			VideoCount:       c.Signal.VideoCount,
+			CategoryHitRatio: c.Signal.CategoryHitRatio,
+			ModelHitRatio:    c.Signal.ModelHitRatio,
+			Comments:         string(data),
+			Status:           models.BrandCandidatePending,
+		})
+	}
+	if err := database.DB.Create(&rows).Error; err != nil {
+		log.Printf("[Candidate] 保存 %d 个候选品牌失败: %v", len(rows), err)
+	}
+}
+
+// CandidateComments 解析候选品牌保存的评论及得分
+func CandidateComments(candidate *models.BrandCandidate) ([]report.CommentWithScore, error) {
+	var comments []report.CommentWithScore
+	if candidate.Comments == "" {
+		return comments, nil
+	}
+	if err := json.Unmarshal([]byte(candidate.Comments), &comments); err != nil {
+		return nil, fmt.Errorf("解析候选品牌评论失败: %w", err)
+	}
+	return comments, nil
+}
+
+// AcceptBrandCandidate 接受候选品牌：把它的评论加入报告并重新生成报告（不重新抓取和分析），
+// 再把品牌名称和原始写法写入品牌词典，之后的分析会直接归并这些写法
+//
+// 重新生成时购买建议使用基于评分的规则生成，Token 用量和已记录的别名归并保持不变；
+// 评论权重、商单处理方式和型号库沿用报告生成时保存的配置，不受之后修改设置的影响
+func AcceptBrandCandidate(candidate *models.BrandCandidate, opts AcceptCandidateOptions) (*report.ReportData, error) {
+	unlock := lockReport(candidate.ReportID)
+	defer unlock()
+
+	// 加锁后重新读取状态，避免同一候选品牌被重复接受
+	if err := database.DB.First(candidate, candidate.ID).Error; err != nil {
+		return nil, fmt.Errorf("候选品牌不存在: %w", err)
+	}
+	if candidate.Status != models.BrandCandidatePending {
+		return nil, ErrCandidateReviewed
+	}
+	name := strings.TrimSpace(opts.Name)
+	if name == "" {
+		name = candidate.Brand
+	}
+	comments, err := CandidateComments(candidate)
+	if err != nil {
+		return nil, err
+	}
+
+	var reportModel models.Report
+	if err := database.DB.First(&reportModel, candidate.ReportID).Error; err != nil {
+		return nil, fmt.Errorf("报告不存在: %w", err)
+	}
+	var previous report.ReportData
+	if err := json.Unmarshal([]byte(reportModel.ReportData), &previous); err != nil {
+		return nil, fmt.Errorf("解析报告数据失败: %w", err)
+	}
+	rows, err := database.ListReportComments(reportModel.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询证据评论失败: %w", err)
+	}
+
+	for i := range comments {
+		comments[i].Brand = name
+	}
+	results := report.CommentsFromReport(rows)
+	results[name] = append(results[name], comments...)
+
+	stats := previous.Stats
+	stats.CommentsByBrand = maps.Clone(stats.CommentsByBrand)
+	if stats.CommentsByBrand == nil {
+		stats.CommentsByBrand = make(map[string]int)
+	}
+	stats.CommentsByBrand[name] += len(comments)
+
+	videos := make([]bilibili.VideoInfo, 0, len(previous.VideoSources))
+	for _, v := range previous.VideoSources {
+		videos = append(videos, bilibili.VideoInfo{
+			BVID: v.BVID, Title: v.Title, Author: v.Author, Play: v.Play, VideoReview: v.VideoReview,
+			Sponsored: v.Sponsored, SponsorSignals: v.SponsorSignals,
+		})
+	}
+
+	brands := previous.Brands
+	if !slices.Contains(brands, name) {
+		brands = append(slices.Clone(brands), name)
+	}
+	data, err := report.GenerateReportWithInput(report.GenerateReportInput{
+		Category:         previous.Category,
+		Brands:           brands,
+		Dimensions:       previous.Dimensions,
+		AnalysisResults:  results,
+		Stats:            stats,
+		Videos:           videos,
+		ModelCatalog:     reportModelCatalog(&previous),
+		Weighting:        previous.Weighting,
+		ExcludeSponsored: previous.ExcludeSponsored || (previous.SponsoredComparison != nil && previous.SponsoredComparison.Excluded),
+		Suspicious:       previous.SuspiciousComments,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("重新生成报告失败: %w", err)
+	}
+	data.CatalogModels = previous.CatalogModels
+	data.TokenUsage = previous.TokenUsage
+	data.MergedAliases = previous.MergedAliases
+	if merged := candidateMergedSpellings(candidate, name); len(merged) > 0 {
+		data.MergedAliases = maps.Clone(data.MergedAliases)
+		if data.MergedAliases == nil {
+			data.MergedAliases = make(map[string][]string)
+		}
+		for _, s := range merged {
+			if !slices.Contains(data.MergedAliases[name], s) {
+				data.MergedAliases[name] = append(data.MergedAliases[name], s)
+			}
+		}
+		slices.Sort(data.MergedAliases[name])
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	candidate.AcceptedAs = name
+	evidence := report.BuildReportComments(reportModel.ID, map[string][]report.CommentWithScore{name: comments})
+	if err := database.AcceptBrandCandidate(candidate, string(encoded), evidence); err != nil {
+		return nil, fmt.Errorf("保存报告失败: %w", err)
+	}
+
+	// 写入品牌词典：同类目已有同名词条时只合并别名
+	aliases := append(candidateMergedSpellings(candidate, name), opts.Aliases...)
+	if _, _, err := database.SeedBrandAliases(opts.Category, []models.BrandAlias{{
+		Name:    name,
+		Aliases: strings.Join(aliases, ","),
+		Source:  models.BrandAliasSourceManual,
+	}}); err != nil {
+		log.Printf("[Candidate] 候选品牌 %s 写入品牌词典失败: %v", name, err)
+	}
+	return data, nil
+}
+
+// reportModelCatalog 使用报告保存的型号库词条重建型号库
+// 词条中的品牌和型号已是生成时的标准名称，不需要品牌词典；旧报告没有保存词条时使用当前型号库
+func reportModelCatalog(data *report.ReportData) *comment.ModelCatalog {
+	if data.CatalogModels == nil {
+		return LoadModelCatalog(LoadBrandDictionary(data.Category))
+	}
+	return comment.NewModelCatalog(data.CatalogModels, nil)
+}
+
+// candidateBrands 候选品牌名称
+func candidateBrands(candidates []brandCandidate) []string {
+	brands := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		brands = append(brands, c.Brand)
+	}
+	return brands
+}
+
+// RejectBrandCandidate 拒绝候选品牌（报告不变）
+func RejectBrandCandidate(candidate *models.BrandCandidate) error {
+	unlock := lockReport(candidate.ReportID)
+	defer unlock()
+
+	// 与接受共用报告锁，加锁后重新读取状态，避免拒绝覆盖已接受的结果
+	if err := database.DB.First(candidate, candidate.ID).Error; err != nil {
+		return fmt.Errorf("候选品牌不存在: %w", err)
+	}
+	if candidate.Status != models.BrandCandidatePending {
+		return ErrCandidateReviewed
+	}
+	candidate.Status = models.BrandCandidateRejected
+	return database.DB.Save(candidate).Error
+}
+
+// candidateMergedSpellings 候选品牌归并到 name 的写法（候选品牌名称和 AI 返回的原始写法，不含与 name 相同的写法）
+func candidateMergedSpellings(candidate *models.BrandCandidate, name string) []string {
+	var spellings []string
+	for _, s := range append([]string{candidate.Brand}, database.SplitAliasList(candidate.Spellings)...) {
+		if comment.BrandKey(s) != comment.BrandKey(name) && !slices.Contains(spellings, s) {
+			spellings = append(spellings, s)
+		}
+	}
+	return spellings
+}
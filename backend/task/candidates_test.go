@@ -0,0 +1,159 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/comment"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/report"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func candidateTestComments(brand, model string, score float64) []report.CommentWithScore {
+	comments := make([]report.CommentWithScore, 0, 3)
+	for i := 0; i < 3; i++ {
+		s := score
+		comments = append(comments, report.CommentWithScore{
+			Content: brand + model + "用着不错",
+			Scores:  map[string]*float64{"吸力": &s},
+			Brand:   brand,
+			Model:   model,
+			RPID:    int64(len(brand)*10 + i),
+		})
+	}
+	return comments
+}
+
+// createCandidateTestReport 生成一份带两个候选品牌的报告，型号库只在生成时存在
+func createCandidateTestReport(t *testing.T) (*models.Report, []models.BrandCandidate) {
+	t.Helper()
+	catalog := comment.NewModelCatalog([]comment.ModelEntry{
+		{Brand: "戴森", Model: "V12 Detect Slim"},
+		{Brand: "追觅", Model: "X20 Pro"},
+	}, nil)
+
+	results := map[string][]report.CommentWithScore{"戴森": candidateTestComments("戴森", "V12 Detect Slim", 8)}
+	data, err := report.GenerateReportWithInput(report.GenerateReportInput{
+		Category:        "吸尘器",
+		Brands:          []string{"戴森"},
+		Dimensions:      []ai.Dimension{{Name: "吸力"}},
+		AnalysisResults: results,
+		Stats:           report.ReportStats{CommentsByBrand: map[string]int{"戴森": 3}},
+		ModelCatalog:    catalog,
+	})
+	if err != nil {
+		t.Fatalf("GenerateReportWithInput failed: %v", err)
+	}
+	data.CatalogModels = catalog.Entries([]string{"戴森", "追觅", "石头"})
+
+	encoded, _ := json.Marshal(data)
+	reportModel := &models.Report{HistoryID: 1, Category: "吸尘器", ReportData: string(encoded)}
+	if err := database.DB.Create(reportModel).Error; err != nil {
+		t.Fatalf("create report failed: %v", err)
+	}
+	SaveReportComments(reportModel.ID, results)
+
+	var candidates []models.BrandCandidate
+	for _, c := range []struct{ brand, model string }{{"追觅", "X20 Pro"}, {"石头", "G20"}} {
+		comments, _ := json.Marshal(candidateTestComments(c.brand, c.model, 7))
+		candidate := models.BrandCandidate{
+			HistoryID: 1,
+			ReportID:  reportModel.ID,
+			Category:  "吸尘器",
+			Brand:     c.brand,
+			Comments:  string(comments),
+			Status:    models.BrandCandidatePending,
+		}
+		if err := database.DB.Create(&candidate).Error; err != nil {
+			t.Fatalf("create candidate failed: %v", err)
+		}
+		candidates = append(candidates, candidate)
+	}
+	return reportModel, candidates
+}
+
+func TestAcceptBrandCandidateConcurrent(t *testing.T) {
+	setupTestDB(t)
+	reportModel, candidates := createCandidateTestReport(t)
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(candidates))
+	for i := range candidates {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			candidate := candidates[i]
+			_, errs[i] = AcceptBrandCandidate(&candidate, AcceptCandidateOptions{})
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("accept %s failed: %v", candidates[i].Brand, err)
+		}
+	}
+
+	var saved models.Report
+	database.DB.First(&saved, reportModel.ID)
+	var data report.ReportData
+	if err := json.Unmarshal([]byte(saved.ReportData), &data); err != nil {
+		t.Fatalf("decode report failed: %v", err)
+	}
+	for _, brand := range []string{"戴森", "追觅", "石头"} {
+		if !slices.Contains(data.Brands, brand) {
+			t.Errorf("brand %s missing from report, brands=%v", brand, data.Brands)
+		}
+	}
+
+	// 同一候选品牌不能重复接受
+	candidate := candidates[0]
+	candidate.Status = models.BrandCandidatePending
+	if _, err := AcceptBrandCandidate(&candidate, AcceptCandidateOptions{}); !errors.Is(err, ErrCandidateReviewed) {
+		t.Errorf("second accept error = %v, want ErrCandidateReviewed", err)
+	}
+
+	// 拿着接受前读到的旧状态拒绝，不能覆盖已接受的结果
+	stale := candidates[1]
+	if err := RejectBrandCandidate(&stale); !errors.Is(err, ErrCandidateReviewed) {
+		t.Errorf("reject after accept error = %v, want ErrCandidateReviewed", err)
+	}
+	var stored models.BrandCandidate
+	database.DB.First(&stored, candidates[1].ID)
+	if stored.Status != models.BrandCandidateAccepted {
+		t.Errorf("candidate status = %s, want accepted", stored.Status)
+	}
+}
+
+func TestAcceptBrandCandidateReusesReportSettings(t *testing.T) {
+	setupTestDB(t)
+	reportModel, candidates := createCandidateTestReport(t)
+
+	// 报告生成后修改全局设置：型号库为空、默认开启评论加权、排除商单
+	setTestSetting(t, models.SettingKeySponsoredVideoMode, SponsoredModeExclude)
+
+	data, err := AcceptBrandCandidate(&candidates[0], AcceptCandidateOptions{})
+	if err != nil {
+		t.Fatalf("AcceptBrandCandidate failed: %v", err)
+	}
+	if data.Weighting != nil || data.WeightedScores != nil {
+		t.Errorf("weighting should stay disabled as when the report was generated, got %+v", data.Weighting)
+	}
+	if data.ExcludeSponsored {
+		t.Error("sponsored mode should follow the report, not the current setting")
+	}
+	if len(data.CatalogModels) != 2 {
+		t.Errorf("catalog models should be kept, got %+v", data.CatalogModels)
+	}
+
+	verified := make(map[string]bool)
+	for _, m := range data.ModelRankings {
+		verified[m.Brand+" "+m.Model] = m.Verified
+	}
+	if !verified["戴森 V12 Detect Slim"] || !verified["追觅 X20 Pro"] {
+		t.Errorf("models should be verified against the catalog saved with report %d: %v", reportModel.ID, verified)
+	}
+}
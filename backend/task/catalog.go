@@ -0,0 +1,68 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/comment"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/report"
+	"log"
+)
+
+// LoadModelCatalog 加载型号库，品牌通过 brandDict 归并到标准名称
+// 读取失败时返回空型号库，型号按原有规则处理
+func LoadModelCatalog(brandDict *comment.BrandDictionary) *comment.ModelCatalog {
+	entries, err := database.ListModelCatalog("")
+	if err != nil {
+		log.Printf("[Model] 加载型号库失败: %v", err)
+		return comment.NewModelCatalog(nil, brandDict)
+	}
+	return comment.NewModelCatalog(ModelEntries(entries), brandDict)
+}
+
+// ModelEntries 把数据库词条转换为型号库条目
+func ModelEntries(entries []models.ModelCatalogEntry) []comment.ModelEntry {
+	result := make([]comment.ModelEntry, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, comment.ModelEntry{
+			ID:          e.ID,
+			Brand:       e.Brand,
+			Model:       e.Model,
+			Aliases:     database.SplitAliasList(e.Aliases),
+			ReleaseYear: e.ReleaseYear,
+		})
+	}
+	return result
+}
+
+// CanonicalModel 统一评论的型号：命中型号库时返回标准型号；
+// 型号为空或泛指时先在评论内容中查找型号库中的型号，再用正则后备提取
+func CanonicalModel(catalog *comment.ModelCatalog, brand, model, content string) string {
+	if comment.IsGenericModel(model) {
+		if entry, ok := catalog.FindInText(brand, content); ok {
+			return entry.Model
+		}
+		model = extractModelFromContent(content)
+	}
+	if entry, ok := catalog.Match(brand, model); ok {
+		return entry.Model
+	}
+	return model
+}
+
+// RecordUnknownModels 把报告中未命中型号库的型号加入审核队列
+func RecordUnknownModels(reportID uint, category string, rankings []report.ModelRanking, catalog *comment.ModelCatalog) {
+	var unknown []database.UnknownModel
+	for _, m := range rankings {
+		if m.Verified || m.Brand == "未知品牌" || comment.IsGenericModel(m.Model) {
+			continue
+		}
+		unknown = append(unknown, database.UnknownModel{
+			Brand:    catalog.CanonicalBrand(m.Brand),
+			Model:    m.Model,
+			Mentions: m.CommentCount,
+		})
+	}
+	if err := database.RecordModelReviews(reportID, category, unknown); err != nil {
+		log.Printf("[Model] 记录待审核型号失败: %v", err)
+	}
+}
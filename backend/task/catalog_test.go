@@ -0,0 +1,61 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/comment"
+	"testing"
+)
+
+func TestExtractModelFromContent(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"用了半年的 iPhone 15 Pro Max，续航一般", "iPhone 15 Pro Max"},
+		{"Mate60Pro 信号很好", "Mate60Pro"},
+		{"V12 Detect 吸力够用", "V12 Detect"},
+		{"T2S 性价比高", "T2S"},
+		{"X20 拖地很干净", "X20"},
+		// 只有系列后缀或单字母时不算型号
+		{"还是 Pro 版本好用", ""},
+		{"买 Max 还是 Ultra 纠结", ""},
+		{"这个 V 系列不错", ""},
+		{"吸力很强", ""},
+	}
+
+	for _, tt := range tests {
+		if got := extractModelFromContent(tt.content); got != tt.want {
+			t.Errorf("extractModelFromContent(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalModel(t *testing.T) {
+	catalog := comment.NewModelCatalog([]comment.ModelEntry{
+		{Brand: "戴森", Model: "V12 Detect Slim", Aliases: []string{"V12"}},
+		{Brand: "石头", Model: "G20"},
+	}, nil)
+
+	tests := []struct {
+		name    string
+		brand   string
+		model   string
+		content string
+		want    string
+	}{
+		{"catalog alias", "戴森", "V12", "", "V12 Detect Slim"},
+		{"catalog found in content", "戴森", "", "V12 Detect Slim 太吵了", "V12 Detect Slim"},
+		{"catalog before fallback", "石头", "Pro", "G20 拖地不错，Pro 版本没必要", "G20"},
+		{"longer model not in catalog", "石头", "", "G20 Pro 拖地不错", "G20 Pro"},
+		{"fallback pattern", "戴森", "未知", "V15 Detect 比上一代好", "V15 Detect"},
+		{"unknown model kept", "石头", "P10", "", "P10"},
+		{"generic stays empty", "石头", "通用", "Pro 版本好用", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalModel(catalog, tt.brand, tt.model, tt.content); got != tt.want {
+				t.Errorf("CanonicalModel(%q, %q, %q) = %q, want %q", tt.brand, tt.model, tt.content, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/bilibili"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/sse"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// stageOrder 任务阶段顺序，用于判断恢复时哪些阶段已经完成
+// 阶段值与 AnalysisHistory.Stage 中持久化的值一致
+var stageOrder = map[string]int{
+	"initializing":       0,
+	sse.StatusSearching:  1,
+	sse.StatusScraping:   2,
+	sse.StatusAnalyzing:  3,
+	sse.StatusGenerating: 4,
+}
+
+// stageReached 判断已持久化的阶段是否已到达（或越过）目标阶段
+func stageReached(stage, target string) bool {
+	current, ok := stageOrder[stage]
+	if !ok {
+		return false
+	}
+	return current >= stageOrder[target]
+}
+
+// saveVideoList 保存相关性过滤后的视频列表（搜索阶段完成）
+func saveVideoList(historyID uint, videos []bilibili.VideoInfo) error {
+	data, err := json.Marshal(videos)
+	if err != nil {
+		return fmt.Errorf("marshal videos failed: %w", err)
+	}
+	return database.DB.Model(&models.AnalysisHistory{}).Where("id = ?", historyID).
+		Update("video_list", string(data)).Error
+}
+
+// loadVideoList 读取已保存的视频列表，未保存时返回 nil
+func loadVideoList(history *models.AnalysisHistory) []bilibili.VideoInfo {
+	if history.VideoList == "" {
+		return nil
+	}
+	var videos []bilibili.VideoInfo
+	if err := json.Unmarshal([]byte(history.VideoList), &videos); err != nil {
+		log.Printf("[Checkpoint] Failed to unmarshal video list for history %d: %v", history.ID, err)
+		return nil
+	}
+	return videos
+}
+
+// restoreVideoList 恢复任务时读取已保存的视频列表
+// 任务尚未进入抓取阶段（搜索未完成）时返回 nil，需要重新搜索
+func restoreVideoList(history *models.AnalysisHistory) []bilibili.VideoInfo {
+	if !stageReached(history.Stage, sse.StatusScraping) {
+		return nil
+	}
+	return loadVideoList(history)
+}
+
+// restoreScrapeResult 恢复任务时从 raw_comments 还原抓取结果
+// 任务尚未进入分析阶段（抓取未完成）时返回 nil，需要重新抓取
+func restoreScrapeResult(history *models.AnalysisHistory, videos []bilibili.VideoInfo) (*bilibili.ScrapeResult, error) {
+	if !stageReached(history.Stage, sse.StatusAnalyzing) {
+		return nil, nil
+	}
+	return loadScrapeResult(history.ID, videos)
+}
+
+// saveScrapedComments 保存抓取到的评论（抓取阶段完成）
+// 根评论和楼中楼都展开为独立行，通过 RootID 记录层级关系
+func saveScrapedComments(historyID uint, result *bilibili.ScrapeResult) error {
+	var rows []models.RawComment
+	for bvid, comments := range result.Comments {
+		for _, c := range comments {
+			row, err := buildRawComment(historyID, bvid, c)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, row)
+			for _, r := range c.Replies {
+				replyRow, err := buildRawComment(historyID, bvid, r)
+				if err != nil {
+					return err
+				}
+				if replyRow.RootID == 0 {
+					replyRow.RootID = c.RPID
+				}
+				rows = append(rows, replyRow)
+			}
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil
+	}
+
+	// 分批写入放在同一事务中，避免中途失败只留下部分评论却被当作已完成的抓取结果恢复；
+	// 同一任务内重复出现的评论（如楼中楼与根评论重复）直接忽略
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(rows, 100).Error
+	})
+}
+
+func buildRawComment(historyID uint, bvid string, c bilibili.Comment) (models.RawComment, error) {
+	replies := c.Replies
+	c.Replies = nil
+	payload, err := json.Marshal(c)
+	c.Replies = replies
+	if err != nil {
+		return models.RawComment{}, fmt.Errorf("marshal comment failed: %w", err)
+	}
+
+	return models.RawComment{
+		HistoryID:   historyID,
+		VideoID:     bvid,
+		CommentID:   buildCommentKey(c),
+		RootID:      c.Root,
+		Content:     c.Content.Message,
+		Author:      c.Member.Uname,
+		Likes:       c.Like,
+		ReplyCount:  c.RCount,
+		PublishTime: time.Unix(c.Ctime, 0),
+		Payload:     string(payload),
+	}, nil
+}
+
+// loadScrapeResult 从 raw_comments 重建抓取结果
+// 没有保存任何评论时返回 nil
+func loadScrapeResult(historyID uint, videos []bilibili.VideoInfo) (*bilibili.ScrapeResult, error) {
+	var rows []models.RawComment
+	if err := database.DB.Where("history_id = ?", historyID).Order("id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	result := &bilibili.ScrapeResult{
+		Videos:   videos,
+		Comments: make(map[string][]bilibili.Comment),
+		Stats: bilibili.ScrapeStats{
+			TotalVideos: len(videos),
+		},
+	}
+
+	// 先还原根评论，再把楼中楼挂回对应根评论
+	rootIndex := make(map[int64]int) // rpid -> 在所属视频评论切片中的下标
+	var replies []models.RawComment
+	for _, row := range rows {
+		if row.RootID != 0 {
+			replies = append(replies, row)
+			continue
+		}
+		var c bilibili.Comment
+		if err := json.Unmarshal([]byte(row.Payload), &c); err != nil {
+			log.Printf("[Checkpoint] Skip broken comment %s: %v", row.CommentID, err)
+			continue
+		}
+		result.Comments[row.VideoID] = append(result.Comments[row.VideoID], c)
+		if c.IsDanmaku() {
+			result.Stats.TotalDanmaku++
+			continue
+		}
+		rootIndex[c.RPID] = len(result.Comments[row.VideoID]) - 1
+		result.Stats.TotalComments++
+	}
+
+	for _, row := range replies {
+		var r bilibili.Comment
+		if err := json.Unmarshal([]byte(row.Payload), &r); err != nil {
+			log.Printf("[Checkpoint] Skip broken reply %s: %v", row.CommentID, err)
+			continue
+		}
+		idx, ok := rootIndex[row.RootID]
+		if !ok {
+			// 根评论缺失时作为独立评论保留
+			result.Comments[row.VideoID] = append(result.Comments[row.VideoID], r)
+			result.Stats.TotalComments++
+			continue
+		}
+		result.Comments[row.VideoID][idx].Replies = append(result.Comments[row.VideoID][idx].Replies, r)
+		result.Stats.TotalReplies++
+	}
+
+	return result, nil
+}
+
+// loadAnalysisResults 读取已保存的AI分析结果
+// 返回：评论唯一键 -> 分析结果
+func loadAnalysisResults(historyID uint) map[string]ai.CommentAnalysisResult {
+	var rows []models.RawComment
+	if err := database.DB.Select("comment_id", "analysis_result").
+		Where("history_id = ? AND analysis_result <> ''", historyID).
+		Find(&rows).Error; err != nil {
+		log.Printf("[Checkpoint] Failed to load analysis results for history %d: %v", historyID, err)
+		return nil
+	}
+
+	results := make(map[string]ai.CommentAnalysisResult, len(rows))
+	for _, row := range rows {
+		var r ai.CommentAnalysisResult
+		if err := json.Unmarshal([]byte(row.AnalysisResult), &r); err != nil {
+			continue
+		}
+		results[row.CommentID] = r
+	}
+	return results
+}
+
+// saveAnalysisResults 保存AI分析结果（分析阶段完成）
+// 参数：
+//   - historyID: 分析历史ID
+//   - results: AI分析结果
+//   - keyByID: AI输入ID -> 评论唯一键
+//
+// 分析失败的评论不保存，恢复时会重新分析
+func saveAnalysisResults(historyID uint, results []ai.CommentAnalysisResult, keyByID map[string]string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, r := range results {
+			if r.Error != "" || r.Scores == nil {
+				continue
+			}
+			key, ok := keyByID[r.CommentID]
+			if !ok {
+				continue
+			}
+			data, err := json.Marshal(r)
+			if err != nil {
+				return fmt.Errorf("marshal analysis result failed: %w", err)
+			}
+			if err := tx.Model(&models.RawComment{}).
+				Where("history_id = ? AND comment_id = ?", historyID, key).
+				Update("analysis_result", string(data)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
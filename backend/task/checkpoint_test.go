@@ -0,0 +1,207 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/bilibili"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/sse"
+	"testing"
+)
+
+// setupTestDB 使用内存 SQLite 初始化全局数据库，测试结束时关闭
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	if err := database.InitDB(":memory:"); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+// createTestHistory 创建处于指定阶段的历史记录
+func createTestHistory(t *testing.T, taskID, status, stage string) *models.AnalysisHistory {
+	t.Helper()
+	history := &models.AnalysisHistory{
+		TaskID:   taskID,
+		Category: "吸尘器",
+		Status:   status,
+		Stage:    stage,
+		Progress: 60,
+	}
+	if err := database.DB.Create(history).Error; err != nil {
+		t.Fatalf("create history failed: %v", err)
+	}
+	return history
+}
+
+func testScrapeResult() *bilibili.ScrapeResult {
+	root := bilibili.Comment{RPID: 1, Ctime: 1700000000, Like: 3}
+	root.Content.Message = "吸力很强"
+	reply := bilibili.Comment{RPID: 2, Root: 1, Parent: 1, Ctime: 1700000100}
+	reply.Content.Message = "同感"
+	root.Replies = []bilibili.Comment{reply}
+
+	danmaku := bilibili.Comment{DanmakuID: 9, Source: bilibili.SourceDanmaku, Ctime: 1700000200}
+	danmaku.Content.Message = "太吵了"
+
+	return &bilibili.ScrapeResult{
+		Comments: map[string][]bilibili.Comment{
+			"BV1": {root, danmaku},
+		},
+	}
+}
+
+func TestStageReached(t *testing.T) {
+	tests := []struct {
+		stage  string
+		target string
+		want   bool
+	}{
+		{"", sse.StatusSearching, false},
+		{"initializing", sse.StatusSearching, false},
+		{sse.StatusSearching, sse.StatusSearching, true},
+		{sse.StatusSearching, sse.StatusScraping, false},
+		{sse.StatusScraping, sse.StatusScraping, true},
+		{sse.StatusAnalyzing, sse.StatusScraping, true},
+		{sse.StatusGenerating, sse.StatusAnalyzing, true},
+		{"unknown", sse.StatusSearching, false},
+	}
+
+	for _, tt := range tests {
+		if got := stageReached(tt.stage, tt.target); got != tt.want {
+			t.Errorf("stageReached(%q, %q) = %v, want %v", tt.stage, tt.target, got, tt.want)
+		}
+	}
+}
+
+func TestScrapedCommentsRoundTrip(t *testing.T) {
+	setupTestDB(t)
+	history := createTestHistory(t, "task-roundtrip", models.StatusProcessing, sse.StatusAnalyzing)
+
+	if err := saveScrapedComments(history.ID, testScrapeResult()); err != nil {
+		t.Fatalf("saveScrapedComments failed: %v", err)
+	}
+	// 重复保存同一批评论不应产生重复行
+	if err := saveScrapedComments(history.ID, testScrapeResult()); err != nil {
+		t.Fatalf("saveScrapedComments (again) failed: %v", err)
+	}
+
+	videos := []bilibili.VideoInfo{{BVID: "BV1", Title: "测评"}}
+	result, err := loadScrapeResult(history.ID, videos)
+	if err != nil {
+		t.Fatalf("loadScrapeResult failed: %v", err)
+	}
+	if result == nil {
+		t.Fatal("expected restored scrape result")
+	}
+
+	if result.Stats.TotalComments != 1 || result.Stats.TotalReplies != 1 || result.Stats.TotalDanmaku != 1 {
+		t.Errorf("unexpected stats: %+v", result.Stats)
+	}
+	comments := result.Comments["BV1"]
+	if len(comments) != 2 {
+		t.Fatalf("expected 2 top-level items, got %d", len(comments))
+	}
+	if comments[0].RPID != 1 || len(comments[0].Replies) != 1 || comments[0].Replies[0].RPID != 2 {
+		t.Errorf("reply not attached to root: %+v", comments[0])
+	}
+	if !comments[1].IsDanmaku() || comments[1].Content.Message != "太吵了" {
+		t.Errorf("danmaku not restored: %+v", comments[1])
+	}
+}
+
+func TestAnalysisResultsRoundTrip(t *testing.T) {
+	setupTestDB(t)
+	history := createTestHistory(t, "task-analysis", models.StatusProcessing, sse.StatusAnalyzing)
+	if err := saveScrapedComments(history.ID, testScrapeResult()); err != nil {
+		t.Fatalf("saveScrapedComments failed: %v", err)
+	}
+
+	score := 8.0
+	results := []ai.CommentAnalysisResult{
+		{CommentID: "c1", Scores: map[string]*float64{"吸力": &score}},
+		{CommentID: "c2", Error: "timeout"},
+	}
+	keyByID := map[string]string{
+		"c1": "rpid_1",
+		"c2": "rpid_2",
+	}
+	if err := saveAnalysisResults(history.ID, results, keyByID); err != nil {
+		t.Fatalf("saveAnalysisResults failed: %v", err)
+	}
+
+	saved := loadAnalysisResults(history.ID)
+	if len(saved) != 1 {
+		t.Fatalf("expected only the successful result to be saved, got %d", len(saved))
+	}
+	r, ok := saved["rpid_1"]
+	if !ok || r.Scores["吸力"] == nil || *r.Scores["吸力"] != 8.0 {
+		t.Errorf("unexpected saved result: %+v", saved)
+	}
+}
+
+// TestRestoreFromEachStage 验证从各阶段恢复时复用的断点数据
+func TestRestoreFromEachStage(t *testing.T) {
+	setupTestDB(t)
+
+	videos := []bilibili.VideoInfo{{BVID: "BV1", Title: "测评"}}
+	tests := []struct {
+		stage          string
+		wantVideos     bool
+		wantComments   bool
+		wantNoProgress bool // 恢复时不应重置阶段和进度
+	}{
+		{"initializing", false, false, false},
+		{sse.StatusSearching, false, false, true},
+		{sse.StatusScraping, true, false, true},
+		{sse.StatusAnalyzing, true, true, true},
+		{sse.StatusGenerating, true, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stage, func(t *testing.T) {
+			history := createTestHistory(t, "task-"+tt.stage, models.StatusProcessing, tt.stage)
+			if err := saveVideoList(history.ID, videos); err != nil {
+				t.Fatalf("saveVideoList failed: %v", err)
+			}
+			if err := saveScrapedComments(history.ID, testScrapeResult()); err != nil {
+				t.Fatalf("saveScrapedComments failed: %v", err)
+			}
+			if err := database.DB.First(history, history.ID).Error; err != nil {
+				t.Fatalf("reload history failed: %v", err)
+			}
+
+			NewExecutor(nil).startProgress(history.TaskID, history)
+
+			var stored models.AnalysisHistory
+			database.DB.First(&stored, history.ID)
+			if tt.wantNoProgress {
+				if stored.Stage != tt.stage || stored.Progress != 60 {
+					t.Errorf("checkpoint overwritten: stage=%s progress=%d", stored.Stage, stored.Progress)
+				}
+			} else if stored.Stage != sse.StatusSearching || stored.Progress != 0 {
+				t.Errorf("new task should start searching at 0, got stage=%s progress=%d", stored.Stage, stored.Progress)
+			}
+
+			restoredVideos := restoreVideoList(&stored)
+			if got := len(restoredVideos) > 0; got != tt.wantVideos {
+				t.Errorf("videos restored = %v, want %v", got, tt.wantVideos)
+			}
+
+			result, err := restoreScrapeResult(&stored, restoredVideos)
+			if err != nil {
+				t.Fatalf("restoreScrapeResult failed: %v", err)
+			}
+			if got := result != nil; got != tt.wantComments {
+				t.Errorf("comments restored = %v, want %v", got, tt.wantComments)
+			}
+			if result != nil && result.Stats.TotalVideos != len(videos) {
+				t.Errorf("expected %d videos in restored result, got %d", len(videos), result.Stats.TotalVideos)
+			}
+		})
+	}
+}
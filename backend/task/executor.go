@@ -13,21 +13,24 @@ import (
 	"fmt"
 	"log"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// modelPatterns 型号库未命中时的正则后备规则
+// 品牌专属的型号写法（如单字母前缀的 "V12 Detect Slim"）由型号库维护，这里只保留通用的"系列名+数字+后缀"写法
 var modelPatterns = []*regexp.Regexp{
-	regexp.MustCompile(`(?i)(iPhone|Galaxy|Pixel|Mate|Mi|Redmi|V|G|X|S)\s*(\d+)\s*(Pro|Max|Plus|Ultra|Detect|Slim)(\s+(Pro|Max|Plus|Ultra))?`),
+	regexp.MustCompile(`(?i)(iPhone|Galaxy|Pixel|Mate|Mi|Redmi)\s*(\d+)\s*(Pro|Max|Plus|Ultra|Detect|Slim)(\s+(Pro|Max|Plus|Ultra))?`),
 	regexp.MustCompile(`(?i)\b(Pura\s+(X|Max)|T[1-4]S?|SCOOPER(\s+SE)?|Young|M1(\s+Pro)?|T\s+Air)\b`),
 	regexp.MustCompile(`(?i)([A-Z]+)(\d+)\s*(Pro|Max|Plus|Ultra|Detect|Slim)`),
 	regexp.MustCompile(`(?i)\b([A-Z]+)(\d+)\b`),
-	regexp.MustCompile(`(?i)\s(Pro|Max|Plus|Ultra)\s`),
 }
 
 // AppSettings 应用配置（从数据库读取后的结构化配置）
 type AppSettings struct {
+	AIProvider                  string
 	AIBaseURL                   string
 	AIAPIKey                    string
 	AIModel                     string
@@ -90,20 +93,28 @@ func DefaultTaskConfig() TaskConfig {
 
 // TaskRequest 任务请求
 type TaskRequest struct {
-	TaskID      string         // 任务ID
-	Requirement string         // 用户原始需求
-	Brands      []string       // 品牌列表
-	Dimensions  []ai.Dimension // 评价维度
-	Keywords    []string       // 搜索关键词
+	TaskID         string         `json:"task_id"`         // 任务ID
+	Requirement    string         `json:"requirement"`     // 用户原始需求
+	Brands         []string       `json:"brands"`          // 品牌列表
+	Dimensions     []ai.Dimension `json:"dimensions"`      // 评价维度
+	Keywords       []string       `json:"keywords"`        // 搜索关键词
+	NoCache        bool           `json:"no_cache"`        // 跳过AI响应缓存（强制重新调用AI）
+	TokenBudget    int64          `json:"token_budget"`    // Token 预算（0 表示使用全局配置）
+	IncludeDanmaku bool           `json:"include_danmaku"` // 同时抓取视频弹幕作为补充评论来源
+
+	// Videos 指定分析的视频（单视频分析），非空时跳过搜索和相关性过滤；
+	// 此时 Brands 一般为空，报告保留评论中识别出的所有品牌
+	Videos []bilibili.VideoInfo `json:"videos,omitempty"`
 }
 
 // CommentWithVideo 带视频信息的评论
 type CommentWithVideo struct {
-	Content    string // 评论内容
-	VideoTitle string // 视频标题
-	VideoBVID  string // 视频BVID
-	Comment    bilibili.Comment
-	CommentKey string
+	Content     string    // 评论内容
+	VideoTitle  string    // 视频标题
+	VideoBVID   string    // 视频BVID
+	PublishTime time.Time // 评论发布时间
+	Comment     bilibili.Comment
+	CommentKey  string
 }
 
 // Executor 任务执行器
@@ -154,90 +165,96 @@ func (e *Executor) Execute(ctx context.Context, req TaskRequest) error {
 	log.Printf("[Task %s] Starting execution...", taskID)
 
 	// 阶段0：创建历史记录
-	history, err := e.createHistory(req, req.TaskID)
+	history, err := e.createHistory(req, req.TaskID, models.StatusProcessing, 0)
 	if err != nil {
 		sse.PushError(taskID, fmt.Sprintf("创建任务记录失败: %v", err))
 		return err
 	}
 	log.Printf("[Task %s] History created: ID=%d", taskID, history.ID)
 
-	// 阶段1：获取配置
-	sse.PushProgress(taskID, sse.StatusSearching, 0, 100, "正在加载配置...")
-	e.updateTaskProgress(history.ID, sse.StatusSearching, 0, "正在加载配置...")
+	return e.run(ctx, req, history)
+}
 
-	settings, err := e.loadSettings()
-	if err != nil {
-		e.updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, fmt.Sprintf("加载配置失败: %v", err))
-		return err
+// Resume 从已有的历史记录恢复任务
+// 根据 history.Stage 跳过已完成的阶段：
+//   - 已进入抓取阶段：复用保存的视频列表，不再重新搜索和相关性过滤
+//   - 已进入分析阶段：从 raw_comments 还原评论，不再重新抓取
+//   - 已保存AI分析结果的评论不再重复调用AI
+//
+// 队列中的新任务（Stage 为 initializing）也通过此方法执行，此时会完整执行所有阶段
+func (e *Executor) Resume(ctx context.Context, history *models.AnalysisHistory, req TaskRequest) error {
+	// 任务可能在出队后、开始执行前被取消
+	var current models.AnalysisHistory
+	if err := database.DB.Select("status").First(&current, history.ID).Error; err != nil {
+		return fmt.Errorf("load history failed: %w", err)
+	}
+	if current.Status == models.StatusCancelled {
+		log.Printf("[Task %s] Cancelled before execution, skipping", req.TaskID)
+		return context.Canceled
+	}
+	if ctx.Err() != nil {
+		e.fail(ctx, history.ID, req.TaskID, fmt.Sprintf("任务开始前中断: %v", ctx.Err()))
+		return ctx.Err()
 	}
 
-	// 阶段2：搜索视频
-	sse.PushProgress(taskID, sse.StatusSearching, 5, 100, "正在搜索相关视频...")
-	e.updateTaskProgress(history.ID, sse.StatusSearching, 5, "正在搜索相关视频...")
+	log.Printf("[Task %s] Resuming from stage %s (history ID=%d)", req.TaskID, history.Stage, history.ID)
+	e.updateHistoryStatus(history.ID, models.StatusProcessing)
+	return e.run(ctx, req, history)
+}
 
-	biliClient := bilibili.NewClient(settings.BilibiliCookie)
-	allVideos, err := e.searchVideos(ctx, taskID, biliClient, req.Keywords)
+// run 执行任务的各个阶段（Execute 和 Resume 共用）
+func (e *Executor) run(ctx context.Context, req TaskRequest, history *models.AnalysisHistory) error {
+	taskID := req.TaskID
+
+	// 阶段1：获取配置
+	e.startProgress(taskID, history)
+
+	settings, err := e.loadSettings()
 	if err != nil {
-		e.updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, fmt.Sprintf("搜索视频失败: %v", err))
+		e.fail(ctx, history.ID, taskID, fmt.Sprintf("加载配置失败: %v", err))
 		return err
 	}
 
-	if len(allVideos) == 0 {
-		e.updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, "未找到相关视频，请尝试其他关键词")
-		return fmt.Errorf("no videos found")
-	}
-
-	log.Printf("[Task %s] Found %d videos", taskID, len(allVideos))
-
-	// 阶段2.5：视频相关性过滤
-	sse.PushProgress(taskID, sse.StatusSearching, 18, 100, "正在过滤不相关视频...")
-	e.updateTaskProgress(history.ID, sse.StatusSearching, 18, "正在过滤不相关视频...")
-
+	biliClient := NewBilibiliClient(settings.BilibiliCookie, taskID)
 	aiClient := ai.NewClient(ai.Config{
-		APIBase: settings.AIBaseURL,
-		APIKey:  settings.AIAPIKey,
-		Model:   settings.AIModel,
+		Provider: settings.AIProvider,
+		APIBase:  settings.AIBaseURL,
+		APIKey:   settings.AIAPIKey,
+		Model:    settings.AIModel,
+		Cache:    NewAICache(req.NoCache),
 	})
 
-	videoTitles := make([]string, len(allVideos))
-	for i, v := range allVideos {
-		videoTitles[i] = v.Title
-	}
-
-	relevanceChecker := ai.NewVideoRelevanceChecker(aiClient)
-	relevantIndices, irrelevantVideos, err := relevanceChecker.BatchCheckRelevance(
-		ctx,
-		videoTitles,
-		req.Requirement,
-		5,
-	)
-	if err != nil {
-		log.Printf("[Task %s] 视频相关性检查失败: %v，继续使用所有视频", taskID, err)
+	// Token 用量统计：超出预算时取消任务上下文，已完成的分析结果会保留
+	ctx, usage, stopUsage := TrackUsage(ctx, history, aiClient, TokenBudget(req.TokenBudget))
+	defer stopUsage()
+	// 成功时在生成报告前保存用量；失败、取消等提前返回的路径在这里保存
+	usageSaved := false
+	defer func() {
+		if !usageSaved {
+			SaveTokenUsage(history.ID, usage, settings.AIModel)
+		}
+	}()
+
+	// 阶段2：搜索视频（恢复任务时优先复用已保存的视频列表）
+	allVideos := restoreVideoList(history)
+	if len(allVideos) > 0 {
+		log.Printf("[Task %s] Restored %d videos from checkpoint", taskID, len(allVideos))
+		sse.PushProgress(taskID, sse.StatusSearching, 18, 100,
+			fmt.Sprintf("已恢复搜索结果，共%d个视频", len(allVideos)))
 	} else {
-		if len(irrelevantVideos) > 0 {
-			log.Printf("[Task %s] 过滤 %d 个不相关视频", taskID, len(irrelevantVideos))
-			for _, info := range irrelevantVideos {
-				log.Printf("[Task %s]   - 标题: %s, 理由: %s", taskID, info["title"], info["reason"])
-			}
-
-			filteredVideos := make([]bilibili.VideoInfo, 0, len(relevantIndices))
-			for _, idx := range relevantIndices {
-				filteredVideos = append(filteredVideos, allVideos[idx])
-			}
-			allVideos = filteredVideos
-			log.Printf("[Task %s] 过滤后剩余 %d 个相关视频", taskID, len(allVideos))
+		if len(req.Videos) > 0 {
+			// 指定了视频：视频信息已包含商业推广声明、完整简介和 cid，无需再请求视频详情
+			allVideos = append([]bilibili.VideoInfo(nil), req.Videos...)
+			err = e.detectSponsored(ctx, taskID, history.ID, nil, aiClient, allVideos)
 		} else {
-			log.Printf("[Task %s] 所有视频均相关，无需过滤", taskID)
+			allVideos, err = e.searchAndFilterVideos(ctx, taskID, history.ID, biliClient, aiClient, req)
+		}
+		if err != nil {
+			return err
+		}
+		if err := saveVideoList(history.ID, allVideos); err != nil {
+			log.Printf("[Task %s] Failed to save video list: %v", taskID, err)
 		}
-	}
-
-	if len(allVideos) == 0 {
-		e.updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, "过滤后没有相关视频，请尝试其他关键词")
-		return fmt.Errorf("no relevant videos found after filtering")
 	}
 
 	// 阶段3：计算按比例分配
@@ -250,32 +267,56 @@ func (e *Executor) Execute(ctx context.Context, req TaskRequest) error {
 
 	log.Printf("[Task %s] Comment allocation calculated for %d videos", taskID, len(commentAllocation))
 
-	// 阶段4：抓取评论
-	sse.PushProgress(taskID, sse.StatusScraping, 20, 100, fmt.Sprintf("开始抓取%d个视频的评论...", len(allVideos)))
-	e.updateTaskProgress(history.ID, sse.StatusScraping, 20, fmt.Sprintf("开始抓取%d个视频的评论...", len(allVideos)))
+	// 阶段4：抓取评论（恢复任务时优先复用已保存的评论）
+	scrapeResult, err := restoreScrapeResult(history, allVideos)
+	if err != nil {
+		log.Printf("[Task %s] Failed to restore comments: %v", taskID, err)
+	} else if scrapeResult != nil {
+		log.Printf("[Task %s] Restored %d comments from checkpoint", taskID, scrapeResult.Stats.TotalComments)
+		sse.PushProgress(taskID, sse.StatusScraping, 50, 100,
+			fmt.Sprintf("已恢复抓取结果，共%d条评论", scrapeResult.Stats.TotalComments))
+	}
+	if scrapeResult == nil {
+		sse.PushProgress(taskID, sse.StatusScraping, 20, 100, fmt.Sprintf("开始抓取%d个视频的评论...", len(allVideos)))
+		e.updateTaskProgress(history.ID, sse.StatusScraping, 20, fmt.Sprintf("开始抓取%d个视频的评论...", len(allVideos)))
+
+		scraper := bilibili.NewScraper(biliClient, &bilibili.ScraperConfig{
+			MaxVideos:           len(allVideos),
+			MaxCommentsPerVideo: e.config.MaxCommentsPerVideo,
+			MaxConcurrency:      int64(e.config.MaxConcurrency),
+			FetchReplies:        true,
+			FetchDanmaku:        req.IncludeDanmaku,
+		})
 
-	scraper := bilibili.NewScraper(biliClient, &bilibili.ScraperConfig{
-		MaxVideos:           len(allVideos),
-		MaxCommentsPerVideo: e.config.MaxCommentsPerVideo,
-		MaxConcurrency:      int64(e.config.MaxConcurrency),
-		FetchReplies:        true,
-		RequestDelay:        200 * time.Millisecond,
-	})
+		scraper.SetProgressCallback(func(stage string, current, total int, message string) {
+			progress := 20 + (current * 30 / max(total, 1))
+			sse.PushProgress(taskID, sse.StatusScraping, progress, 100, message)
+		})
 
-	scraper.SetProgressCallback(func(stage string, current, total int, message string) {
-		progress := 20 + (current * 30 / max(total, 1))
-		sse.PushProgress(taskID, sse.StatusScraping, progress, 100, message)
-	})
+		scrapeResult, err = scraper.ScrapeByVideos(ctx, allVideos, commentAllocation)
+		if err != nil {
+			e.fail(ctx, history.ID, taskID, fmt.Sprintf("抓取评论失败: %v", err))
+			return err
+		}
 
-	scrapeResult, err := scraper.ScrapeByVideos(ctx, allVideos, commentAllocation)
-	if err != nil {
-		e.updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, fmt.Sprintf("抓取评论失败: %v", err))
-		return err
+		// 评论没有保存下来时不能进入分析阶段，否则恢复任务时会把空的 raw_comments 当作抓取结果
+		if err := saveScrapedComments(history.ID, scrapeResult); err != nil {
+			e.fail(ctx, history.ID, taskID, fmt.Sprintf("保存评论失败: %v", err))
+			return err
+		}
 	}
 
-	log.Printf("[Task %s] Scraped %d comments from %d videos",
-		taskID, scrapeResult.Stats.TotalComments, scrapeResult.Stats.TotalVideos)
+	log.Printf("[Task %s] Scraped %d comments and %d danmaku from %d videos",
+		taskID, scrapeResult.Stats.TotalComments, scrapeResult.Stats.TotalDanmaku, scrapeResult.Stats.TotalVideos)
+	if scrapeResult.Stats.TotalDanmaku > 0 {
+		sse.PushProgress(taskID, sse.StatusScraping, 50, 100,
+			fmt.Sprintf("已抓取%d条评论和%d条弹幕", scrapeResult.Stats.TotalComments, scrapeResult.Stats.TotalDanmaku))
+	}
+	if n := len(scrapeResult.Stats.Throttled); n > 0 {
+		log.Printf("[Task %s] %d videos partially scraped due to risk control: %v", taskID, n, scrapeResult.Stats.Throttled)
+		sse.PushProgress(taskID, sse.StatusScraping, 50, 100,
+			fmt.Sprintf("有%d个视频因B站风控仅抓取到部分评论，已保留已抓取的内容", n))
+	}
 
 	// 更新历史记录的统计信息
 	e.updateHistoryStats(history.ID, scrapeResult.Stats.TotalVideos, scrapeResult.Stats.TotalComments)
@@ -297,12 +338,24 @@ func (e *Executor) Execute(ctx context.Context, req TaskRequest) error {
 		MinComments:        settings.DiscoveryMinComments,
 		MinVideos:          settings.DiscoveryMinVideos,
 	}
-	analysisResults, err := e.analyzeComments(
-		ctx, taskID, aiClient, scrapeResult, req.Brands, req.Keywords, req.Dimensions, req.Requirement, discoveryCfg,
+	// 识别复制粘贴、跨视频刷评和品牌账号评论，exclude 模式下不送入AI分析
+	analysisInput, suspicious := ScreenSuspiciousComments(scrapeResult, req.Brands)
+	if suspicious != nil && suspicious.Total > 0 {
+		log.Printf("[Task %s] Flagged %d suspicious comments (excluded=%v)", taskID, suspicious.Total, suspicious.Excluded)
+		message := fmt.Sprintf("已标记%d条可疑评论", suspicious.Total)
+		if suspicious.Excluded {
+			message = fmt.Sprintf("已排除%d条可疑评论", suspicious.Total)
+		}
+		sse.PushProgress(taskID, sse.StatusAnalyzing, 50, 100, message)
+	}
+
+	brandDict := LoadBrandDictionary(req.Requirement)
+	modelCatalog := LoadModelCatalog(brandDict)
+	analysisResults, candidates, err := e.analyzeComments(
+		ctx, taskID, history.ID, aiClient, analysisInput, req.Brands, req.Keywords, req.Dimensions, req.Requirement, discoveryCfg, brandDict, modelCatalog,
 	)
 	if err != nil {
-		e.updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, fmt.Sprintf("AI分析失败: %v", err))
+		e.fail(ctx, history.ID, taskID, fmt.Sprintf("AI分析失败: %v", err))
 		return err
 	}
 
@@ -326,17 +379,21 @@ func (e *Executor) Execute(ctx context.Context, req TaskRequest) error {
 		Stats: report.ReportStats{
 			TotalVideos:     scrapeResult.Stats.TotalVideos,
 			TotalComments:   scrapeResult.Stats.TotalComments,
+			TotalDanmaku:    scrapeResult.Stats.TotalDanmaku,
 			CommentsByBrand: commentsByBrand,
 		},
-		Videos: scrapeResult.Videos,
+		Videos:           scrapeResult.Videos,
+		ModelCatalog:     modelCatalog,
+		Weighting:        LoadCommentWeighting(),
+		ExcludeSponsored: LoadSponsoredMode() == SponsoredModeExclude && len(req.Videos) == 0, // 单视频报告只标记商单，不排除评论
+		Suspicious:       suspicious,
 	}
 
 	log.Printf("[Executor] scrapeResult.Videos count: %d", len(scrapeResult.Videos))
 
 	reportData, err := report.GenerateReportWithInput(reportInput)
 	if err != nil {
-		e.updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, fmt.Sprintf("生成报告失败: %v", err))
+		e.fail(ctx, history.ID, taskID, fmt.Sprintf("生成报告失败: %v", err))
 		return err
 	}
 
@@ -346,6 +403,17 @@ func (e *Executor) Execute(ctx context.Context, req TaskRequest) error {
 	if err == nil && aiRecommendation != "" {
 		reportData.Recommendation = aiRecommendation
 	}
+	// 生成建议时超出预算不影响报告：保留基于评分生成的建议
+	if ctx.Err() != nil && !BudgetExceeded(ctx) {
+		e.fail(ctx, history.ID, taskID, fmt.Sprintf("生成报告中断: %v", ctx.Err()))
+		return ctx.Err()
+	}
+
+	tokenUsage := SaveTokenUsage(history.ID, usage, settings.AIModel)
+	usageSaved = true
+	reportData.TokenUsage = &tokenUsage
+	reportData.MergedAliases = brandDict.Merged(reportData.Brands)
+	reportData.CatalogModels = modelCatalog.Entries(append(slices.Clone(reportData.Brands), candidateBrands(candidates)...))
 
 	// 阶段6：保存报告到数据库
 	sse.PushProgress(taskID, sse.StatusGenerating, 95, 100, "正在保存报告...")
@@ -353,10 +421,12 @@ func (e *Executor) Execute(ctx context.Context, req TaskRequest) error {
 
 	reportID, err := e.saveReport(history.ID, reportData)
 	if err != nil {
-		e.updateHistoryStatus(history.ID, models.StatusFailed)
-		sse.PushError(taskID, fmt.Sprintf("保存报告失败: %v", err))
+		e.fail(ctx, history.ID, taskID, fmt.Sprintf("保存报告失败: %v", err))
 		return err
 	}
+	SaveReportComments(reportID, analysisResults)
+	RecordUnknownModels(reportID, req.Requirement, reportData.ModelRankings, modelCatalog)
+	saveBrandCandidates(history.ID, reportID, req.Requirement, candidates, brandDict)
 
 	// 更新历史记录状态为完成
 	e.updateHistoryWithReport(history.ID, reportID)
@@ -378,8 +448,118 @@ func (e *Executor) Execute(ctx context.Context, req TaskRequest) error {
 	return nil
 }
 
+// searchAndFilterVideos 搜索视频并使用AI过滤不相关视频
+func (e *Executor) searchAndFilterVideos(
+	ctx context.Context,
+	taskID string,
+	historyID uint,
+	biliClient *bilibili.Client,
+	aiClient *ai.Client,
+	req TaskRequest,
+) ([]bilibili.VideoInfo, error) {
+	sse.PushProgress(taskID, sse.StatusSearching, 5, 100, "正在搜索相关视频...")
+	e.updateTaskProgress(historyID, sse.StatusSearching, 5, "正在搜索相关视频...")
+
+	allVideos, err := e.searchVideos(ctx, taskID, biliClient, req.Keywords)
+	if err != nil {
+		e.fail(ctx, historyID, taskID, fmt.Sprintf("搜索视频失败: %v", err))
+		return nil, err
+	}
+
+	if len(allVideos) == 0 {
+		e.fail(ctx, historyID, taskID, "未找到相关视频，请尝试其他关键词")
+		return nil, fmt.Errorf("no videos found")
+	}
+
+	log.Printf("[Task %s] Found %d videos", taskID, len(allVideos))
+
+	// 视频相关性过滤
+	sse.PushProgress(taskID, sse.StatusSearching, 18, 100, "正在过滤不相关视频...")
+	e.updateTaskProgress(historyID, sse.StatusSearching, 18, "正在过滤不相关视频...")
+
+	videoTitles := make([]string, len(allVideos))
+	for i, v := range allVideos {
+		videoTitles[i] = v.Title
+	}
+
+	relevanceChecker := ai.NewVideoRelevanceChecker(aiClient)
+	relevantIndices, irrelevantVideos, err := relevanceChecker.BatchCheckRelevance(
+		ctx,
+		videoTitles,
+		req.Requirement,
+		5,
+	)
+	if ctx.Err() != nil {
+		// 任务已取消，不再继续后续阶段
+		e.fail(ctx, historyID, taskID, fmt.Sprintf("视频相关性检查中断: %v", ctx.Err()))
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		log.Printf("[Task %s] 视频相关性检查失败: %v，继续使用所有视频", taskID, err)
+	} else {
+		if len(irrelevantVideos) > 0 {
+			log.Printf("[Task %s] 过滤 %d 个不相关视频", taskID, len(irrelevantVideos))
+			for _, info := range irrelevantVideos {
+				log.Printf("[Task %s]   - 标题: %s, 理由: %s", taskID, info["title"], info["reason"])
+			}
+
+			filteredVideos := make([]bilibili.VideoInfo, 0, len(relevantIndices))
+			for _, idx := range relevantIndices {
+				filteredVideos = append(filteredVideos, allVideos[idx])
+			}
+			allVideos = filteredVideos
+			log.Printf("[Task %s] 过滤后剩余 %d 个相关视频", taskID, len(allVideos))
+		} else {
+			log.Printf("[Task %s] 所有视频均相关，无需过滤", taskID)
+		}
+	}
+
+	if len(allVideos) == 0 {
+		e.fail(ctx, historyID, taskID, "过滤后没有相关视频，请尝试其他关键词")
+		return nil, fmt.Errorf("no relevant videos found after filtering")
+	}
+
+	if err := e.detectSponsored(ctx, taskID, historyID, biliClient, aiClient, allVideos); err != nil {
+		return nil, err
+	}
+	return allVideos, nil
+}
+
+// detectSponsored 识别商单视频，结果写入 videos
+// 结果随视频列表保存，恢复任务时不再重复识别；biliClient 为 nil 时不请求视频详情
+func (e *Executor) detectSponsored(
+	ctx context.Context,
+	taskID string,
+	historyID uint,
+	biliClient *bilibili.Client,
+	aiClient *ai.Client,
+	videos []bilibili.VideoInfo,
+) error {
+	if LoadSponsoredMode() == SponsoredModeOff {
+		return nil
+	}
+
+	sse.PushProgress(taskID, sse.StatusSearching, 19, 100, "正在识别商单视频...")
+	e.updateTaskProgress(historyID, sse.StatusSearching, 19, "正在识别商单视频...")
+	DetectSponsoredVideos(ctx, biliClient, aiClient, videos)
+	if ctx.Err() != nil {
+		e.fail(ctx, historyID, taskID, fmt.Sprintf("商单识别中断: %v", ctx.Err()))
+		return ctx.Err()
+	}
+	var sponsored int
+	for _, v := range videos {
+		if v.Sponsored {
+			sponsored++
+			log.Printf("[Task %s]   - 商单: %s, 依据: %s", taskID, v.Title, strings.Join(v.SponsorSignals, "；"))
+		}
+	}
+	log.Printf("[Task %s] 识别出 %d 个商单视频", taskID, sponsored)
+	return nil
+}
+
 // createHistory 创建分析历史记录
-func (e *Executor) createHistory(req TaskRequest, taskID string) (*models.AnalysisHistory, error) {
+// status 为 pending 时表示任务进入队列等待执行
+func (e *Executor) createHistory(req TaskRequest, taskID, status string, priority int) (*models.AnalysisHistory, error) {
 	keywordsJSON, err := json.Marshal(req.Keywords)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal keywords: %w", err)
@@ -404,18 +584,26 @@ func (e *Executor) createHistory(req TaskRequest, taskID string) (*models.Analys
 		return nil, fmt.Errorf("failed to marshal config: %w", err)
 	}
 
+	// 序列化完整请求（保留维度描述，供出队和恢复使用）
+	requestJSON, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
 	history := &models.AnalysisHistory{
 		TaskID:        taskID,
 		Category:      req.Requirement,
 		Keywords:      string(keywordsJSON),
 		Brands:        string(brandsJSON),
 		Dimensions:    string(dimensionsJSON),
-		Status:        models.StatusProcessing,
+		Status:        status,
 		Stage:         "initializing",
 		Progress:      0,
 		ProgressMsg:   "任务初始化中...",
 		TaskConfig:    string(configJSON),
 		LastHeartbeat: time.Now(),
+		Priority:      priority,
+		Request:       string(requestJSON),
 	}
 
 	if err := database.DB.Create(history).Error; err != nil {
@@ -435,6 +623,7 @@ func (e *Executor) loadSettings() (*AppSettings, error) {
 	}
 
 	settings := &AppSettings{
+		AIProvider:                  getSettingValue(models.SettingKeyAIProvider),
 		AIBaseURL:                   getSettingValue(models.SettingKeyAIAPIBase),
 		AIAPIKey:                    getSettingValue(models.SettingKeyAIAPIKey),
 		AIModel:                     getSettingValue(models.SettingKeyAIModel),
@@ -446,11 +635,11 @@ func (e *Executor) loadSettings() (*AppSettings, error) {
 		DiscoveryMinVideos:          parseIntSetting(getSettingValue("brand_discovery_min_videos"), 2),
 	}
 
-	if settings.AIAPIKey == "" {
+	if settings.AIAPIKey == "" && ai.RequiresAPIKey(settings.AIProvider) {
 		return nil, fmt.Errorf("请先配置AI API Key")
 	}
-	if settings.BilibiliCookie == "" {
-		return nil, fmt.Errorf("请先配置B站Cookie")
+	if !HasBilibiliCookie(settings.BilibiliCookie) {
+		return nil, fmt.Errorf("请先配置B站Cookie（或账号池中没有可用账号）")
 	}
 	if settings.DiscoveryCandidateThreshold > settings.DiscoveryMainThreshold {
 		settings.DiscoveryCandidateThreshold = settings.DiscoveryMainThreshold
@@ -481,6 +670,24 @@ func (e *Executor) loadSettings() (*AppSettings, error) {
 	return settings, nil
 }
 
+// NewAICache 按配置创建AI响应缓存
+// 任务要求跳过缓存，或缓存有效期配置为0时返回 nil（不使用缓存）
+func NewAICache(bypass bool) ai.Cache {
+	if bypass {
+		return nil
+	}
+
+	ttlHours := int(database.DefaultAICacheTTL / time.Hour)
+	var setting models.Settings
+	if err := database.DB.Where("key = ?", models.SettingKeyAICacheTTLHours).First(&setting).Error; err == nil {
+		ttlHours = parseIntSetting(setting.Value, ttlHours)
+	}
+	if ttlHours <= 0 {
+		return nil
+	}
+	return database.NewAICacheStore(time.Duration(ttlHours) * time.Hour)
+}
+
 // searchVideos 搜索视频
 func (e *Executor) searchVideos(ctx context.Context, taskID string, client *bilibili.Client, keywords []string) ([]bilibili.VideoInfo, error) {
 	var allVideos []bilibili.VideoInfo
@@ -510,9 +717,6 @@ func (e *Executor) searchVideos(ctx context.Context, taskID string, client *bili
 				allVideos = append(allVideos, v)
 			}
 		}
-
-		// 添加延迟避免请求过快
-		time.Sleep(500 * time.Millisecond)
 	}
 
 	// 视频时间过滤：过滤掉发布时间超过指定月数的旧视频
@@ -607,9 +811,11 @@ func (e *Executor) calculateProportionalAllocation(
 }
 
 // analyzeComments 分析评论
+// 返回进入报告的品牌评论，以及进入候选池、等待人工审核的发现品牌
 func (e *Executor) analyzeComments(
 	ctx context.Context,
 	taskID string,
+	historyID uint,
 	aiClient *ai.Client,
 	scrapeResult *bilibili.ScrapeResult,
 	brands []string,
@@ -617,12 +823,14 @@ func (e *Executor) analyzeComments(
 	dimensions []ai.Dimension,
 	category string,
 	discoveryCfg brandDiscoveryConfig,
-) (map[string][]report.CommentWithScore, error) {
+	brandDict *comment.BrandDictionary,
+	modelCatalog *comment.ModelCatalog,
+) (map[string][]report.CommentWithScore, []brandCandidate, error) {
 
 	// 1. 使用 GetAllCommentsWithVideo 获取评论
 	allComments := GetAllCommentsWithVideo(scrapeResult)
 	if len(allComments) == 0 {
-		return nil, fmt.Errorf("没有获取到任何评论")
+		return nil, nil, fmt.Errorf("没有获取到任何评论")
 	}
 
 	// 2. 统一评论质量过滤（长度、纯符号、热度/关键词排序）
@@ -635,20 +843,22 @@ func (e *Executor) analyzeComments(
 		}
 	}
 
-	filteredComments := comment.FilterAndRank(rawComments, comment.FilterConfig{
+	// 弹幕最多占分析名额的 1/4，避免大量短弹幕挤占评论
+	filteredComments := comment.FilterAndRankWithSource(rawComments, comment.FilterConfig{
 		MaxComments: e.config.MaxComments,
 		Keywords:    keywords,
 		MinLength:   10,
 		FilterEmoji: true,
-	})
+	}, e.config.MaxComments/4)
 
 	if len(filteredComments) == 0 {
-		return nil, fmt.Errorf("过滤后没有有效评论")
+		return nil, nil, fmt.Errorf("过滤后没有有效评论")
 	}
 
 	// 3. 构建 AI 输入（按过滤后的优先级顺序）
 	var inputs []ai.CommentInput
 	commentVideoByID := make(map[string]string, len(filteredComments))
+	commentKeyByID := make(map[string]string, len(filteredComments))
 	for i, c := range filteredComments {
 		key := buildCommentKey(c)
 		meta, ok := commentMetaByKey[key]
@@ -661,17 +871,20 @@ func (e *Executor) analyzeComments(
 		}
 		commentID := fmt.Sprintf("comment_%d", i)
 		inputs = append(inputs, ai.CommentInput{
-			ID:         commentID,
-			Content:    meta.Content,
-			VideoTitle: meta.VideoTitle,
-			VideoBVID:  meta.VideoBVID,
+			ID:          commentID,
+			Content:     meta.Content,
+			VideoTitle:  meta.VideoTitle,
+			VideoBVID:   meta.VideoBVID,
+			Source:      c.Source,
+			PublishTime: meta.PublishTime,
 		})
 		commentVideoByID[commentID] = meta.VideoBVID
+		commentKeyByID[commentID] = key
 	}
 
 	// 如果过滤后没有评论，返回错误
 	if len(inputs) == 0 {
-		return nil, fmt.Errorf("过滤后没有有效评论")
+		return nil, nil, fmt.Errorf("过滤后没有有效评论")
 	}
 
 	log.Printf("[Task %s] Prepared %d comments for analysis", taskID, len(inputs))
@@ -679,10 +892,10 @@ func (e *Executor) analyzeComments(
 	sse.PushProgress(taskID, sse.StatusAnalyzing, 55, 100,
 		fmt.Sprintf("正在AI分析 %d 条评论...", len(inputs)))
 
-	// 3. AI 分析
-	analysisResults, err := aiClient.AnalyzeCommentsWithRateLimit(ctx, inputs, dimensions, e.config.AIConcurrency)
+	// 3. AI 分析（已保存分析结果的评论直接复用，避免恢复任务时重复调用AI）
+	analysisResults, err := e.analyzeWithCheckpoint(ctx, taskID, historyID, aiClient, inputs, dimensions, commentKeyByID)
 	if err != nil {
-		return nil, fmt.Errorf("AI分析失败: %w", err)
+		return nil, nil, err
 	}
 
 	// === 批量识别未知品牌 ===
@@ -725,6 +938,7 @@ func (e *Executor) analyzeComments(
 	}
 
 	// 更新分析结果中的品牌
+	rawBrands := make([]string, len(analysisResults)) // AI返回的原始品牌写法，用于记录别名归并
 	for i := range analysisResults {
 		r := &analysisResults[i]
 		brand := strings.TrimSpace(r.Brand)
@@ -733,90 +947,84 @@ func (e *Executor) analyzeComments(
 		// 如果品牌未知，尝试从AI识别结果获取
 		if (brand == "" || brand == "未知") && model != "" {
 			if identifiedBrand, ok := modelToBrand[model]; ok && identifiedBrand != "" && identifiedBrand != "未知" {
-				r.Brand = identifiedBrand
 				brand = identifiedBrand
 			}
 		}
 
-		// 格式化品牌名称（纯字母转大写）
-		if brand != "" {
-			r.Brand = formatBrandName(brand)
-		}
-
-		// AI分析返回后，清洗品牌和型号
-		analysisResults[i].Brand = comment.CleanBrandName(r.Brand, brands)
-		analysisResults[i].Model = comment.CleanModelName(r.Model)
+		// 通过品牌词典统一为标准名称（别名、英文名、拼音、"品牌A/品牌B"写法）
+		rawBrands[i] = brand
+		r.Brand = brandDict.Resolve(brand, brands)
+		r.Model = comment.CleanModelName(r.Model)
 	}
 
 	// === DISCOVERY MODE: 收集所有AI识别的品牌，不仅仅是用户指定的 ===
 
-	// 创建用户指定品牌的映射（用于分类，不是过滤）
-	specifiedBrands := make(map[string]string) // lowercase -> original
+	// 用户指定品牌的标准名称 key -> 用户填写的名称（用于分类，不是过滤）
+	// 报告中的指定品牌沿用用户填写的名称
+	specifiedBrands := make(map[string]string)
 	for _, brand := range brands {
-		specifiedBrands[strings.ToLower(brand)] = brand
+		specifiedBrands[comment.BrandKey(brandDict.Resolve(brand, nil))] = brand
 	}
 
+	// 未指定品牌（单视频分析）时保留评论中识别出的所有品牌
+	keepAllBrands := len(brands) == 0
+
 	// 分类收集结果：指定品牌 vs 发现的新品牌
 	specifiedResults := make(map[string][]report.CommentWithScore)
 	discoveredResults := make(map[string][]report.CommentWithScore)
 	discoverySignals := make(map[string]*brandDiscoverySignal)
 	discoveredVideoSets := make(map[string]map[string]struct{})
 
-	for _, r := range analysisResults {
+	for i, r := range analysisResults {
 		if r.Error != "" || r.Scores == nil {
 			continue
 		}
 
-		// 从AI结果获取品牌
+		// 从AI结果获取品牌（已归一化为标准名称）
 		brand := strings.TrimSpace(r.Brand)
-		brand = normalizeBrand(brand)
 		if brand == "" || brand == "未知" {
-			// 尝试从评论内容中匹配用户指定的品牌
-			contentLower := strings.ToLower(r.Content)
-			for specBrandLower, origBrand := range specifiedBrands {
-				if strings.Contains(contentLower, specBrandLower) {
-					brand = origBrand
-					break
-				}
+			// 尝试从评论内容中匹配用户指定的品牌（任一写法）
+			if mentioned := brandDict.FindInText(r.Content, brands); mentioned != "" {
+				brand = mentioned
 			}
 		}
 
+		if keepAllBrands && (brand == "" || brand == "未知") {
+			brand = "未知品牌"
+		}
 		if brand == "" {
 			continue // 仍然没有品牌则跳过
 		}
 
-		// 型号后备提取：仅在AI未提取到有效型号时使用正则匹配
-		model := r.Model
-		if model == "" || model == "未知" || model == "通用" {
-			model = extractModelFromContent(r.Content)
-		}
-
-		// 由于 CommentWithVideo 不包含时间信息，这里使用当前时间或默认值
-		// 注意：如果需要准确时间，需要在 CommentWithVideo 中添加 Time 字段
-		publishTime := time.Time{}
+		// 型号优先匹配型号库；AI未提取到有效型号时先在评论中查找型号库中的型号，再用正则后备提取
+		model := CanonicalModel(modelCatalog, brand, r.Model, r.Content)
 
 		commentItem := report.CommentWithScore{
 			Content:     r.Content,
 			Scores:      r.Scores,
+			Aspects:     r.Aspects,
 			Brand:       brand,
 			Model:       model,
-			PublishTime: publishTime,
-		}
-
-		// 分类：指定品牌还是发现的新品牌
-		brandLower := strings.ToLower(brand)
-		brand = normalizeBrand(brand)
-		brandLower = strings.ToLower(brand)
-		isSpecified := false
-		for specBrandLower, origBrand := range specifiedBrands {
-			if strings.Contains(brandLower, specBrandLower) || strings.Contains(specBrandLower, brandLower) {
-				specifiedResults[origBrand] = append(specifiedResults[origBrand], commentItem)
-				isSpecified = true
-				break
-			}
+			PublishTime: r.PublishTime,
+			Source:      r.Source,
+		}
+		if meta, ok := commentMetaByKey[commentKeyByID[r.CommentID]]; ok {
+			SetCommentEvidence(&commentItem, meta.VideoBVID, meta.Comment)
+		}
+
+		// 分类：指定品牌还是发现的新品牌（按标准名称完整匹配，不做子串匹配）
+		origBrand, isSpecified := specifiedBrands[comment.BrandKey(brand)]
+		if keepAllBrands {
+			origBrand, isSpecified = brand, true
+		}
+		if isSpecified {
+			commentItem.Brand = origBrand
+			specifiedResults[origBrand] = append(specifiedResults[origBrand], commentItem)
+			brandDict.RecordMerge(origBrand, rawBrands[i])
 		}
 
 		if !isSpecified && discoveryCfg.Enabled {
+			brandDict.RecordMerge(brand, rawBrands[i])
 			// 可选：发现新品牌模式开启时，保留新品牌。
 			discoveredResults[brand] = append(discoveredResults[brand], commentItem)
 			signal := discoverySignals[brand]
@@ -865,6 +1073,7 @@ func (e *Executor) analyzeComments(
 
 	// 合并结果：先指定品牌，再发现的品牌
 	results := make(map[string][]report.CommentWithScore)
+	var candidates []brandCandidate
 	for brand, comments := range specifiedResults {
 		results[brand] = comments
 		log.Printf("[Task %s] 指定品牌 %s: %d 条评论", taskID, brand, len(comments))
@@ -883,7 +1092,7 @@ func (e *Executor) analyzeComments(
 			continue
 		}
 
-		comments = sanitizeDiscoveredModels(comments)
+		comments = sanitizeDiscoveredModels(comments, modelCatalog)
 
 		switch {
 		case signal.Score >= discoveryCfg.MainThreshold:
@@ -891,6 +1100,7 @@ func (e *Executor) analyzeComments(
 			log.Printf("[Task %s] 发现品牌 %s 进入主榜: score=%.2f comments=%d coverage=%d",
 				taskID, brand, signal.Score, signal.CommentCount, signal.VideoCount)
 		case signal.Score >= discoveryCfg.CandidateThreshold:
+			candidates = append(candidates, brandCandidate{Brand: brand, Signal: *signal, Comments: comments})
 			log.Printf("[Task %s] 发现品牌 %s 进入候选池: score=%.2f comments=%d coverage=%d",
 				taskID, brand, signal.Score, signal.CommentCount, signal.VideoCount)
 		default:
@@ -899,6 +1109,57 @@ func (e *Executor) analyzeComments(
 		}
 	}
 
+	return results, candidates, nil
+}
+
+// analyzeWithCheckpoint AI分析评论，并持久化每条评论的分析结果
+// 已有分析结果的评论不再发送给AI，结果按 inputs 原顺序返回
+func (e *Executor) analyzeWithCheckpoint(
+	ctx context.Context,
+	taskID string,
+	historyID uint,
+	aiClient *ai.Client,
+	inputs []ai.CommentInput,
+	dimensions []ai.Dimension,
+	commentKeyByID map[string]string,
+) ([]ai.CommentAnalysisResult, error) {
+	saved := loadAnalysisResults(historyID)
+
+	results := make([]ai.CommentAnalysisResult, len(inputs))
+	var pending []ai.CommentInput
+	pendingIndex := make(map[string]int)
+	for i, input := range inputs {
+		if r, ok := saved[commentKeyByID[input.ID]]; ok {
+			r.CommentID = input.ID
+			r.Source = input.Source
+			r.PublishTime = input.PublishTime
+			results[i] = r
+			continue
+		}
+		pendingIndex[input.ID] = i
+		pending = append(pending, input)
+	}
+
+	if reused := len(inputs) - len(pending); reused > 0 {
+		log.Printf("[Task %s] Reused %d saved analysis results, %d comments left", taskID, reused, len(pending))
+	}
+
+	if len(pending) > 0 {
+		fresh, err := aiClient.AnalyzeCommentsWithRateLimit(ctx, pending, dimensions, e.config.AIConcurrency)
+		// 中途取消时也保存已完成批次的结果，下次恢复无需重复分析
+		if saveErr := saveAnalysisResults(historyID, fresh, commentKeyByID); saveErr != nil {
+			log.Printf("[Task %s] Failed to save analysis results: %v", taskID, saveErr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("AI分析失败: %w", err)
+		}
+		for _, r := range fresh {
+			if idx, ok := pendingIndex[r.CommentID]; ok {
+				results[idx] = r
+			}
+		}
+	}
+
 	return results, nil
 }
 
@@ -933,6 +1194,41 @@ func (e *Executor) saveReport(historyID uint, reportData *report.ReportData) (ui
 	return reportRecord.ID, nil
 }
 
+// SaveReportComments 保存报告的证据评论（每条评分评论及其来源），失败只记录日志，不影响报告
+func SaveReportComments(reportID uint, analysisResults map[string][]report.CommentWithScore) {
+	rows := report.BuildReportComments(reportID, analysisResults)
+	if err := database.SaveReportComments(rows); err != nil {
+		log.Printf("[Report %d] Failed to save %d evidence comments: %v", reportID, len(rows), err)
+	}
+}
+
+// fail 标记任务失败并推送错误信息
+// 任务已被用户取消时改为标记为已取消，并推送取消状态；超出 Token 预算时推送预算用尽的提示
+func (e *Executor) fail(ctx context.Context, historyID uint, taskID, message string) {
+	if IsCancelled(ctx) {
+		log.Printf("[Task %s] Cancelled: %s", taskID, message)
+		MarkCancelled(historyID, taskID)
+		return
+	}
+	if BudgetExceeded(ctx) {
+		log.Printf("[Task %s] Aborted by token budget: %s", taskID, message)
+		message = BudgetExceededMessage
+	}
+	e.updateHistoryStatus(historyID, models.StatusFailed)
+	sse.PushError(taskID, message)
+}
+
+// startProgress 推送任务开始的进度
+// 恢复任务时保留已持久化的阶段和进度，避免断点被重置为搜索阶段
+func (e *Executor) startProgress(taskID string, history *models.AnalysisHistory) {
+	if stageReached(history.Stage, sse.StatusSearching) {
+		sse.PushProgress(taskID, history.Stage, history.Progress, 100, "正在加载配置...")
+		return
+	}
+	sse.PushProgress(taskID, sse.StatusSearching, 0, 100, "正在加载配置...")
+	e.updateTaskProgress(history.ID, sse.StatusSearching, 0, "正在加载配置...")
+}
+
 // updateHistoryStatus 更新历史记录状态
 func (e *Executor) updateHistoryStatus(historyID uint, status string) {
 	if err := database.DB.Model(&models.AnalysisHistory{}).Where("id = ?", historyID).Update("status", status).Error; err != nil {
@@ -1017,62 +1313,6 @@ func (e *Executor) generateAIRecommendation(ctx context.Context, aiClient *ai.Cl
 	})
 }
 
-// normalizeBrand 品牌名称归一化
-// 处理常见的品牌别名，返回统一的品牌名称
-func normalizeBrand(brand string) string {
-	brandLower := strings.ToLower(strings.TrimSpace(brand))
-
-	// 品牌别名映射
-	aliases := map[string][]string{
-		"苹果":      {"apple", "iphone", "ipad", "mac", "airpods"},
-		"戴森":      {"dyson"},
-		"小米":      {"xiaomi", "mi", "redmi"},
-		"华为":      {"huawei", "honor", "荣耀"},
-		"三星":      {"samsung", "galaxy"},
-		"索尼":      {"sony", "playstation", "ps5"},
-		"小佩":      {"petkit"},
-		"CATLINK": {"catlink", "猫猫狗狗"},
-	}
-
-	for normalized, aliasList := range aliases {
-		for _, alias := range aliasList {
-			if strings.Contains(brandLower, alias) || strings.Contains(alias, brandLower) {
-				return normalized
-			}
-		}
-		// 也检查归一化名称本身
-		if strings.Contains(brandLower, strings.ToLower(normalized)) {
-			return normalized
-		}
-	}
-
-	return brand // 返回原始名称
-}
-
-// formatBrandName 格式化品牌名称
-// 纯字母品牌转全大写，中文品牌保持原样
-func formatBrandName(brand string) string {
-	brand = strings.TrimSpace(brand)
-	if brand == "" {
-		return brand
-	}
-
-	// 检查是否为纯字母（ASCII字母）
-	isPureAlpha := true
-	for _, r := range brand {
-		if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')) {
-			isPureAlpha = false
-			break
-		}
-	}
-
-	if isPureAlpha {
-		return strings.ToUpper(brand)
-	}
-
-	return brand
-}
-
 // extractModelFromContent 从评论内容中提取型号（正则匹配后备方案）
 // 仅在AI未能提取型号时使用
 // 参数：
@@ -1082,8 +1322,9 @@ func formatBrandName(brand string) string {
 //   - 提取到的型号，如果未找到则返回空字符串
 func extractModelFromContent(content string) string {
 	for _, re := range modelPatterns {
-		if match := re.FindString(content); match != "" {
-			return strings.TrimSpace(match)
+		// 只匹配到 "Pro"、"Max" 等系列后缀时不算型号
+		if match := strings.TrimSpace(re.FindString(content)); match != "" && !comment.IsGenericModel(match) {
+			return match
 		}
 	}
 	return ""
@@ -1128,20 +1369,22 @@ func GetAllCommentsWithVideo(result *bilibili.ScrapeResult) []CommentWithVideo {
 		for _, c := range videoComments {
 			cKey := buildCommentKey(c)
 			comments = append(comments, CommentWithVideo{
-				Content:    c.Content.Message,
-				VideoTitle: videoTitle,
-				VideoBVID:  bvid,
-				Comment:    c,
-				CommentKey: cKey,
+				Content:     c.Content.Message,
+				VideoTitle:  videoTitle,
+				VideoBVID:   bvid,
+				PublishTime: c.PublishTime(),
+				Comment:     c,
+				CommentKey:  cKey,
 			})
 			for _, r := range c.Replies {
 				rKey := buildCommentKey(r)
 				comments = append(comments, CommentWithVideo{
-					Content:    r.Content.Message,
-					VideoTitle: videoTitle,
-					VideoBVID:  bvid,
-					Comment:    r,
-					CommentKey: rKey,
+					Content:     r.Content.Message,
+					VideoTitle:  videoTitle,
+					VideoBVID:   bvid,
+					PublishTime: r.PublishTime(),
+					Comment:     r,
+					CommentKey:  rKey,
 				})
 			}
 		}
@@ -1150,7 +1393,23 @@ func GetAllCommentsWithVideo(result *bilibili.ScrapeResult) []CommentWithVideo {
 	return comments
 }
 
+// SetCommentEvidence 记录评论的来源信息（视频、作者、点赞数）和评论加权所需的回复数、楼层、账号等级，用于报告追溯原评论
+func SetCommentEvidence(item *report.CommentWithScore, bvid string, c bilibili.Comment) {
+	item.RPID = c.RPID
+	item.VideoBVID = bvid
+	item.Mid = c.Mid
+	item.Author = c.Member.Uname
+	item.Like = c.Like
+	item.ReplyCount = c.RCount
+	item.IsReply = c.Root != 0
+	item.AuthorLevel = c.Member.LevelInfo.CurrentLevel
+}
+
 func buildCommentKey(c bilibili.Comment) string {
+	// 弹幕没有 RPID，使用弹幕ID
+	if c.IsDanmaku() {
+		return fmt.Sprintf("dm_%d", c.DanmakuID)
+	}
 	// 正常情况下 RPID 全局唯一；若异常缺失，退化为内容+时间组合键。
 	if c.RPID > 0 {
 		return fmt.Sprintf("rpid_%d", c.RPID)
@@ -1166,7 +1425,8 @@ func computeDiscoveryScore(signal brandDiscoverySignal) float64 {
 	return 0.35*commentScore + 0.25*videoScore + 0.20*categoryScore + 0.20*modelScore
 }
 
-func sanitizeDiscoveredModels(comments []report.CommentWithScore) []report.CommentWithScore {
+// sanitizeDiscoveredModels 发现品牌的型号只出现一次或不像型号时改为"通用"，型号库中的型号始终保留
+func sanitizeDiscoveredModels(comments []report.CommentWithScore, catalog *comment.ModelCatalog) []report.CommentWithScore {
 	if len(comments) == 0 {
 		return comments
 	}
@@ -1186,6 +1446,9 @@ func sanitizeDiscoveredModels(comments []report.CommentWithScore) []report.Comme
 		if model == "" || model == "未知" || model == "通用" {
 			continue
 		}
+		if _, ok := catalog.Match(out[i].Brand, model); ok {
+			continue
+		}
 		if modelCount[strings.ToLower(model)] < 2 || !isLikelyModelText(model) {
 			out[i].Model = "通用"
 		}
@@ -1195,7 +1458,7 @@ func sanitizeDiscoveredModels(comments []report.CommentWithScore) []report.Comme
 
 func isLikelyModelText(model string) bool {
 	model = strings.TrimSpace(model)
-	if model == "" || len([]rune(model)) < 2 {
+	if len([]rune(model)) < 2 || comment.IsGenericModel(model) {
 		return false
 	}
 	for _, re := range modelPatterns {
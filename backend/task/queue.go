@@ -0,0 +1,301 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/sse"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 队列排序方式
+const (
+	QueueOrderFIFO     = "fifo"     // 先进先出
+	QueueOrderPriority = "priority" // 按优先级（高优先级先执行，同优先级先进先出）
+)
+
+const (
+	defaultQueueWorkers = 1               // 默认同时执行的任务数（共用同一个B站Cookie，默认串行）
+	queuePollInterval   = 5 * time.Second // 兜底轮询间隔（防止漏掉唤醒信号）
+)
+
+// queueWake 唤醒调度器的信号（有新任务入队、任务被取消时发送）
+var queueWake = make(chan struct{}, 1)
+
+// notifyQueue 唤醒调度器，非阻塞
+func notifyQueue() {
+	select {
+	case queueWake <- struct{}{}:
+	default:
+	}
+}
+
+// queueSettings 队列配置
+type queueSettings struct {
+	Workers int    // 同时执行的任务数
+	Order   string // 排序方式
+}
+
+// loadQueueSettings 读取队列配置，每轮调度都会重新读取，修改配置后无需重启
+func loadQueueSettings() queueSettings {
+	getSettingValue := func(key string) string {
+		var setting models.Settings
+		if err := database.DB.Where("key = ?", key).First(&setting).Error; err != nil {
+			return ""
+		}
+		return setting.Value
+	}
+
+	settings := queueSettings{
+		Workers: parseIntSetting(getSettingValue(models.SettingKeyQueueWorkers), defaultQueueWorkers),
+		Order:   getSettingValue(models.SettingKeyQueueOrder),
+	}
+	if settings.Workers < 1 {
+		settings.Workers = defaultQueueWorkers
+	}
+	if settings.Order != QueueOrderPriority {
+		settings.Order = QueueOrderFIFO
+	}
+	return settings
+}
+
+// queueOrderClause 返回排队任务的排序子句
+func queueOrderClause(order string) string {
+	if order == QueueOrderPriority {
+		return "priority DESC, created_at ASC, id ASC"
+	}
+	return "created_at ASC, id ASC"
+}
+
+// Enqueue 将分析任务加入队列
+// 任务以 pending 状态写入 analysis_history，由 StartQueue 启动的调度器按配置的并发数依次执行
+// 参数：
+//   - req: 任务请求
+//   - config: 任务配置（nil 使用默认配置）
+//   - priority: 优先级（数值越大越先执行，仅在 priority 排序模式下生效）
+//
+// 返回：
+//   - *models.AnalysisHistory: 新建的历史记录
+//   - error: 写入数据库失败时返回错误
+func Enqueue(req TaskRequest, config *TaskConfig, priority int) (*models.AnalysisHistory, error) {
+	executor := NewExecutor(config)
+	history, err := executor.createHistory(req, req.TaskID, models.StatusPending, priority)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Queue] Task %s enqueued (history ID=%d, priority=%d)", req.TaskID, history.ID, priority)
+	notifyQueue()
+	return history, nil
+}
+
+// QueuePosition 获取任务在队列中的位置（从1开始）
+// 任务不在队列中（已开始执行或不存在）时返回 0
+func QueuePosition(taskID string) int {
+	ids, err := pendingTaskIDs(loadQueueSettings().Order)
+	if err != nil {
+		return 0
+	}
+	for i, id := range ids {
+		if id == taskID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// CancelQueued 取消排队中（尚未开始执行）的任务
+// 返回 false 表示任务不在队列中
+func CancelQueued(taskID string) bool {
+	var history models.AnalysisHistory
+	if err := database.DB.Where("task_id = ? AND status = ?", taskID, models.StatusPending).First(&history).Error; err != nil {
+		return false
+	}
+
+	// 条件更新，避免与调度器出队产生竞争
+	result := database.DB.Model(&models.AnalysisHistory{}).
+		Where("id = ? AND status = ?", history.ID, models.StatusPending).
+		Updates(map[string]interface{}{
+			"status":       models.StatusCancelled,
+			"progress_msg": "任务已取消",
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
+
+	sse.PushStatus(taskID, sse.TaskStatus{
+		TaskID:  taskID,
+		Status:  sse.StatusCancelled,
+		Message: "任务已取消",
+	})
+	sse.CloseTaskChannel(taskID)
+	notifyQueue()
+	return true
+}
+
+// StartQueue 启动任务队列调度器（阻塞运行，应在 goroutine 中调用）
+// 按配置的并发数从 analysis_history 中取出 pending 任务执行，并通过SSE推送排队位置
+func StartQueue() {
+	log.Println("[Queue] Scheduler started")
+
+	finished := make(chan struct{})
+	ticker := time.NewTicker(queuePollInterval)
+	defer ticker.Stop()
+
+	running := 0
+	lastPositions := make(map[string]int)
+
+	for {
+		settings := loadQueueSettings()
+
+		running = fillWorkers(settings, running, func(c *claimedTask) {
+			go func() {
+				defer func() { finished <- struct{}{} }()
+				runQueuedTask(c)
+			}()
+		})
+
+		lastPositions = broadcastQueuePositions(settings.Order, lastPositions)
+
+		select {
+		case <-finished:
+			running--
+		case <-queueWake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// fillWorkers 填满空闲的执行槽位
+// 依次出队任务并交给 start 执行，返回填充后正在执行的任务数
+func fillWorkers(settings queueSettings, running int, start func(*claimedTask)) int {
+	for running < settings.Workers {
+		claimed, err := claimNextTask(settings.Order)
+		if err != nil {
+			log.Printf("[Queue] Failed to claim task: %v", err)
+			break
+		}
+		if claimed == nil {
+			break
+		}
+
+		running++
+		log.Printf("[Queue] Task %s started (%d/%d workers busy)", claimed.history.TaskID, running, settings.Workers)
+		start(claimed)
+	}
+	return running
+}
+
+// claimedTask 已出队的任务
+type claimedTask struct {
+	history models.AnalysisHistory
+	ctx     context.Context // 已登记的可取消上下文
+	done    func()          // 任务结束时调用，释放登记
+}
+
+// claimNextTask 取出下一个排队任务并标记为 processing
+// 任务在标记为 processing 之前就已登记取消函数，出队后收到的取消请求总能取消任务上下文
+// 队列为空时返回 nil
+func claimNextTask(order string) (*claimedTask, error) {
+	var history models.AnalysisHistory
+	err := database.DB.Where("status = ?", models.StatusPending).
+		Order(queueOrderClause(order)).
+		First(&history).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 登记任务，支持通过 /api/task/:id/cancel 取消
+	ctx, done := Register(context.Background(), history.TaskID)
+
+	// 条件更新，任务在此期间被取消时放弃本次出队
+	result := database.DB.Model(&models.AnalysisHistory{}).
+		Where("id = ? AND status = ?", history.ID, models.StatusPending).
+		Updates(map[string]interface{}{
+			"status":         models.StatusProcessing,
+			"last_heartbeat": time.Now(),
+		})
+	if result.Error != nil {
+		done()
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		done()
+		notifyQueue()
+		return nil, nil
+	}
+
+	history.Status = models.StatusProcessing
+	return &claimedTask{history: history, ctx: ctx, done: done}, nil
+}
+
+// runQueuedTask 执行出队的任务
+// 新任务从头执行；重启前中断的任务从最后完成的阶段继续
+func runQueuedTask(claimed *claimedTask) {
+	defer claimed.done()
+	history := claimed.history
+	taskID := history.TaskID
+
+	if _, exists := sse.GetTaskChannel(taskID); !exists {
+		sse.CreateTaskChannel(taskID)
+	}
+	defer sse.CloseTaskChannel(taskID)
+
+	req, config, err := requestFromHistory(&history)
+	if err != nil {
+		log.Printf("[Queue] Task %s has invalid request: %v", taskID, err)
+		database.DB.Model(&history).Update("status", models.StatusFailed)
+		sse.PushError(taskID, fmt.Sprintf("任务参数无效: %v", err))
+		return
+	}
+
+	if stageReached(history.Stage, sse.StatusSearching) {
+		sse.PushProgress(taskID, history.Stage, history.Progress, 100,
+			"任务恢复中: "+history.ProgressMsg)
+	}
+
+	executor := NewExecutor(&config)
+	if err := executor.Resume(claimed.ctx, &history, req); err != nil {
+		log.Printf("[Queue] Task %s finished with error: %v", taskID, err)
+	}
+}
+
+// pendingTaskIDs 按出队顺序返回所有排队任务的ID
+func pendingTaskIDs(order string) ([]string, error) {
+	var ids []string
+	err := database.DB.Model(&models.AnalysisHistory{}).
+		Where("status = ?", models.StatusPending).
+		Order(queueOrderClause(order)).
+		Pluck("task_id", &ids).Error
+	return ids, err
+}
+
+// broadcastQueuePositions 向排队中的任务推送当前排队位置
+// 只推送位置发生变化的任务，返回最新的位置表
+func broadcastQueuePositions(order string, last map[string]int) map[string]int {
+	ids, err := pendingTaskIDs(order)
+	if err != nil {
+		log.Printf("[Queue] Failed to load pending tasks: %v", err)
+		return last
+	}
+
+	positions := make(map[string]int, len(ids))
+	for i, id := range ids {
+		position := i + 1
+		positions[id] = position
+		if last[id] == position {
+			continue
+		}
+		sse.PushProgress(id, sse.StatusQueued, position, len(ids),
+			fmt.Sprintf("排队中，前面还有 %d 个任务", position-1))
+	}
+	return positions
+}
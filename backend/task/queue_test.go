@@ -0,0 +1,253 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"testing"
+	"time"
+)
+
+// enqueueTestTask 写入一个排队任务，createdAt 用于控制先进先出顺序
+func enqueueTestTask(t *testing.T, taskID string, priority int, createdAt time.Time) *models.AnalysisHistory {
+	t.Helper()
+	history := &models.AnalysisHistory{
+		TaskID:    taskID,
+		Category:  "吸尘器",
+		Status:    models.StatusPending,
+		Stage:     "initializing",
+		Priority:  priority,
+		Request:   `{"requirement":"吸尘器","brands":["戴森"],"keywords":["吸尘器"]}`,
+		CreatedAt: createdAt,
+	}
+	if err := database.DB.Create(history).Error; err != nil {
+		t.Fatalf("create history failed: %v", err)
+	}
+	return history
+}
+
+func setTestSetting(t *testing.T, key, value string) {
+	t.Helper()
+	if err := database.DB.Create(&models.Settings{Key: key, Value: value}).Error; err != nil {
+		t.Fatalf("save setting failed: %v", err)
+	}
+}
+
+func TestLoadQueueSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		workers     string
+		order       string
+		wantWorkers int
+		wantOrder   string
+	}{
+		{"defaults", "", "", defaultQueueWorkers, QueueOrderFIFO},
+		{"configured", "3", QueueOrderPriority, 3, QueueOrderPriority},
+		{"invalid workers", "0", "random", defaultQueueWorkers, QueueOrderFIFO},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDB(t)
+			if tt.workers != "" {
+				setTestSetting(t, models.SettingKeyQueueWorkers, tt.workers)
+			}
+			if tt.order != "" {
+				setTestSetting(t, models.SettingKeyQueueOrder, tt.order)
+			}
+
+			got := loadQueueSettings()
+			if got.Workers != tt.wantWorkers || got.Order != tt.wantOrder {
+				t.Errorf("loadQueueSettings() = %+v, want workers=%d order=%s", got, tt.wantWorkers, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestClaimOrder(t *testing.T) {
+	base := time.Now().Add(-time.Hour)
+	tests := []struct {
+		name  string
+		order string
+		want  []string
+	}{
+		{"fifo", QueueOrderFIFO, []string{"low-old", "high-new", "high-newest"}},
+		{"priority", QueueOrderPriority, []string{"high-new", "high-newest", "low-old"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDB(t)
+			enqueueTestTask(t, "low-old", 0, base)
+			enqueueTestTask(t, "high-new", 5, base.Add(time.Minute))
+			enqueueTestTask(t, "high-newest", 5, base.Add(2*time.Minute))
+
+			if ids, _ := pendingTaskIDs(tt.order); len(ids) != len(tt.want) || ids[0] != tt.want[0] {
+				t.Errorf("pendingTaskIDs(%s) = %v, want %v", tt.order, ids, tt.want)
+			}
+
+			for _, want := range tt.want {
+				claimed, err := claimNextTask(tt.order)
+				if err != nil {
+					t.Fatalf("claimNextTask failed: %v", err)
+				}
+				if claimed == nil {
+					t.Fatalf("expected %s, queue is empty", want)
+				}
+				claimed.done()
+				if claimed.history.TaskID != want {
+					t.Errorf("claimed %s, want %s", claimed.history.TaskID, want)
+				}
+				if claimed.history.Status != models.StatusProcessing {
+					t.Errorf("claimed task status = %s, want processing", claimed.history.Status)
+				}
+			}
+
+			claimed, err := claimNextTask(tt.order)
+			if err != nil || claimed != nil {
+				t.Errorf("expected empty queue, got %+v, %v", claimed, err)
+			}
+		})
+	}
+}
+
+func TestFillWorkersRespectsConcurrencyLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		workers     int
+		running     int
+		queued      int
+		wantStarted int
+	}{
+		{"single worker", 1, 0, 3, 1},
+		{"several workers", 2, 0, 3, 2},
+		{"slots already busy", 2, 2, 3, 0},
+		{"fewer tasks than slots", 4, 1, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDB(t)
+			base := time.Now().Add(-time.Hour)
+			for i := 0; i < tt.queued; i++ {
+				enqueueTestTask(t, tt.name+string(rune('a'+i)), 0, base.Add(time.Duration(i)*time.Minute))
+			}
+
+			var started []*claimedTask
+			settings := queueSettings{Workers: tt.workers, Order: QueueOrderFIFO}
+			running := fillWorkers(settings, tt.running, func(c *claimedTask) {
+				started = append(started, c)
+			})
+			for _, c := range started {
+				c.done()
+			}
+
+			if len(started) != tt.wantStarted {
+				t.Errorf("started %d tasks, want %d", len(started), tt.wantStarted)
+			}
+			if running != tt.running+tt.wantStarted {
+				t.Errorf("running = %d, want %d", running, tt.running+tt.wantStarted)
+			}
+			if ids, _ := pendingTaskIDs(QueueOrderFIFO); len(ids) != tt.queued-tt.wantStarted {
+				t.Errorf("%d tasks left in queue, want %d", len(ids), tt.queued-tt.wantStarted)
+			}
+		})
+	}
+}
+
+func TestRecoverIncompleteTasks(t *testing.T) {
+	setupTestDB(t)
+
+	rows := []struct {
+		taskID     string
+		status     string
+		heartbeat  time.Time
+		wantStatus string
+	}{
+		{"recent", models.StatusProcessing, time.Now().Add(-time.Minute), models.StatusPending},
+		{"no-heartbeat", models.StatusProcessing, time.Time{}, models.StatusPending},
+		{"stale", models.StatusProcessing, time.Now().Add(-2 * time.Hour), models.StatusFailed},
+		{"queued", models.StatusPending, time.Now(), models.StatusPending},
+		{"done", models.StatusCompleted, time.Now().Add(-2 * time.Hour), models.StatusCompleted},
+	}
+	for _, r := range rows {
+		history := &models.AnalysisHistory{
+			TaskID:        r.taskID,
+			Category:      "吸尘器",
+			Status:        r.status,
+			Stage:         "analyzing",
+			LastHeartbeat: r.heartbeat,
+		}
+		if err := database.DB.Create(history).Error; err != nil {
+			t.Fatalf("create history failed: %v", err)
+		}
+	}
+
+	RecoverIncompleteTasks()
+
+	for _, r := range rows {
+		var history models.AnalysisHistory
+		database.DB.Where("task_id = ?", r.taskID).First(&history)
+		if history.Status != r.wantStatus {
+			t.Errorf("task %s status = %s, want %s", r.taskID, history.Status, r.wantStatus)
+		}
+		if history.Stage != "analyzing" {
+			t.Errorf("task %s stage = %s, recovery should keep the checkpoint", r.taskID, history.Stage)
+		}
+	}
+}
+
+// TestCancelBetweenClaimAndRun 出队后、开始执行前收到的取消请求不应被执行器覆盖
+func TestCancelBetweenClaimAndRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		cancel func(history models.AnalysisHistory) bool
+	}{
+		{
+			// HandleCancelTask 优先取消已登记的任务上下文
+			name: "registered context",
+			cancel: func(history models.AnalysisHistory) bool {
+				return Cancel(history.TaskID)
+			},
+		},
+		{
+			// 没有执行者的 processing 记录直接标记为已取消
+			name: "marked cancelled",
+			cancel: func(history models.AnalysisHistory) bool {
+				MarkCancelled(history.ID, history.TaskID)
+				return true
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDB(t)
+			enqueueTestTask(t, "cancel-"+tt.name, 0, time.Now())
+
+			claimed, err := claimNextTask(QueueOrderFIFO)
+			if err != nil || claimed == nil {
+				t.Fatalf("claimNextTask failed: %+v, %v", claimed, err)
+			}
+			if !IsRunning(claimed.history.TaskID) {
+				t.Fatal("claimed task should be registered for cancellation")
+			}
+			if !tt.cancel(claimed.history) {
+				t.Fatal("cancel request was not accepted")
+			}
+
+			runQueuedTask(claimed)
+
+			var history models.AnalysisHistory
+			database.DB.First(&history, claimed.history.ID)
+			if history.Status != models.StatusCancelled {
+				t.Errorf("status = %s, want cancelled", history.Status)
+			}
+			if history.Stage != "initializing" {
+				t.Errorf("stage = %s, cancelled task should not start running", history.Stage)
+			}
+			if IsRunning(claimed.history.TaskID) {
+				t.Error("task registration should be released after run")
+			}
+		})
+	}
+}
@@ -4,15 +4,16 @@ import (
 	"bilibili-analyzer/backend/ai"
 	"bilibili-analyzer/backend/database"
 	"bilibili-analyzer/backend/models"
-	"bilibili-analyzer/backend/sse"
-	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"time"
 )
 
 // RecoverIncompleteTasks 恢复未完成的任务
-// 在后端启动时调用，检查 processing 状态的任务并尝试恢复
+// 在后端启动时、任务队列启动前调用：
+//   - processing 状态的任务（重启前正在执行）重新放回队列，出队后从最后完成的阶段继续
+//   - pending 状态的任务本身就在队列中，无需处理
 func RecoverIncompleteTasks() {
 	var tasks []models.AnalysisHistory
 
@@ -30,9 +31,7 @@ func RecoverIncompleteTasks() {
 		// 检查 LastHeartbeat 是否为零值（可能是旧数据或数据库迁移问题）
 		if task.LastHeartbeat.IsZero() {
 			log.Printf("[Recovery] Task %s has zero LastHeartbeat (old data?), skipping timeout check and attempting recovery", task.TaskID)
-			// 更新 LastHeartbeat 为当前时间，然后尝试恢复
-			database.DB.Model(&task).Update("last_heartbeat", time.Now())
-			go recoverTask(task)
+			requeueTask(task)
 			continue
 		}
 
@@ -47,76 +46,74 @@ func RecoverIncompleteTasks() {
 
 		log.Printf("[Recovery] Task %s is recent (last heartbeat: %v ago), attempting recovery",
 			task.TaskID, timeSinceHeartbeat)
-		// 尝试恢复任务
-		go recoverTask(task)
+		requeueTask(task)
 	}
 }
 
-func recoverTask(history models.AnalysisHistory) {
-	taskID := history.TaskID
-	log.Printf("[Recovery] Recovering task %s from stage %s", taskID, history.Stage)
-
-	// 创建 SSE 通道
-	sse.CreateTaskChannel(taskID)
-	defer sse.CloseTaskChannel(taskID)
+// requeueTask 将中断的任务放回队列
+// 保留 Stage 等断点信息，出队后从最后完成的阶段继续执行
+func requeueTask(history models.AnalysisHistory) {
+	if err := database.DB.Model(&history).Updates(map[string]interface{}{
+		"status":         models.StatusPending,
+		"last_heartbeat": time.Now(),
+	}).Error; err != nil {
+		log.Printf("[Recovery] Failed to requeue task %s: %v", history.TaskID, err)
+		return
+	}
+	log.Printf("[Recovery] Task %s requeued from stage %s", history.TaskID, history.Stage)
+	notifyQueue()
+}
 
+// requestFromHistory 从历史记录还原任务请求和配置
+// 优先使用完整请求 JSON；旧数据没有保存完整请求时从各字段还原（维度描述为空）
+func requestFromHistory(history *models.AnalysisHistory) (TaskRequest, TaskConfig, error) {
 	// 解析任务配置
-	var config TaskConfig
+	config := DefaultTaskConfig()
 	if history.TaskConfig != "" {
 		if err := json.Unmarshal([]byte(history.TaskConfig), &config); err != nil {
-			log.Printf("[Recovery] Failed to unmarshal task config for task %s: %v, using defaults", taskID, err)
+			log.Printf("[Recovery] Failed to unmarshal task config for task %s: %v, using defaults", history.TaskID, err)
 			config = DefaultTaskConfig()
 		}
-	} else {
-		config = DefaultTaskConfig()
+	}
+
+	if history.Request != "" {
+		var req TaskRequest
+		if err := json.Unmarshal([]byte(history.Request), &req); err == nil {
+			req.TaskID = history.TaskID
+			return req, config, nil
+		}
+		log.Printf("[Recovery] Failed to unmarshal request for task %s, falling back to history fields", history.TaskID)
 	}
 
 	// 解析任务请求参数
 	var keywords, brands []string
 	if err := json.Unmarshal([]byte(history.Keywords), &keywords); err != nil {
-		log.Printf("[Recovery] Failed to unmarshal keywords for task %s: %v", taskID, err)
-		database.DB.Model(&history).Update("status", models.StatusFailed)
-		return
+		return TaskRequest{}, config, fmt.Errorf("unmarshal keywords failed: %w", err)
 	}
 	if err := json.Unmarshal([]byte(history.Brands), &brands); err != nil {
-		log.Printf("[Recovery] Failed to unmarshal brands for task %s: %v", taskID, err)
-		database.DB.Model(&history).Update("status", models.StatusFailed)
-		return
+		return TaskRequest{}, config, fmt.Errorf("unmarshal brands failed: %w", err)
 	}
 
 	// 解析评价维度
 	var dimNames []string
 	if err := json.Unmarshal([]byte(history.Dimensions), &dimNames); err != nil {
-		log.Printf("[Recovery] Failed to unmarshal dimensions for task %s: %v", taskID, err)
-		database.DB.Model(&history).Update("status", models.StatusFailed)
-		return
+		return TaskRequest{}, config, fmt.Errorf("unmarshal dimensions failed: %w", err)
 	}
 	dimensions := make([]ai.Dimension, len(dimNames))
 	for i, name := range dimNames {
 		dimensions[i] = ai.Dimension{
 			Name:        name,
-			Description: "", // 恢复时不需要描述
+			Description: "", // 旧数据没有保存维度描述
 		}
 	}
 
-	// 推送恢复状态
-	sse.PushProgress(taskID, sse.StatusSearching, history.Progress, 100,
-		"任务恢复中: "+history.ProgressMsg)
-
-	// 重新执行任务
-	executor := NewExecutor(&config)
-	err := executor.Execute(context.Background(), TaskRequest{
-		TaskID:      taskID,
+	return TaskRequest{
+		TaskID:      history.TaskID,
 		Requirement: history.Category,
 		Brands:      brands,
 		Dimensions:  dimensions,
 		Keywords:    keywords,
-	})
-
-	if err != nil {
-		log.Printf("[Recovery] Task %s recovery failed: %v", taskID, err)
-		database.DB.Model(&history).Update("status", models.StatusFailed)
-	}
+	}, config, nil
 }
 
 // CleanupTimedOutTasks 清理超时任务
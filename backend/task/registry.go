@@ -0,0 +1,83 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/sse"
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+// runningTasks 正在运行的任务，key: 任务ID，value: 取消函数
+// 用于响应用户的取消请求
+var (
+	runningTasks   = make(map[string]context.CancelFunc)
+	runningTasksMu sync.Mutex
+)
+
+// Register 登记一个正在运行的任务
+// 返回派生的可取消上下文和结束函数，任务结束时必须调用结束函数以释放登记
+//
+// 示例：
+//
+//	ctx, done := task.Register(context.Background(), taskID)
+//	defer done()
+func Register(parent context.Context, taskID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+
+	runningTasksMu.Lock()
+	runningTasks[taskID] = cancel
+	runningTasksMu.Unlock()
+
+	done := func() {
+		runningTasksMu.Lock()
+		delete(runningTasks, taskID)
+		runningTasksMu.Unlock()
+		cancel()
+	}
+	return ctx, done
+}
+
+// Cancel 取消正在运行的任务
+// 返回 false 表示该任务当前不在运行（已结束或不存在）
+func Cancel(taskID string) bool {
+	runningTasksMu.Lock()
+	cancel, ok := runningTasks[taskID]
+	runningTasksMu.Unlock()
+
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// IsRunning 判断任务是否正在运行
+func IsRunning(taskID string) bool {
+	runningTasksMu.Lock()
+	defer runningTasksMu.Unlock()
+	_, ok := runningTasks[taskID]
+	return ok
+}
+
+// IsCancelled 判断上下文是否被用户取消（超时和超出 Token 预算不算取消）
+func IsCancelled(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.Canceled) && !BudgetExceeded(ctx)
+}
+
+// MarkCancelled 将任务标记为已取消并推送取消状态
+func MarkCancelled(historyID uint, taskID string) {
+	if err := database.DB.Model(&models.AnalysisHistory{}).Where("id = ?", historyID).Updates(map[string]interface{}{
+		"status":       models.StatusCancelled,
+		"progress_msg": "任务已取消",
+	}).Error; err != nil {
+		log.Printf("[Task %s] Failed to mark history %d as cancelled: %v", taskID, historyID, err)
+	}
+
+	sse.PushStatus(taskID, sse.TaskStatus{
+		TaskID:  taskID,
+		Status:  sse.StatusCancelled,
+		Message: "任务已取消",
+	})
+}
@@ -0,0 +1,105 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/bilibili"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// 商单视频的处理方式（配置项 sponsored_video_mode）
+const (
+	SponsoredModeOff     = "off"     // 不检测商单（默认）
+	SponsoredModeTag     = "tag"     // 检测并标记，评论照常计入得分
+	SponsoredModeExclude = "exclude" // 检测并标记，商单视频下的评论不计入得分和排名
+)
+
+// ParseSponsoredMode 校验商单处理方式，空字符串表示默认的 off
+func ParseSponsoredMode(s string) (string, error) {
+	switch mode := strings.TrimSpace(s); mode {
+	case "":
+		return SponsoredModeOff, nil
+	case SponsoredModeOff, SponsoredModeTag, SponsoredModeExclude:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("只能是 %s、%s 或 %s", SponsoredModeOff, SponsoredModeTag, SponsoredModeExclude)
+	}
+}
+
+// LoadSponsoredMode 读取商单处理方式配置，未配置或配置有误时按默认的 off 处理
+// （识别商单需要为每个视频额外请求视频详情并可能调用AI，需要手动开启）
+func LoadSponsoredMode() string {
+	var setting models.Settings
+	if err := database.DB.Where("key = ?", models.SettingKeySponsoredVideoMode).First(&setting).Error; err != nil {
+		return SponsoredModeOff
+	}
+	mode, err := ParseSponsoredMode(setting.Value)
+	if err != nil {
+		log.Printf("[Task] Invalid sponsored video mode %q: %v", setting.Value, err)
+		return SponsoredModeOff
+	}
+	return mode
+}
+
+// DetectSponsoredVideos 识别商单视频，结果写入 videos 的 Sponsored 和 SponsorSignals
+// 依次使用三类信号：B站的商业推广声明、标题和简介中的商单表述、AI判断（只判断前两类都没有命中的视频）。
+// biliClient 不为 nil 时先通过视频详情接口补充商业推广声明、完整简介和 cid（之后抓取弹幕直接使用，不再请求详情）；
+// aiClient 为 nil 时跳过AI判断。详情或AI请求失败只记录日志，不影响任务
+func DetectSponsoredVideos(ctx context.Context, biliClient *bilibili.Client, aiClient *ai.Client, videos []bilibili.VideoInfo) {
+	if biliClient != nil {
+		for i := range videos {
+			if ctx.Err() != nil {
+				return
+			}
+			detail, err := biliClient.GetVideoInfoWithContext(ctx, videos[i].BVID)
+			if err != nil {
+				log.Printf("[Sponsor] Failed to get video detail %s: %v", videos[i].BVID, err)
+				continue
+			}
+			videos[i].CID = detail.CID
+			if videos[i].AID == 0 {
+				videos[i].AID = detail.AID
+			}
+			videos[i].Commercial = detail.Commercial
+			if len([]rune(detail.Description)) > len([]rune(videos[i].Description)) {
+				videos[i].Description = detail.Description
+			}
+		}
+	}
+
+	var pending []int
+	for i := range videos {
+		v := &videos[i]
+		v.SponsorSignals = nil
+		if v.Commercial {
+			v.SponsorSignals = append(v.SponsorSignals, "B站商业推广声明")
+		}
+		v.SponsorSignals = append(v.SponsorSignals, ai.SponsorCues(v.Title, v.Description)...)
+		v.Sponsored = len(v.SponsorSignals) > 0
+		if !v.Sponsored {
+			pending = append(pending, i)
+		}
+	}
+
+	if aiClient != nil && len(pending) > 0 {
+		inputs := make([]ai.SponsorCheckVideo, len(pending))
+		for j, i := range pending {
+			inputs[j] = ai.SponsorCheckVideo{Title: videos[i].Title, Author: videos[i].Author, Description: videos[i].Description}
+		}
+		verdicts, err := aiClient.BatchClassifySponsored(ctx, inputs, 3)
+		if err != nil {
+			log.Printf("[Sponsor] AI classification failed: %v", err)
+		}
+		for j, verdict := range verdicts {
+			if verdict.Sponsored {
+				v := &videos[pending[j]]
+				v.Sponsored = true
+				v.SponsorSignals = append(v.SponsorSignals, "AI判断："+verdict.Reason)
+			}
+		}
+	}
+}
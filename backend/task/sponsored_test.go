@@ -0,0 +1,33 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/models"
+	"testing"
+)
+
+func TestLoadSponsoredMode(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		set   bool
+		want  string
+	}{
+		{"not configured", "", false, SponsoredModeOff},
+		{"empty", "", true, SponsoredModeOff},
+		{"tag", SponsoredModeTag, true, SponsoredModeTag},
+		{"exclude", SponsoredModeExclude, true, SponsoredModeExclude},
+		{"invalid", "random", true, SponsoredModeOff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDB(t)
+			if tt.set {
+				setTestSetting(t, models.SettingKeySponsoredVideoMode, tt.value)
+			}
+			if got := LoadSponsoredMode(); got != tt.want {
+				t.Errorf("LoadSponsoredMode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/bilibili"
+	"bilibili-analyzer/backend/comment"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/report"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// 可疑评论的处理方式（配置项 suspicious_comment_mode）
+const (
+	SuspiciousModeOff     = "off"     // 不识别
+	SuspiciousModeTag     = "tag"     // 识别并在报告中列出，评论照常参与AI分析（默认）
+	SuspiciousModeExclude = "exclude" // 识别并在报告中列出，AI分析前去掉这些评论
+)
+
+// ParseSuspiciousMode 校验可疑评论处理方式，空字符串表示默认的 tag
+func ParseSuspiciousMode(s string) (string, error) {
+	switch mode := strings.TrimSpace(s); mode {
+	case "":
+		return SuspiciousModeTag, nil
+	case SuspiciousModeOff, SuspiciousModeTag, SuspiciousModeExclude:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("只能是 %s、%s 或 %s", SuspiciousModeOff, SuspiciousModeTag, SuspiciousModeExclude)
+	}
+}
+
+// LoadSuspiciousMode 读取可疑评论处理方式配置，配置有误时按默认的 tag 处理
+func LoadSuspiciousMode() string {
+	var setting models.Settings
+	if err := database.DB.Where("key = ?", models.SettingKeySuspiciousMode).First(&setting).Error; err != nil {
+		return SuspiciousModeTag
+	}
+	mode, err := ParseSuspiciousMode(setting.Value)
+	if err != nil {
+		log.Printf("[Task] Invalid suspicious comment mode %q: %v", setting.Value, err)
+		return SuspiciousModeTag
+	}
+	return mode
+}
+
+// ScreenSuspiciousComments 按配置识别可疑评论，返回送入AI分析的抓取结果和报告用的汇总
+// 关闭识别时原样返回抓取结果，汇总为 nil；exclude 模式下返回去掉可疑评论后的副本
+func ScreenSuspiciousComments(result *bilibili.ScrapeResult, brands []string) (*bilibili.ScrapeResult, *report.SuspiciousSummary) {
+	mode := LoadSuspiciousMode()
+	if mode == SuspiciousModeOff {
+		return result, nil
+	}
+	config := comment.DefaultSuspiciousConfig()
+	config.Brands = brands
+	flagged := comment.DetectSuspicious(result, config)
+	excluded := mode == SuspiciousModeExclude
+	if excluded {
+		result = comment.ExcludeSuspicious(result, flagged)
+	}
+	return result, report.NewSuspiciousSummary(flagged, excluded)
+}
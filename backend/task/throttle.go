@@ -0,0 +1,51 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/bilibili"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/sse"
+	"fmt"
+	"log"
+	"time"
+)
+
+// NewBilibiliClient 创建任务使用的B站客户端
+// 按配置调整全局请求速率，请求在账号池的健康账号间轮换（cookie 为旧版单Cookie配置，会一并加入池中），
+// 并在触发风控时向前端推送 throttled 状态，让用户知道任务正在等待而不是卡住
+// taskID 为空时（如解析视频链接等非任务请求）不推送状态
+func NewBilibiliClient(cookie, taskID string) *bilibili.Client {
+	applyRateLimit()
+
+	client := bilibili.NewClient(cookie)
+	client.SetCookiePool(newCookiePool(cookie))
+	if taskID == "" {
+		return client
+	}
+	client.SetThrottleCallback(func(event bilibili.ThrottleEvent) {
+		wait := event.Wait.Round(time.Second)
+		var msg string
+		if event.Attempt > 0 {
+			msg = fmt.Sprintf("触发B站风控（%s），全部请求暂停 %v 后重试（第%d次）", event.Reason, wait, event.Attempt)
+		} else {
+			msg = fmt.Sprintf("B站风控暂停中，%v 后继续抓取", wait)
+		}
+		sse.PushThrottled(taskID, msg)
+	})
+	return client
+}
+
+// applyRateLimit 把配置的请求速率应用到共享限流器（未配置时使用默认值）
+func applyRateLimit() {
+	rate := bilibili.DefaultRateLimit
+	var setting models.Settings
+	if err := database.DB.Where("key = ?", models.SettingKeyBilibiliRateLimit).First(&setting).Error; err == nil {
+		rate = parseFloatSetting(setting.Value, rate)
+	}
+	if rate <= 0 {
+		log.Printf("[Task] Invalid bilibili_rate_limit %q, using default", setting.Value)
+		rate = bilibili.DefaultRateLimit
+	}
+	// 突发请求数与速率一致，至少为1
+	bilibili.DefaultLimiter().SetRate(rate, max(int(rate), 1))
+}
@@ -0,0 +1,89 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/ai"
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+)
+
+// BudgetExceededMessage 任务因 Token 预算用尽而中止时的提示
+const BudgetExceededMessage = "Token预算已用尽，任务已中止（已完成的AI分析结果已保存，可提高预算后重新执行）"
+
+// TokenBudget 计算任务的 Token 预算
+// 请求中指定了预算时优先使用，否则使用全局配置 ai_token_budget（0 表示不限制）
+func TokenBudget(requested int64) int64 {
+	if requested > 0 {
+		return requested
+	}
+	var setting models.Settings
+	if err := database.DB.Where("key = ?", models.SettingKeyAITokenBudget).First(&setting).Error; err != nil {
+		return 0
+	}
+	return int64(parseIntSetting(setting.Value, 0))
+}
+
+// TrackUsage 为任务创建 Token 用量统计并设置到AI客户端
+// 历史记录中已保存的用量（恢复任务时）会计入预算
+// 返回的上下文在超出预算时以 ai.ErrTokenBudgetExceeded 为原因取消，已完成的分析结果由各阶段的断点保存逻辑保留
+//
+// 示例：
+//
+//	ctx, usage, stop := task.TrackUsage(ctx, history, aiClient, task.TokenBudget(req.TokenBudget))
+//	defer stop()
+func TrackUsage(ctx context.Context, history *models.AnalysisHistory, aiClient *ai.Client, budget int64) (context.Context, *ai.UsageTracker, func()) {
+	budgetCtx, cancel := context.WithCancelCause(ctx)
+	tracker := ai.NewUsageTracker(budget, func() {
+		log.Printf("[Task %s] Token budget %d exceeded, aborting", history.TaskID, budget)
+		cancel(ai.ErrTokenBudgetExceeded)
+	})
+
+	if history.TokenUsage != "" {
+		var prev ai.UsageSummary
+		if err := json.Unmarshal([]byte(history.TokenUsage), &prev); err == nil {
+			tracker.Restore(prev)
+		}
+	}
+
+	aiClient.SetUsageTracker(tracker)
+	return budgetCtx, tracker, func() { cancel(nil) }
+}
+
+// BudgetExceeded 判断上下文是否因超出 Token 预算而取消
+func BudgetExceeded(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ai.ErrTokenBudgetExceeded)
+}
+
+// SaveTokenUsage 按价格表计算费用并把用量汇总保存到历史记录
+func SaveTokenUsage(historyID uint, tracker *ai.UsageTracker, model string) ai.UsageSummary {
+	summary := tracker.Summary(model, loadPriceTable())
+
+	usageJSON, _ := json.Marshal(summary)
+	if err := database.DB.Model(&models.AnalysisHistory{}).Where("id = ?", historyID).Updates(map[string]interface{}{
+		"prompt_tokens":     summary.PromptTokens,
+		"completion_tokens": summary.CompletionTokens,
+		"total_tokens":      summary.TotalTokens,
+		"cost":              summary.Cost,
+		"token_usage":       string(usageJSON),
+	}).Error; err != nil {
+		log.Printf("[Task] Failed to save token usage for history %d: %v", historyID, err)
+	}
+	return summary
+}
+
+// loadPriceTable 读取模型价格表配置，配置有误时返回空表（费用记为0）
+func loadPriceTable() ai.PriceTable {
+	var setting models.Settings
+	if err := database.DB.Where("key = ?", models.SettingKeyAIPriceTable).First(&setting).Error; err != nil {
+		return ai.PriceTable{}
+	}
+	table, err := ai.ParsePriceTable(setting.Value)
+	if err != nil {
+		log.Printf("[Task] Invalid AI price table: %v", err)
+		return ai.PriceTable{}
+	}
+	return table
+}
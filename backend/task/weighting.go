@@ -0,0 +1,23 @@
+package task
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/report"
+	"log"
+)
+
+// LoadCommentWeighting 读取评论权重模型配置，未配置时使用默认模型，配置有误时不计算加权得分
+func LoadCommentWeighting() *report.CommentWeighting {
+	var setting models.Settings
+	if err := database.DB.Where("key = ?", models.SettingKeyCommentWeighting).First(&setting).Error; err != nil {
+		w := report.DefaultCommentWeighting()
+		return &w
+	}
+	w, err := report.ParseCommentWeighting(setting.Value)
+	if err != nil {
+		log.Printf("[Task] Invalid comment weighting: %v", err)
+		return nil
+	}
+	return &w
+}
@@ -0,0 +1,399 @@
+package webhook
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/sse"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 投递参数
+const (
+	maxAttempts      = 6                // 最多尝试次数（首次投递 + 5次重试）
+	baseRetryDelay   = 30 * time.Second // 第一次重试的等待时间，之后每次翻倍
+	deliveryTimeout  = 10 * time.Second // 单次请求超时
+	pollInterval     = 5 * time.Second  // 检查待重试投递的间隔
+	batchSize        = 20               // 每轮最多投递的记录数
+	maxResponseBody  = 500              // 记录的响应内容最大长度
+	statusBufferSize = 256              // 状态事件缓冲（满了丢弃进度事件，不阻塞任务执行）
+)
+
+// 请求头
+const (
+	HeaderEvent     = "X-Webhook-Event"     // 事件类型
+	HeaderDelivery  = "X-Webhook-Delivery"  // 投递记录ID
+	HeaderTimestamp = "X-Webhook-Timestamp" // 签名时间戳（Unix 秒）
+	HeaderSignature = "X-Webhook-Signature" // 签名：sha256=<hex(HMAC-SHA256(secret, timestamp + "." + body))>
+)
+
+var (
+	client   = &http.Client{Timeout: deliveryTimeout}
+	statuses = newStatusQueue(statusBufferSize)
+	wake     = make(chan struct{}, 1)
+)
+
+// StartDispatcher 启动 Webhook 投递（阻塞运行，应在 goroutine 中调用）
+// 监听任务状态推送生成任务事件，并按退避间隔投递待发送的记录
+func StartDispatcher() {
+	tracker := newStageTracker()
+	sse.AddListener(statuses.push)
+
+	go func() {
+		for {
+			for _, status := range statuses.drain() {
+				event := tracker.observe(status)
+				if event == "" {
+					continue
+				}
+				Emit(event, &Payload{Task: taskInfoFromStatus(event, status)})
+			}
+		}
+	}()
+
+	log.Println("[Webhook] Dispatcher started")
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		deliverDue(time.Now())
+		select {
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// statusQueue 状态推送缓冲
+// 进度推送超出容量时丢弃；完成、出错、取消这类终态推送总是入队，保证结束事件一定发出。
+// 入队从不阻塞推送方，终态与进度推送共用一个队列以保持先后顺序
+type statusQueue struct {
+	mu      sync.Mutex
+	pending []sse.TaskStatus
+	limit   int
+	ready   chan struct{}
+}
+
+func newStatusQueue(limit int) *statusQueue {
+	return &statusQueue{limit: limit, ready: make(chan struct{}, 1)}
+}
+
+// push 把状态推送放入缓冲
+func (q *statusQueue) push(status sse.TaskStatus) {
+	q.mu.Lock()
+	if len(q.pending) >= q.limit && !isTerminalStatus(status.Status) {
+		q.mu.Unlock()
+		log.Printf("[Webhook] Status buffer full, dropping %s event of task %s", status.Status, status.TaskID)
+		return
+	}
+	q.pending = append(q.pending, status)
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// drain 等待并取出缓冲中的全部状态推送
+func (q *statusQueue) drain() []sse.TaskStatus {
+	<-q.ready
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	batch := q.pending
+	q.pending = nil
+	return batch
+}
+
+// isTerminalStatus 是否为任务结束状态
+func isTerminalStatus(status string) bool {
+	switch status {
+	case sse.StatusCompleted, sse.StatusError, sse.StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// Emit 为订阅了该事件的所有已启用接收端生成投递记录
+// payload 的 ID、Event、CreatedAt 由这里填写
+func Emit(event string, payload *Payload) {
+	EmitExceptURL(event, payload, "")
+}
+
+// EmitExceptURL 与 Emit 相同，但跳过地址为 skipURL 的接收端（该地址已通过专用接收端单独投递）
+func EmitExceptURL(event string, payload *Payload, skipURL string) {
+	endpoints, err := database.EnabledWebhookEndpoints()
+	if err != nil {
+		log.Printf("[Webhook] Failed to load endpoints: %v", err)
+		return
+	}
+
+	var targets []models.WebhookEndpoint
+	for _, endpoint := range endpoints {
+		if skipURL != "" && endpoint.URL == skipURL {
+			continue
+		}
+		if Subscribed(&endpoint, event) {
+			targets = append(targets, endpoint)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	fillPayload(event, payload)
+	for i := range targets {
+		if _, err := enqueue(&targets[i], payload); err != nil {
+			log.Printf("[Webhook] Failed to enqueue %s for endpoint %d: %v", event, targets[i].ID, err)
+		}
+	}
+	notify()
+}
+
+// EmitTo 向指定接收端发送事件（不检查启用状态和订阅，用于测试连通性）
+func EmitTo(endpoint *models.WebhookEndpoint, event string, payload *Payload) (*models.WebhookDelivery, error) {
+	fillPayload(event, payload)
+	delivery, err := enqueue(endpoint, payload)
+	if err != nil {
+		return nil, err
+	}
+	notify()
+	return delivery, nil
+}
+
+// SaveDedicatedEndpoint 创建或更新专用接收端（如定时计划自己的告警地址）
+// 专用接收端不启用，不接收广播事件，只通过 EmitTo 投递；id 为 0 时新建并生成签名密钥，
+// 否则沿用原记录和签名密钥，只更新名称、地址和订阅事件。url 为空时删除原记录并返回 nil
+func SaveDedicatedEndpoint(id uint, name, url, event string) (*models.WebhookEndpoint, error) {
+	if url == "" {
+		if id == 0 {
+			return nil, nil
+		}
+		return nil, database.DB.Delete(&models.WebhookEndpoint{}, id).Error
+	}
+
+	var endpoint models.WebhookEndpoint
+	if id != 0 {
+		err := database.DB.First(&endpoint, id).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	if endpoint.Secret == "" {
+		secret, err := NewSecret()
+		if err != nil {
+			return nil, err
+		}
+		endpoint.Secret = secret
+	}
+	endpoint.Name = name
+	endpoint.URL = url
+	endpoint.Events = event
+	endpoint.Enabled = false
+	if err := database.DB.Save(&endpoint).Error; err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// Retry 重新投递一条记录（重置尝试次数，立即发送）
+func Retry(delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	if err := database.DB.Model(delivery).Updates(map[string]interface{}{
+		"status":          models.DeliveryStatusPending,
+		"attempts":        0,
+		"error":           "",
+		"next_attempt_at": now,
+	}).Error; err != nil {
+		return err
+	}
+	notify()
+	return nil
+}
+
+// Subscribed 判断接收端是否订阅了事件（未配置订阅列表表示订阅全部事件）
+func Subscribed(endpoint *models.WebhookEndpoint, event string) bool {
+	if strings.TrimSpace(endpoint.Events) == "" {
+		return true
+	}
+	for _, e := range strings.Split(endpoint.Events, ",") {
+		if strings.TrimSpace(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign 计算请求签名
+// 接收方用相同的密钥对 "时间戳.请求体" 计算 HMAC-SHA256 并与请求头比较即可验证来源
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func fillPayload(event string, payload *Payload) {
+	payload.ID = uuid.New().String()
+	payload.Event = event
+	payload.CreatedAt = time.Now()
+}
+
+// enqueue 保存一条待投递记录
+func enqueue(endpoint *models.WebhookEndpoint, payload *Payload) (*models.WebhookDelivery, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	delivery := models.WebhookDelivery{
+		WebhookID:     endpoint.ID,
+		Event:         payload.Event,
+		PayloadID:     payload.ID,
+		URL:           endpoint.URL,
+		Payload:       string(body),
+		Status:        models.DeliveryStatusPending,
+		NextAttemptAt: &now,
+	}
+	if payload.Task != nil {
+		delivery.TaskID = payload.Task.TaskID
+	}
+	if err := database.DB.Create(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// notify 唤醒投递循环
+func notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliverDue 投递到达重试时间的记录
+func deliverDue(now time.Time) {
+	deliveries, err := database.DueWebhookDeliveries(now, batchSize)
+	if err != nil {
+		log.Printf("[Webhook] Failed to load due deliveries: %v", err)
+		return
+	}
+	for i := range deliveries {
+		attempt(&deliveries[i])
+	}
+}
+
+// attempt 尝试投递一次并记录结果
+// 失败且未达到最大次数时按 30s、1m、2m、4m、8m 的间隔安排下次重试
+func attempt(delivery *models.WebhookDelivery) {
+	var endpoint models.WebhookEndpoint
+	if err := database.DB.First(&endpoint, delivery.WebhookID).Error; err != nil {
+		msg := "读取接收端失败: " + err.Error()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			msg = "接收端已删除"
+		}
+		database.DB.Model(delivery).Updates(map[string]interface{}{
+			"status":          models.DeliveryStatusFailed,
+			"error":           msg,
+			"next_attempt_at": nil,
+		})
+		return
+	}
+
+	code, respBody, err := send(&endpoint, delivery)
+	delivery.Attempts++
+	updates := map[string]interface{}{
+		"attempts":      delivery.Attempts,
+		"url":           endpoint.URL,
+		"response_code": code,
+		"response_body": respBody,
+	}
+
+	if err == nil {
+		now := time.Now()
+		updates["status"] = models.DeliveryStatusSuccess
+		updates["error"] = ""
+		updates["delivered_at"] = now
+		updates["next_attempt_at"] = nil
+	} else {
+		updates["error"] = err.Error()
+		if delivery.Attempts >= maxAttempts {
+			updates["status"] = models.DeliveryStatusFailed
+			updates["next_attempt_at"] = nil
+			log.Printf("[Webhook] Delivery %d (%s) to %s failed after %d attempts: %v",
+				delivery.ID, delivery.Event, endpoint.URL, delivery.Attempts, err)
+		} else {
+			updates["next_attempt_at"] = time.Now().Add(retryDelay(delivery.Attempts))
+		}
+	}
+
+	if err := database.DB.Model(delivery).Updates(updates).Error; err != nil {
+		log.Printf("[Webhook] Failed to update delivery %d: %v", delivery.ID, err)
+	}
+}
+
+// retryDelay 第 attempts 次尝试失败后的等待时间
+func retryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return baseRetryDelay << (attempts - 1)
+}
+
+// send 发送签名请求，2xx 视为成功
+func send(endpoint *models.WebhookEndpoint, delivery *models.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bilibili-analyzer-webhook")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	if endpoint.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(endpoint.Secret, timestamp, body))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	respBody := strings.ToValidUTF8(string(raw), "")
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, respBody, fmt.Errorf("接收端返回状态码 %d", resp.StatusCode)
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+// NewSecret 生成随机签名密钥
+func NewSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
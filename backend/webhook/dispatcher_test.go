@@ -0,0 +1,170 @@
+package webhook
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/sse"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	sig := Sign("secret", 1700000000, body)
+	if !strings.HasPrefix(sig, "sha256=") || len(sig) != len("sha256=")+64 {
+		t.Fatalf("unexpected signature format: %s", sig)
+	}
+	if sig != Sign("secret", 1700000000, body) {
+		t.Error("signature should be deterministic")
+	}
+	if sig == Sign("other", 1700000000, body) {
+		t.Error("signature should depend on secret")
+	}
+	if sig == Sign("secret", 1700000001, body) {
+		t.Error("signature should depend on timestamp")
+	}
+}
+
+func TestSubscribed(t *testing.T) {
+	all := &models.WebhookEndpoint{}
+	if !Subscribed(all, EventTaskFailed) {
+		t.Error("empty event list should subscribe to all events")
+	}
+	some := &models.WebhookEndpoint{Events: "task.completed, task.failed"}
+	if !Subscribed(some, EventTaskFailed) || !Subscribed(some, EventTaskCompleted) {
+		t.Error("listed events should be subscribed")
+	}
+	if Subscribed(some, EventTaskStarted) {
+		t.Error("unlisted event should not be subscribed")
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute}
+	for i, w := range want {
+		if got := retryDelay(i + 1); got != w {
+			t.Errorf("retryDelay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	payload := `{"id":"abc","event":"task.completed"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != payload {
+			t.Errorf("body = %s", body)
+		}
+		if r.Header.Get(HeaderEvent) != EventTaskCompleted || r.Header.Get(HeaderDelivery) != "7" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		ts, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if err != nil {
+			t.Fatalf("invalid timestamp header: %v", err)
+		}
+		if r.Header.Get(HeaderSignature) != Sign("secret", ts, body) {
+			t.Error("signature mismatch")
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	endpoint := &models.WebhookEndpoint{URL: server.URL, Secret: "secret"}
+	delivery := &models.WebhookDelivery{ID: 7, Event: EventTaskCompleted, Payload: payload}
+	code, body, err := send(endpoint, delivery)
+	if err != nil || code != http.StatusOK || body != "ok" {
+		t.Fatalf("send() = %d, %q, %v", code, body, err)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(strings.Repeat("x", maxResponseBody*2)))
+	}))
+	defer failing.Close()
+	endpoint.URL = failing.URL
+	code, body, err = send(endpoint, delivery)
+	if err == nil || code != http.StatusBadGateway {
+		t.Errorf("expected error for non-2xx response, got %d, %v", code, err)
+	}
+	if len(body) != maxResponseBody {
+		t.Errorf("response body should be truncated to %d, got %d", maxResponseBody, len(body))
+	}
+}
+
+func TestStatusQueueKeepsTerminalEvents(t *testing.T) {
+	q := newStatusQueue(1)
+	q.push(sse.TaskStatus{TaskID: "t1", Status: sse.StatusAnalyzing})
+	// 缓冲已满，进度推送直接丢弃，终态推送照样入队且不阻塞
+	q.push(sse.TaskStatus{TaskID: "t1", Status: sse.StatusGenerating})
+	q.push(sse.TaskStatus{TaskID: "t1", Status: sse.StatusCompleted})
+
+	got := q.drain()
+	if len(got) != 2 || got[0].Status != sse.StatusAnalyzing || got[1].Status != sse.StatusCompleted {
+		t.Fatalf("drain() = %+v, want analyzing then completed", got)
+	}
+
+	q.push(sse.TaskStatus{TaskID: "t2", Status: sse.StatusScraping})
+	if got := q.drain(); len(got) != 1 || got[0].TaskID != "t2" {
+		t.Errorf("drain() after reset = %+v", got)
+	}
+}
+
+func TestSaveDedicatedEndpoint(t *testing.T) {
+	if err := database.InitDB(":memory:"); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	const url = "https://example.com/alert"
+	database.DB.Create(&models.WebhookEndpoint{Name: "全局", URL: url, Secret: "s", Enabled: true})
+
+	first, err := SaveDedicatedEndpoint(0, "定时计划「周报」告警", url, EventScheduleAlert)
+	if err != nil {
+		t.Fatalf("SaveDedicatedEndpoint failed: %v", err)
+	}
+	if first.ID == 0 || first.Secret == "" || first.Enabled {
+		t.Errorf("unexpected endpoint: %+v", first)
+	}
+
+	// 地址变化时沿用原记录和密钥
+	second, err := SaveDedicatedEndpoint(first.ID, "定时计划「周报」告警", url+"/v2", EventScheduleAlert)
+	if err != nil {
+		t.Fatalf("SaveDedicatedEndpoint (update) failed: %v", err)
+	}
+	if second.ID != first.ID || second.Secret != first.Secret || second.URL != url+"/v2" {
+		t.Errorf("endpoint should be reused, got %+v", second)
+	}
+
+	delivery, err := EmitTo(second, EventScheduleAlert, &Payload{Data: map[string]int{"alerts": 1}})
+	if err != nil {
+		t.Fatalf("EmitTo failed: %v", err)
+	}
+	if delivery.WebhookID != first.ID || delivery.Status != models.DeliveryStatusPending {
+		t.Errorf("unexpected delivery: %+v", delivery)
+	}
+
+	// 专用接收端不参与广播，跳过的地址也不再收到广播
+	EmitExceptURL(EventScheduleAlert, &Payload{}, url)
+	var count int64
+	database.DB.Model(&models.WebhookDelivery{}).Count(&count)
+	if count != 1 {
+		t.Errorf("deliveries = %d, want 1 (broadcast should skip the dedicated URL)", count)
+	}
+
+	// 地址清空时删除记录
+	if endpoint, err := SaveDedicatedEndpoint(first.ID, "", "", EventScheduleAlert); err != nil || endpoint != nil {
+		t.Fatalf("SaveDedicatedEndpoint (clear) = %v, %v", endpoint, err)
+	}
+	if err := database.DB.First(&models.WebhookEndpoint{}, first.ID).Error; err == nil {
+		t.Error("dedicated endpoint should be deleted when the URL is cleared")
+	}
+}
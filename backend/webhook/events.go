@@ -0,0 +1,173 @@
+package webhook
+
+import (
+	"bilibili-analyzer/backend/database"
+	"bilibili-analyzer/backend/models"
+	"bilibili-analyzer/backend/report"
+	"bilibili-analyzer/backend/sse"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 事件类型
+const (
+	EventTaskStarted      = "task.started"       // 任务开始执行（恢复执行的任务也会发送）
+	EventTaskStageChanged = "task.stage_changed" // 任务进入新阶段（搜索/抓取/分析/生成报告）
+	EventTaskCompleted    = "task.completed"     // 任务完成（含报告ID和得分摘要）
+	EventTaskFailed       = "task.failed"        // 任务失败
+	EventTaskCancelled    = "task.cancelled"     // 任务被取消
+	EventScheduleAlert    = "schedule.alert"     // 定时计划的品牌指标变化告警
+	EventPing             = "ping"               // 测试事件（只发送给指定的接收端）
+)
+
+// Events 可订阅的事件列表
+var Events = []string{
+	EventTaskStarted,
+	EventTaskStageChanged,
+	EventTaskCompleted,
+	EventTaskFailed,
+	EventTaskCancelled,
+	EventScheduleAlert,
+}
+
+// Payload Webhook 请求体
+type Payload struct {
+	ID        string    `json:"id"`             // 事件ID（重试时不变，可用于去重）
+	Event     string    `json:"event"`          // 事件类型
+	CreatedAt time.Time `json:"created_at"`     // 事件发生时间
+	Task      *TaskInfo `json:"task,omitempty"` // 任务事件的任务信息
+	Data      any       `json:"data,omitempty"` // 其他事件的数据（如 schedule.alert 的告警内容）
+}
+
+// TaskInfo 任务事件中的任务信息
+type TaskInfo struct {
+	TaskID    string         `json:"task_id"`
+	HistoryID uint           `json:"history_id,omitempty"`
+	Category  string         `json:"category,omitempty"`
+	Stage     string         `json:"stage"`    // SSE 状态（searching/scraping/analyzing/generating/completed/error/cancelled）
+	Progress  int            `json:"progress"` // 整体进度百分比
+	Message   string         `json:"message,omitempty"`
+	Error     string         `json:"error,omitempty"`
+	ReportID  uint           `json:"report_id,omitempty"`
+	Summary   *ReportSummary `json:"summary,omitempty"` // 仅 task.completed
+}
+
+// ReportSummary 报告得分摘要
+type ReportSummary struct {
+	TotalVideos   int            `json:"total_videos"`
+	TotalComments int            `json:"total_comments"`
+	Rankings      []BrandSummary `json:"rankings"`
+}
+
+// BrandSummary 品牌得分摘要
+type BrandSummary struct {
+	Brand        string             `json:"brand"`
+	Rank         int                `json:"rank"`
+	OverallScore float64            `json:"overall_score"`
+	Scores       map[string]float64 `json:"scores"`
+}
+
+// stageTracker 根据 SSE 状态推断任务生命周期事件
+// 排队、解析、等待确认、限流等状态不产生事件；第一次进入执行阶段视为开始，之后阶段变化时产生阶段事件
+type stageTracker struct {
+	mu     sync.Mutex
+	stages map[string]string // 任务ID -> 当前阶段
+}
+
+func newStageTracker() *stageTracker {
+	return &stageTracker{stages: make(map[string]string)}
+}
+
+// observe 处理一条状态推送，返回对应的事件类型（没有事件时返回空字符串）
+func (t *stageTracker) observe(status sse.TaskStatus) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch status.Status {
+	case sse.StatusQueued, sse.StatusParsing, sse.StatusWaitingConfirm, sse.StatusThrottled:
+		return ""
+	case sse.StatusCompleted:
+		delete(t.stages, status.TaskID)
+		return EventTaskCompleted
+	case sse.StatusError:
+		delete(t.stages, status.TaskID)
+		return EventTaskFailed
+	case sse.StatusCancelled:
+		delete(t.stages, status.TaskID)
+		return EventTaskCancelled
+	}
+
+	prev, seen := t.stages[status.TaskID]
+	t.stages[status.TaskID] = status.Status
+	switch {
+	case !seen:
+		return EventTaskStarted
+	case prev != status.Status:
+		return EventTaskStageChanged
+	}
+	return ""
+}
+
+// taskInfoFromStatus 构建任务事件的任务信息
+// 从 analysis_history 补充类目和历史记录ID；完成事件附带报告得分摘要
+func taskInfoFromStatus(event string, status sse.TaskStatus) *TaskInfo {
+	info := &TaskInfo{
+		TaskID:  status.TaskID,
+		Stage:   status.Status,
+		Message: status.Message,
+		Error:   status.Error,
+	}
+	var history models.AnalysisHistory
+	if err := database.DB.Where("task_id = ?", status.TaskID).Order("id DESC").First(&history).Error; err == nil {
+		info.HistoryID = history.ID
+		info.Category = history.Category
+		info.Progress = history.Progress
+		info.ReportID = history.ReportID
+	}
+
+	if event == EventTaskCompleted {
+		info.Progress = 100
+		// 完成状态通过 Progress.Stage 传递报告ID
+		if status.Progress != nil {
+			if id, err := strconv.ParseUint(status.Progress.Stage, 10, 32); err == nil && id > 0 {
+				info.ReportID = uint(id)
+			}
+		}
+		if info.ReportID > 0 {
+			info.Summary = loadReportSummary(info.ReportID)
+		}
+	}
+	return info
+}
+
+// loadReportSummary 读取报告的得分摘要，读取失败时返回 nil
+func loadReportSummary(reportID uint) *ReportSummary {
+	var reportModel models.Report
+	if err := database.DB.First(&reportModel, reportID).Error; err != nil {
+		return nil
+	}
+	var data report.ReportData
+	if err := json.Unmarshal([]byte(reportModel.ReportData), &data); err != nil {
+		return nil
+	}
+	return summarizeReport(&data)
+}
+
+func summarizeReport(data *report.ReportData) *ReportSummary {
+	summary := &ReportSummary{
+		TotalVideos:   data.Stats.TotalVideos,
+		TotalComments: data.Stats.TotalComments,
+		Rankings:      make([]BrandSummary, 0, len(data.Rankings)),
+	}
+	for _, r := range data.Rankings {
+		summary.Rankings = append(summary.Rankings, BrandSummary{
+			Brand:        r.Brand,
+			Rank:         r.Rank,
+			OverallScore: r.OverallScore,
+			Scores:       r.Scores,
+		})
+	}
+	return summary
+}
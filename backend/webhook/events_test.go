@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"bilibili-analyzer/backend/report"
+	"bilibili-analyzer/backend/sse"
+	"testing"
+)
+
+func TestStageTrackerObserve(t *testing.T) {
+	tracker := newStageTracker()
+	steps := []struct {
+		taskID string
+		status string
+		want   string
+	}{
+		{"t1", sse.StatusQueued, ""},
+		{"t1", sse.StatusSearching, EventTaskStarted},
+		{"t1", sse.StatusSearching, ""},
+		{"t1", sse.StatusThrottled, ""},
+		{"t1", sse.StatusScraping, EventTaskStageChanged},
+		{"t2", sse.StatusParsing, ""},
+		{"t2", sse.StatusWaitingConfirm, ""},
+		{"t2", sse.StatusSearching, EventTaskStarted},
+		{"t1", sse.StatusAnalyzing, EventTaskStageChanged},
+		{"t1", sse.StatusCompleted, EventTaskCompleted},
+		{"t2", sse.StatusError, EventTaskFailed},
+		// 恢复执行的任务重新视为开始
+		{"t1", sse.StatusAnalyzing, EventTaskStarted},
+		{"t1", sse.StatusCancelled, EventTaskCancelled},
+	}
+	for i, step := range steps {
+		got := tracker.observe(sse.TaskStatus{TaskID: step.taskID, Status: step.status})
+		if got != step.want {
+			t.Errorf("step %d (%s %s): got %q, want %q", i, step.taskID, step.status, got, step.want)
+		}
+	}
+	if len(tracker.stages) != 0 {
+		t.Errorf("finished tasks should be forgotten, got %v", tracker.stages)
+	}
+}
+
+func TestSummarizeReport(t *testing.T) {
+	data := &report.ReportData{
+		Stats: report.ReportStats{TotalVideos: 3, TotalComments: 120},
+		Rankings: []report.BrandRanking{
+			{Brand: "戴森", Rank: 1, OverallScore: 8.6, Scores: map[string]float64{"吸力": 9}},
+			{Brand: "小米", Rank: 2, OverallScore: 7.9, Scores: map[string]float64{"吸力": 8}},
+		},
+	}
+	summary := summarizeReport(data)
+	if summary.TotalVideos != 3 || summary.TotalComments != 120 {
+		t.Errorf("unexpected totals: %+v", summary)
+	}
+	if len(summary.Rankings) != 2 || summary.Rankings[0].Brand != "戴森" || summary.Rankings[1].OverallScore != 7.9 {
+		t.Errorf("unexpected rankings: %+v", summary.Rankings)
+	}
+	if summary.Rankings[0].Scores["吸力"] != 9 {
+		t.Errorf("dimension scores missing: %+v", summary.Rankings[0])
+	}
+}